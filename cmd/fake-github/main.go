@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/storacha/project-agent/internal/github/githubtest"
+)
+
+// fake-github serves a fixture-backed stand-in for the GitHub GraphQL API so
+// the other commands can run offline:
+//
+//	go run ./cmd/fake-github -fixture internal/github/githubtest/testdata/board.json
+//	GITHUB_GRAPHQL_URL=http://localhost:8787/graphql GITHUB_TOKEN=x GITHUB_ORG=storacha PROJECT_NUMBER=1 go run ./cmd/project-agent triage-stale
+func main() {
+	fixturePath := flag.String("fixture", "internal/github/githubtest/testdata/board.json", "path to the board fixture")
+	addr := flag.String("addr", "localhost:8787", "address to listen on")
+	snapshotPath := flag.String("snapshot", "", "write the final board state to this file on shutdown")
+	flag.Parse()
+
+	fixture, err := githubtest.LoadFixture(*fixturePath)
+	if err != nil {
+		log.Fatalf("Failed to load fixture: %v", err)
+	}
+
+	server, err := githubtest.NewHandler(fixture)
+	if err != nil {
+		log.Fatalf("Failed to build stand-in server: %v", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/graphql", server)
+	httpServer := &http.Server{Addr: *addr, Handler: mux}
+
+	go func() {
+		log.Printf("Serving fixture %s at http://%s/graphql\n", *fixturePath, *addr)
+		if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("Server failed: %v", err)
+		}
+	}()
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	<-stop
+
+	_ = httpServer.Close()
+
+	log.Printf("Served %d requests, applied %d mutations\n", server.Requests(), len(server.Mutations()))
+	for _, m := range server.Mutations() {
+		log.Printf("  - %s %v\n", m.Name, m.Input)
+	}
+
+	if *snapshotPath != "" {
+		data, err := json.MarshalIndent(server.Snapshot(), "", "  ")
+		if err != nil {
+			log.Fatalf("Failed to encode snapshot: %v", err)
+		}
+		if err := os.WriteFile(*snapshotPath, data, 0o644); err != nil {
+			log.Fatalf("Failed to write snapshot: %v", err)
+		}
+		log.Printf("Wrote board snapshot to %s\n", *snapshotPath)
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/storacha/project-agent/internal/logging"
+	"github.com/storacha/project-agent/internal/tasks"
+)
+
+var applyCommand = &command{
+	name:    "apply",
+	summary: "<plan-file>: make exactly the changes in a plan saved with --plan-out",
+	run:     runApply,
+}
+
+// runApply applies a saved plan. It refuses if the plan was made for another
+// board or if any item it changes was updated since it was planned.
+func runApply(ctx context.Context, env *environment) (*summary, error) {
+	if len(env.args) != 1 {
+		return nil, fmt.Errorf("usage: project-agent apply [flags] <plan-file>")
+	}
+	cfg := env.cfg
+
+	file, err := tasks.ReadPlanFile(env.args[0])
+	if err != nil {
+		return nil, err
+	}
+	t, ok := tasks.Lookup(file.Task)
+	if !ok {
+		return nil, fmt.Errorf("plan file is for unknown task %q", file.Task)
+	}
+	if file.Org != cfg.GithubOrg || file.ProjectNumber != cfg.ProjectNumber {
+		return nil, fmt.Errorf("plan was made for %s project %d, but the configuration is for %s project %d",
+			file.Org, file.ProjectNumber, cfg.GithubOrg, cfg.ProjectNumber)
+	}
+
+	needs := t.Needs(cfg)
+	needs.Similarity = false // Scoring happens while planning
+	taskEnv, closeEnv, err := newTaskEnv(ctx, cfg, needs, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer closeEnv()
+
+	ctx = logging.With(ctx, "task", file.Task)
+	logRunStart(ctx, cfg, fmt.Sprintf("apply of %s plan from %s", file.Task, file.CreatedAt.Format("2006-01-02 15:04 MST")))
+
+	plan := file.Plan()
+	if taskEnv.Board != nil {
+		slog.InfoContext(ctx, "Checking the planned changes against the board", "changes", len(plan.Actions))
+		if err := tasks.CheckCurrent(ctx, taskEnv.Board, plan.Actions); err != nil {
+			err = fmt.Errorf("refusing to apply a stale plan (run %s again to replan): %w", file.Task, err)
+			notifyFailure(ctx, cfg, "apply", []string{err.Error()})
+			return nil, err
+		}
+	}
+
+	return applyPlan(ctx, t, taskEnv, plan)
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/storacha/project-agent/internal/github"
+	"github.com/storacha/project-agent/internal/messages"
+	"github.com/storacha/project-agent/internal/tasks"
+)
+
+// configOffline skips the project board checks in config validate
+var configOffline bool
+
+var configCommand = &command{
+	name:    "config",
+	summary: "validate [task...]: check the configuration and the statuses and fields each task uses",
+	flags: func(fs *flag.FlagSet) {
+		fs.BoolVar(&configOffline, "offline", false, "skip the project board checks")
+	},
+	run: runConfig,
+}
+
+func runConfig(ctx context.Context, env *environment) (*summary, error) {
+	if len(env.args) == 0 || env.args[0] != "validate" {
+		return nil, fmt.Errorf("usage: project-agent config validate [-offline] [task...]")
+	}
+	cfg := env.cfg
+
+	// Check the tasks that read the board
+	all := make(map[string]github.Requirements)
+	var known []string
+	for _, t := range tasks.All() {
+		if needs := t.Needs(cfg); needs.Board {
+			all[t.Name()] = needs.Schema
+			known = append(known, t.Name())
+		}
+	}
+	names := env.args[1:]
+	if len(names) == 0 {
+		names = known
+	}
+	for _, name := range names {
+		if _, ok := all[name]; !ok {
+			return nil, fmt.Errorf("unknown task %q (tasks that read the board: %s)", name, strings.Join(known, ", "))
+		}
+	}
+
+	// problems maps each checked task to what its board is missing, or ""
+	problems := make(map[string]string)
+	s := &summary{
+		Command: "config",
+		Title:   "Configuration Check",
+		Notes:   []string{"✓ Configuration is valid"},
+		Report:  problems,
+	}
+	if cfg.TemplatesDir != "" {
+		templates, err := messages.Load(cfg.TemplatesDir)
+		if err == nil {
+			err = templates.Check()
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid message templates: %w", err)
+		}
+		s.Notes = append(s.Notes, fmt.Sprintf("✓ Message templates in %s render", cfg.TemplatesDir))
+	}
+	people, err := loadDirectory(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Directory != "" {
+		s.Notes = append(s.Notes, fmt.Sprintf("✓ Team directory %s lists %d people (%d active)", cfg.Directory, people.Len(), len(people.Active())))
+	}
+	if configOffline {
+		return s, nil
+	}
+
+	githubClient, err := github.NewClientWithEndpoint(cfg.GithubGraphQLURL, cfg.GithubToken, cfg.GithubOrg, cfg.ProjectNumber)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GitHub client: %w", err)
+	}
+
+	for _, name := range names {
+		if err := githubClient.Require(all[name]); err != nil {
+			problems[name] = err.Error()
+			s.Notes = append(s.Notes, fmt.Sprintf("✗ %s: %v", name, err))
+			s.Failed = true
+			continue
+		}
+		problems[name] = ""
+		s.Notes = append(s.Notes, fmt.Sprintf("✓ %s: project has every status and field it uses", name))
+	}
+
+	return s, nil
+}
@@ -7,17 +7,22 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
-	"os"
 	"time"
 
-	"github.com/joho/godotenv"
 	"github.com/shurcooL/githubv4"
+	"github.com/storacha/project-agent/internal/github"
+	"github.com/storacha/project-agent/internal/logging"
 	"golang.org/x/crypto/nacl/box"
-	"golang.org/x/oauth2"
 )
 
+var deployPRWorkflowCommand = &command{
+	name:    "deploy-pr-workflow",
+	summary: "Install the PR notification workflow in every repository of the organization (not needed with serve webhooks)",
+	run:     runDeployPRWorkflow,
+}
+
 const workflowContent = `name: Notify PR Event
 
 on:
@@ -37,50 +42,42 @@ jobs:
             -d "{\"event_type\":\"pr-event\",\"client_payload\":{\"pr_repo\":\"${{ github.repository }}\",\"pr_number\":${{ github.event.pull_request.number }},\"pr_author\":\"${{ github.event.pull_request.user.login }}\",\"pr_title\":$(echo '${{ github.event.pull_request.title }}' | jq -Rs .),\"pr_body\":$(echo '${{ github.event.pull_request.body }}' | jq -Rs .)}}"
 `
 
-type Repository struct {
+// deployRepository is a repository the PR notification workflow is deployed to
+type deployRepository struct {
 	Name          string
 	DefaultBranch struct {
 		Name string
 	}
 }
 
-func main() {
-	// Load .env file if it exists (ignore error if file doesn't exist)
-	_ = godotenv.Load()
-
-	ctx := context.Background()
-
-	githubToken := os.Getenv("GITHUB_TOKEN")
-	if githubToken == "" {
-		log.Fatal("GITHUB_TOKEN environment variable is required")
-	}
+// DeployReport contains the results of a workflow deployment
+type DeployReport struct {
+	TotalRepos int
+	Deployed   int
+	Skipped    int
+	Errors     []string
+}
 
-	projectAgentPAT := os.Getenv("PROJECT_AGENT_PAT")
-	if projectAgentPAT == "" {
-		log.Fatal("PROJECT_AGENT_PAT environment variable is required (PAT for repository_dispatch events)")
-	}
+func runDeployPRWorkflow(ctx context.Context, env *environment) (*summary, error) {
+	cfg := env.cfg
+	org := cfg.GithubOrg
 
-	org := os.Getenv("GITHUB_ORG")
-	if org == "" {
-		org = "storacha"
+	if cfg.ProjectAgentPAT == "" {
+		return nil, fmt.Errorf("PROJECT_AGENT_PAT environment variable is required (PAT for repository_dispatch events)")
 	}
 
-	dryRun := os.Getenv("DRY_RUN") == "true"
-
 	// Create GraphQL client
-	src := oauth2.StaticTokenSource(
-		&oauth2.Token{AccessToken: githubToken},
-	)
-	httpClient := oauth2.NewClient(ctx, src)
-	client := githubv4.NewClient(httpClient)
+	httpClient := github.NewHTTPClient(cfg.GithubToken, github.NewBudget())
+	client := githubv4.NewEnterpriseClient(cfg.GithubGraphQLURL, httpClient)
 
-	log.Printf("Fetching repositories for organization: %s\n", org)
+	logRunStart(ctx, cfg, "PR workflow deployment")
+	slog.InfoContext(ctx, "Fetching repositories", "org", org)
 
 	// Fetch all repositories
 	var query struct {
 		Organization struct {
 			Repositories struct {
-				Nodes    []Repository
+				Nodes    []deployRepository
 				PageInfo struct {
 					EndCursor   githubv4.String
 					HasNextPage bool
@@ -94,11 +91,11 @@ func main() {
 		"cursor": (*githubv4.String)(nil),
 	}
 
-	var allRepos []Repository
+	var allRepos []deployRepository
 
 	for {
 		if err := client.Query(ctx, &query, variables); err != nil {
-			log.Fatalf("Failed to query repositories: %v", err)
+			return nil, fmt.Errorf("failed to query repositories: %w", err)
 		}
 
 		allRepos = append(allRepos, query.Organization.Repositories.Nodes...)
@@ -110,77 +107,75 @@ func main() {
 		variables["cursor"] = githubv4.NewString(query.Organization.Repositories.PageInfo.EndCursor)
 	}
 
-	log.Printf("Found %d repositories\n", len(allRepos))
+	slog.InfoContext(ctx, "Found repositories", "count", len(allRepos))
 
 	// Deploy workflow to each repository
-	deploymentCount := 0
-	skippedCount := 0
-	errorCount := 0
+	report := &DeployReport{TotalRepos: len(allRepos)}
 
 	for _, repo := range allRepos {
 		// Skip project-agent itself
 		if repo.Name == "project-agent" {
-			log.Printf("Skipping project-agent repository\n")
-			skippedCount++
+			slog.InfoContext(ctx, "Skipping project-agent repository")
+			report.Skipped++
 			continue
 		}
 
-		log.Printf("\nProcessing: %s/%s\n", org, repo.Name)
+		ctx := logging.With(ctx, "repo", org+"/"+repo.Name)
+		slog.InfoContext(ctx, "Processing repository")
 
 		// Check if workflow already exists
 		workflowPath := ".github/workflows/notify-pr.yml"
 		exists, err := checkFileExists(ctx, client, org, repo.Name, workflowPath, repo.DefaultBranch.Name)
 		if err != nil {
-			log.Printf("  ERROR: Failed to check if workflow exists: %v\n", err)
-			errorCount++
+			slog.ErrorContext(ctx, "Failed to check if workflow exists", "error", err)
+			report.Errors = append(report.Errors, fmt.Sprintf("Failed to check if workflow exists in %s: %v", repo.Name, err))
 			continue
 		}
 
 		if exists {
-			log.Printf("  Workflow already exists, skipping\n")
-			skippedCount++
+			slog.InfoContext(ctx, "Workflow already exists, skipping")
+			report.Skipped++
 			continue
 		}
 
-		if dryRun {
-			log.Printf("  [DRY RUN] Would set PROJECT_AGENT_PAT secret\n")
-			log.Printf("  [DRY RUN] Would create workflow at %s\n", workflowPath)
-			deploymentCount++
+		if cfg.DryRun {
+			slog.InfoContext(ctx, "[DRY RUN] Would set PROJECT_AGENT_PAT secret")
+			slog.InfoContext(ctx, "[DRY RUN] Would create workflow", "path", workflowPath)
+			report.Deployed++
 		} else {
 			// Set the PROJECT_AGENT_PAT secret first
-			log.Printf("  Setting PROJECT_AGENT_PAT secret...\n")
-			if err := setRepositorySecret(ctx, githubToken, org, repo.Name, "PROJECT_AGENT_PAT", projectAgentPAT); err != nil {
-				log.Printf("  ERROR: Failed to set secret: %v\n", err)
-				errorCount++
+			slog.InfoContext(ctx, "Setting PROJECT_AGENT_PAT secret")
+			if err := setRepositorySecret(ctx, cfg.GithubToken, org, repo.Name, "PROJECT_AGENT_PAT", cfg.ProjectAgentPAT); err != nil {
+				slog.ErrorContext(ctx, "Failed to set secret", "error", err)
+				report.Errors = append(report.Errors, fmt.Sprintf("Failed to set secret in %s: %v", repo.Name, err))
 				continue
 			}
-			log.Printf("  Successfully set secret\n")
+			slog.InfoContext(ctx, "Successfully set secret")
 
 			// Then create the workflow file
-			if err := createWorkflowFile(ctx, githubToken, org, repo.Name, repo.DefaultBranch.Name, workflowPath); err != nil {
-				log.Printf("  ERROR: Failed to create workflow: %v\n", err)
-				errorCount++
+			if err := createWorkflowFile(ctx, cfg.GithubToken, org, repo.Name, repo.DefaultBranch.Name, workflowPath); err != nil {
+				slog.ErrorContext(ctx, "Failed to create workflow", "error", err)
+				report.Errors = append(report.Errors, fmt.Sprintf("Failed to create workflow in %s: %v", repo.Name, err))
 			} else {
-				log.Printf("  Successfully created workflow\n")
-				deploymentCount++
+				slog.InfoContext(ctx, "Successfully created workflow", "path", workflowPath)
+				report.Deployed++
 			}
 			time.Sleep(2 * time.Second) // Rate limiting
 		}
 	}
 
-	// Print summary
-	fmt.Println("\n" + "==========================================================")
-	fmt.Println("DEPLOYMENT SUMMARY")
-	fmt.Println("==========================================================")
-	fmt.Printf("Total repositories: %d\n", len(allRepos))
-	fmt.Printf("Workflows deployed: %d\n", deploymentCount)
-	fmt.Printf("Skipped: %d\n", skippedCount)
-	fmt.Printf("Errors: %d\n", errorCount)
-	fmt.Println("==========================================================")
-
-	if dryRun {
-		fmt.Println("\nThis was a dry run. Set DRY_RUN=false to perform actual deployment.")
+	s := &summary{
+		Command: "deploy-pr-workflow",
+		Title:   "Deployment Summary",
+		DryRun:  cfg.DryRun,
+		Errors:  report.Errors,
+		Report:  report,
 	}
+	s.field("Total repositories", report.TotalRepos)
+	s.field("Workflows deployed", report.Deployed)
+	s.field("Skipped", report.Skipped)
+
+	return s, nil
 }
 
 func checkFileExists(ctx context.Context, client *githubv4.Client, owner, repo, path, branch string) (bool, error) {
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"log/slog"
+	"os"
+
+	"github.com/storacha/project-agent/internal/logging"
+)
+
+// setupLogging makes the default logger write records of level and above to
+// stderr in format, text or json. The standard library's log package writes
+// through it too. The run ID is added by the command's context, since serve
+// starts many runs.
+func setupLogging(level, format string) error {
+	minLevel, err := logging.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	logger, err := logging.New(os.Stderr, format, minLevel)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(logger)
+	return nil
+}
+
+// fatal logs an error and exits
+func fatal(msg string, err error) {
+	slog.Error(msg, "run_id", runID, "error", err)
+	os.Exit(1)
+}
@@ -0,0 +1,211 @@
+// Command project-agent runs the project maintenance tasks. Each task is a
+// subcommand sharing the --config, --dry-run, --output, --report-file and
+// --log-level flags.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/storacha/project-agent/internal/config"
+	"github.com/storacha/project-agent/internal/github"
+	"github.com/storacha/project-agent/internal/logging"
+)
+
+// command is a project-agent subcommand
+type command struct {
+	name    string
+	summary string
+	// flags registers the command's own flags, if it has any
+	flags func(fs *flag.FlagSet)
+	// run performs the command and returns its report summary
+	run func(ctx context.Context, env *environment) (*summary, error)
+	// noConfig skips loading the configuration before run
+	noConfig bool
+}
+
+// commands lists every subcommand in the order usage shows them: the
+// registered tasks, then the commands that are not tasks
+var commands = append(taskCommands(),
+	applyCommand,
+	undoCommand,
+	serveCommand,
+	replayWebhookCommand,
+	deployPRWorkflowCommand,
+	configCommand,
+)
+
+// runID identifies this run in the audit log and reports
+var runID = github.NewRunID()
+
+// environment is what a command runs with: the loaded configuration and the
+// shared flag values
+type environment struct {
+	cfg        *config.Config
+	configPath string
+	output     string
+	args       []string // Positional arguments after the flags
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: project-agent <command> [flags]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-20s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Run 'project-agent <command> -h' for the command's flags.")
+}
+
+func main() {
+	// Load .env file if it exists (ignore error if file doesn't exist)
+	_ = godotenv.Load()
+
+	if len(os.Args) < 2 || os.Args[1] == "-h" || os.Args[1] == "--help" || os.Args[1] == "help" {
+		usage()
+		if len(os.Args) < 2 {
+			os.Exit(2)
+		}
+		return
+	}
+
+	var cmd *command
+	for _, c := range commands {
+		if c.name == os.Args[1] {
+			cmd = c
+		}
+	}
+	if cmd == nil {
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+
+	env := &environment{}
+	fs := flag.NewFlagSet("project-agent "+cmd.name, flag.ExitOnError)
+	fs.StringVar(&env.configPath, "config", os.Getenv("PROJECT_AGENT_CONFIG"), "path to the YAML config file")
+	dryRun := fs.Bool("dry-run", false, "report what would change without changing anything (default from DRY_RUN)")
+	fs.StringVar(&env.output, "output", "text", "report format: text, json or markdown")
+	reportFile := fs.String("report-file", os.Getenv("REPORT_FILE"), "also write the JSON report to this file")
+	logLevel := fs.String("log-level", envOr("LOG_LEVEL", "info"), "minimum log level: debug, info, warn or error")
+	logFormat := fs.String("log-format", envOr("LOG_FORMAT", "text"), "log format: text or json")
+	if cmd.flags != nil {
+		cmd.flags(fs)
+	}
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: project-agent %s [flags]\n\n%s\n\n", cmd.name, cmd.summary)
+		fs.PrintDefaults()
+	}
+	// Flags may come before, after or between positional arguments
+	args := os.Args[2:]
+	for {
+		_ = fs.Parse(args)
+		if fs.NArg() == 0 {
+			break
+		}
+		env.args = append(env.args, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+
+	if err := setupLogging(*logLevel, *logFormat); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid --log-level or --log-format: %v\n", err)
+		os.Exit(2)
+	}
+	if !validOutput(env.output) {
+		fatal("Invalid --output", fmt.Errorf("%q must be text, json or markdown", env.output))
+	}
+
+	if !cmd.noConfig {
+		cfg, err := config.Load(env.configPath)
+		if err != nil {
+			fatal("Failed to load configuration", err)
+		}
+		logging.AddSecrets(cfg.Secrets()...)
+		fs.Visit(func(f *flag.Flag) {
+			if f.Name == "dry-run" {
+				cfg.DryRun = *dryRun
+			}
+		})
+		env.cfg = cfg
+	}
+
+	ctx := logging.With(context.Background(), "run_id", runID, "command", cmd.name)
+	started := time.Now()
+	s, err := cmd.run(ctx, env)
+	recordRun(cmd.name, env.cfg, started, s, err)
+	exportMetrics(ctx, env.cfg, "command", cmd.name)
+	if err != nil {
+		fatal("Command failed", err)
+	}
+	if s == nil {
+		return
+	}
+	s.RunID = runID
+
+	if err := s.write(os.Stdout, env.output); err != nil {
+		fatal("Failed to write report", err)
+	}
+	if *reportFile != "" {
+		if err := s.writeFile(*reportFile, "json", false); err != nil {
+			fatal("Failed to write report", err)
+		}
+	}
+	// In a GitHub Actions job, the report also goes on the run's summary page
+	if path := os.Getenv("GITHUB_STEP_SUMMARY"); path != "" {
+		if err := s.writeFile(path, "markdown", true); err != nil {
+			slog.WarnContext(ctx, "Failed to write the job summary", "error", err)
+		}
+	}
+	if s.Failed {
+		os.Exit(1)
+	}
+}
+
+// newProjectClient creates the GitHub project client and checks that the
+// board has the fields and statuses the command needs. Its changes are
+// audited as run; an empty run leaves auditing to the caller.
+func newProjectClient(cfg *config.Config, req github.Requirements, run string) (*github.Client, error) {
+	if cfg.ProjectNumber == 0 {
+		return nil, fmt.Errorf("PROJECT_NUMBER environment variable or project_number in the config file is required")
+	}
+
+	githubClient, err := github.NewClientWithEndpoint(cfg.GithubGraphQLURL, cfg.GithubToken, cfg.GithubOrg, cfg.ProjectNumber)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GitHub client: %w", err)
+	}
+	if err := githubClient.Require(req); err != nil {
+		return nil, fmt.Errorf("project schema check failed: %w", err)
+	}
+	if run != "" && cfg.AuditLog != "" && !cfg.DryRun {
+		githubClient.SetAuditLog(github.NewAuditLog(cfg.AuditLog, run))
+	}
+	githubClient.SetCommentOptions(github.CommentOptions{MinimizeOutdated: cfg.MinimizeOutdatedComments})
+
+	return githubClient, nil
+}
+
+// logRunStart logs the record every command starts with
+func logRunStart(ctx context.Context, cfg *config.Config, what string) {
+	args := []any{"org", cfg.GithubOrg, "dry_run", cfg.DryRun}
+	if cfg.ProjectNumber != 0 {
+		args = append(args, "project", cfg.ProjectNumber)
+	}
+	slog.InfoContext(ctx, "Starting "+what, args...)
+	if cfg.DryRun {
+		slog.InfoContext(ctx, "[DRY RUN MODE] - No changes will be made")
+	}
+}
+
+func envOr(name, fallback string) string {
+	if value := strings.TrimSpace(os.Getenv(name)); value != "" {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/storacha/project-agent/internal/config"
+	"github.com/storacha/project-agent/internal/metrics"
+	"github.com/storacha/project-agent/internal/tasks"
+)
+
+// recordRun updates the run metrics of a command that started at started
+// and finished with s and err
+func recordRun(command string, cfg *config.Config, started time.Time, s *summary, err error) {
+	now := time.Now()
+	result := "success"
+	switch {
+	case err != nil || (s != nil && s.Failed):
+		result = "failure"
+	case cfg != nil && cfg.DryRun:
+		result = "dry-run"
+	}
+
+	metrics.Runs.Inc(command, result)
+	metrics.RunDuration.Set(now.Sub(started).Seconds(), command)
+	metrics.LastRun.Set(float64(now.Unix()), command)
+	if result == "success" {
+		metrics.LastSuccess.Set(float64(now.Unix()), command)
+	}
+}
+
+// recordReport counts a task report's items by outcome and sets its counts
+func recordReport(report *tasks.Report) {
+	for _, item := range report.Items {
+		metrics.Items.Inc(report.Task, string(item.Action), string(item.Outcome))
+	}
+	for key, value := range report.Values {
+		// Values read back from a plan file are float64
+		switch n := value.(type) {
+		case int:
+			metrics.ReportValues.Set(float64(n), report.Task, key)
+		case float64:
+			metrics.ReportValues.Set(n, report.Task, key)
+		}
+	}
+}
+
+// exportMetrics writes the metrics file and pushes to the Pushgateway, as
+// configured, under the job and the grouping labels, given as name, value
+// pairs. A single run pushes as its command, so each command's last run is
+// kept; serve pushes everything it has recorded as its instance.
+func exportMetrics(ctx context.Context, cfg *config.Config, grouping ...string) {
+	if cfg == nil {
+		return
+	}
+	if cfg.Metrics.File != "" {
+		if err := metrics.Default.WriteFile(cfg.Metrics.File); err != nil {
+			slog.WarnContext(ctx, "Failed to write metrics", "path", cfg.Metrics.File, "error", err)
+		}
+	}
+	if cfg.Metrics.PushURL != "" {
+		if err := metrics.Default.Push(ctx, cfg.Metrics.PushURL, cfg.Metrics.Job, grouping...); err != nil {
+			slog.WarnContext(ctx, "Failed to push metrics", "error", err)
+		}
+	}
+}
+
+// serveInstance is the Pushgateway instance label of a serve process
+func serveInstance() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		return "serve"
+	}
+	return host
+}
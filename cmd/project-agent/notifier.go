@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/storacha/project-agent/internal/config"
+	"github.com/storacha/project-agent/internal/discord"
+	"github.com/storacha/project-agent/internal/email"
+	"github.com/storacha/project-agent/internal/matrix"
+	"github.com/storacha/project-agent/internal/messages"
+	"github.com/storacha/project-agent/internal/notify"
+	"github.com/storacha/project-agent/internal/slack"
+	"github.com/storacha/project-agent/internal/tasks"
+)
+
+// notifier is what every transport's client provides: the tasks' Notifier,
+// failure reports, and the settings the runner applies
+type notifier interface {
+	tasks.Notifier
+	SendTaskFailure(ctx context.Context, task string, errs []string) error
+	SetStatusLayout(layout notify.StatusLayout)
+	SetTemplates(templates *messages.Templates)
+}
+
+// newNotifier creates the configured transport's client for kind. A channel
+// notifier is optional: it is nil, with no error, when the transport has no
+// report channel configured. A direct notifier is required.
+func newNotifier(cfg *config.Config, kind tasks.NotifierKind, templates *messages.Templates) (notifier, error) {
+	var n notifier
+	required := kind == tasks.DirectNotifier
+	missing := func(what string) error {
+		if required {
+			return fmt.Errorf("%s is required to send through %s", what, cfg.Notifier.Transport)
+		}
+		return nil
+	}
+
+	switch transport := cfg.Notifier; transport.Transport {
+	case "discord":
+		if required {
+			if cfg.DiscordBotToken == "" {
+				return nil, fmt.Errorf("DISCORD_BOT_TOKEN environment variable is required")
+			}
+			discordClient := discord.NewBotClient(cfg.DiscordBotToken)
+			discordClient.SetAPIURL(cfg.DiscordAPIURL)
+			n = discordClient
+		} else if cfg.DiscordWebhookURL != "" {
+			n = discord.NewClient(cfg.DiscordWebhookURL)
+		}
+
+	case "slack":
+		slackConfig := transport.Slack
+		switch {
+		case required && slackConfig.BotToken == "":
+			return nil, missing("SLACK_BOT_TOKEN")
+		case required || slackConfig.WebhookURL != "" || (slackConfig.BotToken != "" && slackConfig.Channel != ""):
+			slackClient := slack.NewClient(slackConfig.WebhookURL, slackConfig.BotToken, slackConfig.Channel)
+			slackClient.SetAPIURL(slackConfig.APIURL)
+			n = slackClient
+		}
+
+	case "matrix":
+		matrixConfig := transport.Matrix
+		switch {
+		case matrixConfig.Homeserver == "" || matrixConfig.AccessToken == "":
+			if err := missing("notifier.matrix.homeserver with MATRIX_ACCESS_TOKEN"); err != nil {
+				return nil, err
+			}
+		case required || matrixConfig.Room != "":
+			n = matrix.NewClient(matrixConfig.Homeserver, matrixConfig.AccessToken, matrixConfig.Room)
+		}
+
+	case "email":
+		emailConfig := transport.Email
+		switch {
+		case emailConfig.Host == "" || emailConfig.From == "":
+			if err := missing("notifier.email.host with notifier.email.from"); err != nil {
+				return nil, err
+			}
+		case required || len(emailConfig.To) > 0:
+			n = email.NewClient(emailConfig.Host, emailConfig.Port, emailConfig.Username, emailConfig.Password, emailConfig.From, emailConfig.To)
+		}
+
+	default:
+		return nil, fmt.Errorf("unknown notifier transport %q", transport.Transport)
+	}
+
+	if n == nil {
+		return nil, nil
+	}
+	n.SetStatusLayout(notify.StatusLayout{Active: cfg.Statuses.Active, Order: cfg.Statuses.Order})
+	n.SetTemplates(templates)
+	return n, nil
+}
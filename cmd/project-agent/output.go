@@ -0,0 +1,275 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/storacha/project-agent/internal/logging"
+)
+
+// maxErrorsShown caps the errors listed in text and markdown reports
+const maxErrorsShown = 10
+
+// maxItemsShown caps the items listed in text and markdown reports; the
+// JSON report has them all
+const maxItemsShown = 50
+
+// reportVersion is the version of the JSON report's layout. It changes
+// whenever a field is removed or changes meaning.
+const reportVersion = 1
+
+// summary is a command's report in a form every output format can render
+type summary struct {
+	Command  string
+	Title    string
+	DryRun   bool
+	RunID    string
+	PlanFile string           // Where the planned changes were saved, if they were
+	Fields   []summaryField   // Headline counts, in order
+	Sections []summarySection // Detail lists, in order
+	Items    []summaryItem    // What became of each change, in order
+	Notes    []string         // Free-form lines shown after the sections
+	Errors   []string
+	// Failed makes the command exit non-zero once the report is written
+	Failed bool
+	// Report is the task's own report, written as-is by --output=json
+	Report interface{}
+}
+
+type summaryField struct {
+	Label string
+	Value interface{}
+}
+
+type summarySection struct {
+	Heading string
+	Lines   []string
+}
+
+type summaryItem struct {
+	Outcome string
+	Text    string
+	Reason  string // Why the change failed or was skipped
+}
+
+// outcomes counts the items by outcome, as "2 done, 1 failed"
+func (s *summary) outcomes() string {
+	counts := make(map[string]int)
+	var order []string
+	for _, item := range s.Items {
+		if counts[item.Outcome] == 0 {
+			order = append(order, item.Outcome)
+		}
+		counts[item.Outcome]++
+	}
+	parts := make([]string, len(order))
+	for i, outcome := range order {
+		parts[i] = fmt.Sprintf("%d %s", counts[outcome], outcome)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// field appends a headline count or value
+func (s *summary) field(label string, value interface{}) {
+	s.Fields = append(s.Fields, summaryField{Label: label, Value: value})
+}
+
+// section appends a detail list; empty lists are skipped
+func (s *summary) section(heading string, lines []string) {
+	if len(lines) > 0 {
+		s.Sections = append(s.Sections, summarySection{Heading: heading, Lines: lines})
+	}
+}
+
+func validOutput(format string) bool {
+	return format == "text" || format == "json" || format == "markdown"
+}
+
+// write renders the summary in the given format
+func (s *summary) write(w io.Writer, format string) error {
+	// Errors can quote secrets, such as a webhook URL in a failed request,
+	// so the report is redacted like the logs
+	var b bytes.Buffer
+	var err error
+	switch format {
+	case "json":
+		err = s.writeJSON(&b)
+	case "markdown":
+		err = s.writeMarkdown(&b)
+	default:
+		err = s.writeText(&b)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, logging.Redact(b.String()))
+	return err
+}
+
+// writeFile writes the summary in the given format to path, appending to the
+// file if appendTo is set
+func (s *summary) writeFile(path, format string, appendTo bool) error {
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if appendTo {
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+	f, err := os.OpenFile(path, flags, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	if err := s.write(f, format); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return f.Close()
+}
+
+func (s *summary) writeJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(struct {
+		Version  int         `json:"version"`
+		Command  string      `json:"command"`
+		RunID    string      `json:"run_id"`
+		DryRun   bool        `json:"dry_run"`
+		PlanFile string      `json:"plan_file,omitempty"`
+		RunDate  time.Time   `json:"run_date"`
+		Report   interface{} `json:"report"`
+	}{reportVersion, s.Command, s.RunID, s.DryRun, s.PlanFile, time.Now().UTC(), s.Report})
+}
+
+func (s *summary) writeText(w io.Writer) error {
+	rule := strings.Repeat("=", 60)
+	var b strings.Builder
+
+	fmt.Fprintln(&b, "\n"+rule)
+	fmt.Fprintln(&b, strings.ToUpper(s.Title))
+	fmt.Fprintln(&b, rule)
+	if s.DryRun {
+		fmt.Fprintln(&b, "[DRY RUN MODE - No changes were made]")
+	}
+	fmt.Fprintf(&b, "Run Date: %s\n", time.Now().Format(time.RFC3339))
+	fmt.Fprintf(&b, "Run ID: %s\n\n", s.RunID)
+
+	for _, f := range s.Fields {
+		fmt.Fprintf(&b, "%s: %v\n", f.Label, f.Value)
+	}
+	for _, section := range s.Sections {
+		fmt.Fprintf(&b, "\n%s:\n", section.Heading)
+		for _, line := range section.Lines {
+			fmt.Fprintf(&b, "  - %s\n", line)
+		}
+	}
+	if len(s.Items) > 0 {
+		fmt.Fprintf(&b, "\nChanges (%s):\n", s.outcomes())
+		for i, item := range s.Items {
+			if i == maxItemsShown {
+				fmt.Fprintf(&b, "  ... and %d more\n", len(s.Items)-maxItemsShown)
+				break
+			}
+			fmt.Fprintf(&b, "  - [%s] %s\n", item.Outcome, item.Text)
+			if item.Reason != "" {
+				fmt.Fprintf(&b, "      %s\n", item.Reason)
+			}
+		}
+	}
+	if len(s.Notes) > 0 {
+		if len(s.Fields) > 0 || len(s.Sections) > 0 || len(s.Items) > 0 {
+			fmt.Fprintln(&b)
+		}
+		for _, note := range s.Notes {
+			fmt.Fprintln(&b, note)
+		}
+	}
+
+	if len(s.Errors) > 0 {
+		fmt.Fprintf(&b, "\nErrors encountered: %d\n", len(s.Errors))
+		for i, errMsg := range s.Errors {
+			if i == maxErrorsShown {
+				fmt.Fprintf(&b, "  ... and %d more errors\n", len(s.Errors)-maxErrorsShown)
+				break
+			}
+			fmt.Fprintf(&b, "  - %s\n", errMsg)
+		}
+	}
+
+	fmt.Fprintln(&b, "\n"+rule)
+	if s.PlanFile != "" {
+		fmt.Fprintf(&b, "\nThe plan was saved to %s. Review it, then run 'project-agent apply %s'.\n", s.PlanFile, s.PlanFile)
+	} else if s.DryRun {
+		fmt.Fprintln(&b, "\nThis was a dry run. Run without --dry-run to apply changes.")
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func (s *summary) writeMarkdown(w io.Writer) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "## %s\n\n", s.Title)
+	if s.DryRun {
+		fmt.Fprintln(&b, "> **Dry run** - no changes were made")
+		fmt.Fprintln(&b)
+	}
+
+	if len(s.Fields) > 0 {
+		fmt.Fprintln(&b, "| | |")
+		fmt.Fprintln(&b, "|---|---|")
+		for _, f := range s.Fields {
+			fmt.Fprintf(&b, "| %s | %v |\n", markdownCell(f.Label), markdownCell(fmt.Sprint(f.Value)))
+		}
+		fmt.Fprintln(&b)
+	}
+	for _, section := range s.Sections {
+		fmt.Fprintf(&b, "### %s\n\n", section.Heading)
+		for _, line := range section.Lines {
+			fmt.Fprintf(&b, "- %s\n", line)
+		}
+		fmt.Fprintln(&b)
+	}
+	if len(s.Items) > 0 {
+		fmt.Fprintf(&b, "### Changes (%s)\n\n", s.outcomes())
+		fmt.Fprintln(&b, "| Outcome | Change | Details |")
+		fmt.Fprintln(&b, "|---|---|---|")
+		for i, item := range s.Items {
+			if i == maxItemsShown {
+				fmt.Fprintf(&b, "| | ... and %d more | |\n", len(s.Items)-maxItemsShown)
+				break
+			}
+			fmt.Fprintf(&b, "| %s | %s | %s |\n", item.Outcome, markdownCell(item.Text), markdownCell(item.Reason))
+		}
+		fmt.Fprintln(&b)
+	}
+	for _, note := range s.Notes {
+		fmt.Fprintf(&b, "%s\n\n", note)
+	}
+	if s.PlanFile != "" {
+		fmt.Fprintf(&b, "Plan saved to `%s`; apply it with `project-agent apply %s`.\n\n", s.PlanFile, s.PlanFile)
+	}
+
+	if len(s.Errors) > 0 {
+		fmt.Fprintf(&b, "### Errors (%d)\n\n", len(s.Errors))
+		for i, errMsg := range s.Errors {
+			if i == maxErrorsShown {
+				fmt.Fprintf(&b, "- ... and %d more errors\n", len(s.Errors)-maxErrorsShown)
+				break
+			}
+			fmt.Fprintf(&b, "- %s\n", errMsg)
+		}
+		fmt.Fprintln(&b)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// markdownCell escapes the characters that would break a table cell
+func markdownCell(s string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ").Replace(s)
+}
@@ -0,0 +1,282 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+
+	"github.com/storacha/project-agent/internal/config"
+	"github.com/storacha/project-agent/internal/directory"
+	"github.com/storacha/project-agent/internal/logging"
+	"github.com/storacha/project-agent/internal/messages"
+	"github.com/storacha/project-agent/internal/similarity"
+	"github.com/storacha/project-agent/internal/state"
+	"github.com/storacha/project-agent/internal/tasks"
+)
+
+// taskCommands returns a subcommand for every registered task
+func taskCommands() []*command {
+	var cmds []*command
+	for _, t := range tasks.All() {
+		t := t
+		var planOut string
+		cmd := &command{
+			name:    t.Name(),
+			summary: t.Summary(),
+			flags: func(fs *flag.FlagSet) {
+				fs.StringVar(&planOut, "plan-out", "", "write the plan to this file for 'project-agent apply' instead of applying it")
+				if ft, ok := t.(tasks.FlagTask); ok {
+					ft.Flags(fs)
+				}
+			},
+			run: func(ctx context.Context, env *environment) (*summary, error) {
+				return runTask(ctx, t, env.cfg, planOut)
+			},
+		}
+		cmds = append(cmds, cmd)
+	}
+	return cmds
+}
+
+// runTask sets up the clients a task needs, plans it and, unless this is a
+// dry run or the plan is being saved, applies the plan. Failed runs are
+// reported to the configured notifier's report channel when failure
+// notifications are on.
+func runTask(ctx context.Context, t tasks.Task, cfg *config.Config, planOut string) (*summary, error) {
+	ctx = logging.With(ctx, "task", t.Name())
+	env, closeEnv, err := newTaskEnv(ctx, cfg, t.Needs(cfg), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer closeEnv()
+
+	return planTask(ctx, t, env, planOut)
+}
+
+// planTask plans a task against env and, unless this is a dry run or the
+// plan is being saved, applies the plan
+func planTask(ctx context.Context, t tasks.Task, env *tasks.Env, planOut string) (*summary, error) {
+	cfg := env.Config
+	logRunStart(ctx, cfg, t.Name())
+
+	plan, err := t.Plan(ctx, env)
+	if err != nil {
+		notifyFailure(ctx, cfg, t.Name(), []string{err.Error()})
+		return failedSummary(t, cfg, nil, err), err
+	}
+
+	if planOut != "" {
+		if err := tasks.WritePlanFile(planOut, tasks.NewPlanFile(plan, cfg)); err != nil {
+			return nil, err
+		}
+		slog.InfoContext(ctx, fmt.Sprintf("Wrote the plan; run 'project-agent apply %s' to make the changes", planOut), "path", planOut, "changes", len(plan.Actions))
+		plan.Report.Planned(plan.Actions)
+		recordReport(plan.Report)
+		s := taskSummary(t.Schema(cfg), plan.Report, true)
+		s.PlanFile = planOut
+		return s, nil
+	}
+
+	return applyPlan(ctx, t, env, plan)
+}
+
+// applyPlan applies a task's plan, or logs it on a dry run, and summarizes
+// the result. Planned actions the task did not get to are reported as
+// skipped.
+func applyPlan(ctx context.Context, t tasks.Task, env *tasks.Env, plan *tasks.Plan) (*summary, error) {
+	cfg := env.Config
+	report := plan.Report
+	if cfg.DryRun {
+		for _, action := range plan.Actions {
+			slog.InfoContext(ctx, "[DRY RUN] Would: "+action.String(), action.LogAttrs()...)
+		}
+		report.Planned(plan.Actions)
+	} else {
+		var err error
+		report, err = t.Apply(ctx, env, plan)
+		if report == nil {
+			report = plan.Report
+		}
+		report.Unapplied(plan.Actions)
+		if err != nil {
+			notifyFailure(ctx, cfg, t.Name(), []string{err.Error()})
+			return failedSummary(t, cfg, report, err), err
+		}
+		if env.State != nil {
+			if err := env.State.Save(ctx); err != nil {
+				slog.ErrorContext(ctx, "Failed to save state", "error", err)
+				report.Errors = append(report.Errors, fmt.Sprintf("Failed to save state: %v", err))
+			}
+		}
+	}
+
+	if len(report.Errors) > 0 {
+		notifyFailure(ctx, cfg, t.Name(), report.Errors)
+	}
+	recordReport(report)
+
+	return taskSummary(t.Schema(cfg), report, cfg.DryRun), nil
+}
+
+// newTaskEnv creates the clients a task needs, reusing those shared has, if
+// it is given. The returned function releases the clients it created.
+func newTaskEnv(ctx context.Context, cfg *config.Config, needs tasks.Needs, shared *tasks.Env) (*tasks.Env, func(), error) {
+	templates, err := messages.Load(cfg.TemplatesDir)
+	if err != nil {
+		return nil, nil, err
+	}
+	people, err := loadDirectory(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	env := &tasks.Env{Config: cfg, Messages: templates, Directory: people}
+	if shared != nil {
+		env.Board, env.PullRequests, env.Scorer = shared.Board, shared.PullRequests, shared.Scorer
+	}
+	var closers []func()
+	closeEnv := func() {
+		for _, c := range closers {
+			c()
+		}
+	}
+
+	if needs.Board && env.Board == nil {
+		githubClient, err := newProjectClient(cfg, needs.Schema, runID)
+		if err != nil {
+			return nil, nil, err
+		}
+		env.Board = githubClient
+		env.PullRequests = githubClient
+	}
+
+	if needs.Similarity && env.Scorer == nil {
+		if cfg.GeminiAPIKey == "" {
+			return nil, nil, fmt.Errorf("GEMINI_API_KEY environment variable is required for similarity scoring")
+		}
+		similarityClient, err := similarity.NewClient(cfg.GeminiAPIKey)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create similarity client: %w", err)
+		}
+		env.Scorer = similarityClient
+		closers = append(closers, func() { similarityClient.Close() })
+	}
+
+	if needs.State {
+		store, err := openState(ctx, cfg)
+		if err != nil {
+			closeEnv()
+			return nil, nil, err
+		}
+		env.State = store
+		closers = append(closers, func() { store.Close() })
+	}
+
+	if needs.Notifier != tasks.NoNotifier {
+		n, err := newNotifier(cfg, needs.Notifier, templates)
+		if err != nil {
+			closeEnv()
+			return nil, nil, err
+		}
+		if n != nil {
+			env.Notifier = n
+		}
+	}
+
+	return env, closeEnv, nil
+}
+
+// loadDirectory loads the team directory, adding the people in the users
+// mappings who are not in it
+func loadDirectory(cfg *config.Config) (*directory.Directory, error) {
+	people, err := directory.Load(cfg.Directory)
+	if err != nil {
+		return nil, err
+	}
+	people.AddMappings(cfg.UserMappings, cfg.Notifier.Transport)
+	return people, nil
+}
+
+// openState opens the configured state store
+func openState(ctx context.Context, cfg *config.Config) (state.Store, error) {
+	store, err := state.Open(ctx, state.Options{
+		Backend: cfg.State.Backend,
+		Path:    cfg.State.Path,
+		Repo:    cfg.State.Repo,
+		Branch:  cfg.State.Branch,
+		Token:   cfg.GithubToken,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open state: %w", err)
+	}
+	return store, nil
+}
+
+// notifyFailure posts a failed run to the report channel. Dry runs are
+// never reported.
+func notifyFailure(ctx context.Context, cfg *config.Config, task string, errs []string) {
+	if cfg.DryRun || !cfg.NotifyFailures {
+		return
+	}
+
+	// Broken template overrides may be what failed the run, so the built-in
+	// templates are used instead
+	templates, err := messages.Load(cfg.TemplatesDir)
+	if err != nil {
+		templates = messages.Default()
+	}
+	n, err := newNotifier(cfg, tasks.ChannelNotifier, templates)
+	if err != nil || n == nil {
+		return
+	}
+	redacted := make([]string, len(errs))
+	for i, err := range errs {
+		redacted[i] = logging.Redact(err)
+	}
+	if err := n.SendTaskFailure(ctx, task, redacted); err != nil {
+		slog.WarnContext(ctx, "Failed to send failure notification", "error", err)
+	}
+}
+
+// failedSummary summarizes a run that stopped with err, from the report it
+// got to, if any, so the changes it made before failing are still reported
+func failedSummary(t tasks.Task, cfg *config.Config, report *tasks.Report, err error) *summary {
+	if report == nil {
+		report = tasks.NewReport(t.Name())
+	}
+	report.Errors = append(report.Errors, err.Error())
+	return taskSummary(t.Schema(cfg), report, cfg.DryRun)
+}
+
+// taskSummary renders a task report through its schema, with the outcome of
+// each item
+func taskSummary(schema tasks.ReportSchema, report *tasks.Report, dryRun bool) *summary {
+	s := &summary{
+		Command: report.Task,
+		Title:   schema.Title,
+		DryRun:  dryRun,
+		Notes:   report.Notes,
+		Errors:  report.Errors,
+		Failed:  len(report.Errors) > 0,
+		Report:  report,
+	}
+
+	for _, spec := range schema.Fields {
+		value, ok := report.Values[spec.Key]
+		if !ok {
+			value = 0
+		}
+		if spec.OmitZero && (value == 0 || value == "") {
+			continue
+		}
+		s.field(spec.Label, value)
+	}
+	for _, section := range report.Sections {
+		s.section(section.Heading, section.Lines)
+	}
+	for _, item := range report.Items {
+		s.Items = append(s.Items, summaryItem{Outcome: string(item.Outcome), Text: item.Summary, Reason: item.Reason})
+	}
+
+	return s
+}
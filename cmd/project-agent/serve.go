@@ -0,0 +1,414 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/storacha/project-agent/internal/config"
+	"github.com/storacha/project-agent/internal/github"
+	"github.com/storacha/project-agent/internal/logging"
+	"github.com/storacha/project-agent/internal/metrics"
+	"github.com/storacha/project-agent/internal/schedule"
+	"github.com/storacha/project-agent/internal/similarity"
+	"github.com/storacha/project-agent/internal/tasks"
+	"github.com/storacha/project-agent/internal/webhook"
+)
+
+var serveCommand = &command{
+	name:    "serve",
+	summary: "run tasks on the schedule in the config and on GitHub webhooks until stopped, serving /metrics",
+	run:     runServe,
+}
+
+// runServe runs the scheduled tasks, and the tasks webhook deliveries call
+// for, until SIGTERM or SIGINT. The GitHub client and project metadata are
+// loaded once and shared by every run. On shutdown, running tasks get
+// serve.shutdown_timeout to finish before they are cancelled; their reports
+// are written either way.
+func runServe(ctx context.Context, env *environment) (*summary, error) {
+	cfg := env.cfg
+	loc, err := time.LoadLocation(cfg.Serve.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid serve.timezone: %w", err)
+	}
+
+	jobs, err := scheduledJobs(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if len(jobs) == 0 && cfg.Serve.Addr == "" {
+		return nil, fmt.Errorf("nothing to serve: serve.schedule is empty and serve.addr is off")
+	}
+
+	s := &scheduler{cfg: cfg, out: os.Stdout, output: env.output, instance: serveInstance(), running: make(map[string]bool), pending: make(map[string]pendingRun)}
+	var all []tasks.Task
+	for _, j := range jobs {
+		all = append(all, j.task)
+	}
+	if cfg.Serve.WebhookSecret != "" {
+		all = append(all, webhookTasks...)
+	}
+	if err := s.openClients(ctx, all); err != nil {
+		return nil, err
+	}
+	defer s.close()
+
+	sigCtx, stop := signal.NotifyContext(ctx, syscall.SIGTERM, os.Interrupt)
+	defer stop()
+	// Runs outlive the signal until the shutdown timeout
+	runCtx, cancelRuns := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelRuns()
+
+	var server *http.Server
+	if cfg.Serve.Addr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", metrics.Default.Handler())
+		mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintln(w, "ok")
+		})
+		paths := []string{"/metrics", "/healthz"}
+		if cfg.Serve.WebhookSecret != "" {
+			mux.Handle("/webhook", s.webhookHandler(runCtx))
+			paths = append(paths, "/webhook")
+		}
+		server = &http.Server{
+			Addr:              cfg.Serve.Addr,
+			Handler:           mux,
+			ReadHeaderTimeout: 10 * time.Second,
+			// Requests log with the command's attributes
+			BaseContext: func(net.Listener) context.Context { return ctx },
+		}
+		go func() {
+			if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				slog.ErrorContext(ctx, "HTTP server failed", "addr", cfg.Serve.Addr, "error", err)
+				stop()
+			}
+		}()
+		slog.InfoContext(ctx, "Serving", "addr", cfg.Serve.Addr, "paths", paths)
+	}
+
+	var loops sync.WaitGroup
+	for _, job := range jobs {
+		job := job
+		loops.Add(1)
+		go func() {
+			defer loops.Done()
+			s.loop(sigCtx, runCtx, job, loc)
+		}()
+	}
+
+	<-sigCtx.Done()
+	slog.InfoContext(ctx, "Shutting down", "running", s.runningTasks())
+	loops.Wait()
+
+	if server != nil {
+		shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+		_ = server.Shutdown(shutdownCtx)
+		cancel()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		s.wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(cfg.Serve.ShutdownTimeout):
+		slog.WarnContext(ctx, "Tasks did not finish in time; cancelling them", "running", s.runningTasks(), "timeout", cfg.Serve.ShutdownTimeout)
+		cancelRuns()
+		<-done
+	}
+
+	slog.InfoContext(ctx, "Stopped")
+	return nil, nil
+}
+
+// job is a task and when it runs
+type job struct {
+	task     tasks.Task
+	schedule *schedule.Schedule
+}
+
+// scheduledJobs looks up the tasks in the schedule, in name order
+func scheduledJobs(cfg *config.Config) ([]job, error) {
+	names := make([]string, 0, len(cfg.Serve.Schedule))
+	for name := range cfg.Serve.Schedule {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var jobs []job
+	for _, name := range names {
+		t, ok := tasks.Lookup(name)
+		if !ok {
+			return nil, fmt.Errorf("serve.schedule names unknown task %q", name)
+		}
+		sched, err := schedule.Parse(cfg.Serve.Schedule[name])
+		if err != nil {
+			return nil, fmt.Errorf("serve.schedule.%s: %w", name, err)
+		}
+		// Task flags take their defaults, such as from the environment
+		if ft, ok := t.(tasks.FlagTask); ok {
+			fs := flag.NewFlagSet(name, flag.ContinueOnError)
+			ft.Flags(fs)
+			_ = fs.Parse(nil)
+		}
+		jobs = append(jobs, job{task: t, schedule: sched})
+	}
+	return jobs, nil
+}
+
+// webhookTasks are the tasks webhook deliveries may call for
+var webhookTasks = []tasks.Task{&tasks.PRLinking{}, tasks.ProcessInitiatives{}}
+
+// scheduler starts task runs, never more than one with the same key at a
+// time. Scheduled runs are keyed by task name.
+type scheduler struct {
+	cfg      *config.Config
+	out      io.Writer // Where reports are written, in output's format
+	output   string
+	instance string                 // Pushgateway instance label the metrics are pushed under
+	board    *github.Client         // Shared by every run, when a task needs the board
+	scorer   tasks.SimilarityScorer // Shared by every run, when a task scores similarity
+	closer   func()
+
+	mu      sync.Mutex
+	running map[string]bool
+	pending map[string]pendingRun // Runs queued behind the running one with the same key
+	closed  bool
+	runs    sync.WaitGroup
+	stateMu sync.Mutex // Runs that use the state store take turns
+	outMu   sync.Mutex // Reports are written whole
+}
+
+// pendingRun is a run waiting for the running one with the same key
+type pendingRun struct {
+	ctx     context.Context
+	task    tasks.Task
+	trigger string
+}
+
+// openClients creates the clients the tasks share: the GitHub client, with
+// the project metadata and a schema check covering every task, and the
+// similarity client
+func (s *scheduler) openClients(ctx context.Context, all []tasks.Task) error {
+	var needs tasks.Needs
+	for _, t := range all {
+		n := t.Needs(s.cfg)
+		needs.Board = needs.Board || n.Board
+		needs.Similarity = needs.Similarity || n.Similarity
+		needs.Schema.Fields = append(needs.Schema.Fields, n.Schema.Fields...)
+		needs.Schema.StatusOptions = append(needs.Schema.StatusOptions, n.Schema.StatusOptions...)
+	}
+
+	if needs.Board {
+		board, err := newProjectClient(s.cfg, needs.Schema, "")
+		if err != nil {
+			return err
+		}
+		s.board = board
+	}
+	if needs.Similarity {
+		if s.cfg.GeminiAPIKey == "" {
+			return fmt.Errorf("GEMINI_API_KEY environment variable is required for similarity scoring")
+		}
+		scorer, err := similarity.NewClient(s.cfg.GeminiAPIKey)
+		if err != nil {
+			return fmt.Errorf("failed to create similarity client: %w", err)
+		}
+		s.scorer = scorer
+		s.closer = func() { scorer.Close() }
+	}
+	return nil
+}
+
+func (s *scheduler) close() {
+	if s.closer != nil {
+		s.closer()
+	}
+}
+
+// loop starts a job's runs on its schedule until ctx is done. Runs get
+// runCtx, which outlives ctx.
+func (s *scheduler) loop(ctx, runCtx context.Context, j job, loc *time.Location) {
+	name := j.task.Name()
+	for {
+		next := j.schedule.Next(time.Now().In(loc))
+		if next.IsZero() {
+			slog.WarnContext(ctx, "Schedule never fires", "task", name, "schedule", j.schedule.String())
+			return
+		}
+		slog.InfoContext(ctx, "Next run scheduled", "task", name, "at", next.Format(time.RFC3339))
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+			s.start(runCtx, name, j.task, "schedule")
+		}
+	}
+}
+
+// start runs a task in the background, unless a run with the same key is
+// already going or the scheduler is shutting down. It reports whether the
+// run started.
+func (s *scheduler) start(ctx context.Context, key string, t tasks.Task, trigger string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return false
+	}
+	if s.running[key] {
+		slog.WarnContext(ctx, "Previous run is still going, skipping this one", "task", t.Name(), "key", key, "trigger", trigger)
+		metrics.Runs.Inc(t.Name(), "overlap")
+		return false
+	}
+	s.launch(ctx, key, t, trigger)
+	return true
+}
+
+// queue runs a task in the background like start, but when a run with the
+// same key is going, it runs once that finishes instead, as what it acts on
+// may have changed since that run read it. A later queued run replaces an
+// earlier one. It reports false only when shutting down.
+func (s *scheduler) queue(ctx context.Context, key string, t tasks.Task, trigger string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return false
+	}
+	if s.running[key] {
+		s.pending[key] = pendingRun{ctx: ctx, task: t, trigger: trigger}
+		return true
+	}
+	s.launch(ctx, key, t, trigger)
+	return true
+}
+
+// launch starts the runs of key, the given one and then any queued behind
+// it. The caller holds s.mu.
+func (s *scheduler) launch(ctx context.Context, key string, t tasks.Task, trigger string) {
+	s.running[key] = true
+	s.runs.Add(1)
+	go func() {
+		defer s.runs.Done()
+		for {
+			s.run(ctx, t, trigger)
+
+			s.mu.Lock()
+			next, ok := s.pending[key]
+			delete(s.pending, key)
+			if !ok || s.closed {
+				delete(s.running, key)
+				s.mu.Unlock()
+				if ok {
+					slog.WarnContext(next.ctx, "Dropping a queued run on shutdown", "task", next.task.Name(), "key", key)
+				}
+				return
+			}
+			s.mu.Unlock()
+			ctx, t, trigger = next.ctx, next.task, next.trigger
+		}
+	}()
+}
+
+// webhookHandler receives GitHub webhook deliveries and queues the runs they
+// call for with runCtx
+func (s *scheduler) webhookHandler(runCtx context.Context) http.Handler {
+	var opts webhook.Options
+	if s.board != nil {
+		opts.ProjectID = s.board.ProjectID()
+	}
+	return webhook.NewHandler(s.cfg.Serve.WebhookSecret, opts, func(d webhook.Delivery, j webhook.Job) bool {
+		ctx := logging.With(runCtx, "delivery", d.ID, "event", d.Event)
+		return s.queue(ctx, j.Key, j.Task, "webhook")
+	})
+}
+
+// run runs a task once with its own run ID and writes its report. A run
+// that fails, or is cancelled on shutdown, still writes the report it got
+// to, marked failed.
+func (s *scheduler) run(ctx context.Context, t tasks.Task, trigger string) {
+	id := github.NewRunID()
+	ctx = logging.With(ctx, "run_id", id, "task", t.Name(), "trigger", trigger)
+	needs := t.Needs(s.cfg)
+	if needs.State {
+		s.stateMu.Lock()
+		defer s.stateMu.Unlock()
+	}
+
+	started := time.Now()
+	sum, err := s.runOnce(ctx, t, needs, id)
+	recordRun(t.Name(), s.cfg, started, sum, err)
+	// The registry holds every task's series, so they are pushed as one
+	// group; a command label would clash with the series' own
+	exportMetrics(ctx, s.cfg, "instance", s.instance)
+
+	if sum != nil {
+		sum.RunID = id
+		sum.Failed = sum.Failed || err != nil
+		s.outMu.Lock()
+		if err := sum.write(s.out, s.output); err != nil {
+			slog.ErrorContext(ctx, "Failed to write report", "error", err)
+		}
+		s.outMu.Unlock()
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Task failed", "error", err)
+	}
+}
+
+// runOnce plans and applies a task with the shared clients, auditing its
+// changes as run id
+func (s *scheduler) runOnce(ctx context.Context, t tasks.Task, needs tasks.Needs, id string) (*summary, error) {
+	shared := &tasks.Env{Scorer: s.scorer}
+	if s.board != nil {
+		board := s.board
+		if s.cfg.AuditLog != "" && !s.cfg.DryRun {
+			board = board.WithAuditLog(github.NewAuditLog(s.cfg.AuditLog, id))
+		}
+		shared.Board, shared.PullRequests = board, board
+	}
+
+	env, closeEnv, err := newTaskEnv(ctx, s.cfg, needs, shared)
+	if err != nil {
+		return failedSummary(t, s.cfg, nil, err), err
+	}
+	defer closeEnv()
+	return planTask(ctx, t, env, "")
+}
+
+// wait stops new runs from starting and waits for the running ones
+func (s *scheduler) wait() {
+	s.mu.Lock()
+	s.closed = true
+	s.mu.Unlock()
+	s.runs.Wait()
+}
+
+// runningTasks lists the tasks running now
+func (s *scheduler) runningTasks() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var names []string
+	for name := range s.running {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/storacha/project-agent/internal/config"
+	"github.com/storacha/project-agent/internal/github"
+	"github.com/storacha/project-agent/internal/tasks"
+)
+
+// slowTask plans three moves and applies the first, then waits to be
+// cancelled before failing the second
+type slowTask struct {
+	applying chan struct{} // Closed once the first move is applied
+}
+
+func (*slowTask) Name() string                     { return "slow-task" }
+func (*slowTask) Summary() string                  { return "moves issues slowly" }
+func (*slowTask) Needs(*config.Config) tasks.Needs { return tasks.Needs{} }
+func (*slowTask) Schema(*config.Config) tasks.ReportSchema {
+	return tasks.ReportSchema{Title: "Slow Task"}
+}
+
+func (t *slowTask) Plan(ctx context.Context, env *tasks.Env) (*tasks.Plan, error) {
+	plan := &tasks.Plan{Task: t.Name(), Report: tasks.NewReport(t.Name())}
+	for number := 1; number <= 3; number++ {
+		plan.Actions = append(plan.Actions, tasks.Action{
+			Kind:  tasks.ActionMove,
+			Issue: github.Issue{Number: number, RepositoryOwner: "storacha", RepositoryName: "guppy"},
+			Value: "Done",
+		})
+	}
+	return plan, nil
+}
+
+func (t *slowTask) Apply(ctx context.Context, env *tasks.Env, plan *tasks.Plan) (*tasks.Report, error) {
+	report := plan.Report
+	report.Record(plan.Actions[0], nil)
+	close(t.applying)
+	<-ctx.Done()
+	report.Record(plan.Actions[1], ctx.Err())
+	return report, ctx.Err()
+}
+
+func TestSchedulerWritesTheReportOfACancelledRun(t *testing.T) {
+	cfg := config.Default()
+	cfg.NotifyFailures = false
+	cfg.AuditLog = ""
+	var out bytes.Buffer
+	s := &scheduler{cfg: cfg, out: &out, output: "json", running: make(map[string]bool), pending: make(map[string]pendingRun)}
+	task := &slowTask{applying: make(chan struct{})}
+
+	// As on shutdown, the run is cancelled once it is under way
+	runCtx, cancelRuns := context.WithCancel(context.Background())
+	if !s.start(runCtx, task.Name(), task, "schedule") {
+		t.Fatal("run did not start")
+	}
+	<-task.applying
+	cancelRuns()
+	s.wait()
+
+	var written struct {
+		RunID  string       `json:"run_id"`
+		Report tasks.Report `json:"report"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &written); err != nil {
+		t.Fatalf("report %q is not JSON: %v", out.String(), err)
+	}
+	if written.RunID == "" {
+		t.Error("report has no run ID")
+	}
+	var outcomes []string
+	for _, item := range written.Report.Items {
+		outcomes = append(outcomes, string(item.Outcome))
+	}
+	if got, want := strings.Join(outcomes, ","), "done,failed,skipped"; got != want {
+		t.Errorf("outcomes = %s, want %s", got, want)
+	}
+	if errs := written.Report.Errors; len(errs) != 1 || !strings.Contains(errs[0], "context canceled") {
+		t.Errorf("errors = %v, want the cancellation", errs)
+	}
+}
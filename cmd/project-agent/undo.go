@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+
+	"github.com/storacha/project-agent/internal/github"
+)
+
+var (
+	undoRun      string // Run to undo
+	undoMinimize bool   // Minimize the run's comments instead of deleting them
+)
+
+var undoCommand = &command{
+	name:    "undo",
+	summary: "Revert the board changes a run made, from the audit log",
+	flags: func(fs *flag.FlagSet) {
+		fs.StringVar(&undoRun, "run", "", "ID of the run to undo, as shown in its report and the audit log")
+		fs.BoolVar(&undoMinimize, "minimize", false, "hide the run's comments as outdated instead of deleting them")
+	},
+	run: runUndo,
+}
+
+// runUndo reverts a run's mutations newest first: fields get their previous
+// values back, added labels and project items are removed, comments are
+// deleted or minimized, and edited or minimized comments are restored.
+// Fields and comments changed again since the run are left alone.
+func runUndo(ctx context.Context, env *environment) (*summary, error) {
+	cfg := env.cfg
+	if undoRun == "" {
+		return nil, fmt.Errorf("usage: project-agent undo --run <id> [--minimize]")
+	}
+	if cfg.AuditLog == "" {
+		return nil, fmt.Errorf("the audit log is off; set audit_log or AUDIT_LOG to the log the run wrote")
+	}
+
+	entries, err := github.ReadAuditLog(cfg.AuditLog)
+	if err != nil {
+		return nil, err
+	}
+	var run []github.AuditEntry
+	for _, e := range entries {
+		if e.Undoes == undoRun {
+			return nil, fmt.Errorf("run %s was already undone by run %s", undoRun, e.Run)
+		}
+		if e.Run == undoRun {
+			run = append(run, e)
+		}
+	}
+	if len(run) == 0 {
+		return nil, fmt.Errorf("no changes by run %s in %s", undoRun, cfg.AuditLog)
+	}
+
+	githubClient, err := newProjectClient(cfg, github.Requirements{}, "")
+	if err != nil {
+		return nil, err
+	}
+	if !cfg.DryRun {
+		// Tag this run's own entries so the run cannot be undone twice
+		auditLog := github.NewAuditLog(cfg.AuditLog, runID)
+		auditLog.Undoing(undoRun)
+		githubClient.SetAuditLog(auditLog)
+	}
+
+	logRunStart(ctx, cfg, "undo of run "+undoRun)
+	slog.InfoContext(ctx, "Found the run's changes", "undo_run", undoRun, "changes", len(run))
+
+	s := &summary{Command: "undo", Title: "Undo Report", DryRun: cfg.DryRun}
+	var undone []string
+	for i := len(run) - 1; i >= 0; i-- {
+		e := run[i]
+		done, skip, err := undoEntry(ctx, githubClient, e, cfg.DryRun)
+		switch {
+		case err != nil:
+			slog.ErrorContext(ctx, "Failed to undo change", "kind", e.Kind, "issue", e.Issue, "error", err)
+			s.Errors = append(s.Errors, fmt.Sprintf("Failed to undo %s on %s: %v", e.Kind, e.Issue, err))
+		case skip != "":
+			slog.WarnContext(ctx, "Skipped change: "+skip, "kind", e.Kind, "issue", e.Issue)
+			s.Notes = append(s.Notes, fmt.Sprintf("Skipped %s on %s: %s", e.Kind, e.Issue, skip))
+		case cfg.DryRun:
+			slog.InfoContext(ctx, "[DRY RUN] Would: "+done, "kind", e.Kind, "issue", e.Issue)
+			undone = append(undone, done)
+		default:
+			slog.InfoContext(ctx, done, "kind", e.Kind, "issue", e.Issue)
+			undone = append(undone, done)
+		}
+	}
+
+	s.field("Run", undoRun)
+	s.field("Changes in run", len(run))
+	s.field("Changes undone", len(undone))
+	s.section("Undone", undone)
+	s.Failed = len(s.Errors) > 0
+	s.Report = struct {
+		Run     string   `json:"run"`
+		Undone  []string `json:"undone"`
+		Skipped []string `json:"skipped,omitempty"`
+		Errors  []string `json:"errors"`
+	}{undoRun, undone, s.Notes, append([]string{}, s.Errors...)}
+	return s, nil
+}
+
+// undoEntry reverts one audit entry, or on a dry run only checks that it
+// can be reverted. It returns what it did, or why the entry was skipped.
+func undoEntry(ctx context.Context, client *github.Client, e github.AuditEntry, dryRun bool) (done, skip string, err error) {
+	issue := e.Target()
+
+	switch e.Kind {
+	case github.AuditField, github.AuditClearField:
+		current, ok, err := client.GetFieldValue(ctx, issue, e.Field)
+		if err != nil {
+			return "", "", err
+		}
+		set := ""
+		if e.Value != nil {
+			set = e.Value.String()
+		}
+		if now := fieldString(current, ok); now != set {
+			return "", fmt.Sprintf("%s is now %q, not %q as the run left it", e.Field, now, set), nil
+		}
+
+		if e.Previous == nil {
+			done = fmt.Sprintf("Cleared %s of %s", e.Field, e.Issue)
+			if dryRun {
+				return done, "", nil
+			}
+			return done, "", client.ClearFieldValue(ctx, issue, e.Field)
+		}
+		done = fmt.Sprintf("Restored %s of %s to %q", e.Field, e.Issue, e.Previous.String())
+		if dryRun {
+			return done, "", nil
+		}
+		return done, "", client.SetFieldValue(ctx, issue, e.Field, *e.Previous)
+
+	case github.AuditLabel:
+		if e.HadLabel {
+			return "", "the issue already had the label", nil
+		}
+		done = fmt.Sprintf("Removed label %q from %s", e.Label, e.Issue)
+		if dryRun {
+			return done, "", nil
+		}
+		return done, "", client.RemoveLabel(ctx, issue, e.LabelID)
+
+	case github.AuditComment:
+		if e.CommentID == "" {
+			return "", "the comment ID was not recorded", nil
+		}
+		if undoMinimize {
+			done = fmt.Sprintf("Minimized comment %s on %s", e.CommentID, e.Issue)
+			if dryRun {
+				return done, "", nil
+			}
+			return done, "", client.MinimizeComment(ctx, issue, e.CommentID)
+		}
+		done = fmt.Sprintf("Deleted comment %s on %s", e.CommentID, e.Issue)
+		if dryRun {
+			return done, "", nil
+		}
+		return done, "", client.DeleteComment(ctx, issue, e.CommentID)
+
+	case github.AuditEditComment:
+		current, err := client.CommentBody(ctx, e.CommentID)
+		if err != nil {
+			return "", "", err
+		}
+		if current != e.Body {
+			return "", "the comment was edited again since the run", nil
+		}
+		done = fmt.Sprintf("Restored comment %s on %s", e.CommentID, e.Issue)
+		if dryRun {
+			return done, "", nil
+		}
+		return done, "", client.UpdateComment(ctx, issue, github.MarkedComment{ID: e.CommentID, Body: e.Body}, e.PreviousBody)
+
+	case github.AuditMinimizeComment:
+		done = fmt.Sprintf("Unminimized comment %s on %s", e.CommentID, e.Issue)
+		if dryRun {
+			return done, "", nil
+		}
+		return done, "", client.UnminimizeComment(ctx, issue, e.CommentID)
+
+	case github.AuditAddToProject:
+		done = fmt.Sprintf("Removed %s from the project", e.Issue)
+		if dryRun {
+			return done, "", nil
+		}
+		return done, "", client.RemoveFromProject(ctx, issue)
+	}
+
+	return "", "this kind of change cannot be undone", nil
+}
+
+// fieldString renders a field value read from the board; empty fields are ""
+func fieldString(value github.FieldValue, ok bool) string {
+	if !ok {
+		return ""
+	}
+	return value.String()
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/storacha/project-agent/internal/webhook"
+)
+
+var (
+	replayEvent    string // Event name of the payload
+	replayURL      string // Webhook URL to send the payload to
+	replayDelivery string // Delivery ID to send
+)
+
+var replayWebhookCommand = &command{
+	name:    "replay-webhook",
+	summary: "<payload-file>: handle a recorded GitHub webhook payload, or send it signed to a running serve with --url",
+	flags: func(fs *flag.FlagSet) {
+		fs.StringVar(&replayEvent, "event", "", "event of the payload, such as pull_request (default from a file name like pull_request.opened.json)")
+		fs.StringVar(&replayURL, "url", "", "send the payload, signed with GITHUB_WEBHOOK_SECRET, to this webhook URL instead of handling it here")
+		fs.StringVar(&replayDelivery, "delivery", "", "X-GitHub-Delivery ID to send with --url (default a new one)")
+	},
+	run: runReplayWebhook,
+}
+
+// runReplayWebhook routes a recorded payload the way serve does and runs the
+// task it calls for, or sends it to a running serve to exercise the
+// signature check and delivery deduplication too
+func runReplayWebhook(ctx context.Context, env *environment) (*summary, error) {
+	if len(env.args) != 1 {
+		return nil, fmt.Errorf("usage: project-agent replay-webhook [--event <name>] [--url <url>] <payload-file>")
+	}
+	path := env.args[0]
+	body, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read payload: %w", err)
+	}
+	event := replayEvent
+	if event == "" {
+		event, _, _ = strings.Cut(filepath.Base(path), ".")
+	}
+
+	if replayURL != "" {
+		return nil, sendWebhook(ctx, env.cfg.Serve.WebhookSecret, event, body)
+	}
+
+	// Without the board there is no project ID, so projects_v2_item events
+	// for other projects are handled too
+	job, err := webhook.Route(event, body, webhook.Options{})
+	if err != nil {
+		return nil, err
+	}
+	if job.Task == nil {
+		slog.InfoContext(ctx, "The delivery calls for no run", "event", event, "reason", job.Reason)
+		return nil, nil
+	}
+	slog.InfoContext(ctx, "Running the task the delivery calls for", "event", event, "job", job.Key, "reason", job.Reason)
+	return runTask(ctx, job.Task, env.cfg, "")
+}
+
+// sendWebhook posts a payload to replayURL as GitHub would deliver it
+func sendWebhook(ctx context.Context, secret, event string, body []byte) error {
+	if secret == "" {
+		return fmt.Errorf("GITHUB_WEBHOOK_SECRET environment variable is required to sign the payload")
+	}
+	delivery := replayDelivery
+	if delivery == "" {
+		delivery = newDeliveryID()
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, replayURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "GitHub-Hookshot/project-agent-replay")
+	req.Header.Set("X-GitHub-Event", event)
+	req.Header.Set("X-GitHub-Delivery", delivery)
+	req.Header.Set(webhook.SignatureHeader, webhook.Sign([]byte(secret), body))
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	reply, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	slog.InfoContext(ctx, "Sent webhook", "event", event, "delivery", delivery, "status", resp.Status, "response", strings.TrimSpace(string(reply)))
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("webhook was refused: %s: %s", resp.Status, strings.TrimSpace(string(reply)))
+	}
+	return nil
+}
+
+// newDeliveryID returns a random ID in the UUID form GitHub uses
+func newDeliveryID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	h := hex.EncodeToString(b)
+	return h[:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:]
+}
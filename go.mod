@@ -9,6 +9,7 @@ require (
 	golang.org/x/crypto v0.23.0
 	golang.org/x/oauth2 v0.21.0
 	google.golang.org/api v0.183.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
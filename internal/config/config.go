@@ -1,182 +1,697 @@
 package config
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/mail"
+	"net/url"
 	"os"
+	"regexp"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
+	"github.com/storacha/project-agent/internal/directory"
+	"github.com/storacha/project-agent/internal/schedule"
+	"gopkg.in/yaml.v3"
 )
 
 // Config holds all configuration for the agent
 type Config struct {
 	// GitHub configuration
-	GithubToken   string
-	GithubOrg     string
-	ProjectNumber int
+	GithubToken      string `yaml:"-"` // Secret, only read from GITHUB_TOKEN
+	GithubOrg        string `yaml:"org"`
+	ProjectNumber    int    `yaml:"project_number"`
+	GithubGraphQLURL string `yaml:"graphql_url"` // GraphQL endpoint, overridable for GitHub Enterprise or a local stand-in
+
+	// ProjectAgentPAT is deployed to each repository by deploy-pr-workflow so
+	// their PR workflows can dispatch events to this one
+	ProjectAgentPAT string `yaml:"-"` // Secret, only read from PROJECT_AGENT_PAT
 
 	// Gemini AI configuration
-	GeminiAPIKey string
+	GeminiAPIKey string `yaml:"-"` // Secret, only read from GEMINI_API_KEY
 
 	// Discord configuration
-	DiscordWebhookURL       string
-	DiscordBotToken         string
-	DiscordStandupChannelID string            // Discord channel ID for async standup threads
-	DiscordStandupRoleID    string            // Discord role ID to mention in standup threads
-	UserMappings            map[string]string // GitHub username -> Discord user ID
-	UnassignedIssuesUserID  string            // Discord user ID to receive unassigned issues
-	DailyUpdateThreshold    int               // Days since last update to flag as stale
+	DiscordWebhookURL string            `yaml:"-"`     // Secret, only read from DISCORD_WEBHOOK_URL
+	DiscordBotToken   string            `yaml:"-"`     // Secret, only read from DISCORD_BOT_TOKEN
+	UserMappings      map[string]string `yaml:"users"` // GitHub username -> user ID on the transport; prefer Directory
+	// DiscordAPIURL is the bot's API endpoint, overridable for a local stand-in
+	DiscordAPIURL string `yaml:"discord_api_url"`
+
+	// Notifier picks the transport reports and messages are sent through
+	Notifier NotifierConfig `yaml:"notifier"`
 
 	// Agent behavior configuration
-	StalenessThresholdDays int
-	DuplicateSimilarity    float64
-	SemanticMatching       bool
-	DryRun                 bool
-	TargetStatuses         []string // Which statuses to analyze
+	DryRun   bool        `yaml:"dry_run"`
+	Statuses StatusRoles `yaml:"statuses"`
+	// NotifyFailures posts failed task runs to the report channel, when set
+	NotifyFailures bool `yaml:"notify_failures"`
+	// AuditLog is the JSONL file every board mutation is appended to; empty
+	// turns the audit log off
+	AuditLog string `yaml:"audit_log"`
+	// MinimizeOutdatedComments posts a changed bot comment anew and hides the
+	// old one as outdated, instead of editing it in place
+	MinimizeOutdatedComments bool `yaml:"minimize_outdated_comments"`
+	// TemplatesDir holds <name>.tmpl files that replace the built-in message
+	// templates of the same name
+	TemplatesDir string `yaml:"templates_dir"`
+	// Directory is the team directory file: who is on the team and how to
+	// reach them. Entries in users are added to it.
+	Directory string `yaml:"directory"`
+	// State is where tasks keep what they need to remember between runs
+	State StateConfig `yaml:"state"`
+	// Metrics is where each run's metrics are exported
+	Metrics MetricsConfig `yaml:"metrics"`
+	// Serve configures the long-running serve command
+	Serve ServeConfig `yaml:"serve"`
+
+	// Per-task configuration
+	StaleTriage        StaleTriageConfig        `yaml:"stale_triage"`
+	DuplicateDetection DuplicateDetectionConfig `yaml:"duplicate_detection"`
+	PRLinking          PRLinkingConfig          `yaml:"pr_linking"`
+	DailyUpdates       DailyUpdatesConfig       `yaml:"daily_updates"`
+	WeeklyDMs          WeeklyDMsConfig          `yaml:"weekly_dms"`
+	AsyncStandup       AsyncStandupConfig       `yaml:"async_standup"`
+}
+
+// StatusRoles maps the roles statuses play in the workflow to the names of
+// the project's Status options
+type StatusRoles struct {
+	Active     []string `yaml:"active"`      // Issues being worked on; checked for updates and listed in DMs
+	InProgress []string `yaml:"in_progress"` // Searched for a PR's semantic match
+	Review     string   `yaml:"review"`      // Issues with a linked PR are moved here
+	Dead       string   `yaml:"dead"`        // Stale issues are moved here
+	Intake     string   `yaml:"intake"`      // Given to issues added to the project
+	Parked     string   `yaml:"parked"`      // Issues set aside for later; optional
+	Done       string   `yaml:"done"`        // Finished issues, never triaged as stale
+	Order      []string `yaml:"order"`       // Order statuses are rendered in messages
+}
+
+// StateConfig configures the state store
+type StateConfig struct {
+	Backend string `yaml:"backend"` // "file" or "git"
+	Path    string `yaml:"path"`    // Directory the file backend keeps state in
+	Repo    string `yaml:"repo"`    // Repository URL or path the git backend commits to
+	Branch  string `yaml:"branch"`  // Branch the git backend commits to
+}
+
+// MetricsConfig configures where metrics are exported after a run. Both
+// exports are off when empty.
+type MetricsConfig struct {
+	File    string `yaml:"file"`     // OpenMetrics textfile, such as for the node exporter
+	PushURL string `yaml:"push_url"` // Pushgateway base URL
+	Job     string `yaml:"job"`      // Pushgateway job name
+}
+
+// ServeConfig configures the serve command: which tasks it runs when, and
+// the HTTP server metrics and webhooks are served on
+type ServeConfig struct {
+	Addr     string            `yaml:"addr"`     // Address to serve /metrics and /healthz on; empty turns the server off
+	Timezone string            `yaml:"timezone"` // Time zone the schedule is read in
+	Schedule map[string]string `yaml:"schedule"` // Task name to cron expression
+	// ShutdownTimeout is how long running tasks get to finish on SIGTERM
+	// before they are cancelled
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+	// WebhookSecret turns on the GitHub webhook receiver at /webhook, which
+	// only accepts deliveries signed with it
+	WebhookSecret string `yaml:"-"` // Secret, only read from GITHUB_WEBHOOK_SECRET
+}
+
+// NotifierConfig picks the notification transport and configures the
+// transports other than Discord, which keeps its settings above
+type NotifierConfig struct {
+	Transport string       `yaml:"transport"` // "discord", "slack", "matrix" or "email"
+	Slack     SlackConfig  `yaml:"slack"`
+	Matrix    MatrixConfig `yaml:"matrix"`
+	Email     EmailConfig  `yaml:"email"`
+}
+
+// SlackConfig configures the Slack transport
+type SlackConfig struct {
+	WebhookURL string `yaml:"-"`       // Secret, only read from SLACK_WEBHOOK_URL
+	BotToken   string `yaml:"-"`       // Secret, only read from SLACK_BOT_TOKEN
+	Channel    string `yaml:"channel"` // Channel ID reports are posted to with the bot token, without a webhook
+	APIURL     string `yaml:"api_url"` // Web API endpoint, overridable for a local stand-in
 }
 
-// LoadFromEnv loads configuration from environment variables
+// MatrixConfig configures the Matrix transport
+type MatrixConfig struct {
+	Homeserver  string `yaml:"homeserver"` // Base URL of the homeserver
+	AccessToken string `yaml:"-"`          // Secret, only read from MATRIX_ACCESS_TOKEN
+	Room        string `yaml:"room"`       // Room ID reports are posted to
+}
+
+// EmailConfig configures the SMTP email transport
+type EmailConfig struct {
+	Host     string   `yaml:"host"`
+	Port     int      `yaml:"port"`
+	Username string   `yaml:"username"` // Empty sends without authenticating
+	Password string   `yaml:"-"`        // Secret, only read from SMTP_PASSWORD
+	From     string   `yaml:"from"`
+	To       []string `yaml:"to"` // Report recipients
+}
+
+// StaleTriageConfig configures triage-stale
+type StaleTriageConfig struct {
+	ThresholdDays  int      `yaml:"threshold_days"`  // Days of inactivity before an issue is stale
+	TargetStatuses []string `yaml:"target_statuses"` // Statuses to analyze
+}
+
+// DuplicateDetectionConfig configures detect-duplicates
+type DuplicateDetectionConfig struct {
+	Similarity     float64  `yaml:"similarity"`      // Minimum score to flag a pair as duplicates
+	TargetStatuses []string `yaml:"target_statuses"` // Statuses to analyze
+}
+
+// PRLinkingConfig configures link-pr and scan-open-prs
+type PRLinkingConfig struct {
+	SemanticMatching bool    `yaml:"semantic_matching"` // Match PRs without issue references by similarity
+	Similarity       float64 `yaml:"similarity"`        // Minimum score for a semantic match
+}
+
+// DailyUpdatesConfig configures check-daily-updates
+type DailyUpdatesConfig struct {
+	ThresholdDays int `yaml:"threshold_days"` // Days since last update to flag as stale
+}
+
+// WeeklyDMsConfig configures send-weekly-dms
+type WeeklyDMsConfig struct {
+	UnassignedUserID string `yaml:"unassigned_user_id"` // Discord user ID to receive unassigned issues
+}
+
+// AsyncStandupConfig configures async-standup
+type AsyncStandupConfig struct {
+	ChannelID string `yaml:"channel_id"` // Discord channel ID for standup threads
+	RoleID    string `yaml:"role_id"`    // Discord role ID to mention in standup threads
+}
+
+// DefaultStatusRoles returns the status roles of the Storacha project board
+func DefaultStatusRoles() StatusRoles {
+	return StatusRoles{
+		Active:     []string{"Sprint Backlog", "In Progress", "PR Review"},
+		InProgress: []string{"In Progress", "Sprint Backlog"},
+		Review:     "PR Review",
+		Dead:       "Stuck / Dead Issue",
+		Intake:     "Inbox",
+		Done:       "Done",
+		Order:      []string{"Inbox", "Backlog", "Sprint Backlog", "In Progress", "PR Review", "Stuck / Dead Issue", "Done"},
+	}
+}
+
+// Default returns the configuration used before any file or environment
+// overrides are applied
+func Default() *Config {
+	targetStatuses := []string{"Inbox", "Backlog", "Sprint Backlog", "In Progress", "PR Review"}
+	return &Config{
+		GithubGraphQLURL: "https://api.github.com/graphql",
+		DiscordAPIURL:    "https://discord.com/api/v10",
+		UserMappings:     make(map[string]string),
+		Statuses:         DefaultStatusRoles(),
+		NotifyFailures:   true,
+		AuditLog:         "project-agent-audit.jsonl",
+		Notifier: NotifierConfig{
+			Transport: "discord",
+			Slack:     SlackConfig{APIURL: "https://slack.com/api"},
+			Email:     EmailConfig{Port: 587},
+		},
+		State: StateConfig{
+			Backend: "file",
+			Path:    ".project-agent-state",
+			Branch:  "project-agent-state",
+		},
+		Metrics: MetricsConfig{Job: "project-agent"},
+		Serve: ServeConfig{
+			Addr:            ":8080",
+			Timezone:        "UTC",
+			ShutdownTimeout: 30 * time.Second,
+		},
+		StaleTriage: StaleTriageConfig{
+			ThresholdDays:  180, // 6 months
+			TargetStatuses: targetStatuses,
+		},
+		DuplicateDetection: DuplicateDetectionConfig{
+			Similarity:     0.85, // 85% similarity threshold
+			TargetStatuses: targetStatuses,
+		},
+		PRLinking: PRLinkingConfig{
+			SemanticMatching: true,
+			Similarity:       0.85,
+		},
+		DailyUpdates: DailyUpdatesConfig{
+			ThresholdDays: 3,
+		},
+	}
+}
+
+// LoadFromEnv loads configuration from the file named by PROJECT_AGENT_CONFIG,
+// if set, and environment variables
 func LoadFromEnv() (*Config, error) {
 	// Load .env file if it exists (ignore error if file doesn't exist)
 	_ = godotenv.Load()
 
-	cfg := &Config{
-		// Defaults
-		StalenessThresholdDays: 180,  // 6 months
-		DuplicateSimilarity:    0.85, // 85% similarity threshold
-		DailyUpdateThreshold:   3,    // 3 days
-		SemanticMatching:       true, // Enable semantic matching by default
-		DryRun:                 false,
-		TargetStatuses:         []string{"Inbox", "Backlog", "Sprint Backlog", "In Progress", "PR Review"},
-		UserMappings:           make(map[string]string),
+	return Load(os.Getenv("PROJECT_AGENT_CONFIG"))
+}
+
+// Load reads the YAML config file at path (skipped when path is empty),
+// applies environment variable overrides on top, and validates the result.
+// Unknown keys in the file are an error. The project number is not required
+// here, since not every command reads the project board.
+func Load(path string) (*Config, error) {
+	cfg := Default()
+
+	if path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := cfg.applyEnv(); err != nil {
+		return nil, err
 	}
 
 	// Required fields
-	cfg.GithubToken = os.Getenv("GITHUB_TOKEN")
 	if cfg.GithubToken == "" {
 		return nil, fmt.Errorf("GITHUB_TOKEN environment variable is required")
 	}
-
-	cfg.GithubOrg = os.Getenv("GITHUB_ORG")
 	if cfg.GithubOrg == "" {
-		return nil, fmt.Errorf("GITHUB_ORG environment variable is required")
+		return nil, fmt.Errorf("GITHUB_ORG environment variable or org in the config file is required")
 	}
 
-	projectNumStr := os.Getenv("PROJECT_NUMBER")
-	if projectNumStr == "" {
-		return nil, fmt.Errorf("PROJECT_NUMBER environment variable is required")
+	if err := cfg.Validate(); err != nil {
+		return nil, err
 	}
-	projectNum, err := strconv.Atoi(projectNumStr)
+
+	return cfg, nil
+}
+
+// loadFile decodes a YAML config file over the current values
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
 	if err != nil {
-		return nil, fmt.Errorf("PROJECT_NUMBER must be a valid integer: %w", err)
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(c); err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// applyEnv overrides file and default values with environment variables
+func (c *Config) applyEnv() error {
+	// Secrets are only read from the environment
+	c.GithubToken = os.Getenv("GITHUB_TOKEN")
+	c.ProjectAgentPAT = os.Getenv("PROJECT_AGENT_PAT")
+	c.GeminiAPIKey = os.Getenv("GEMINI_API_KEY")
+	c.DiscordWebhookURL = os.Getenv("DISCORD_WEBHOOK_URL")
+	c.DiscordBotToken = os.Getenv("DISCORD_BOT_TOKEN")
+	c.Notifier.Slack.WebhookURL = os.Getenv("SLACK_WEBHOOK_URL")
+	c.Notifier.Slack.BotToken = os.Getenv("SLACK_BOT_TOKEN")
+	c.Notifier.Matrix.AccessToken = os.Getenv("MATRIX_ACCESS_TOKEN")
+	c.Notifier.Email.Password = os.Getenv("SMTP_PASSWORD")
+	c.Serve.WebhookSecret = os.Getenv("GITHUB_WEBHOOK_SECRET")
+
+	if org := os.Getenv("GITHUB_ORG"); org != "" {
+		c.GithubOrg = org
 	}
-	cfg.ProjectNumber = projectNum
 
-	// Gemini AI is optional - only needed for similarity detection tasks
-	cfg.GeminiAPIKey = os.Getenv("GEMINI_API_KEY")
+	if projectNumStr := os.Getenv("PROJECT_NUMBER"); projectNumStr != "" {
+		projectNum, err := strconv.Atoi(projectNumStr)
+		if err != nil {
+			return fmt.Errorf("PROJECT_NUMBER must be a valid integer: %w", err)
+		}
+		c.ProjectNumber = projectNum
+	}
+
+	if endpoint := os.Getenv("GITHUB_GRAPHQL_URL"); endpoint != "" {
+		c.GithubGraphQLURL = endpoint
+	}
+
+	if endpoint := os.Getenv("DISCORD_API_URL"); endpoint != "" {
+		c.DiscordAPIURL = endpoint
+	}
+
+	for env, setting := range map[string]*string{
+		"NOTIFIER":          &c.Notifier.Transport,
+		"SLACK_CHANNEL":     &c.Notifier.Slack.Channel,
+		"SLACK_API_URL":     &c.Notifier.Slack.APIURL,
+		"MATRIX_HOMESERVER": &c.Notifier.Matrix.Homeserver,
+		"MATRIX_ROOM":       &c.Notifier.Matrix.Room,
+		"SMTP_HOST":         &c.Notifier.Email.Host,
+		"SMTP_USERNAME":     &c.Notifier.Email.Username,
+		"EMAIL_FROM":        &c.Notifier.Email.From,
+	} {
+		if value := strings.TrimSpace(os.Getenv(env)); value != "" {
+			*setting = value
+		}
+	}
+	if portStr := os.Getenv("SMTP_PORT"); portStr != "" {
+		port, err := strconv.Atoi(portStr)
+		if err != nil {
+			return fmt.Errorf("SMTP_PORT must be a valid integer: %w", err)
+		}
+		c.Notifier.Email.Port = port
+	}
+	if to := os.Getenv("EMAIL_TO"); to != "" {
+		c.Notifier.Email.To = splitList(to)
+	}
 
-	// Optional overrides
 	if thresholdStr := os.Getenv("STALENESS_THRESHOLD_DAYS"); thresholdStr != "" {
 		threshold, err := strconv.Atoi(thresholdStr)
 		if err != nil {
-			return nil, fmt.Errorf("STALENESS_THRESHOLD_DAYS must be a valid integer: %w", err)
+			return fmt.Errorf("STALENESS_THRESHOLD_DAYS must be a valid integer: %w", err)
 		}
-		cfg.StalenessThresholdDays = threshold
+		c.StaleTriage.ThresholdDays = threshold
 	}
 
+	// DUPLICATE_SIMILARITY used to set both thresholds, so it still does;
+	// PR_SIMILARITY overrides the PR linking one alone
 	if simStr := os.Getenv("DUPLICATE_SIMILARITY"); simStr != "" {
 		sim, err := strconv.ParseFloat(simStr, 64)
 		if err != nil {
-			return nil, fmt.Errorf("DUPLICATE_SIMILARITY must be a valid float: %w", err)
+			return fmt.Errorf("DUPLICATE_SIMILARITY must be a valid float: %w", err)
 		}
-		cfg.DuplicateSimilarity = sim
+		c.DuplicateDetection.Similarity = sim
+		c.PRLinking.Similarity = sim
+	}
+	if simStr := os.Getenv("PR_SIMILARITY"); simStr != "" {
+		sim, err := strconv.ParseFloat(simStr, 64)
+		if err != nil {
+			return fmt.Errorf("PR_SIMILARITY must be a valid float: %w", err)
+		}
+		c.PRLinking.Similarity = sim
 	}
 
 	if dryRunStr := os.Getenv("DRY_RUN"); dryRunStr == "true" {
-		cfg.DryRun = true
+		c.DryRun = true
+	}
+
+	if notifyStr := os.Getenv("NOTIFY_FAILURES"); notifyStr == "false" {
+		c.NotifyFailures = false
+	}
+
+	if templatesDir := os.Getenv("TEMPLATES_DIR"); templatesDir != "" {
+		c.TemplatesDir = templatesDir
+	}
+
+	if directoryFile := os.Getenv("DIRECTORY_FILE"); directoryFile != "" {
+		c.Directory = directoryFile
+	}
+
+	if minimizeStr := os.Getenv("MINIMIZE_OUTDATED_COMMENTS"); minimizeStr != "" {
+		c.MinimizeOutdatedComments = minimizeStr == "true"
+	}
+
+	if auditLog := strings.TrimSpace(os.Getenv("AUDIT_LOG")); auditLog == "off" {
+		c.AuditLog = ""
+	} else if auditLog != "" {
+		c.AuditLog = auditLog
+	}
+
+	for env, setting := range map[string]*string{
+		"STATE_BACKEND":    &c.State.Backend,
+		"STATE_PATH":       &c.State.Path,
+		"STATE_REPO":       &c.State.Repo,
+		"STATE_BRANCH":     &c.State.Branch,
+		"METRICS_FILE":     &c.Metrics.File,
+		"METRICS_PUSH_URL": &c.Metrics.PushURL,
+		"METRICS_JOB":      &c.Metrics.Job,
+		"SERVE_TIMEZONE":   &c.Serve.Timezone,
+	} {
+		if value := strings.TrimSpace(os.Getenv(env)); value != "" {
+			*setting = value
+		}
+	}
+
+	// SERVE_ADDR may be set empty to turn the server off
+	if addr, ok := os.LookupEnv("SERVE_ADDR"); ok {
+		c.Serve.Addr = strings.TrimSpace(addr)
 	}
 
 	if semanticMatchingStr := os.Getenv("SEMANTIC_MATCHING"); semanticMatchingStr == "false" {
-		cfg.SemanticMatching = false
+		c.PRLinking.SemanticMatching = false
 	}
 
-	if statusesStr := os.Getenv("TARGET_STATUSES"); statusesStr != "" {
-		// Split by comma and trim spaces
-		statuses := []string{}
-		for _, s := range splitAndTrim(statusesStr, ",") {
-			if s != "" {
-				statuses = append(statuses, s)
-			}
+	if statuses := splitList(os.Getenv("TARGET_STATUSES")); len(statuses) > 0 {
+		c.StaleTriage.TargetStatuses = statuses
+		c.DuplicateDetection.TargetStatuses = statuses
+	}
+
+	// Status role overrides
+	for env, roles := range map[string]*[]string{
+		"STATUS_ACTIVE":      &c.Statuses.Active,
+		"STATUS_IN_PROGRESS": &c.Statuses.InProgress,
+		"STATUS_ORDER":       &c.Statuses.Order,
+	} {
+		if statuses := splitList(os.Getenv(env)); len(statuses) > 0 {
+			*roles = statuses
 		}
-		if len(statuses) > 0 {
-			cfg.TargetStatuses = statuses
+	}
+	for env, role := range map[string]*string{
+		"STATUS_REVIEW": &c.Statuses.Review,
+		"STATUS_DEAD":   &c.Statuses.Dead,
+		"STATUS_INTAKE": &c.Statuses.Intake,
+		"STATUS_PARKED": &c.Statuses.Parked,
+		"STATUS_DONE":   &c.Statuses.Done,
+	} {
+		if value := strings.TrimSpace(os.Getenv(env)); value != "" {
+			*role = value
 		}
 	}
 
-	// Discord configuration (optional for most commands)
-	cfg.DiscordWebhookURL = os.Getenv("DISCORD_WEBHOOK_URL")
-	cfg.DiscordBotToken = os.Getenv("DISCORD_BOT_TOKEN")
-	cfg.DiscordStandupChannelID = os.Getenv("DISCORD_STANDUP_CHANNEL_ID")
-	cfg.DiscordStandupRoleID = os.Getenv("DISCORD_STANDUP_ROLE_ID")
-	cfg.UnassignedIssuesUserID = os.Getenv("UNASSIGNED_ISSUES_USER_ID")
+	if channelID := os.Getenv("DISCORD_STANDUP_CHANNEL_ID"); channelID != "" {
+		c.AsyncStandup.ChannelID = channelID
+	}
+	if roleID := os.Getenv("DISCORD_STANDUP_ROLE_ID"); roleID != "" {
+		c.AsyncStandup.RoleID = roleID
+	}
+	if userID := os.Getenv("UNASSIGNED_ISSUES_USER_ID"); userID != "" {
+		c.WeeklyDMs.UnassignedUserID = userID
+	}
 
 	if updateThresholdStr := os.Getenv("DAILY_UPDATE_THRESHOLD"); updateThresholdStr != "" {
 		threshold, err := strconv.Atoi(updateThresholdStr)
 		if err != nil {
-			return nil, fmt.Errorf("DAILY_UPDATE_THRESHOLD must be a valid integer: %w", err)
+			return fmt.Errorf("DAILY_UPDATE_THRESHOLD must be a valid integer: %w", err)
 		}
-		cfg.DailyUpdateThreshold = threshold
+		c.DailyUpdates.ThresholdDays = threshold
 	}
 
-	// Load GitHub -> Discord user mappings from JSON
+	// GitHub -> Discord user mappings from JSON replace the file's users
 	if mappingsJSON := os.Getenv("USER_MAPPINGS"); mappingsJSON != "" {
-		if err := json.Unmarshal([]byte(mappingsJSON), &cfg.UserMappings); err != nil {
-			return nil, fmt.Errorf("USER_MAPPINGS must be valid JSON: %w", err)
+		mappings := make(map[string]string)
+		if err := json.Unmarshal([]byte(mappingsJSON), &mappings); err != nil {
+			return fmt.Errorf("USER_MAPPINGS must be valid JSON: %w", err)
 		}
+		c.UserMappings = mappings
 	}
 
-	return cfg, nil
+	return nil
 }
 
-// splitAndTrim splits a string by delimiter and trims whitespace from each part
-func splitAndTrim(s, delim string) []string {
-	parts := []string{}
-	for _, part := range splitString(s, delim) {
-		trimmed := trimSpace(part)
-		if trimmed != "" {
-			parts = append(parts, trimmed)
+// Secrets returns the secret values set, the ones only read from the
+// environment, so they can be kept out of logs
+func (c *Config) Secrets() []string {
+	var secrets []string
+	for _, value := range []string{
+		c.GithubToken,
+		c.ProjectAgentPAT,
+		c.GeminiAPIKey,
+		c.DiscordWebhookURL,
+		c.DiscordBotToken,
+		c.Notifier.Slack.WebhookURL,
+		c.Notifier.Slack.BotToken,
+		c.Notifier.Matrix.AccessToken,
+		c.Notifier.Email.Password,
+		c.Serve.WebhookSecret,
+	} {
+		if value != "" {
+			secrets = append(secrets, value)
 		}
 	}
-	return parts
+	// A Pushgateway behind basic auth takes its password in the URL
+	if u, err := url.Parse(c.Metrics.PushURL); err == nil && u.User != nil {
+		if password, ok := u.User.Password(); ok && password != "" {
+			secrets = append(secrets, password)
+		}
+	}
+	return secrets
 }
 
-func splitString(s, delim string) []string {
-	if s == "" {
-		return []string{}
+// Validate checks value ranges and ID formats, returning every problem
+// found joined into one error
+func (c *Config) Validate() error {
+	var errs []error
+	problem := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
 	}
-	var parts []string
-	var current string
-	for _, char := range s {
-		if string(char) == delim {
-			parts = append(parts, current)
-			current = ""
-		} else {
-			current += string(char)
+
+	if c.ProjectNumber < 0 {
+		problem("project_number must be positive, got %d", c.ProjectNumber)
+	}
+	if c.StaleTriage.ThresholdDays < 1 {
+		problem("stale_triage.threshold_days must be at least 1, got %d", c.StaleTriage.ThresholdDays)
+	}
+	if c.DailyUpdates.ThresholdDays < 1 {
+		problem("daily_updates.threshold_days must be at least 1, got %d", c.DailyUpdates.ThresholdDays)
+	}
+	if s := c.DuplicateDetection.Similarity; s <= 0 || s > 1 {
+		problem("duplicate_detection.similarity must be in (0, 1], got %g", s)
+	}
+	if s := c.PRLinking.Similarity; s <= 0 || s > 1 {
+		problem("pr_linking.similarity must be in (0, 1], got %g", s)
+	}
+
+	if len(c.StaleTriage.TargetStatuses) == 0 {
+		problem("stale_triage.target_statuses must not be empty")
+	}
+	if len(c.DuplicateDetection.TargetStatuses) == 0 {
+		problem("duplicate_detection.target_statuses must not be empty")
+	}
+	if len(c.Statuses.Active) == 0 {
+		problem("statuses.active must not be empty")
+	}
+	if len(c.Statuses.InProgress) == 0 {
+		problem("statuses.in_progress must not be empty")
+	}
+	for name, value := range map[string]string{
+		"statuses.review": c.Statuses.Review,
+		"statuses.dead":   c.Statuses.Dead,
+		"statuses.intake": c.Statuses.Intake,
+	} {
+		if value == "" {
+			problem("%s must not be empty", name)
 		}
 	}
-	parts = append(parts, current)
-	return parts
-}
 
-func trimSpace(s string) string {
-	start := 0
-	end := len(s)
-	for start < end && (s[start] == ' ' || s[start] == '\t' || s[start] == '\n' || s[start] == '\r') {
-		start++
+	switch c.State.Backend {
+	case "file":
+		if c.State.Path == "" {
+			problem("state.path must not be empty for the file backend")
+		}
+	case "git":
+		if c.State.Repo == "" {
+			problem("state.repo must be set for the git backend")
+		}
+		if c.State.Branch == "" {
+			problem("state.branch must not be empty for the git backend")
+		}
+	default:
+		problem("state.backend must be file or git, got %q", c.State.Backend)
 	}
-	for end > start && (s[end-1] == ' ' || s[end-1] == '\t' || s[end-1] == '\n' || s[end-1] == '\r') {
-		end--
+
+	if _, err := time.LoadLocation(c.Serve.Timezone); err != nil {
+		problem("serve.timezone must be a time zone name such as Europe/Berlin, got %q", c.Serve.Timezone)
+	}
+	if c.Serve.ShutdownTimeout < 0 {
+		problem("serve.shutdown_timeout must not be negative, got %s", c.Serve.ShutdownTimeout)
+	}
+	if c.Serve.WebhookSecret != "" && c.Serve.Addr == "" {
+		problem("serve.addr must be set to receive webhooks with GITHUB_WEBHOOK_SECRET")
+	}
+	for task, expr := range c.Serve.Schedule {
+		if _, err := schedule.Parse(expr); err != nil {
+			problem("serve.schedule.%s: %v", task, err)
+		}
+	}
+
+	if c.Metrics.PushURL != "" {
+		if u, err := url.Parse(c.Metrics.PushURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			problem("metrics.push_url must be an http or https URL, got %q", c.Metrics.PushURL)
+		}
+		if c.Metrics.Job == "" {
+			problem("metrics.job must not be empty when metrics.push_url is set")
+		}
+	}
+
+	// User, channel and role IDs are the transport's own
+	var isUserID, isChannelID func(string) bool
+	var userIDs, channelIDs string
+	switch c.Notifier.Transport {
+	case "discord":
+		isUserID, isChannelID = directory.IsDiscordID, directory.IsDiscordID
+		userIDs, channelIDs = "a numeric Discord snowflake", "a numeric Discord snowflake"
+	case "slack":
+		isUserID, isChannelID = directory.IsSlackUserID, isSlackChannelID
+		userIDs, channelIDs = "a Slack user ID such as U012AB3CD", "a Slack channel ID such as C012AB3CD"
+	case "matrix":
+		isUserID, isChannelID = directory.IsMatrixUserID, isMatrixRoomID
+		userIDs, channelIDs = "a Matrix user ID such as @alice:example.org", "a Matrix room ID such as !abc:example.org"
+		if c.Notifier.Matrix.Homeserver == "" {
+			problem("notifier.matrix.homeserver must be set for the matrix transport")
+		}
+	case "email":
+		isUserID, isChannelID = directory.IsEmailAddress, directory.IsEmailAddress
+		userIDs, channelIDs = "an email address", "an email address"
+		if c.Notifier.Email.Host == "" {
+			problem("notifier.email.host must be set for the email transport")
+		}
+		if _, err := mail.ParseAddress(c.Notifier.Email.From); err != nil {
+			problem("notifier.email.from must be an email address, got %q", c.Notifier.Email.From)
+		}
+		if p := c.Notifier.Email.Port; p < 1 || p > 65535 {
+			problem("notifier.email.port must be in [1, 65535], got %d", p)
+		}
+		for _, to := range c.Notifier.Email.To {
+			if !directory.IsEmailAddress(to) {
+				problem("notifier.email.to: %q is not an email address", to)
+			}
+		}
+	default:
+		problem("notifier.transport must be discord, slack, matrix or email, got %q", c.Notifier.Transport)
+		isUserID, isChannelID = func(string) bool { return true }, func(string) bool { return true }
+	}
+
+	for githubUser, userID := range c.UserMappings {
+		if !directory.IsGithubUsername(githubUser) {
+			problem("users: %q is not a valid GitHub username", githubUser)
+		}
+		if !isUserID(userID) {
+			problem("users: ID %q for %s must be %s", userID, githubUser, userIDs)
+		}
+	}
+	if value := c.WeeklyDMs.UnassignedUserID; value != "" && !isUserID(value) {
+		problem("weekly_dms.unassigned_user_id %q must be %s", value, userIDs)
+	}
+	if value := c.AsyncStandup.ChannelID; value != "" && !isChannelID(value) {
+		problem("async_standup.channel_id %q must be %s", value, channelIDs)
+	}
+	if value := c.AsyncStandup.RoleID; value != "" && c.Notifier.Transport == "discord" && !directory.IsDiscordID(value) {
+		problem("async_standup.role_id %q must be a numeric Discord snowflake", value)
 	}
-	return s[start:end]
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("invalid configuration:\n%w", errors.Join(errs...))
+}
+
+// isSlackChannelID reports whether s looks like a Slack channel ID
+func isSlackChannelID(s string) bool {
+	return slackChannelID.MatchString(s)
+}
+
+var slackChannelID = regexp.MustCompile(`^[CG][A-Z0-9]{6,}$`)
+
+// isMatrixRoomID reports whether s looks like a Matrix room ID
+func isMatrixRoomID(s string) bool {
+	return strings.HasPrefix(s, "!") && strings.Contains(s, ":")
+}
+
+// splitList splits a comma-separated list, trimming whitespace and dropping
+// empty entries
+func splitList(s string) []string {
+	var parts []string
+	for _, part := range strings.Split(s, ",") {
+		if trimmed := strings.TrimSpace(part); trimmed != "" {
+			parts = append(parts, trimmed)
+		}
+	}
+	return parts
 }
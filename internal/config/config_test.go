@@ -0,0 +1,238 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeConfig writes a config file to a temporary directory and returns its
+// path
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// setEnv sets the environment for Load: the token it requires, plus vars,
+// with every other override it reads cleared
+func setEnv(t *testing.T, vars map[string]string) {
+	t.Helper()
+	for _, name := range []string{
+		"GITHUB_ORG", "PROJECT_NUMBER", "GITHUB_GRAPHQL_URL", "STALENESS_THRESHOLD_DAYS",
+		"DUPLICATE_SIMILARITY", "PR_SIMILARITY", "DRY_RUN", "SEMANTIC_MATCHING", "TARGET_STATUSES",
+		"STATUS_ACTIVE", "STATUS_IN_PROGRESS", "STATUS_ORDER", "STATUS_REVIEW", "STATUS_DEAD",
+		"STATUS_INTAKE", "STATUS_PARKED", "STATUS_DONE", "DISCORD_STANDUP_CHANNEL_ID", "DISCORD_STANDUP_ROLE_ID",
+		"UNASSIGNED_ISSUES_USER_ID", "DAILY_UPDATE_THRESHOLD", "USER_MAPPINGS", "NOTIFIER",
+		"SLACK_CHANNEL", "SLACK_API_URL", "MATRIX_HOMESERVER", "MATRIX_ROOM", "SMTP_HOST", "SMTP_PORT",
+		"SMTP_USERNAME", "EMAIL_FROM", "EMAIL_TO", "DIRECTORY_FILE",
+		"STATE_BACKEND", "STATE_PATH", "STATE_REPO", "STATE_BRANCH", "METRICS_FILE", "METRICS_PUSH_URL", "METRICS_JOB",
+		"SERVE_ADDR", "SERVE_TIMEZONE", "GITHUB_WEBHOOK_SECRET",
+	} {
+		// Setenv restores the variable after the test; unsetting it leaves
+		// it absent, not empty, during it
+		t.Setenv(name, "")
+		os.Unsetenv(name)
+	}
+	t.Setenv("GITHUB_TOKEN", "ghp_test")
+	for name, value := range vars {
+		t.Setenv(name, value)
+	}
+}
+
+func TestLoadMinimalFile(t *testing.T) {
+	setEnv(t, nil)
+	path := writeConfig(t, "org: storacha\nproject_number: 1\n")
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.GithubOrg != "storacha" || cfg.ProjectNumber != 1 || cfg.GithubToken != "ghp_test" {
+		t.Errorf("org %q, project %d, token %q", cfg.GithubOrg, cfg.ProjectNumber, cfg.GithubToken)
+	}
+	def := Default()
+	if cfg.StaleTriage.ThresholdDays != def.StaleTriage.ThresholdDays ||
+		cfg.DailyUpdates.ThresholdDays != def.DailyUpdates.ThresholdDays ||
+		cfg.PRLinking.Similarity != def.PRLinking.Similarity || !cfg.PRLinking.SemanticMatching {
+		t.Errorf("task defaults not applied: %+v %+v %+v", cfg.StaleTriage, cfg.DailyUpdates, cfg.PRLinking)
+	}
+	if cfg.Statuses.Review != "PR Review" || cfg.Statuses.Intake != "Inbox" || len(cfg.Statuses.Order) != len(def.Statuses.Order) {
+		t.Errorf("statuses = %+v, want the defaults", cfg.Statuses)
+	}
+	if cfg.Serve.Addr != ":8080" || cfg.Serve.ShutdownTimeout != 30*time.Second {
+		t.Errorf("serve = %+v, want the defaults", cfg.Serve)
+	}
+	if cfg.GithubGraphQLURL != "https://api.github.com/graphql" {
+		t.Errorf("graphql_url = %q", cfg.GithubGraphQLURL)
+	}
+}
+
+func TestLoadRejectsUnknownKeys(t *testing.T) {
+	setEnv(t, nil)
+
+	for name, content := range map[string]string{
+		"top level":   "org: storacha\nproject_number: 1\ntreshold_days: 30\n",
+		"task":        "org: storacha\nproject_number: 1\nstale_triage:\n  threshold: 30\n",
+		"status role": "org: storacha\nproject_number: 1\nstatuses:\n  reviewing: In Review\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := Load(writeConfig(t, content))
+			if err == nil || !strings.Contains(err.Error(), "not found") {
+				t.Errorf("err = %v, want the unknown key rejected", err)
+			}
+		})
+	}
+}
+
+func TestEnvOverridesFile(t *testing.T) {
+	setEnv(t, map[string]string{
+		"GITHUB_ORG":               "storacha-env",
+		"STALENESS_THRESHOLD_DAYS": "90",
+		"STATUS_REVIEW":            "In Review",
+		"PR_SIMILARITY":            "0.7",
+	})
+	path := writeConfig(t, `org: storacha
+project_number: 3
+stale_triage:
+  threshold_days: 30
+duplicate_detection:
+  similarity: 0.9
+pr_linking:
+  similarity: 0.95
+statuses:
+  review: Review
+  dead: Graveyard
+`)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.GithubOrg != "storacha-env" || cfg.StaleTriage.ThresholdDays != 90 ||
+		cfg.Statuses.Review != "In Review" || cfg.PRLinking.Similarity != 0.7 {
+		t.Errorf("environment did not override the file: org %q, threshold %d, review %q, similarity %g",
+			cfg.GithubOrg, cfg.StaleTriage.ThresholdDays, cfg.Statuses.Review, cfg.PRLinking.Similarity)
+	}
+	// Values the environment does not set keep the file's
+	if cfg.ProjectNumber != 3 || cfg.DuplicateDetection.Similarity != 0.9 || cfg.Statuses.Dead != "Graveyard" {
+		t.Errorf("file values lost: project %d, similarity %g, dead %q",
+			cfg.ProjectNumber, cfg.DuplicateDetection.Similarity, cfg.Statuses.Dead)
+	}
+}
+
+func TestLoadDurations(t *testing.T) {
+	setEnv(t, nil)
+	path := writeConfig(t, `org: storacha
+project_number: 1
+serve:
+  shutdown_timeout: 2m30s
+  schedule:
+    triage-stale: "@daily"
+`)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Serve.ShutdownTimeout != 150*time.Second {
+		t.Errorf("shutdown_timeout = %s, want 2m30s", cfg.Serve.ShutdownTimeout)
+	}
+
+	_, err = Load(writeConfig(t, "org: storacha\nproject_number: 1\nserve:\n  shutdown_timeout: soon\n"))
+	if err == nil {
+		t.Error("Load accepted a shutdown_timeout that is not a duration")
+	}
+}
+
+func TestLoadRequiresToken(t *testing.T) {
+	setEnv(t, map[string]string{"GITHUB_TOKEN": ""})
+
+	_, err := Load(writeConfig(t, "org: storacha\nproject_number: 1\n"))
+	if err == nil || !strings.Contains(err.Error(), "GITHUB_TOKEN") {
+		t.Errorf("err = %v, want GITHUB_TOKEN required", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := Default().Validate(); err != nil {
+		t.Fatalf("defaults are invalid: %v", err)
+	}
+
+	for _, tc := range []struct {
+		name string
+		edit func(c *Config)
+		want string
+	}{
+		{"negative project", func(c *Config) { c.ProjectNumber = -1 }, "project_number must be positive"},
+		{"stale threshold", func(c *Config) { c.StaleTriage.ThresholdDays = 0 }, "stale_triage.threshold_days must be at least 1"},
+		{"update threshold", func(c *Config) { c.DailyUpdates.ThresholdDays = -3 }, "daily_updates.threshold_days must be at least 1"},
+		{"similarity above 1", func(c *Config) { c.DuplicateDetection.Similarity = 1.5 }, "duplicate_detection.similarity must be in (0, 1]"},
+		{"similarity of 0", func(c *Config) { c.PRLinking.Similarity = 0 }, "pr_linking.similarity must be in (0, 1]"},
+		{"no target statuses", func(c *Config) { c.StaleTriage.TargetStatuses = nil }, "stale_triage.target_statuses must not be empty"},
+		{"no active statuses", func(c *Config) { c.Statuses.Active = nil }, "statuses.active must not be empty"},
+		{"empty status role", func(c *Config) { c.Statuses.Review = "" }, "statuses.review must not be empty"},
+		{"bad GitHub username", func(c *Config) { c.UserMappings["-alice"] = "123456789012345678" }, `"-alice" is not a valid GitHub username`},
+		{"bad Discord user ID", func(c *Config) { c.UserMappings["alice"] = "alice#1234" }, "must be a numeric Discord snowflake"},
+		{"bad channel ID", func(c *Config) { c.AsyncStandup.ChannelID = "standup" }, `async_standup.channel_id "standup" must be a numeric Discord snowflake`},
+		{"bad unassigned user", func(c *Config) { c.WeeklyDMs.UnassignedUserID = "12345" }, "weekly_dms.unassigned_user_id"},
+		{"unknown state backend", func(c *Config) { c.State.Backend = "s3" }, `state.backend must be file or git, got "s3"`},
+		{"negative shutdown timeout", func(c *Config) { c.Serve.ShutdownTimeout = -time.Second }, "serve.shutdown_timeout must not be negative, got -1s"},
+		{"unknown time zone", func(c *Config) { c.Serve.Timezone = "Mars/Olympus" }, `serve.timezone must be a time zone name such as Europe/Berlin, got "Mars/Olympus"`},
+		{"bad schedule", func(c *Config) { c.Serve.Schedule = map[string]string{"triage-stale": "0 25 * * *"} }, "serve.schedule.triage-stale: invalid cron expression"},
+		{"webhooks without a server", func(c *Config) {
+			c.Serve.WebhookSecret = "webhook-secret"
+			c.Serve.Addr = ""
+		}, "serve.addr must be set to receive webhooks"},
+		{"bad push URL", func(c *Config) { c.Metrics.PushURL = "pushgateway:9091" }, `metrics.push_url must be an http or https URL, got "pushgateway:9091"`},
+		{"push without job", func(c *Config) {
+			c.Metrics.PushURL = "http://pushgateway:9091"
+			c.Metrics.Job = ""
+		}, "metrics.job must not be empty"},
+		{"unknown transport", func(c *Config) { c.Notifier.Transport = "irc" }, `notifier.transport must be discord, slack, matrix or email, got "irc"`},
+		{"Discord ID on Slack", func(c *Config) {
+			c.Notifier.Transport = "slack"
+			c.UserMappings["alice"] = "123456789012345678"
+		}, "must be a Slack user ID"},
+		{"Matrix without homeserver", func(c *Config) {
+			c.Notifier.Transport = "matrix"
+		}, "notifier.matrix.homeserver must be set"},
+		{"bad email sender", func(c *Config) {
+			c.Notifier.Transport = "email"
+			c.Notifier.Email.Host = "smtp.example.org"
+			c.Notifier.Email.From = "reports"
+		}, `notifier.email.from must be an email address, got "reports"`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := Default()
+			tc.edit(cfg)
+			err := cfg.Validate()
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("err = %v, want %q", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestValidateReportsEveryProblem(t *testing.T) {
+	cfg := Default()
+	cfg.StaleTriage.ThresholdDays = 0
+	cfg.PRLinking.Similarity = 2
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("Validate succeeded")
+	}
+	for _, want := range []string{"stale_triage.threshold_days", "pr_linking.similarity"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("err = %v, want it to mention %s", err, want)
+		}
+	}
+}
@@ -0,0 +1,299 @@
+// Package directory is the team directory: who is on the team, how to reach
+// them on each notification transport, and what they want to be sent. It is
+// loaded from a YAML file; the older GitHub login to user ID mappings are
+// folded into it.
+package directory
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Role is a part a person plays in the workflow
+type Role string
+
+// Roles
+const (
+	RoleLead    Role = "lead"    // Receives the unassigned issues DM when no recipient is configured
+	RoleTriager Role = "triager" // Triages incoming issues
+)
+
+// Status is whether a person is still on the team
+type Status string
+
+// Statuses
+const (
+	StatusActive Status = "active"
+	StatusAlumni Status = "alumni" // Former members: not DMed, mentioned or treated as team members
+)
+
+// Person is one member of the team
+type Person struct {
+	GitHub   string      `yaml:"github"`   // GitHub login
+	Name     string      `yaml:"name"`     // Display name
+	Discord  string      `yaml:"discord"`  // Discord user ID
+	Slack    string      `yaml:"slack"`    // Slack member ID
+	Matrix   string      `yaml:"matrix"`   // Matrix user ID
+	Email    string      `yaml:"email"`    // Email address
+	Timezone string      `yaml:"timezone"` // IANA time zone, such as Europe/Berlin
+	Team     string      `yaml:"team"`
+	Roles    []Role      `yaml:"roles"`
+	Status   Status      `yaml:"status"` // Active unless set
+	Notify   Preferences `yaml:"notify"`
+}
+
+// Preferences are what a person wants to be sent. Unset preferences are on.
+type Preferences struct {
+	WeeklyDM *bool `yaml:"weekly_dm"` // The weekly DM of their assigned issues
+	Mention  *bool `yaml:"mention"`   // Mentions in channel reports; otherwise they are named as @login
+}
+
+// Active reports whether the person is on the team
+func (p Person) Active() bool {
+	return p.Status != StatusAlumni
+}
+
+// HasRole reports whether the person plays role
+func (p Person) HasRole(role Role) bool {
+	for _, r := range p.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// WantsWeeklyDM reports whether the person gets the weekly DM
+func (p Person) WantsWeeklyDM() bool {
+	return p.Active() && (p.Notify.WeeklyDM == nil || *p.Notify.WeeklyDM)
+}
+
+// WantsMention reports whether the person is mentioned in channel reports
+func (p Person) WantsMention() bool {
+	return p.Active() && (p.Notify.Mention == nil || *p.Notify.Mention)
+}
+
+// Handle returns the person's ID on a notification transport, or "" if
+// they have none
+func (p Person) Handle(transport string) string {
+	switch transport {
+	case "discord":
+		return p.Discord
+	case "slack":
+		return p.Slack
+	case "matrix":
+		return p.Matrix
+	case "email":
+		return p.Email
+	}
+	return ""
+}
+
+// setHandle sets the person's ID on a notification transport
+func (p *Person) setHandle(transport, id string) {
+	switch transport {
+	case "discord":
+		p.Discord = id
+	case "slack":
+		p.Slack = id
+	case "matrix":
+		p.Matrix = id
+	case "email":
+		p.Email = id
+	}
+}
+
+// Directory is the team, looked up by GitHub login. A nil Directory is
+// empty.
+type Directory struct {
+	people  []Person       // Sorted by GitHub login
+	byLogin map[string]int // Lowercased GitHub login to index in people
+}
+
+// file is the layout of a directory file
+type file struct {
+	People []Person `yaml:"people"`
+}
+
+// Load reads a directory file. An empty path is an empty directory.
+func Load(path string) (*Directory, error) {
+	if path == "" {
+		return New(nil)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory: %w", err)
+	}
+
+	var f file
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&f); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse directory %s: %w", path, err)
+	}
+
+	d, err := New(f.People)
+	if err != nil {
+		return nil, fmt.Errorf("invalid directory %s:\n%w", path, err)
+	}
+	return d, nil
+}
+
+// New builds a directory from people, returning every problem found with
+// them joined into one error
+func New(people []Person) (*Directory, error) {
+	var errs []error
+	problem := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	d := &Directory{byLogin: make(map[string]int)}
+	for i, p := range people {
+		where := fmt.Sprintf("people[%d]", i)
+		if p.GitHub != "" {
+			where = p.GitHub
+		}
+
+		if !IsGithubUsername(p.GitHub) {
+			problem("%s: github %q is not a valid GitHub username", where, p.GitHub)
+		}
+		if _, dup := d.byLogin[strings.ToLower(p.GitHub)]; dup {
+			problem("%s: listed more than once", where)
+		}
+		for _, handle := range []struct {
+			name, value, want string
+			valid             func(string) bool
+		}{
+			{"discord", p.Discord, "a numeric Discord snowflake", IsDiscordID},
+			{"slack", p.Slack, "a Slack member ID such as U012AB3CD", IsSlackUserID},
+			{"matrix", p.Matrix, "a Matrix user ID such as @alice:example.org", IsMatrixUserID},
+			{"email", p.Email, "an email address", IsEmailAddress},
+		} {
+			if handle.value != "" && !handle.valid(handle.value) {
+				problem("%s: %s %q must be %s", where, handle.name, handle.value, handle.want)
+			}
+		}
+		if p.Timezone != "" {
+			if _, err := time.LoadLocation(p.Timezone); err != nil {
+				problem("%s: unknown timezone %q", where, p.Timezone)
+			}
+		}
+		for _, role := range p.Roles {
+			if role != RoleLead && role != RoleTriager {
+				problem("%s: role %q must be %s or %s", where, role, RoleLead, RoleTriager)
+			}
+		}
+		switch p.Status {
+		case "":
+			p.Status = StatusActive
+		case StatusActive, StatusAlumni:
+		default:
+			problem("%s: status %q must be %s or %s", where, p.Status, StatusActive, StatusAlumni)
+		}
+
+		d.byLogin[strings.ToLower(p.GitHub)] = len(d.people)
+		d.people = append(d.people, p)
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	d.sort()
+	return d, nil
+}
+
+// AddMappings adds the people in GitHub login to user ID mappings, the
+// USER_MAPPINGS format, who are not in the directory yet. Their IDs are on
+// transport.
+func (d *Directory) AddMappings(mappings map[string]string, transport string) {
+	for login, id := range mappings {
+		if _, ok := d.byLogin[strings.ToLower(login)]; ok {
+			continue
+		}
+		p := Person{GitHub: login, Status: StatusActive}
+		p.setHandle(transport, id)
+		d.byLogin[strings.ToLower(login)] = len(d.people)
+		d.people = append(d.people, p)
+	}
+	d.sort()
+}
+
+// sort orders people by GitHub login and reindexes them
+func (d *Directory) sort() {
+	sort.Slice(d.people, func(i, j int) bool {
+		return strings.ToLower(d.people[i].GitHub) < strings.ToLower(d.people[j].GitHub)
+	})
+	for i, p := range d.people {
+		d.byLogin[strings.ToLower(p.GitHub)] = i
+	}
+}
+
+// Len is how many people the directory lists, alumni included
+func (d *Directory) Len() int {
+	if d == nil {
+		return 0
+	}
+	return len(d.people)
+}
+
+// Person looks a person up by GitHub login, ignoring case
+func (d *Directory) Person(login string) (Person, bool) {
+	if d == nil {
+		return Person{}, false
+	}
+	i, ok := d.byLogin[strings.ToLower(login)]
+	if !ok {
+		return Person{}, false
+	}
+	return d.people[i], true
+}
+
+// IsMember reports whether a GitHub login belongs to an active team member
+func (d *Directory) IsMember(login string) bool {
+	p, ok := d.Person(login)
+	return ok && p.Active()
+}
+
+// Active returns the active team members, by GitHub login
+func (d *Directory) Active() []Person {
+	return d.filter(Person.Active)
+}
+
+// WithRole returns the active team members who play role, by GitHub login
+func (d *Directory) WithRole(role Role) []Person {
+	return d.filter(func(p Person) bool { return p.Active() && p.HasRole(role) })
+}
+
+func (d *Directory) filter(keep func(Person) bool) []Person {
+	if d == nil {
+		return nil
+	}
+	var people []Person
+	for _, p := range d.people {
+		if keep(p) {
+			people = append(people, p)
+		}
+	}
+	return people
+}
+
+// Mentions maps the lowercased GitHub logins of the people who can be
+// mentioned on transport to their IDs there: active members who want
+// mentions and have a handle
+func (d *Directory) Mentions(transport string) map[string]string {
+	mentions := make(map[string]string)
+	for _, p := range d.filter(Person.WantsMention) {
+		if handle := p.Handle(transport); handle != "" {
+			mentions[strings.ToLower(p.GitHub)] = handle
+		}
+	}
+	return mentions
+}
@@ -0,0 +1,52 @@
+package directory
+
+import (
+	"net/mail"
+	"regexp"
+	"strings"
+)
+
+// IsDiscordID reports whether s looks like a Discord snowflake ID
+func IsDiscordID(s string) bool {
+	if len(s) < 17 || len(s) > 20 {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+var slackUserID = regexp.MustCompile(`^[UW][A-Z0-9]{6,}$`)
+
+// IsSlackUserID reports whether s looks like a Slack user ID
+func IsSlackUserID(s string) bool {
+	return slackUserID.MatchString(s)
+}
+
+// IsMatrixUserID reports whether s looks like a Matrix user ID
+func IsMatrixUserID(s string) bool {
+	return strings.HasPrefix(s, "@") && strings.Contains(s, ":")
+}
+
+// IsEmailAddress reports whether s is a bare email address
+func IsEmailAddress(s string) bool {
+	addr, err := mail.ParseAddress(s)
+	return err == nil && addr.Address == s
+}
+
+// IsGithubUsername reports whether s is a valid GitHub login: up to 39
+// alphanumerics or single hyphens, not starting or ending with a hyphen
+func IsGithubUsername(s string) bool {
+	if s == "" || len(s) > 39 || s[0] == '-' || s[len(s)-1] == '-' || strings.Contains(s, "--") {
+		return false
+	}
+	for _, r := range s {
+		if !(r == '-' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z') {
+			return false
+		}
+	}
+	return true
+}
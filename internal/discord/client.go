@@ -1,31 +1,45 @@
 package discord
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"time"
 
+	"github.com/storacha/project-agent/internal/directory"
 	"github.com/storacha/project-agent/internal/github"
+	"github.com/storacha/project-agent/internal/messages"
+	"github.com/storacha/project-agent/internal/notify"
 )
 
+// DefaultAPIURL is the Discord API the bot client talks to
+const DefaultAPIURL = "https://discord.com/api/v10"
+
 // Client handles Discord webhook interactions
 type Client struct {
 	webhookURL string
 	botToken   string
+	apiURL     string
 	httpClient *http.Client
+	limiter    *rateLimiter
+	statuses   notify.StatusLayout
+	templates  *messages.Templates
 }
 
 // NewClient creates a new Discord client
 func NewClient(webhookURL string) *Client {
 	return &Client{
 		webhookURL: webhookURL,
+		apiURL:     DefaultAPIURL,
 		httpClient: &http.Client{
 			Timeout: 10 * time.Second,
 		},
+		limiter:   newRateLimiter(),
+		statuses:  notify.DefaultStatusLayout(),
+		templates: messages.Default(),
 	}
 }
 
@@ -33,12 +47,32 @@ func NewClient(webhookURL string) *Client {
 func NewBotClient(botToken string) *Client {
 	return &Client{
 		botToken: botToken,
+		apiURL:   DefaultAPIURL,
 		httpClient: &http.Client{
 			Timeout: 10 * time.Second,
 		},
+		limiter:   newRateLimiter(),
+		statuses:  notify.DefaultStatusLayout(),
+		templates: messages.Default(),
 	}
 }
 
+// SetAPIURL points the bot client at another Discord API, such as a local
+// stand-in
+func (c *Client) SetAPIURL(apiURL string) {
+	c.apiURL = strings.TrimRight(apiURL, "/")
+}
+
+// SetStatusLayout replaces the client's status layout
+func (c *Client) SetStatusLayout(layout notify.StatusLayout) {
+	c.statuses = layout
+}
+
+// SetTemplates sets the templates messages are rendered with
+func (c *Client) SetTemplates(templates *messages.Templates) {
+	c.templates = templates
+}
+
 // WebhookMessage represents a Discord webhook message
 type WebhookMessage struct {
 	Content string  `json:"content,omitempty"`
@@ -61,77 +95,28 @@ type Field struct {
 	Inline bool   `json:"inline,omitempty"`
 }
 
-// StaleIssue represents an issue that needs attention
-type StaleIssue struct {
-	Issue           github.Issue
-	DaysSinceUpdate int
-	AssignedTo      []string // GitHub usernames
-}
-
-// SendStaleIssuesReport sends a summary of stale issues to Discord
-func (c *Client) SendStaleIssuesReport(ctx context.Context, staleIssues []StaleIssue, userMappings map[string]string) error {
+// SendStaleIssuesReport sends a summary of stale issues to Discord.
+// thresholdDays is how long an issue went without an update to be listed.
+func (c *Client) SendStaleIssuesReport(ctx context.Context, staleIssues []notify.StaleIssue, people *directory.Directory, thresholdDays int) error {
+	headline, sections := c.statuses.StaleReport(staleIssues, people, transport, thresholdDays, mention)
+	content, err := c.templates.Render(messages.StaleReportTemplate, headline)
+	if err != nil {
+		return err
+	}
 	if len(staleIssues) == 0 {
 		// Send a "all good" message
-		msg := WebhookMessage{
-			Content: "✅ All issues in Sprint Backlog, In Progress, and PR Review have been updated recently!",
-		}
-		return c.sendWebhook(ctx, msg)
+		return c.sendWebhook(ctx, WebhookMessage{Content: content})
 	}
 
-	// Group issues by status
-	byStatus := make(map[string]map[string][]StaleIssue)
-	for _, stale := range staleIssues {
-		statusMap := byStatus[stale.Issue.ProjectItem.StatusValue]
-		if statusMap == nil {
-			statusMap = make(map[string][]StaleIssue)
-			byStatus[stale.Issue.ProjectItem.StatusValue] = statusMap
-		}
-		if len(stale.AssignedTo) > 0 {
-			mentions := ""
-			for i, githubUser := range stale.AssignedTo {
-				if discordID, ok := userMappings[githubUser]; ok {
-					mentions += fmt.Sprintf("<@%s>", discordID)
-				} else {
-					mentions += fmt.Sprintf("@%s", githubUser)
-				}
-				if i < len(stale.AssignedTo)-1 {
-					mentions += " "
-				}
-			}
-			statusMap[mentions] = append(statusMap[mentions], stale)
-		} else {
-			statusMap["Unassigned"] = append(statusMap["Unassigned"], stale)
-		}
-	}
-
-	// Build embed
-	// Add fields for each status
-	statuses := []string{"Sprint Backlog", "In Progress", "PR Review"}
-	embeds := make([]Embed, 0, len(statuses))
-
-	for _, status := range statuses {
-
-		byAssignee := byStatus[status]
-		if len(byAssignee) == 0 {
-			continue
-		}
-
-		description := fmt.Sprintf("%d issues haven't been updated in 3+ days\n\n", len(byAssignee))
-		for assignee, issues := range byAssignee {
-			description += fmt.Sprintf("**%s**\n\n", assignee)
-			for _, stale := range issues {
-				// Build issue line
-				line := fmt.Sprintf("• [%s #%d](%s) %s", stale.Issue.RepositoryName, stale.Issue.Number, stale.Issue.URL, stale.Issue.Title)
-
-				// Add days since update
-				line += fmt.Sprintf(" *(%d days)*", stale.DaysSinceUpdate)
-
-				description += line + "\n"
-			}
-			description += "\n"
+	// One embed per status
+	embeds := make([]Embed, 0, len(sections))
+	for _, section := range sections {
+		description, err := c.templates.Render(messages.StaleReportStatusTemplate, section)
+		if err != nil {
+			return err
 		}
 		embeds = append(embeds, Embed{
-			Title:       status,
+			Title:       section.Status,
 			Description: description,
 			Color:       0xFF9900, // Orange
 			Timestamp:   time.Now().Format("2006-01-02T15:04:05Z"),
@@ -140,29 +125,67 @@ func (c *Client) SendStaleIssuesReport(ctx context.Context, staleIssues []StaleI
 	}
 
 	msg := WebhookMessage{
-		Content: fmt.Sprintf("⚠️ Stale Issue Report - %d issues need attention", len(staleIssues)),
+		Content: content,
 		Embeds:  embeds,
 	}
 
 	return c.sendWebhook(ctx, msg)
 }
 
-// sendWebhook sends a message to the Discord webhook
-func (c *Client) sendWebhook(ctx context.Context, msg WebhookMessage) error {
-	payload, err := json.Marshal(msg)
+// transport is how the directory names Discord
+const transport = "discord"
+
+// mention mentions a Discord user
+func mention(userID string) string {
+	return fmt.Sprintf("<@%s>", userID)
+}
+
+// SendTaskFailure reports a failed task run to the Discord webhook. Only the
+// first few errors are listed.
+func (c *Client) SendTaskFailure(ctx context.Context, task string, errs []string) error {
+	failure, err := notify.RenderTaskFailure(c.templates, task, errs)
 	if err != nil {
-		return fmt.Errorf("failed to marshal webhook message: %w", err)
+		return err
 	}
 
-	fmt.Println("Discord Webhook Payload:", string(payload)) // Debugging line
-	req, err := http.NewRequestWithContext(ctx, "POST", c.webhookURL, bytes.NewBuffer(payload))
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+	msg := WebhookMessage{
+		Content: failure.Content,
+		Embeds: []Embed{{
+			Title:       failure.Title,
+			Description: failure.Description,
+			Color:       0xE01E5A, // Red
+			Timestamp:   time.Now().Format("2006-01-02T15:04:05Z"),
+		}},
 	}
 
-	req.Header.Set("Content-Type", "application/json")
+	return c.sendWebhook(ctx, msg)
+}
 
-	resp, err := c.httpClient.Do(req)
+// sendWebhook sends a message to the Discord webhook, split into as many
+// messages as Discord's limits require
+func (c *Client) sendWebhook(ctx context.Context, msg WebhookMessage) error {
+	parts := layoutWebhook(msg)
+	for i, part := range parts {
+		if err := c.sendWebhookPart(ctx, part); err != nil {
+			if len(parts) > 1 {
+				return fmt.Errorf("failed to send message %d of %d: %w", i+1, len(parts), err)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// sendWebhookPart sends one message that fits Discord's limits
+func (c *Client) sendWebhookPart(ctx context.Context, msg WebhookMessage) error {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook message: %w", err)
+	}
+
+	// Webhook messages cannot be deduplicated, so only rate limited sends,
+	// which Discord rejected, are retried
+	resp, err := c.do(ctx, request{method: "POST", url: c.webhookURL, body: payload})
 	if err != nil {
 		return fmt.Errorf("failed to send webhook: %w", err)
 	}
@@ -176,59 +199,30 @@ func (c *Client) sendWebhook(ctx context.Context, msg WebhookMessage) error {
 	return nil
 }
 
-// UserIssues groups issues by user for weekly DM
-type UserIssues struct {
-	GithubUsername string
-	DiscordUserID  string
-	Issues         []github.Issue
-}
-
 // SendWeeklyDM sends a DM to a user with their assigned issues
-func (c *Client) SendWeeklyDM(ctx context.Context, userIssues UserIssues) error {
+func (c *Client) SendWeeklyDM(ctx context.Context, userIssues notify.UserIssues) error {
 	if c.botToken == "" {
 		return fmt.Errorf("bot token not configured")
 	}
 
 	// Step 1: Create a DM channel with the user
-	dmChannel, err := c.createDMChannel(ctx, userIssues.DiscordUserID)
+	dmChannel, err := c.createDMChannel(ctx, userIssues.UserID)
 	if err != nil {
 		return fmt.Errorf("failed to create DM channel: %w", err)
 	}
 
 	// Step 2: Build the message content
-	content := fmt.Sprintf("👋 Hi! Here's your weekly issue update for **%s**.\n\n", userIssues.GithubUsername)
-	content += fmt.Sprintf("You have **%d** issue(s) assigned to you. Please review and update any whose status has changed:\n\n", len(userIssues.Issues))
-
-	// Group by status
-	byStatus := make(map[string][]github.Issue)
-	for _, issue := range userIssues.Issues {
-		status := issue.ProjectItem.StatusValue
-		byStatus[status] = append(byStatus[status], issue)
-	}
-
-	// Add issues by status
-	statuses := []string{"Sprint Backlog", "In Progress", "PR Review"}
-	for _, status := range statuses {
-		issues := byStatus[status]
-		if len(issues) == 0 {
-			continue
-		}
-
-		content += fmt.Sprintf("**%s (%d)**\n", status, len(issues))
-		for _, issue := range issues {
-			content += fmt.Sprintf("• [#%d](%s) %s\n", issue.Number, issue.URL, issue.Title)
-		}
-		content += "\n"
+	content, err := c.templates.Render(messages.WeeklyDMTemplate, messages.WeeklyDM{
+		GithubUsername: userIssues.GithubUsername,
+		Count:          len(userIssues.Issues),
+		Groups:         c.statuses.GroupByStatus(userIssues.Issues),
+	})
+	if err != nil {
+		return err
 	}
 
-	content += "Please update the status of any issues that have changed, or add a comment if you're stuck or need help. Thanks! 🙏"
-
 	// Step 3: Send the message
-	msg := map[string]interface{}{
-		"content": content,
-	}
-
-	return c.sendBotMessage(ctx, dmChannel, msg)
+	return c.sendBotContent(ctx, dmChannel, content)
 }
 
 // DMChannel represents a Discord DM channel
@@ -247,15 +241,8 @@ func (c *Client) createDMChannel(ctx context.Context, userID string) (string, er
 		return "", fmt.Errorf("failed to marshal payload: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", "https://discord.com/api/v10/users/@me/channels", bytes.NewBuffer(jsonPayload))
-	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Authorization", "Bot "+c.botToken)
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.httpClient.Do(req)
+	// Opening a DM channel returns the existing one, so it is safe to retry
+	resp, err := c.do(ctx, request{method: "POST", url: c.apiURL + "/users/@me/channels", body: jsonPayload, auth: true, idempotent: true})
 	if err != nil {
 		return "", fmt.Errorf("failed to send request: %w", err)
 	}
@@ -273,23 +260,42 @@ func (c *Client) createDMChannel(ctx context.Context, userID string) (string, er
 	return dmChannel.ID, nil
 }
 
-// sendBotMessage sends a message to a channel using the bot
+// sendBotContent sends text to a channel using the bot, split into as many
+// messages as Discord's content limit requires
+func (c *Client) sendBotContent(ctx context.Context, channelID, content string) error {
+	parts := notify.SplitText(content, maxContentLength)
+	for i, part := range parts {
+		msg := map[string]interface{}{
+			"content": part,
+		}
+		if err := c.sendBotMessage(ctx, channelID, msg); err != nil {
+			if len(parts) > 1 {
+				return fmt.Errorf("failed to send message %d of %d: %w", i+1, len(parts), err)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// sendBotMessage sends a message to a channel using the bot. The message
+// carries an enforced nonce, so Discord drops a retried copy of a message it
+// already posted.
 func (c *Client) sendBotMessage(ctx context.Context, channelID string, msg map[string]interface{}) error {
-	jsonPayload, err := json.Marshal(msg)
+	nonce, err := newNonce()
 	if err != nil {
-		return fmt.Errorf("failed to marshal message: %w", err)
+		return err
 	}
+	msg["nonce"] = nonce
+	msg["enforce_nonce"] = true
 
-	url := fmt.Sprintf("https://discord.com/api/v10/channels/%s/messages", channelID)
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonPayload))
+	jsonPayload, err := json.Marshal(msg)
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return fmt.Errorf("failed to marshal message: %w", err)
 	}
 
-	req.Header.Set("Authorization", "Bot "+c.botToken)
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.httpClient.Do(req)
+	url := fmt.Sprintf("%s/channels/%s/messages", c.apiURL, channelID)
+	resp, err := c.do(ctx, request{method: "POST", url: url, body: jsonPayload, auth: true, idempotent: true})
 	if err != nil {
 		return fmt.Errorf("failed to send message: %w", err)
 	}
@@ -309,59 +315,24 @@ func (c *Client) SendUnassignedIssuesDM(ctx context.Context, discordUserID strin
 		return fmt.Errorf("bot token not configured")
 	}
 
-	if len(issues) == 0 {
-		// No unassigned issues - send a positive message
-		dmChannel, err := c.createDMChannel(ctx, discordUserID)
-		if err != nil {
-			return fmt.Errorf("failed to create DM channel: %w", err)
-		}
-
-		msg := map[string]interface{}{
-			"content": "✅ Great news! There are no unassigned issues in Sprint Backlog, In Progress, or PR Review.",
-		}
-		return c.sendBotMessage(ctx, dmChannel, msg)
+	// Step 1: Build the message content
+	content, err := c.templates.Render(messages.UnassignedDMTemplate, messages.UnassignedDM{
+		Count:          len(issues),
+		ActiveStatuses: c.statuses.DescribeActive("or"),
+		Groups:         c.statuses.GroupByStatus(issues),
+	})
+	if err != nil {
+		return err
 	}
 
-	// Step 1: Create a DM channel with the user
+	// Step 2: Create a DM channel with the user
 	dmChannel, err := c.createDMChannel(ctx, discordUserID)
 	if err != nil {
 		return fmt.Errorf("failed to create DM channel: %w", err)
 	}
 
-	// Step 2: Build the message content
-	content := fmt.Sprintf("⚠️ **Unassigned Issues Report**\n\n")
-	content += fmt.Sprintf("There are **%d** unassigned issue(s) in active statuses. Please review and assign them:\n\n", len(issues))
-
-	// Group by status
-	byStatus := make(map[string][]github.Issue)
-	for _, issue := range issues {
-		status := issue.ProjectItem.StatusValue
-		byStatus[status] = append(byStatus[status], issue)
-	}
-
-	// Add issues by status
-	statuses := []string{"Sprint Backlog", "In Progress", "PR Review"}
-	for _, status := range statuses {
-		statusIssues := byStatus[status]
-		if len(statusIssues) == 0 {
-			continue
-		}
-
-		content += fmt.Sprintf("**%s (%d)**\n", status, len(statusIssues))
-		for _, issue := range statusIssues {
-			content += fmt.Sprintf("• [#%d](%s) %s\n", issue.Number, issue.URL, issue.Title)
-		}
-		content += "\n"
-	}
-
-	content += "Please assign these issues to the appropriate team members. Thanks! 🙏"
-
 	// Step 3: Send the message
-	msg := map[string]interface{}{
-		"content": content,
-	}
-
-	return c.sendBotMessage(ctx, dmChannel, msg)
+	return c.sendBotContent(ctx, dmChannel, content)
 }
 
 // ThreadResponse represents a Discord thread creation response
@@ -376,10 +347,20 @@ func (c *Client) CreateStandupThread(ctx context.Context, channelID, roleID stri
 		return fmt.Errorf("bot token not configured")
 	}
 
-	// Step 1: Create the thread
-	now := time.Now()
-	threadName := fmt.Sprintf("Async Standup - %s", now.Format("Monday, January 2, 2006"))
+	standup := messages.Standup{Date: time.Now(), RoleID: roleID}
+	if roleID != "" {
+		standup.RoleMention = fmt.Sprintf("<@&%s>", roleID)
+	}
+	threadName, err := c.templates.Render(messages.StandupThreadNameTemplate, standup)
+	if err != nil {
+		return err
+	}
+	content, err := c.templates.Render(messages.StandupTemplate, standup)
+	if err != nil {
+		return err
+	}
 
+	// Step 1: Create the thread
 	threadPayload := map[string]interface{}{
 		"name":                threadName,
 		"auto_archive_duration": 1440, // 24 hours
@@ -391,16 +372,8 @@ func (c *Client) CreateStandupThread(ctx context.Context, channelID, roleID stri
 		return fmt.Errorf("failed to marshal thread payload: %w", err)
 	}
 
-	url := fmt.Sprintf("https://discord.com/api/v10/channels/%s/threads", channelID)
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonPayload))
-	if err != nil {
-		return fmt.Errorf("failed to create thread request: %w", err)
-	}
-
-	req.Header.Set("Authorization", "Bot "+c.botToken)
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.httpClient.Do(req)
+	url := fmt.Sprintf("%s/channels/%s/threads", c.apiURL, channelID)
+	resp, err := c.do(ctx, request{method: "POST", url: url, body: jsonPayload, auth: true})
 	if err != nil {
 		return fmt.Errorf("failed to create thread: %w", err)
 	}
@@ -417,21 +390,5 @@ func (c *Client) CreateStandupThread(ctx context.Context, channelID, roleID stri
 	}
 
 	// Step 2: Post the standup message in the thread
-	roleMention := ""
-	if roleID != "" {
-		roleMention = fmt.Sprintf("<@&%s> ", roleID)
-	}
-
-	content := fmt.Sprintf("%sGood morning! 🌅\n\n", roleMention)
-	content += "**It's time for async standup!** Please share:\n\n"
-	content += "1️⃣ What did you work on recently?\n"
-	content += "2️⃣ What are you working on today?\n"
-	content += "3️⃣ Any blockers or help needed?\n\n"
-	content += "Reply to this thread with your update. Thanks! 🙏"
-
-	msg := map[string]interface{}{
-		"content": content,
-	}
-
-	return c.sendBotMessage(ctx, threadResp.ID, msg)
+	return c.sendBotContent(ctx, threadResp.ID, content)
 }
@@ -0,0 +1,75 @@
+package discord
+
+import (
+	"github.com/storacha/project-agent/internal/notify"
+)
+
+// Discord's message limits, in characters
+const (
+	maxContentLength     = 2000
+	maxDescriptionLength = 4096
+	maxEmbedsPerMessage  = 10
+	maxEmbedsLength      = 6000 // Titles, descriptions and fields of all embeds in a message
+)
+
+// layoutWebhook splits a message into messages Discord accepts. Long content
+// is sent as several messages, long embed descriptions as several embeds with
+// the same title, and the embeds are spread over as many messages as needed.
+// The embeds follow the last part of the content.
+func layoutWebhook(msg WebhookMessage) []WebhookMessage {
+	var messages []WebhookMessage
+	if msg.Content != "" {
+		for _, part := range notify.SplitText(msg.Content, maxContentLength) {
+			messages = append(messages, WebhookMessage{Content: part})
+		}
+	}
+
+	var embeds []Embed
+	for _, embed := range msg.Embeds {
+		embeds = append(embeds, splitEmbed(embed)...)
+	}
+
+	// The embeds join the last content message, then fill new messages
+	var current *WebhookMessage
+	currentLength := 0
+	if len(messages) > 0 {
+		current = &messages[len(messages)-1]
+	}
+	for _, embed := range embeds {
+		length := embedLength(embed)
+		if current == nil || len(current.Embeds) == maxEmbedsPerMessage || currentLength+length > maxEmbedsLength {
+			messages = append(messages, WebhookMessage{})
+			current = &messages[len(messages)-1]
+			currentLength = 0
+		}
+		current.Embeds = append(current.Embeds, embed)
+		currentLength += length
+	}
+
+	return messages
+}
+
+// splitEmbed splits an embed whose description is too long into several
+// with the same title, color and timestamp. Fields stay on the first one.
+func splitEmbed(embed Embed) []Embed {
+	parts := notify.SplitText(embed.Description, maxDescriptionLength)
+	if len(parts) <= 1 {
+		return []Embed{embed}
+	}
+
+	embeds := make([]Embed, len(parts))
+	for i, part := range parts {
+		embeds[i] = Embed{Title: embed.Title, Description: part, Color: embed.Color, Timestamp: embed.Timestamp}
+	}
+	embeds[0].Fields = embed.Fields
+	return embeds
+}
+
+// embedLength is how much of a message's embed allowance an embed uses
+func embedLength(embed Embed) int {
+	length := notify.TextLength(embed.Title) + notify.TextLength(embed.Description)
+	for _, field := range embed.Fields {
+		length += notify.TextLength(field.Name) + notify.TextLength(field.Value)
+	}
+	return length
+}
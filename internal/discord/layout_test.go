@@ -0,0 +1,113 @@
+package discord
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/storacha/project-agent/internal/notify"
+)
+
+// issueList returns n issue lines as separate paragraphs, so the parts they
+// are split into hold whole lines with no repeated headings
+func issueList(prefix string, n int) string {
+	var lines []string
+	for i := 0; i < n; i++ {
+		lines = append(lines, fmt.Sprintf("• [storacha/guppy#%d](https://github.com/storacha/guppy/issues/%d) - %s issue %d, with a reasonably long title", i, i, prefix, i))
+	}
+	return strings.Join(lines, "\n\n")
+}
+
+func nonBlankLines(text string) []string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+func TestLayoutWebhookFitsDiscordLimits(t *testing.T) {
+	content := issueList("Content", 90)
+	msg := WebhookMessage{Content: content}
+	for i := 0; i < 12; i++ {
+		msg.Embeds = append(msg.Embeds, Embed{
+			Title:       fmt.Sprintf("Report %d", i),
+			Description: issueList(fmt.Sprint("Report ", i), 30*(i%4)),
+			Fields:      []Field{{Name: "Issues", Value: fmt.Sprint(i)}},
+		})
+	}
+
+	messages := layoutWebhook(msg)
+
+	var contents []string
+	descriptions := make(map[string][]string)
+	fields := make(map[string]int)
+	for i, m := range messages {
+		if n := notify.TextLength(m.Content); n > maxContentLength {
+			t.Errorf("message %d content has %d characters, over %d", i, n, maxContentLength)
+		}
+		if len(m.Embeds) > maxEmbedsPerMessage {
+			t.Errorf("message %d has %d embeds, over %d", i, len(m.Embeds), maxEmbedsPerMessage)
+		}
+		total := 0
+		for _, embed := range m.Embeds {
+			if n := notify.TextLength(embed.Description); n > maxDescriptionLength {
+				t.Errorf("message %d embed %q description has %d characters, over %d", i, embed.Title, n, maxDescriptionLength)
+			}
+			total += embedLength(embed)
+			descriptions[embed.Title] = append(descriptions[embed.Title], embed.Description)
+			fields[embed.Title] += len(embed.Fields)
+		}
+		if total > maxEmbedsLength {
+			t.Errorf("message %d embeds total %d characters, over %d", i, total, maxEmbedsLength)
+		}
+		if m.Content != "" {
+			contents = append(contents, m.Content)
+		}
+	}
+
+	if got, want := nonBlankLines(strings.Join(contents, "\n")), nonBlankLines(content); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("content rejoins to %d lines, want the %d input lines", len(got), len(want))
+	}
+	for _, embed := range msg.Embeds {
+		parts := descriptions[embed.Title]
+		if got, want := nonBlankLines(strings.Join(parts, "\n")), nonBlankLines(embed.Description); strings.Join(got, "\n") != strings.Join(want, "\n") {
+			t.Errorf("%s: descriptions rejoin to %d lines, want the %d input lines", embed.Title, len(got), len(want))
+		}
+		if fields[embed.Title] != 1 {
+			t.Errorf("%s: fields appear %d times, want once", embed.Title, fields[embed.Title])
+		}
+	}
+}
+
+func TestSplitEmbedKeepsFieldsOnTheFirstPart(t *testing.T) {
+	embed := Embed{
+		Title:       "Stale issues",
+		Description: issueList("Stale", 80),
+		Color:       0xff0000,
+		Fields:      []Field{{Name: "Threshold", Value: "30 days"}},
+	}
+
+	parts := splitEmbed(embed)
+
+	if len(parts) < 2 {
+		t.Fatalf("got %d embeds for %d characters", len(parts), len(embed.Description))
+	}
+	for i, part := range parts {
+		if part.Title != embed.Title || part.Color != embed.Color {
+			t.Errorf("embed %d is %q in %#x, want the original title and color", i, part.Title, part.Color)
+		}
+		if n := notify.TextLength(part.Description); n > maxDescriptionLength {
+			t.Errorf("embed %d description has %d characters, over %d", i, n, maxDescriptionLength)
+		}
+		want := 0
+		if i == 0 {
+			want = len(embed.Fields)
+		}
+		if len(part.Fields) != want {
+			t.Errorf("embed %d has %d fields, want %d", i, len(part.Fields), want)
+		}
+	}
+}
@@ -0,0 +1,257 @@
+package discord
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Retry limits for Discord API requests
+const (
+	maxAttempts      = 5
+	maxRateLimitWait = 2 * time.Minute // Longer waits fail the request instead
+	retryBackoff     = time.Second     // Before resending after a network or server error
+)
+
+// request is a Discord API request. Its body is kept so it can be resent.
+type request struct {
+	method string
+	url    string
+	body   []byte
+	// auth sends the bot token
+	auth bool
+	// idempotent requests are safe to resend after a network or server
+	// error, when Discord may have acted on the first attempt
+	idempotent bool
+}
+
+// bucket is what is known of one of Discord's rate limit buckets
+type bucket struct {
+	remaining int
+	resetAt   time.Time
+}
+
+// rateLimiter follows Discord's rate limits: each route maps to a bucket,
+// learned from the X-RateLimit-Bucket header, and buckets are counted per
+// major parameter (the channel or webhook). A global 429 pauses every route.
+type rateLimiter struct {
+	mu          sync.Mutex
+	routes      map[string]string  // Route to bucket hash
+	buckets     map[string]*bucket // By bucket hash and major parameter
+	globalUntil time.Time
+}
+
+func newRateLimiter() *rateLimiter {
+	return &rateLimiter{
+		routes:  make(map[string]string),
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// bucketKey identifies the bucket of a route. Until Discord names the
+// route's bucket, the route is its own bucket.
+func (l *rateLimiter) bucketKey(route, major string) string {
+	if hash, ok := l.routes[route]; ok {
+		return hash + " " + major
+	}
+	return route
+}
+
+// wait blocks until a request on route may be sent, and counts it against
+// the route's bucket
+func (l *rateLimiter) wait(ctx context.Context, route, major string) error {
+	for {
+		l.mu.Lock()
+		now := time.Now()
+		delay := l.globalUntil.Sub(now)
+		b := l.buckets[l.bucketKey(route, major)]
+		if b != nil && b.remaining <= 0 && b.resetAt.Sub(now) > delay {
+			delay = b.resetAt.Sub(now)
+		}
+		if delay <= 0 {
+			if b != nil && b.resetAt.After(now) {
+				b.remaining--
+			}
+			l.mu.Unlock()
+			return nil
+		}
+		l.mu.Unlock()
+
+		if err := sleep(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// update records the bucket state a response reports
+func (l *rateLimiter) update(route, major string, header http.Header) {
+	hash := header.Get("X-RateLimit-Bucket")
+	remaining, err := strconv.Atoi(header.Get("X-RateLimit-Remaining"))
+	if hash == "" || err != nil {
+		return
+	}
+	resetAfter, err := strconv.ParseFloat(header.Get("X-RateLimit-Reset-After"), 64)
+	if err != nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.routes[route] = hash
+	l.buckets[l.bucketKey(route, major)] = &bucket{
+		remaining: remaining,
+		resetAt:   time.Now().Add(seconds(resetAfter)),
+	}
+}
+
+// limited records a 429 response and returns how long to wait before
+// retrying
+func (l *rateLimiter) limited(route, major string, resp *http.Response) time.Duration {
+	var body struct {
+		RetryAfter float64 `json:"retry_after"`
+		Global     bool    `json:"global"`
+	}
+	data, _ := io.ReadAll(resp.Body)
+	_ = json.Unmarshal(data, &body)
+
+	retryAfter := seconds(body.RetryAfter)
+	if retryAfter <= 0 {
+		if after, err := strconv.ParseFloat(resp.Header.Get("Retry-After"), 64); err == nil {
+			retryAfter = seconds(after)
+		}
+	}
+	if retryAfter <= 0 {
+		retryAfter = retryBackoff
+	}
+
+	l.update(route, major, resp.Header)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	until := time.Now().Add(retryAfter)
+	if body.Global || resp.Header.Get("X-RateLimit-Global") == "true" {
+		l.globalUntil = until
+	} else {
+		l.buckets[l.bucketKey(route, major)] = &bucket{remaining: 0, resetAt: until}
+	}
+	return retryAfter
+}
+
+// do sends a request, waiting out Discord's rate limits. A 429 response is
+// always retried, since Discord did not act on it; network and server errors
+// are retried only for idempotent requests. The caller closes the response
+// body.
+func (c *Client) do(ctx context.Context, r request) (*http.Response, error) {
+	route, major, err := routeOf(r.method, r.url)
+	if err != nil {
+		return nil, err
+	}
+
+	for attempt := 1; ; attempt++ {
+		if err := c.limiter.wait(ctx, route, major); err != nil {
+			return nil, err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, r.method, r.url, bytes.NewReader(r.body))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if r.auth {
+			req.Header.Set("Authorization", "Bot "+c.botToken)
+		}
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			if !r.idempotent || attempt == maxAttempts || ctx.Err() != nil {
+				return nil, err
+			}
+			slog.WarnContext(ctx, "Discord request failed, retrying", "resource", major, "error", err)
+			if err := sleep(ctx, retryBackoff*time.Duration(attempt)); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		switch {
+		case resp.StatusCode == http.StatusTooManyRequests:
+			wait := c.limiter.limited(route, major, resp)
+			resp.Body.Close()
+			if attempt == maxAttempts {
+				return nil, fmt.Errorf("rate limited by Discord after %d attempts", attempt)
+			}
+			if wait > maxRateLimitWait {
+				return nil, fmt.Errorf("rate limited by Discord for %s", wait.Round(time.Second))
+			}
+			slog.InfoContext(ctx, "Rate limited by Discord, retrying", "resource", major, "wait", wait.Round(time.Millisecond))
+
+		case resp.StatusCode >= 500 && r.idempotent && attempt < maxAttempts:
+			c.limiter.update(route, major, resp.Header)
+			resp.Body.Close()
+			slog.WarnContext(ctx, "Discord returned a server error, retrying", "resource", major, "status", resp.StatusCode)
+			if err := sleep(ctx, retryBackoff*time.Duration(attempt)); err != nil {
+				return nil, err
+			}
+
+		default:
+			c.limiter.update(route, major, resp.Header)
+			return resp, nil
+		}
+	}
+}
+
+// routeOf returns the rate limit route of a request and its major parameter,
+// the channel or webhook it acts on
+func routeOf(method, rawURL string) (string, string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid Discord URL: %w", err)
+	}
+
+	major := ""
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	for i := 0; i+1 < len(segments); i++ {
+		if segments[i] == "channels" || segments[i] == "webhooks" {
+			major = segments[i] + "/" + segments[i+1]
+			break
+		}
+	}
+	return method + " " + u.Host + u.Path, major, nil
+}
+
+// sleep waits for d or until ctx is done
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// seconds converts Discord's fractional seconds to a duration
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// newNonce returns a random message nonce, within Discord's 25 characters
+func newNonce() (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,194 @@
+package discord
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/storacha/project-agent/internal/standin"
+)
+
+// newStandin returns a stand-in for the Discord API that answers with
+// replies, then with created messages
+func newStandin(t *testing.T, replies ...standin.Reply) *standin.Server {
+	t.Helper()
+	s := standin.New(t, standin.Reply{Body: `{"id":"1"}`})
+	s.Script(replies...)
+	return s
+}
+
+// botClient returns a bot client pointed at s
+func botClient(s *standin.Server) *Client {
+	c := NewBotClient("bot-token")
+	c.SetAPIURL(s.URL)
+	return c
+}
+
+func TestBucketRateLimitIsWaitedOut(t *testing.T) {
+	s := newStandin(t, standin.Reply{
+		Status: http.StatusTooManyRequests,
+		Header: map[string]string{
+			"X-RateLimit-Bucket":      "abcd",
+			"X-RateLimit-Remaining":   "0",
+			"X-RateLimit-Reset-After": "0.1",
+		},
+		Body: `{"message":"You are being rate limited.","retry_after":0.1,"global":false}`,
+	})
+	c := botClient(s)
+
+	if err := c.sendBotContent(context.Background(), "123", "hello"); err != nil {
+		t.Fatal(err)
+	}
+
+	reqs := s.Requests()
+	if len(reqs) != 2 {
+		t.Fatalf("got %d requests, want the 429 retried once", len(reqs))
+	}
+	if gap := reqs[1].At.Sub(reqs[0].At); gap < 90*time.Millisecond {
+		t.Errorf("retried after %s, want retry_after of 100ms", gap)
+	}
+	if reqs[0].Header.Get("Authorization") != "Bot bot-token" || reqs[0].Path != "/channels/123/messages" {
+		t.Errorf("request = %s with Authorization %q", reqs[0].Path, reqs[0].Header.Get("Authorization"))
+	}
+	// The retry is the same message, so Discord can drop a duplicate
+	if string(reqs[0].Body) != string(reqs[1].Body) {
+		t.Errorf("retry body %s differs from %s", reqs[1].Body, reqs[0].Body)
+	}
+
+	route := "POST " + strings.TrimPrefix(s.URL, "http://") + "/channels/123/messages"
+	if hash := c.limiter.routes[route]; hash != "abcd" {
+		t.Errorf("route bucket = %q, want abcd learned from the 429", hash)
+	}
+	if !c.limiter.globalUntil.IsZero() {
+		t.Error("a bucket 429 paused every route")
+	}
+}
+
+func TestGlobalRateLimitPausesEveryRoute(t *testing.T) {
+	s := newStandin(t, standin.Reply{
+		Status: http.StatusTooManyRequests,
+		Header: map[string]string{"X-RateLimit-Global": "true", "Retry-After": "1"},
+		Body:   `{"message":"You are being rate limited.","retry_after":0.2,"global":true}`,
+	})
+	c := botClient(s)
+	ctx := context.Background()
+
+	start := time.Now()
+	if err := c.sendBotContent(ctx, "123", "hello"); err != nil {
+		t.Fatal(err)
+	}
+	// Another channel is a different bucket, but still waits out the
+	// global limit
+	if err := c.limiter.wait(ctx, "POST example/channels/456/messages", "channels/456"); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 190*time.Millisecond {
+		t.Errorf("finished after %s, want the 200ms global retry_after waited out", elapsed)
+	}
+	if n := len(s.Requests()); n != 2 {
+		t.Errorf("got %d requests, want 2", n)
+	}
+	if c.limiter.globalUntil.IsZero() {
+		t.Error("global limit was not recorded")
+	}
+}
+
+func TestWebhookIsNotRetriedAfterServerError(t *testing.T) {
+	s := newStandin(t, standin.Reply{Status: http.StatusBadGateway, Body: `{"message":"upstream"}`})
+	c := NewClient(s.URL + "/webhooks/1/token")
+
+	err := c.sendWebhook(context.Background(), WebhookMessage{Content: "report"})
+	if err == nil || !strings.Contains(err.Error(), "502") {
+		t.Fatalf("err = %v, want the 502", err)
+	}
+	if n := len(s.Requests()); n != 1 {
+		t.Fatalf("webhook was sent %d times, want 1", n)
+	}
+}
+
+func TestBotMessageIsRetriedAfterServerError(t *testing.T) {
+	s := newStandin(t, standin.Reply{Status: http.StatusBadGateway})
+	c := botClient(s)
+
+	if err := c.sendBotContent(context.Background(), "123", "hello"); err != nil {
+		t.Fatal(err)
+	}
+	reqs := s.Requests()
+	if len(reqs) != 2 {
+		t.Fatalf("got %d requests, want the 502 retried once", len(reqs))
+	}
+	var first, second struct {
+		Nonce        string `json:"nonce"`
+		EnforceNonce bool   `json:"enforce_nonce"`
+	}
+	_ = reqs[0].Decode(&first)
+	_ = reqs[1].Decode(&second)
+	if first.Nonce == "" || first.Nonce != second.Nonce || !second.EnforceNonce {
+		t.Errorf("nonces %q and %q, want the same enforced nonce", first.Nonce, second.Nonce)
+	}
+}
+
+func TestRateLimiterLearnsBuckets(t *testing.T) {
+	l := newRateLimiter()
+	ctx := context.Background()
+	route := "POST discord.com/api/v10/channels/1/messages"
+
+	// Until Discord names it, a route is its own bucket
+	if key := l.bucketKey(route, "channels/1"); key != route {
+		t.Errorf("bucketKey = %q, want the route", key)
+	}
+
+	l.update(route, "channels/1", http.Header{
+		"X-Ratelimit-Bucket":      {"abcd"},
+		"X-Ratelimit-Remaining":   {"0"},
+		"X-Ratelimit-Reset-After": {"0.1"},
+	})
+	if key := l.bucketKey(route, "channels/1"); key != "abcd channels/1" {
+		t.Errorf("bucketKey = %q, want abcd per channel", key)
+	}
+
+	// Another channel on the same route has its own count
+	start := time.Now()
+	if err := l.wait(ctx, route, "channels/2"); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Errorf("another channel waited %s", elapsed)
+	}
+
+	if err := l.wait(ctx, route, "channels/1"); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("exhausted bucket waited %s, want its 100ms reset", elapsed)
+	}
+
+	// Responses without bucket headers leave what is known alone
+	l.update(route, "channels/1", http.Header{})
+	if l.routes[route] != "abcd" {
+		t.Error("bucket was forgotten")
+	}
+}
+
+func TestLimitedRetryAfter(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		header http.Header
+		body   string
+		want   time.Duration
+	}{
+		{"body", http.Header{"Retry-After": {"3"}}, `{"retry_after":1.5}`, 1500 * time.Millisecond},
+		{"header", http.Header{"Retry-After": {"2"}}, `not json`, 2 * time.Second},
+		{"neither", http.Header{}, `{}`, retryBackoff},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			resp := &http.Response{StatusCode: http.StatusTooManyRequests, Header: tc.header, Body: io.NopCloser(strings.NewReader(tc.body))}
+			if got := newRateLimiter().limited("GET r", "", resp); got != tc.want {
+				t.Errorf("limited = %s, want %s", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,229 @@
+// Package email sends the agent's notifications as plain text email over
+// SMTP
+package email
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/storacha/project-agent/internal/directory"
+	"github.com/storacha/project-agent/internal/github"
+	"github.com/storacha/project-agent/internal/messages"
+	"github.com/storacha/project-agent/internal/notify"
+)
+
+// Client sends messages by email. Reports go to the report recipients, and
+// direct messages to the user's address. A thread is an email to the
+// channel address, such as a mailing list, with the thread name as subject.
+type Client struct {
+	addr      string // SMTP server host:port
+	auth      smtp.Auth
+	from      string
+	to        []string // Report recipients
+	statuses  notify.StatusLayout
+	templates *messages.Templates
+}
+
+// NewClient creates a new email client. username, if set, authenticates
+// with PLAIN auth, which net/smtp only sends over TLS or to localhost; the
+// connection is upgraded with STARTTLS when the server offers it.
+func NewClient(host string, port int, username, password, from string, to []string) *Client {
+	c := &Client{
+		addr:      net.JoinHostPort(host, strconv.Itoa(port)),
+		from:      from,
+		to:        to,
+		statuses:  notify.DefaultStatusLayout(),
+		templates: messages.Default(),
+	}
+	if username != "" {
+		c.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return c
+}
+
+// SetStatusLayout replaces the client's status layout
+func (c *Client) SetStatusLayout(layout notify.StatusLayout) {
+	c.statuses = layout
+}
+
+// SetTemplates sets the templates messages are rendered with
+func (c *Client) SetTemplates(templates *messages.Templates) {
+	c.templates = templates
+}
+
+// SendStaleIssuesReport emails a summary of stale issues to the report
+// recipients, with the headline as subject and a section per status
+func (c *Client) SendStaleIssuesReport(ctx context.Context, staleIssues []notify.StaleIssue, people *directory.Directory, thresholdDays int) error {
+	headline, sections := c.statuses.StaleReport(staleIssues, people, transport, thresholdDays, mention)
+	subject, err := c.templates.Render(messages.StaleReportTemplate, headline)
+	if err != nil {
+		return err
+	}
+	body := subject
+	for _, section := range sections {
+		description, err := c.templates.Render(messages.StaleReportStatusTemplate, section)
+		if err != nil {
+			return err
+		}
+		body += "\n\n" + section.Status + "\n" + strings.Repeat("=", utf8.RuneCountInString(section.Status)) + "\n" + description
+	}
+	return c.sendReport(ctx, subject, body)
+}
+
+// SendTaskFailure emails a failed task run to the report recipients
+func (c *Client) SendTaskFailure(ctx context.Context, task string, errs []string) error {
+	failure, err := notify.RenderTaskFailure(c.templates, task, errs)
+	if err != nil {
+		return err
+	}
+	return c.sendReport(ctx, failure.Content, failure.Title+"\n\n"+failure.Description)
+}
+
+// SendWeeklyDM emails a user their assigned issues
+func (c *Client) SendWeeklyDM(ctx context.Context, userIssues notify.UserIssues) error {
+	body, err := c.templates.Render(messages.WeeklyDMTemplate, messages.WeeklyDM{
+		GithubUsername: userIssues.GithubUsername,
+		Count:          len(userIssues.Issues),
+		Groups:         c.statuses.GroupByStatus(userIssues.Issues),
+	})
+	if err != nil {
+		return err
+	}
+	return c.send(ctx, []string{userIssues.UserID}, firstLine(body), body)
+}
+
+// SendUnassignedIssuesDM emails all unassigned issues to a user
+func (c *Client) SendUnassignedIssuesDM(ctx context.Context, userID string, issues []github.Issue) error {
+	body, err := c.templates.Render(messages.UnassignedDMTemplate, messages.UnassignedDM{
+		Count:          len(issues),
+		ActiveStatuses: c.statuses.DescribeActive("or"),
+		Groups:         c.statuses.GroupByStatus(issues),
+	})
+	if err != nil {
+		return err
+	}
+	return c.send(ctx, []string{userID}, firstLine(body), body)
+}
+
+// CreateStandupThread emails the standup prompt to the channel address,
+// with the thread name as subject. Replies to it form the thread.
+func (c *Client) CreateStandupThread(ctx context.Context, channelID, roleID string) error {
+	standup := messages.Standup{Date: time.Now(), RoleID: roleID, RoleMention: roleID}
+	subject, err := c.templates.Render(messages.StandupThreadNameTemplate, standup)
+	if err != nil {
+		return err
+	}
+	body, err := c.templates.Render(messages.StandupTemplate, standup)
+	if err != nil {
+		return err
+	}
+	return c.send(ctx, []string{channelID}, subject, body)
+}
+
+// transport is how the directory names email
+const transport = "email"
+
+// mention names a user by their address
+func mention(address string) string {
+	return address
+}
+
+// sendReport emails the report recipients
+func (c *Client) sendReport(ctx context.Context, subject, body string) error {
+	if len(c.to) == 0 {
+		return fmt.Errorf("report recipients not configured")
+	}
+	return c.send(ctx, c.to, subject, body)
+}
+
+// send emails Markdown body as plain text
+func (c *Client) send(ctx context.Context, to []string, subject, body string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	msg, err := c.message(to, plainText(subject), plainText(body))
+	if err != nil {
+		return err
+	}
+	// The envelope sender is the bare address, without a display name
+	sender := c.from
+	if from, err := mail.ParseAddress(c.from); err == nil {
+		sender = from.Address
+	}
+	if err := smtp.SendMail(c.addr, c.auth, sender, to, msg); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
+
+// message builds a quoted-printable UTF-8 text message
+func (c *Client) message(to []string, subject, body string) ([]byte, error) {
+	id := make([]byte, 12)
+	if _, err := rand.Read(id); err != nil {
+		return nil, fmt.Errorf("failed to generate message ID: %w", err)
+	}
+	domain := "project-agent"
+	if from, err := mail.ParseAddress(c.from); err == nil {
+		if at := strings.LastIndex(from.Address, "@"); at >= 0 {
+			domain = from.Address[at+1:]
+		}
+	}
+
+	var buf bytes.Buffer
+	for _, header := range [][2]string{
+		{"From", c.from},
+		{"To", strings.Join(to, ", ")},
+		{"Subject", mime.QEncoding.Encode("utf-8", subject)},
+		{"Date", time.Now().Format(time.RFC1123Z)},
+		{"Message-ID", fmt.Sprintf("<%s@%s>", hex.EncodeToString(id), domain)},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", "text/plain; charset=utf-8"},
+		{"Content-Transfer-Encoding", "quoted-printable"},
+	} {
+		fmt.Fprintf(&buf, "%s: %s\r\n", header[0], header[1])
+	}
+	buf.WriteString("\r\n")
+
+	w := quotedprintable.NewWriter(&buf)
+	if _, err := w.Write([]byte(strings.ReplaceAll(body, "\n", "\r\n"))); err != nil {
+		return nil, fmt.Errorf("failed to encode email: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode email: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+var (
+	markdownLink   = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+	markdownBold   = regexp.MustCompile(`\*\*(.+?)\*\*`)
+	markdownItalic = regexp.MustCompile(`\*([^*\s][^*]*?)\*`)
+)
+
+// plainText strips the Markdown the message templates are written in:
+// links become "text <url>" and bold and italic markers are dropped
+func plainText(markdown string) string {
+	text := markdownLink.ReplaceAllString(markdown, "$1 <$2>")
+	text = markdownBold.ReplaceAllString(text, "$1")
+	return markdownItalic.ReplaceAllString(text, "$1")
+}
+
+// firstLine is the first line of text, used as the subject of messages
+// that have none of their own
+func firstLine(text string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(text), "\n")
+	return line
+}
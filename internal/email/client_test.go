@@ -0,0 +1,204 @@
+package email
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// delivery is a message the fake SMTP server accepted
+type delivery struct {
+	auth string // Decoded AUTH PLAIN credentials
+	from string
+	to   []string
+	data string
+}
+
+// fakeSMTP is a minimal SMTP server that accepts every message. It offers
+// AUTH PLAIN but not STARTTLS, which net/smtp allows only on localhost.
+type fakeSMTP struct {
+	mu         sync.Mutex
+	deliveries []delivery
+	listener   net.Listener
+}
+
+func newFakeSMTP(t *testing.T) *fakeSMTP {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeSMTP{listener: l}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+	t.Cleanup(func() { l.Close() })
+	return f
+}
+
+func (f *fakeSMTP) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+
+	var d delivery
+	reply("220 localhost ESMTP fake")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			reply("250-localhost")
+			reply("250 AUTH PLAIN")
+		case "AUTH":
+			_, creds, _ := strings.Cut(arg, " ")
+			decoded, _ := base64.StdEncoding.DecodeString(creds)
+			d.auth = string(decoded)
+			reply("235 2.7.0 Authentication successful")
+		case "MAIL":
+			d.from = strings.Trim(strings.TrimPrefix(arg, "FROM:"), "<>")
+			reply("250 OK")
+		case "RCPT":
+			d.to = append(d.to, strings.Trim(strings.TrimPrefix(arg, "TO:"), "<>"))
+			reply("250 OK")
+		case "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if line == ".\r\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(line, "."))
+			}
+			d.data = data.String()
+			f.mu.Lock()
+			f.deliveries = append(f.deliveries, d)
+			f.mu.Unlock()
+			d = delivery{}
+			reply("250 OK: queued")
+		case "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+func (f *fakeSMTP) delivered() []delivery {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]delivery(nil), f.deliveries...)
+}
+
+// client returns a client for the fake server
+func (f *fakeSMTP) client(username string) *Client {
+	port := f.listener.Addr().(*net.TCPAddr).Port
+	return NewClient("127.0.0.1", port, username, "hunter2", "Project Agent <agent@example.org>", []string{"team@example.org"})
+}
+
+// parse reads a delivered message and decodes its subject and body
+func parse(t *testing.T, d delivery) (*mail.Message, string, string) {
+	t.Helper()
+	msg, err := mail.ReadMessage(strings.NewReader(d.data))
+	if err != nil {
+		t.Fatalf("invalid message: %v", err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := io.ReadAll(quotedprintable.NewReader(msg.Body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return msg, subject, string(body)
+}
+
+func TestSendTaskFailure(t *testing.T) {
+	f := newFakeSMTP(t)
+
+	if err := f.client("agent").SendTaskFailure(context.Background(), "triage-stale", []string{"boom"}); err != nil {
+		t.Fatal(err)
+	}
+
+	ds := f.delivered()
+	if len(ds) != 1 {
+		t.Fatalf("got %d deliveries, want 1", len(ds))
+	}
+	d := ds[0]
+	if d.auth != "\x00agent\x00hunter2" {
+		t.Errorf("AUTH PLAIN credentials = %q", d.auth)
+	}
+	// The envelope sender is the bare address
+	if d.from != "agent@example.org" || strings.Join(d.to, ",") != "team@example.org" {
+		t.Errorf("envelope = %s to %v", d.from, d.to)
+	}
+
+	msg, subject, body := parse(t, d)
+	if msg.Header.Get("From") != "Project Agent <agent@example.org>" || !strings.HasSuffix(msg.Header.Get("Message-Id"), "@example.org>") {
+		t.Errorf("headers = %v", msg.Header)
+	}
+	if !strings.Contains(subject, "triage-stale") {
+		t.Errorf("subject %q does not name the task", subject)
+	}
+	if !strings.Contains(body, "boom") || strings.Contains(body, "**") {
+		t.Errorf("body = %q, want the error as plain text", body)
+	}
+}
+
+func TestCreateStandupThread(t *testing.T) {
+	f := newFakeSMTP(t)
+
+	if err := f.client("").CreateStandupThread(context.Background(), "standup@lists.example.org", "everyone"); err != nil {
+		t.Fatal(err)
+	}
+
+	ds := f.delivered()
+	if len(ds) != 1 || strings.Join(ds[0].to, ",") != "standup@lists.example.org" {
+		t.Fatalf("deliveries = %+v, want one to the list", ds)
+	}
+	if ds[0].auth != "" {
+		t.Errorf("authenticated without a username")
+	}
+	_, subject, body := parse(t, ds[0])
+	if subject == "" || strings.Contains(subject, "*") {
+		t.Errorf("subject %q, want the plain thread name", subject)
+	}
+	if !strings.Contains(body, "everyone") {
+		t.Errorf("body %q does not mention the role", body)
+	}
+}
+
+func TestSendFailsWhenServerIsDown(t *testing.T) {
+	f := newFakeSMTP(t)
+	c := f.client("")
+	f.listener.Close()
+
+	err := c.SendTaskFailure(context.Background(), "triage-stale", nil)
+	if err == nil || !strings.Contains(err.Error(), "failed to send email") {
+		t.Fatalf("err = %v, want a send failure", err)
+	}
+}
@@ -0,0 +1,407 @@
+// Package fakes provides in-memory implementations of the interfaces the
+// tasks depend on, so task behaviour can be exercised without network access.
+// Every fake records the mutations it receives in call order.
+package fakes
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/storacha/project-agent/internal/github"
+	"github.com/storacha/project-agent/internal/tasks"
+)
+
+var (
+	_ tasks.ProjectBoard      = (*Board)(nil)
+	_ tasks.PullRequestSource = (*Board)(nil)
+	_ tasks.Notifier          = (*Notifier)(nil)
+	_ tasks.SimilarityScorer  = (*Scorer)(nil)
+)
+
+// Mutation kinds recorded by Board
+const (
+	MutationStatus       = "status"
+	MutationInitiative   = "initiative"
+	MutationComment      = "comment"
+	MutationEditComment  = "edit-comment"
+	MutationLabel        = "label"
+	MutationAddToProject = "add-to-project"
+	MutationLinkPR       = "link-pr"
+	MutationField        = "field"
+	MutationClearField   = "clear-field"
+)
+
+// Mutation records a single write made against the fake board
+type Mutation struct {
+	Kind   string
+	Issue  string // owner/repo#number
+	ItemID string
+	Value  string // new status, initiative title, comment body, label, PR reference, or field value
+	Field  string // field name, for field mutations
+	Marker string // comment marker, for upserted comments
+}
+
+// Board is an in-memory project board. Issues with an empty ProjectItem.ID
+// exist in their repository but have not been added to the project.
+type Board struct {
+	mu sync.Mutex
+
+	// Issues holds every issue the board knows about
+	Issues []github.Issue
+	// SubIssues maps an issue key (owner/repo#number) to its direct children
+	SubIssues map[string][]github.SubIssue
+	// Initiatives lists the keys of issues whose issue type is Initiative
+	Initiatives []string
+	// PullRequests maps a repository (owner/repo) to its open pull requests
+	PullRequests map[string][]github.PullRequest
+	// Mutations lists every write in the order it was made
+	Mutations []Mutation
+	// FailOn, when set, is consulted before each write; a non-nil error is
+	// returned to the caller and the write is not applied
+	FailOn func(m Mutation) error
+	// RateBudget is returned by Budget; nil means the board never runs out
+	RateBudget *github.Budget
+
+	nextItemID int
+}
+
+// NewBoard creates a board seeded with the given issues
+func NewBoard(issues ...github.Issue) *Board {
+	return &Board{
+		Issues:       issues,
+		SubIssues:    make(map[string][]github.SubIssue),
+		PullRequests: make(map[string][]github.PullRequest),
+	}
+}
+
+// IssueKey returns the owner/repo#number key used by Board
+func IssueKey(owner, repo string, number int) string {
+	return fmt.Sprintf("%s/%s#%d", owner, repo, number)
+}
+
+func keyOf(issue github.Issue) string {
+	return IssueKey(issue.RepositoryOwner, issue.RepositoryName, issue.Number)
+}
+
+// Budget returns RateBudget
+func (b *Board) Budget() *github.Budget {
+	return b.RateBudget
+}
+
+// MutationsOfKind returns the recorded mutations of a single kind
+func (b *Board) MutationsOfKind(kind string) []Mutation {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var out []Mutation
+	for _, m := range b.Mutations {
+		if m.Kind == kind {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// Issue returns the current state of an issue by key
+func (b *Board) Issue(key string) (github.Issue, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if i := b.find(key); i >= 0 {
+		return b.Issues[i], true
+	}
+	return github.Issue{}, false
+}
+
+func (b *Board) find(key string) int {
+	for i, issue := range b.Issues {
+		if keyOf(issue) == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// record checks FailOn and appends the mutation; callers must hold b.mu
+func (b *Board) record(m Mutation) error {
+	if b.FailOn != nil {
+		if err := b.FailOn(m); err != nil {
+			return err
+		}
+	}
+	b.Mutations = append(b.Mutations, m)
+	return nil
+}
+
+// GetIssuesByStatuses returns the project issues whose status is in statuses
+func (b *Board) GetIssuesByStatuses(ctx context.Context, statuses []string) ([]github.Issue, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	want := make(map[string]bool)
+	for _, s := range statuses {
+		want[s] = true
+	}
+
+	var out []github.Issue
+	for _, issue := range b.Issues {
+		if issue.ProjectItem.ID != "" && want[issue.ProjectItem.StatusValue] {
+			out = append(out, issue)
+		}
+	}
+	return out, nil
+}
+
+// GetIssueByNumber returns an issue that is on the project
+func (b *Board) GetIssueByNumber(ctx context.Context, owner, repo string, number int) (*github.Issue, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	i := b.find(IssueKey(owner, repo, number))
+	if i < 0 || b.Issues[i].ProjectItem.ID == "" {
+		return nil, fmt.Errorf("issue #%d not found in project", number)
+	}
+	issue := b.Issues[i]
+	return &issue, nil
+}
+
+// GetProjectItem returns the issue with the given project item ID
+func (b *Board) GetProjectItem(ctx context.Context, itemID string) (*github.Issue, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, issue := range b.Issues {
+		if issue.ProjectItem.ID == itemID && itemID != "" {
+			return &issue, nil
+		}
+	}
+	return nil, fmt.Errorf("project item %s not found", itemID)
+}
+
+// GetInitiativeIssues returns the project issues listed in Initiatives
+func (b *Board) GetInitiativeIssues(ctx context.Context) ([]github.Issue, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var out []github.Issue
+	for _, key := range b.Initiatives {
+		if i := b.find(key); i >= 0 && b.Issues[i].ProjectItem.ID != "" {
+			out = append(out, b.Issues[i])
+		}
+	}
+	return out, nil
+}
+
+// GetRepositories returns the repositories in PullRequests owned by org,
+// sorted by name
+func (b *Board) GetRepositories(ctx context.Context, org string) ([]github.Repository, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var repos []github.Repository
+	for key := range b.PullRequests {
+		owner, name, _ := strings.Cut(key, "/")
+		if strings.EqualFold(owner, org) {
+			repos = append(repos, github.Repository{Owner: owner, Name: name})
+		}
+	}
+	sort.Slice(repos, func(i, j int) bool { return repos[i].Name < repos[j].Name })
+	return repos, nil
+}
+
+// GetOpenPullRequests returns the pull requests listed for a repository
+func (b *Board) GetOpenPullRequests(ctx context.Context, owner, repo string) ([]github.PullRequest, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return append([]github.PullRequest(nil), b.PullRequests[owner+"/"+repo]...), nil
+}
+
+// GetSubIssuesRecursive walks SubIssues depth first, visiting each issue once
+func (b *Board) GetSubIssuesRecursive(ctx context.Context, owner, repo string, number int) ([]github.SubIssue, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var all []github.SubIssue
+	visited := make(map[string]bool)
+
+	var walk func(key string)
+	walk = func(key string) {
+		if visited[key] {
+			return
+		}
+		visited[key] = true
+		for _, sub := range b.SubIssues[key] {
+			all = append(all, sub)
+			walk(IssueKey(sub.Owner, sub.Repo, sub.Number))
+		}
+	}
+	walk(IssueKey(owner, repo, number))
+
+	return all, nil
+}
+
+// AddIssueToProject adds a known issue to the project with the given status,
+// or returns it unchanged if it is already on the project
+func (b *Board) AddIssueToProject(ctx context.Context, owner, repo string, number int, status string) (*github.Issue, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	key := IssueKey(owner, repo, number)
+	i := b.find(key)
+	if i < 0 {
+		return nil, fmt.Errorf("issue %s not found", key)
+	}
+
+	if b.Issues[i].ProjectItem.ID != "" {
+		issue := b.Issues[i]
+		return &issue, nil
+	}
+
+	b.nextItemID++
+	itemID := fmt.Sprintf("fake-item-%d", b.nextItemID)
+	if err := b.record(Mutation{Kind: MutationAddToProject, Issue: key, ItemID: itemID, Value: status}); err != nil {
+		return nil, err
+	}
+
+	b.Issues[i].ProjectItem.ID = itemID
+	b.Issues[i].ProjectItem.StatusValue = status
+	issue := b.Issues[i]
+	return &issue, nil
+}
+
+// UpdateInitiativeField records the new Initiative value
+func (b *Board) UpdateInitiativeField(ctx context.Context, issue github.Issue, initiativeTitle string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.record(Mutation{Kind: MutationInitiative, Issue: keyOf(issue), ItemID: issue.ProjectItem.ID, Value: initiativeTitle})
+}
+
+// MoveToStatus sets the issue status
+func (b *Board) MoveToStatus(ctx context.Context, issue github.Issue, status string) error {
+	return b.setStatus(issue, status)
+}
+
+func (b *Board) setStatus(issue github.Issue, status string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	key := keyOf(issue)
+	if err := b.record(Mutation{Kind: MutationStatus, Issue: key, ItemID: issue.ProjectItem.ID, Value: status}); err != nil {
+		return err
+	}
+	if i := b.find(key); i >= 0 {
+		b.Issues[i].ProjectItem.StatusValue = status
+	}
+	return nil
+}
+
+// setField records a field update and applies it to the issue's Fields;
+// a nil value clears the field
+func (b *Board) setField(issue github.Issue, fieldName string, value *github.FieldValue) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	key := keyOf(issue)
+	m := Mutation{Kind: MutationClearField, Issue: key, ItemID: issue.ProjectItem.ID, Field: fieldName}
+	if value != nil {
+		m.Kind = MutationField
+		m.Value = value.String()
+	}
+	if err := b.record(m); err != nil {
+		return err
+	}
+
+	if i := b.find(key); i >= 0 {
+		if value == nil {
+			delete(b.Issues[i].Fields, fieldName)
+			return nil
+		}
+		if b.Issues[i].Fields == nil {
+			b.Issues[i].Fields = make(map[string]github.FieldValue)
+		}
+		b.Issues[i].Fields[fieldName] = *value
+	}
+	return nil
+}
+
+// AddComment records the comment body
+func (b *Board) AddComment(ctx context.Context, issue github.Issue, comment string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.record(Mutation{Kind: MutationComment, Issue: keyOf(issue), ItemID: issue.ProjectItem.ID, Value: comment})
+}
+
+// upsertComment records a comment carrying marker, or an edit of it when one
+// was recorded before; nothing is recorded when the body is unchanged
+func (b *Board) upsertComment(issue github.Issue, marker, body string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	key := keyOf(issue)
+	kind := MutationComment
+	for i := len(b.Mutations) - 1; i >= 0; i-- {
+		m := b.Mutations[i]
+		if m.Issue != key || m.Marker != marker {
+			continue
+		}
+		if m.Value == body {
+			return nil
+		}
+		kind = MutationEditComment
+		break
+	}
+	return b.record(Mutation{Kind: kind, Issue: key, ItemID: issue.ProjectItem.ID, Value: body, Marker: marker})
+}
+
+// AddLabel records the label name
+func (b *Board) AddLabel(ctx context.Context, issue github.Issue, labelName string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.record(Mutation{Kind: MutationLabel, Issue: keyOf(issue), ItemID: issue.ProjectItem.ID, Value: labelName})
+}
+
+// ApplyBatch applies each op through the matching single-item method, so
+// batched writes are recorded exactly like unbatched ones
+func (b *Board) ApplyBatch(ctx context.Context, ops []github.BatchOp) []github.BatchResult {
+	results := make([]github.BatchResult, len(ops))
+	for i, op := range ops {
+		var err error
+		switch op.Kind {
+		case github.BatchStatus:
+			err = b.setStatus(op.Issue, op.Value)
+		case github.BatchInitiative:
+			err = b.UpdateInitiativeField(ctx, op.Issue, op.Value)
+		case github.BatchComment:
+			if op.Marker != "" {
+				err = b.upsertComment(op.Issue, op.Marker, op.Value)
+				break
+			}
+			err = b.AddComment(ctx, op.Issue, op.Value)
+		case github.BatchLabel:
+			err = b.AddLabel(ctx, op.Issue, op.Value)
+		case github.BatchField:
+			err = b.setField(op.Issue, op.Field, &op.FieldValue)
+		case github.BatchClearField:
+			err = b.setField(op.Issue, op.Field, nil)
+		default:
+			err = fmt.Errorf("unknown batch operation %q", op.Kind)
+		}
+		results[i] = github.BatchResult{Op: op, Err: err}
+	}
+	return results
+}
+
+// LinkPRToIssue records the PR reference
+func (b *Board) LinkPRToIssue(ctx context.Context, prOwner, prRepo string, prNumber int, issue github.Issue) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.record(Mutation{Kind: MutationLinkPR, Issue: keyOf(issue), ItemID: issue.ProjectItem.ID, Value: IssueKey(prOwner, prRepo, prNumber)})
+}
@@ -0,0 +1,95 @@
+package fakes
+
+import (
+	"context"
+	"sync"
+
+	"github.com/storacha/project-agent/internal/directory"
+	"github.com/storacha/project-agent/internal/github"
+	"github.com/storacha/project-agent/internal/notify"
+)
+
+// StaleReport records a call to SendStaleIssuesReport
+type StaleReport struct {
+	StaleIssues   []notify.StaleIssue
+	People        *directory.Directory
+	ThresholdDays int
+}
+
+// UnassignedDM records a call to SendUnassignedIssuesDM
+type UnassignedDM struct {
+	UserID string
+	Issues []github.Issue
+}
+
+// StandupThread records a call to CreateStandupThread
+type StandupThread struct {
+	ChannelID string
+	RoleID    string
+}
+
+// Notifier records every notification instead of sending it
+type Notifier struct {
+	mu sync.Mutex
+
+	StaleReports   []StaleReport
+	WeeklyDMs      []notify.UserIssues
+	UnassignedDMs  []UnassignedDM
+	StandupThreads []StandupThread
+
+	// Err, when set, is returned from every call and nothing is recorded
+	Err error
+	// FailFor, when set, makes SendWeeklyDM fail for the given GitHub usernames
+	FailFor map[string]error
+}
+
+// SendStaleIssuesReport records the report
+func (n *Notifier) SendStaleIssuesReport(ctx context.Context, staleIssues []notify.StaleIssue, people *directory.Directory, thresholdDays int) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.Err != nil {
+		return n.Err
+	}
+	n.StaleReports = append(n.StaleReports, StaleReport{StaleIssues: staleIssues, People: people, ThresholdDays: thresholdDays})
+	return nil
+}
+
+// SendWeeklyDM records the DM
+func (n *Notifier) SendWeeklyDM(ctx context.Context, userIssues notify.UserIssues) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.Err != nil {
+		return n.Err
+	}
+	if err := n.FailFor[userIssues.GithubUsername]; err != nil {
+		return err
+	}
+	n.WeeklyDMs = append(n.WeeklyDMs, userIssues)
+	return nil
+}
+
+// SendUnassignedIssuesDM records the DM
+func (n *Notifier) SendUnassignedIssuesDM(ctx context.Context, userID string, issues []github.Issue) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.Err != nil {
+		return n.Err
+	}
+	n.UnassignedDMs = append(n.UnassignedDMs, UnassignedDM{UserID: userID, Issues: issues})
+	return nil
+}
+
+// CreateStandupThread records the thread
+func (n *Notifier) CreateStandupThread(ctx context.Context, channelID, roleID string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.Err != nil {
+		return n.Err
+	}
+	n.StandupThreads = append(n.StandupThreads, StandupThread{ChannelID: channelID, RoleID: roleID})
+	return nil
+}
@@ -0,0 +1,54 @@
+package fakes
+
+import (
+	"context"
+	"sync"
+
+	"github.com/storacha/project-agent/internal/github"
+)
+
+// Comparison records a single CompareSimilarity call
+type Comparison struct {
+	Issue1 github.Issue
+	Issue2 github.Issue
+}
+
+// Scorer returns similarity scores from a function instead of calling Gemini
+type Scorer struct {
+	mu sync.Mutex
+
+	// Score computes the similarity of two issues; nil scores everything 0
+	Score func(issue1, issue2 github.Issue) (float64, error)
+	// Comparisons lists every call in order
+	Comparisons []Comparison
+}
+
+// ScoreByTitle returns a scorer that reports 1.0 for the listed title pairs
+// (in either order) and 0.0 for everything else
+func ScoreByTitle(pairs ...[2]string) *Scorer {
+	similar := make(map[[2]string]bool)
+	for _, p := range pairs {
+		similar[p] = true
+		similar[[2]string{p[1], p[0]}] = true
+	}
+	return &Scorer{
+		Score: func(issue1, issue2 github.Issue) (float64, error) {
+			if similar[[2]string{issue1.Title, issue2.Title}] {
+				return 1.0, nil
+			}
+			return 0.0, nil
+		},
+	}
+}
+
+// CompareSimilarity records the call and returns Score's result
+func (s *Scorer) CompareSimilarity(ctx context.Context, issue1, issue2 github.Issue) (float64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.Comparisons = append(s.Comparisons, Comparison{Issue1: issue1, Issue2: issue2})
+	if s.Score == nil {
+		return 0, nil
+	}
+	return s.Score(issue1, issue2)
+}
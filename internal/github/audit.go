@@ -0,0 +1,349 @@
+package github
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shurcooL/githubv4"
+)
+
+// AuditKind identifies a mutation recorded in the audit log
+type AuditKind string
+
+// Audit entry kinds
+const (
+	AuditField        AuditKind = "field"          // Set Field to Value; Previous is the old value
+	AuditClearField   AuditKind = "clear-field"    // Cleared Field; Previous is the old value
+	AuditComment      AuditKind = "comment"        // Posted comment CommentID
+	AuditLabel        AuditKind = "label"          // Added Label; HadLabel when the issue already had it
+	AuditAddToProject AuditKind = "add-to-project" // Added the issue to the project as ItemID
+	AuditEditComment  AuditKind = "edit-comment"   // Replaced PreviousBody of comment CommentID with Body
+
+	// Minimized comment CommentID as outdated, when upserting or undoing
+	AuditMinimizeComment AuditKind = "minimize-comment"
+
+	// Written when undoing a run
+	AuditRemoveLabel       AuditKind = "remove-label"
+	AuditDeleteComment     AuditKind = "delete-comment"
+	AuditUnminimizeComment AuditKind = "unminimize-comment"
+	AuditRemoveFromProject AuditKind = "remove-from-project"
+)
+
+// AuditEntry is one line of the audit log: a mutation that succeeded, with
+// what is needed to reverse it
+type AuditEntry struct {
+	Time         time.Time   `json:"time"`
+	Run          string      `json:"run"`
+	Kind         AuditKind   `json:"kind"`
+	Issue        string      `json:"issue"` // owner/repo#number
+	IssueID      string      `json:"issue_id,omitempty"`
+	RepositoryID string      `json:"repository_id,omitempty"`
+	ItemID       string      `json:"item_id,omitempty"`
+	Field        string      `json:"field,omitempty"`
+	Previous     *FieldValue `json:"previous,omitempty"` // nil when the field was empty
+	Value        *FieldValue `json:"value,omitempty"`
+	CommentID    string      `json:"comment_id,omitempty"`
+	Body         string      `json:"body,omitempty"`
+	PreviousBody string      `json:"previous_body,omitempty"`
+	Label        string      `json:"label,omitempty"`
+	LabelID      string      `json:"label_id,omitempty"`
+	HadLabel     bool        `json:"had_label,omitempty"`
+	Undoes       string      `json:"undoes,omitempty"` // Run being undone, for undo entries
+}
+
+// Target returns the issue the entry changed, with the IDs it recorded
+func (e AuditEntry) Target() Issue {
+	issue := Issue{
+		NodeID:       e.IssueID,
+		RepositoryID: e.RepositoryID,
+		ProjectItem:  ProjectItemInfo{ID: e.ItemID},
+	}
+	if slash, hash := strings.Index(e.Issue, "/"), strings.LastIndex(e.Issue, "#"); slash > 0 && hash > slash {
+		issue.RepositoryOwner = e.Issue[:slash]
+		issue.RepositoryName = e.Issue[slash+1 : hash]
+		issue.Number, _ = strconv.Atoi(e.Issue[hash+1:])
+	}
+	return issue
+}
+
+// AuditLog appends mutation entries to a JSONL file
+type AuditLog struct {
+	mu     sync.Mutex
+	path   string
+	run    string
+	undoes string
+}
+
+// NewAuditLog returns a log that appends to path, tagging every entry with
+// the run ID
+func NewAuditLog(path, run string) *AuditLog {
+	return &AuditLog{path: path, run: run}
+}
+
+// Undoing tags the entries written from now on as undoing another run
+func (l *AuditLog) Undoing(run string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.undoes = run
+}
+
+// Record appends an entry
+func (l *AuditLog) Record(e AuditEntry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	e.Time = time.Now().UTC()
+	e.Run = l.run
+	e.Undoes = l.undoes
+	line, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit entry: %w", err)
+	}
+
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return nil
+}
+
+// ReadAuditLog reads every entry of an audit log, in the order written
+func ReadAuditLog(path string) ([]AuditEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+
+	var entries []AuditEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var e AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("failed to parse audit log line %d: %w", line, err)
+		}
+		entries = append(entries, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	return entries, nil
+}
+
+// NewRunID returns a run ID that sorts by start time
+func NewRunID() string {
+	suffix := make([]byte, 3)
+	_, _ = rand.Read(suffix)
+	return time.Now().UTC().Format("20060102T150405Z") + "-" + hex.EncodeToString(suffix)
+}
+
+// SetAuditLog records every mutation the client makes from now on to l; nil
+// turns auditing off
+func (c *Client) SetAuditLog(l *AuditLog) {
+	c.audit = l
+}
+
+// WithAuditLog returns a client that shares c's connection, rate limit
+// budget and project metadata but records its mutations to l, so a
+// long-running process can tag each run's changes with its own run ID
+func (c *Client) WithAuditLog(l *AuditLog) *Client {
+	clone := *c
+	clone.audit = l
+	return &clone
+}
+
+// record appends an entry to the audit log, if there is one. A failed write
+// is logged but does not fail the mutation, which has already happened.
+func (c *Client) record(ctx context.Context, e AuditEntry) {
+	if c.audit == nil {
+		return
+	}
+	if err := c.audit.Record(e); err != nil {
+		slog.WarnContext(ctx, "Failed to write the audit log", "error", err)
+	}
+}
+
+// previousValue returns a field's value before a mutation: from the issue
+// when its fields were loaded, and from the board otherwise. It returns nil
+// for an empty field, and nothing is looked up when auditing is off.
+func (c *Client) previousValue(ctx context.Context, issue Issue, fieldName string) *FieldValue {
+	if c.audit == nil {
+		return nil
+	}
+	if issue.Fields != nil {
+		if value, ok := issue.Fields[fieldName]; ok {
+			return &value
+		}
+		return nil
+	}
+	if fieldName == "Status" && issue.ProjectItem.StatusValue != "" {
+		value := OptionValue(issue.ProjectItem.StatusValue)
+		value.OptionID = issue.ProjectItem.StatusValueID
+		return &value
+	}
+
+	value, ok, err := c.GetFieldValue(ctx, issue, fieldName)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to read a field for the audit log", "field", fieldName, "issue", issue.Ref(), "error", err)
+		return nil
+	}
+	if !ok {
+		return nil
+	}
+	return &value
+}
+
+// hasLabel reports whether an issue already has a label, reading every page
+// of its labels. It is only looked up when auditing is on. If the labels
+// cannot be read it reports true, so undo leaves the label in place.
+func (c *Client) hasLabel(ctx context.Context, issueID githubv4.ID, labelName string) bool {
+	if c.audit == nil {
+		return false
+	}
+
+	found := false
+	err := paginate(ctx, nil, fmt.Sprintf("labels of issue %v", issueID), func(cursor *githubv4.String) (pageInfo, error) {
+		var query struct {
+			Node struct {
+				Issue struct {
+					Labels struct {
+						PageInfo pageInfo
+						Nodes    []struct {
+							Name githubv4.String
+						}
+					} `graphql:"labels(first: 100, after: $cursor)"`
+				} `graphql:"... on Issue"`
+			} `graphql:"node(id: $issueID)"`
+		}
+
+		variables := map[string]interface{}{
+			"issueID": issueID,
+			"cursor":  cursor,
+		}
+
+		if err := c.client.Query(ctx, &query, variables); err != nil {
+			return pageInfo{}, fmt.Errorf("failed to query labels: %w", err)
+		}
+
+		for _, label := range query.Node.Issue.Labels.Nodes {
+			if string(label.Name) == labelName {
+				found = true
+				return pageInfo{}, nil
+			}
+		}
+		return query.Node.Issue.Labels.PageInfo, nil
+	})
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to read labels for the audit log; undo will keep the label", "issue_id", issueID, "error", err)
+		return true
+	}
+	return found
+}
+
+// RemoveLabel removes a label from an issue
+func (c *Client) RemoveLabel(ctx context.Context, issue Issue, labelID string) error {
+	issueNodeID, err := c.getIssueNodeID(ctx, issue)
+	if err != nil {
+		return fmt.Errorf("failed to get issue node ID: %w", err)
+	}
+
+	var mutation struct {
+		RemoveLabelsFromLabelable struct {
+			ClientMutationID *githubv4.String `graphql:"clientMutationId"`
+		} `graphql:"removeLabelsFromLabelable(input: $input)"`
+	}
+
+	input := githubv4.RemoveLabelsFromLabelableInput{
+		LabelableID: issueNodeID,
+		LabelIDs:    []githubv4.ID{githubv4.ID(labelID)},
+	}
+
+	if err := c.client.Mutate(ctx, &mutation, input, nil); err != nil {
+		return fmt.Errorf("failed to remove label: %w", err)
+	}
+
+	nodeID, _ := issueNodeID.(string)
+	c.record(ctx, AuditEntry{Kind: AuditRemoveLabel, Issue: issue.Ref(), IssueID: nodeID, LabelID: labelID})
+	return nil
+}
+
+// DeleteComment deletes an issue comment
+func (c *Client) DeleteComment(ctx context.Context, issue Issue, commentID string) error {
+	var mutation struct {
+		DeleteIssueComment struct {
+			ClientMutationID *githubv4.String `graphql:"clientMutationId"`
+		} `graphql:"deleteIssueComment(input: $input)"`
+	}
+
+	input := githubv4.DeleteIssueCommentInput{
+		ID: githubv4.ID(commentID),
+	}
+
+	if err := c.client.Mutate(ctx, &mutation, input, nil); err != nil {
+		return fmt.Errorf("failed to delete comment: %w", err)
+	}
+
+	c.record(ctx, AuditEntry{Kind: AuditDeleteComment, Issue: issue.Ref(), CommentID: commentID})
+	return nil
+}
+
+// MinimizeComment hides an issue comment as outdated
+func (c *Client) MinimizeComment(ctx context.Context, issue Issue, commentID string) error {
+	var mutation struct {
+		MinimizeComment struct {
+			ClientMutationID *githubv4.String `graphql:"clientMutationId"`
+		} `graphql:"minimizeComment(input: $input)"`
+	}
+
+	input := githubv4.MinimizeCommentInput{
+		SubjectID:  githubv4.ID(commentID),
+		Classifier: githubv4.ReportedContentClassifiersOutdated,
+	}
+
+	if err := c.client.Mutate(ctx, &mutation, input, nil); err != nil {
+		return fmt.Errorf("failed to minimize comment: %w", err)
+	}
+
+	c.record(ctx, AuditEntry{Kind: AuditMinimizeComment, Issue: issue.Ref(), CommentID: commentID})
+	return nil
+}
+
+// RemoveFromProject deletes an issue's project item. The issue itself is
+// not changed.
+func (c *Client) RemoveFromProject(ctx context.Context, issue Issue) error {
+	var mutation struct {
+		DeleteProjectV2Item struct {
+			DeletedItemID githubv4.ID `graphql:"deletedItemId"`
+		} `graphql:"deleteProjectV2Item(input: $input)"`
+	}
+
+	input := githubv4.DeleteProjectV2ItemInput{
+		ProjectID: githubv4.ID(c.projectID),
+		ItemID:    githubv4.ID(issue.ProjectItem.ID),
+	}
+
+	if err := c.client.Mutate(ctx, &mutation, input, nil); err != nil {
+		return fmt.Errorf("failed to remove item from project: %w", err)
+	}
+
+	c.record(ctx, AuditEntry{Kind: AuditRemoveFromProject, Issue: issue.Ref(), ItemID: issue.ProjectItem.ID})
+	return nil
+}
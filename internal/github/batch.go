@@ -0,0 +1,483 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/shurcooL/githubv4"
+)
+
+// batchSize is the number of mutations packed into one GraphQL document
+const batchSize = 20
+
+// BatchKind identifies the write a BatchOp performs
+type BatchKind string
+
+// Batch operation kinds
+const (
+	BatchStatus     BatchKind = "status"     // Set the Status field to Value
+	BatchInitiative BatchKind = "initiative" // Set the Initiative field to Value
+	BatchComment    BatchKind = "comment"    // Add a comment with body Value, or upsert it when Marker is set
+	BatchLabel      BatchKind = "label"      // Add the label named Value, creating it if needed
+	BatchField      BatchKind = "field"      // Set Field to FieldValue
+	BatchClearField BatchKind = "clear"      // Clear Field
+)
+
+// BatchOp is a single write applied by ApplyBatch
+type BatchOp struct {
+	Kind       BatchKind
+	Issue      Issue
+	Value      string
+	Field      string
+	FieldValue FieldValue
+	Marker     string // Marks an upserted comment; see CommentMarker
+}
+
+// StatusOp moves an issue's project item to a status
+func StatusOp(issue Issue, status string) BatchOp {
+	return BatchOp{Kind: BatchStatus, Issue: issue, Value: status}
+}
+
+// InitiativeOp sets an issue's Initiative field
+func InitiativeOp(issue Issue, initiativeTitle string) BatchOp {
+	return BatchOp{Kind: BatchInitiative, Issue: issue, Value: initiativeTitle}
+}
+
+// CommentOp adds a comment to an issue
+func CommentOp(issue Issue, body string) BatchOp {
+	return BatchOp{Kind: BatchComment, Issue: issue, Value: body}
+}
+
+// MarkedCommentOp upserts the comment tagged with marker on an issue, the
+// way UpsertComment does
+func MarkedCommentOp(issue Issue, marker, body string) BatchOp {
+	return BatchOp{Kind: BatchComment, Issue: issue, Value: body, Marker: marker}
+}
+
+// LabelOp adds a label to an issue
+func LabelOp(issue Issue, labelName string) BatchOp {
+	return BatchOp{Kind: BatchLabel, Issue: issue, Value: labelName}
+}
+
+// FieldOp sets a project field on an issue's item
+func FieldOp(issue Issue, fieldName string, value FieldValue) BatchOp {
+	return BatchOp{Kind: BatchField, Issue: issue, Field: fieldName, FieldValue: value, Value: value.String()}
+}
+
+// ClearFieldOp clears a project field on an issue's item
+func ClearFieldOp(issue Issue, fieldName string) BatchOp {
+	return BatchOp{Kind: BatchClearField, Issue: issue, Field: fieldName}
+}
+
+// BatchResult is the outcome of one BatchOp; Err is nil on success
+type BatchResult struct {
+	Op  BatchOp
+	Err error
+}
+
+// preparedOp is a BatchOp resolved to a mutation field and its input
+type preparedOp struct {
+	index     int
+	field     string
+	inputType string
+	input     map[string]interface{}
+	selection string // Selected from the mutation's payload; clientMutationId when empty
+	// audit is recorded once the op succeeds, with the payload's comment ID
+	// filled in for comments; nil when auditing is off
+	audit  *AuditEntry
+	result json.RawMessage
+}
+
+// ApplyBatch applies ops using aliased GraphQL mutation documents of up to
+// batchSize mutations each, and returns one result per op in the same order.
+// Mutations in a document run in order, and a failing op does not stop the
+// ones after it.
+func (c *Client) ApplyBatch(ctx context.Context, ops []BatchOp) []BatchResult {
+	results := make([]BatchResult, len(ops))
+	for i, op := range ops {
+		results[i].Op = op
+	}
+
+	// Resolve the IDs each op needs; ops that cannot be resolved fail here
+	// and are not sent
+	resolver := &batchResolver{
+		client: c,
+		labels: make(map[string]githubv4.ID),
+		nodes:  make(map[string]githubv4.ID),
+	}
+	var prepared []preparedOp
+	for i, op := range ops {
+		if op.Kind == BatchComment && op.Marker != "" {
+			ps, err := resolver.prepareUpsert(ctx, op)
+			if err != nil {
+				results[i].Err = err
+				continue
+			}
+			for _, p := range ps {
+				p.index = i
+				prepared = append(prepared, p)
+			}
+			continue
+		}
+		p, err := resolver.prepare(ctx, op)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		p.index = i
+		prepared = append(prepared, p)
+	}
+
+	for start := 0; start < len(prepared); start += batchSize {
+		end := start + batchSize
+		if end > len(prepared) {
+			end = len(prepared)
+		}
+		chunk := prepared[start:end]
+		for i, err := range c.sendBatch(ctx, chunk) {
+			// An upsert can take several mutations; its first error is kept
+			if results[chunk[i].index].Err == nil {
+				results[chunk[i].index].Err = err
+			}
+			if err == nil && chunk[i].audit != nil {
+				c.record(ctx, chunk[i].auditEntry())
+			}
+		}
+	}
+
+	return results
+}
+
+// auditEntry completes an op's audit entry from its payload
+func (p preparedOp) auditEntry() AuditEntry {
+	e := *p.audit
+	if e.Kind == AuditComment {
+		var payload struct {
+			CommentEdge struct {
+				Node struct {
+					ID string `json:"id"`
+				} `json:"node"`
+			} `json:"commentEdge"`
+		}
+		if err := json.Unmarshal(p.result, &payload); err == nil {
+			e.CommentID = payload.CommentEdge.Node.ID
+		}
+	}
+	return e
+}
+
+// sendBatch sends one aliased mutation document and returns an error (or
+// nil) per op
+func (c *Client) sendBatch(ctx context.Context, chunk []preparedOp) []error {
+	errs := make([]error, len(chunk))
+	failAll := func(err error) []error {
+		for i := range errs {
+			errs[i] = err
+		}
+		return errs
+	}
+
+	var params, fields []string
+	variables := make(map[string]interface{})
+	for i, op := range chunk {
+		selection := op.selection
+		if selection == "" {
+			selection = "clientMutationId"
+		}
+		params = append(params, fmt.Sprintf("$i%d:%s!", i, op.inputType))
+		fields = append(fields, fmt.Sprintf("m%d:%s(input:$i%d){%s}", i, op.field, i, selection))
+		variables[fmt.Sprintf("i%d", i)] = op.input
+	}
+	document := fmt.Sprintf("mutation(%s){%s}", strings.Join(params, ""), strings.Join(fields, " "))
+
+	data, gqlErrors, err := c.rawGraphQL(ctx, document, variables)
+	if err != nil {
+		return failAll(fmt.Errorf("failed to send batched mutation: %w", err))
+	}
+
+	// Attribute errors to ops through their alias; errors without a path
+	// apply to the whole document
+	for _, e := range gqlErrors {
+		alias := ""
+		if len(e.Path) > 0 {
+			alias, _ = e.Path[0].(string)
+		}
+		var index int
+		if _, scanErr := fmt.Sscanf(alias, "m%d", &index); scanErr != nil || index >= len(chunk) {
+			return failAll(fmt.Errorf("batched mutation failed: %s", e.Message))
+		}
+		if errs[index] == nil {
+			errs[index] = fmt.Errorf("%s", e.Message)
+		}
+	}
+
+	for i := range chunk {
+		if errs[i] != nil {
+			continue
+		}
+		result, ok := data[fmt.Sprintf("m%d", i)]
+		if !ok || string(result) == "null" {
+			errs[i] = fmt.Errorf("no result for mutation %s", chunk[i].field)
+			continue
+		}
+		chunk[i].result = result
+	}
+
+	return errs
+}
+
+// graphQLError is an entry in a GraphQL response's errors list
+type graphQLError struct {
+	Message string        `json:"message"`
+	Type    string        `json:"type"`
+	Path    []interface{} `json:"path"`
+}
+
+// rawGraphQL posts a hand-built GraphQL document, for requests githubv4
+// cannot express such as dynamically aliased mutations
+func (c *Client) rawGraphQL(ctx context.Context, document string, variables map[string]interface{}) (map[string]json.RawMessage, []graphQLError, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"query":     document,
+		"variables": variables,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return nil, nil, fmt.Errorf("non-200 OK status code: %s body: %q", resp.Status, respBody)
+	}
+
+	var out struct {
+		Data   map[string]json.RawMessage `json:"data"`
+		Errors []graphQLError             `json:"errors"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return out.Data, out.Errors, nil
+}
+
+// batchResolver looks up the IDs batch ops need, caching them across ops
+type batchResolver struct {
+	client *Client
+	labels map[string]githubv4.ID // repository ID + label name -> label ID
+	nodes  map[string]githubv4.ID // repository ID + issue number -> issue node ID
+}
+
+func (r *batchResolver) prepare(ctx context.Context, op BatchOp) (preparedOp, error) {
+	c := r.client
+
+	switch op.Kind {
+	case BatchStatus, BatchInitiative, BatchField:
+		fieldName, value := op.Field, op.FieldValue
+		switch op.Kind {
+		case BatchStatus:
+			fieldName, value = "Status", OptionValue(op.Value)
+		case BatchInitiative:
+			fieldName, value = "Initiative", TextValue(op.Value)
+		}
+		if op.Issue.ProjectItem.ID == "" {
+			return preparedOp{}, fmt.Errorf("issue #%d has no project item", op.Issue.Number)
+		}
+		field, valueInput, err := c.fieldValueInput(fieldName, value)
+		if err != nil {
+			return preparedOp{}, err
+		}
+		return preparedOp{
+			field:     "updateProjectV2ItemFieldValue",
+			inputType: "UpdateProjectV2ItemFieldValueInput",
+			input: map[string]interface{}{
+				"projectId": c.projectID,
+				"itemId":    op.Issue.ProjectItem.ID,
+				"fieldId":   field.ID,
+				"value":     valueInput,
+			},
+			audit: r.fieldAudit(ctx, AuditField, op.Issue, field.Name, &value),
+		}, nil
+
+	case BatchClearField:
+		if op.Issue.ProjectItem.ID == "" {
+			return preparedOp{}, fmt.Errorf("issue #%d has no project item", op.Issue.Number)
+		}
+		field, ok := c.schema.Field(op.Field)
+		if !ok {
+			return preparedOp{}, fmt.Errorf("field %q not found in project", op.Field)
+		}
+		if !field.Settable() {
+			return preparedOp{}, fmt.Errorf("field %q has type %s, which cannot be cleared", field.Name, field.Type)
+		}
+		return preparedOp{
+			field:     "clearProjectV2ItemFieldValue",
+			inputType: "ClearProjectV2ItemFieldValueInput",
+			input: map[string]interface{}{
+				"projectId": c.projectID,
+				"itemId":    op.Issue.ProjectItem.ID,
+				"fieldId":   field.ID,
+			},
+			audit: r.fieldAudit(ctx, AuditClearField, op.Issue, field.Name, nil),
+		}, nil
+
+	case BatchComment:
+		return r.addComment(ctx, op.Issue, op.Value)
+
+	case BatchLabel:
+		nodeID, err := r.issueNodeID(ctx, op.Issue)
+		if err != nil {
+			return preparedOp{}, err
+		}
+		labelID, err := r.labelID(ctx, op.Issue, op.Value)
+		if err != nil {
+			return preparedOp{}, err
+		}
+		p := preparedOp{
+			field:     "addLabelsToLabelable",
+			inputType: "AddLabelsToLabelableInput",
+			input: map[string]interface{}{
+				"labelableId": nodeID,
+				"labelIds":    []githubv4.ID{labelID},
+			},
+		}
+		if c.audit != nil {
+			issueID, _ := nodeID.(string)
+			labelIDString, _ := labelID.(string)
+			p.audit = &AuditEntry{Kind: AuditLabel, Issue: op.Issue.Ref(), IssueID: issueID, RepositoryID: op.Issue.RepositoryID,
+				Label: op.Value, LabelID: labelIDString, HadLabel: c.hasLabel(ctx, nodeID, op.Value)}
+		}
+		return p, nil
+	}
+
+	return preparedOp{}, fmt.Errorf("unknown batch operation %q", op.Kind)
+}
+
+// addComment prepares posting a comment
+func (r *batchResolver) addComment(ctx context.Context, issue Issue, body string) (preparedOp, error) {
+	nodeID, err := r.issueNodeID(ctx, issue)
+	if err != nil {
+		return preparedOp{}, err
+	}
+	p := preparedOp{
+		field:     "addComment",
+		inputType: "AddCommentInput",
+		input: map[string]interface{}{
+			"subjectId": nodeID,
+			"body":      body,
+		},
+		selection: "commentEdge{node{id}}",
+	}
+	if r.client.audit != nil {
+		issueID, _ := nodeID.(string)
+		p.audit = &AuditEntry{Kind: AuditComment, Issue: issue.Ref(), IssueID: issueID,
+			RepositoryID: issue.RepositoryID, Body: body}
+	}
+	return p, nil
+}
+
+// prepareUpsert prepares the mutations that upsert a marked comment: none
+// when it is current, an edit, or a new comment followed by minimizing the
+// outdated ones
+func (r *batchResolver) prepareUpsert(ctx context.Context, op BatchOp) ([]preparedOp, error) {
+	c := r.client
+	body := markedBody(op.Value, op.Marker)
+	existing, err := c.FindMarkedComments(ctx, op.Issue, op.Marker)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := c.planUpsert(existing, body)
+	switch {
+	case plan.unchanged:
+		return nil, nil
+	case plan.edit != nil:
+		p := preparedOp{
+			field:     "updateIssueComment",
+			inputType: "UpdateIssueCommentInput",
+			input: map[string]interface{}{
+				"id":   plan.edit.ID,
+				"body": body,
+			},
+		}
+		if c.audit != nil {
+			p.audit = &AuditEntry{Kind: AuditEditComment, Issue: op.Issue.Ref(), IssueID: op.Issue.NodeID,
+				RepositoryID: op.Issue.RepositoryID, CommentID: plan.edit.ID, Body: body, PreviousBody: plan.edit.Body}
+		}
+		return []preparedOp{p}, nil
+	}
+
+	add, err := r.addComment(ctx, op.Issue, body)
+	if err != nil {
+		return nil, err
+	}
+	prepared := []preparedOp{add}
+	for _, outdated := range plan.minimize {
+		p := preparedOp{
+			field:     "minimizeComment",
+			inputType: "MinimizeCommentInput",
+			input: map[string]interface{}{
+				"subjectId":  outdated.ID,
+				"classifier": githubv4.ReportedContentClassifiersOutdated,
+			},
+		}
+		if c.audit != nil {
+			p.audit = &AuditEntry{Kind: AuditMinimizeComment, Issue: op.Issue.Ref(), CommentID: outdated.ID}
+		}
+		prepared = append(prepared, p)
+	}
+	return prepared, nil
+}
+
+// fieldAudit returns the audit entry for setting or, with a nil value,
+// clearing a field; nil when auditing is off
+func (r *batchResolver) fieldAudit(ctx context.Context, kind AuditKind, issue Issue, fieldName string, value *FieldValue) *AuditEntry {
+	c := r.client
+	if c.audit == nil {
+		return nil
+	}
+	return &AuditEntry{Kind: kind, Issue: issue.Ref(), IssueID: issue.NodeID, RepositoryID: issue.RepositoryID,
+		ItemID: issue.ProjectItem.ID, Field: fieldName, Previous: c.previousValue(ctx, issue, fieldName), Value: value}
+}
+
+func (r *batchResolver) issueNodeID(ctx context.Context, issue Issue) (githubv4.ID, error) {
+	key := fmt.Sprintf("%s#%d", issue.RepositoryID, issue.Number)
+	if id, ok := r.nodes[key]; ok {
+		return id, nil
+	}
+	id, err := r.client.getIssueNodeID(ctx, issue)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get issue node ID: %w", err)
+	}
+	r.nodes[key] = id
+	return id, nil
+}
+
+func (r *batchResolver) labelID(ctx context.Context, issue Issue, labelName string) (githubv4.ID, error) {
+	key := issue.RepositoryID + "/" + labelName
+	if id, ok := r.labels[key]; ok {
+		return id, nil
+	}
+	id, err := r.client.ensureLabel(ctx, issue, labelName)
+	if err != nil {
+		return nil, err
+	}
+	r.labels[key] = id
+	return id, nil
+}
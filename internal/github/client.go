@@ -3,24 +3,32 @@ package github
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"time"
 
 	"github.com/shurcooL/githubv4"
-	"golang.org/x/oauth2"
 )
 
 // Client handles GitHub API interactions
 type Client struct {
 	client            *githubv4.Client
+	httpClient        *http.Client
+	endpoint          string
+	budget            *Budget
+	schema            *Schema
 	org               string
 	projectNumber     int
 	projectID         string
+	projectURL        string
 	statusFieldID     string
 	initiativeFieldID string
+	audit             *AuditLog // Records every mutation, when set
+	commentOpts       CommentOptions
 }
 
 // Issue represents a GitHub issue with project metadata
 type Issue struct {
+	NodeID          string // Global node ID, when the query that produced the issue selected it
 	Number          int
 	Title           string
 	Body            string
@@ -28,11 +36,22 @@ type Issue struct {
 	UpdatedAt       time.Time
 	Assignees       []string // GitHub usernames
 	ProjectItem     ProjectItemInfo
+	Fields          map[string]FieldValue // Project field values by field name, when loaded
 	RepositoryID    string
 	RepositoryName  string
 	RepositoryOwner string
 }
 
+// Ref returns the owner/repo#number form of the issue, or repo#number when
+// the owner is not known
+func (i Issue) Ref() string {
+	ref := fmt.Sprintf("%s#%d", i.RepositoryName, i.Number)
+	if i.RepositoryOwner != "" {
+		ref = i.RepositoryOwner + "/" + ref
+	}
+	return ref
+}
+
 // ProjectItemInfo contains project-specific metadata for an issue
 type ProjectItemInfo struct {
 	ID            string
@@ -41,17 +60,26 @@ type ProjectItemInfo struct {
 	StatusFieldID string
 }
 
+// DefaultEndpoint is the public GitHub GraphQL API endpoint
+const DefaultEndpoint = "https://api.github.com/graphql"
+
 // NewClient creates a new GitHub API client
 func NewClient(token, org string, projectNumber int) (*Client, error) {
-	src := oauth2.StaticTokenSource(
-		&oauth2.Token{AccessToken: token},
-	)
-	httpClient := oauth2.NewClient(context.Background(), src)
+	return NewClientWithEndpoint(DefaultEndpoint, token, org, projectNumber)
+}
 
-	client := githubv4.NewClient(httpClient)
+// NewClientWithEndpoint creates a GitHub API client that talks to a custom
+// GraphQL endpoint, such as GitHub Enterprise or a local githubtest server
+func NewClientWithEndpoint(endpoint, token, org string, projectNumber int) (*Client, error) {
+	budget := NewBudget()
+	httpClient := NewHTTPClient(token, budget)
+	client := githubv4.NewEnterpriseClient(endpoint, httpClient)
 
 	c := &Client{
 		client:        client,
+		httpClient:    httpClient,
+		endpoint:      endpoint,
+		budget:        budget,
 		org:           org,
 		projectNumber: projectNumber,
 	}
@@ -64,79 +92,29 @@ func NewClient(token, org string, projectNumber int) (*Client, error) {
 	return c, nil
 }
 
-// fetchProjectMetadata retrieves the project ID and status field ID
-func (c *Client) fetchProjectMetadata(ctx context.Context) error {
-	var query struct {
-		Organization struct {
-			ProjectV2 struct {
-				ID     githubv4.ID
-				Fields struct {
-					Nodes []struct {
-						TypeName    string `graphql:"__typename"`
-						FieldCommon struct {
-							ID   githubv4.ID
-							Name githubv4.String
-						} `graphql:"... on ProjectV2FieldCommon"`
-						SingleSelectField struct {
-							ID      githubv4.ID
-							Name    githubv4.String
-							Options []struct {
-								ID   githubv4.String
-								Name githubv4.String
-							}
-						} `graphql:"... on ProjectV2SingleSelectField"`
-						TextField struct {
-							ID   githubv4.ID
-							Name githubv4.String
-						} `graphql:"... on ProjectV2Field"`
-					}
-				} `graphql:"fields(first: 20)"`
-			} `graphql:"projectV2(number: $projectNumber)"`
-		} `graphql:"organization(login: $org)"`
-	}
-
-	variables := map[string]interface{}{
-		"org":           githubv4.String(c.org),
-		"projectNumber": githubv4.Int(c.projectNumber),
-	}
-
-	if err := c.client.Query(ctx, &query, variables); err != nil {
-		return fmt.Errorf("failed to query project: %w", err)
-	}
+// Budget returns the rate limit budget shared by the client's requests
+func (c *Client) Budget() *Budget {
+	return c.budget
+}
 
-	projectID, ok := query.Organization.ProjectV2.ID.(string)
-	if !ok {
-		return fmt.Errorf("failed to convert project ID to string")
-	}
-	c.projectID = projectID
+// ProjectID returns the project's node ID
+func (c *Client) ProjectID() string {
+	return c.projectID
+}
 
-	// Find the Status and Initiative fields
-	for _, field := range query.Organization.ProjectV2.Fields.Nodes {
-		if field.TypeName == "ProjectV2SingleSelectField" {
-			if string(field.SingleSelectField.Name) == "Status" {
-				statusFieldID, ok := field.SingleSelectField.ID.(string)
-				if !ok {
-					return fmt.Errorf("failed to convert status field ID to string")
-				}
-				c.statusFieldID = statusFieldID
-			}
-		} else if field.TypeName == "ProjectV2Field" {
-			if string(field.TextField.Name) == "Initiative" {
-				initiativeFieldID, ok := field.TextField.ID.(string)
-				if !ok {
-					return fmt.Errorf("failed to convert initiative field ID to string")
-				}
-				c.initiativeFieldID = initiativeFieldID
-			}
-		}
+// fetchProjectMetadata retrieves the project ID and field schema, and finds
+// the Status and Initiative fields. Missing fields are not an error here;
+// commands check what they need with Require.
+func (c *Client) fetchProjectMetadata(ctx context.Context) error {
+	if err := c.loadSchema(ctx); err != nil {
+		return err
 	}
 
-	if c.statusFieldID == "" {
-		return fmt.Errorf("could not find Status field in project")
+	if field, ok := c.schema.Field("Status"); ok && field.Type == FieldSingleSelect {
+		c.statusFieldID = field.ID
 	}
-
-	if c.initiativeFieldID == "" {
-		return fmt.Errorf("could not find Initiative field in project")
+	if field, ok := c.schema.Field("Initiative"); ok && field.Type == FieldText {
+		c.initiativeFieldID = field.ID
 	}
 
 	return nil
@@ -161,35 +139,32 @@ func (c *Client) GetBacklogIssues(ctx context.Context) ([]Issue, error) {
 // getFilteredIssues retrieves issues filtered by status
 func (c *Client) getFilteredIssues(ctx context.Context, statusMap map[string]bool) ([]Issue, error) {
 	var issues []Issue
-	var cursor *githubv4.String
 
-	for {
+	err := paginate(ctx, nil, "project items", func(cursor *githubv4.String) (pageInfo, error) {
 		var query struct {
-			Node struct {
+			RateLimit rateLimitInfo
+			Node      struct {
 				ProjectV2 struct {
 					Items struct {
-						PageInfo struct {
-							HasNextPage githubv4.Boolean
-							EndCursor   githubv4.String
-						}
-						Nodes []struct {
+						PageInfo pageInfo
+						Nodes    []struct {
 							ID      githubv4.ID
 							Content struct {
 								TypeName string `graphql:"__typename"`
 								Issue    struct {
-									Number    githubv4.Int
-									Title     githubv4.String
-									Body      githubv4.String
-									URL       githubv4.URI
-									UpdatedAt githubv4.DateTime
-									Assignees struct {
-										Nodes []struct {
+									ID         githubv4.ID
+									Number     githubv4.Int
+									Title      githubv4.String
+									Body       githubv4.String
+									URL        githubv4.URI
+									UpdatedAt  githubv4.DateTime
+									Assignees  assigneeConnection `graphql:"assignees(first: 10)"`
+									Repository struct {
+										ID    githubv4.ID
+										Name  githubv4.String
+										Owner struct {
 											Login githubv4.String
 										}
-									} `graphql:"assignees(first: 10)"`
-									Repository struct {
-										ID   githubv4.ID
-										Name githubv4.String
 									}
 								} `graphql:"... on Issue"`
 							}
@@ -200,6 +175,7 @@ func (c *Client) getFilteredIssues(ctx context.Context, statusMap map[string]boo
 									Name githubv4.String
 								} `graphql:"... on ProjectV2ItemFieldSingleSelectValue"`
 							} `graphql:"fieldValueByName(name: \"Status\")"`
+							FieldValues fieldValueConnection `graphql:"fieldValues(first: 20)"`
 						}
 					} `graphql:"items(first: 100, after: $cursor)"`
 				} `graphql:"... on ProjectV2"`
@@ -212,7 +188,7 @@ func (c *Client) getFilteredIssues(ctx context.Context, statusMap map[string]boo
 		}
 
 		if err := c.client.Query(ctx, &query, variables); err != nil {
-			return nil, fmt.Errorf("failed to query project items: %w", err)
+			return pageInfo{}, fmt.Errorf("failed to query project items: %w", err)
 		}
 
 		for _, item := range query.Node.ProjectV2.Items.Nodes {
@@ -237,21 +213,30 @@ func (c *Client) getFilteredIssues(ctx context.Context, statusMap map[string]boo
 				continue // Skip if we can't get item ID
 			}
 
-			// Extract assignees
-			assignees := []string{}
-			for _, assignee := range item.Content.Issue.Assignees.Nodes {
-				assignees = append(assignees, string(assignee.Login))
+			assignees, err := c.assigneeLogins(ctx, item.Content.Issue.ID, item.Content.Issue.Assignees)
+			if err != nil {
+				return pageInfo{}, err
+			}
+
+			fields, err := c.itemFieldValues(ctx, item.ID, item.FieldValues)
+			if err != nil {
+				return pageInfo{}, err
 			}
 
+			nodeID, _ := item.Content.Issue.ID.(string)
+
 			issues = append(issues, Issue{
-				Number:         int(item.Content.Issue.Number),
-				Title:          string(item.Content.Issue.Title),
-				Body:           string(item.Content.Issue.Body),
-				URL:            item.Content.Issue.URL.String(),
-				UpdatedAt:      item.Content.Issue.UpdatedAt.Time,
-				Assignees:      assignees,
-				RepositoryID:   repoID,
-				RepositoryName: string(item.Content.Issue.Repository.Name),
+				NodeID:          nodeID,
+				Number:          int(item.Content.Issue.Number),
+				Title:           string(item.Content.Issue.Title),
+				Body:            string(item.Content.Issue.Body),
+				URL:             item.Content.Issue.URL.String(),
+				UpdatedAt:       item.Content.Issue.UpdatedAt.Time,
+				Assignees:       assignees,
+				Fields:          fields,
+				RepositoryID:    repoID,
+				RepositoryName:  string(item.Content.Issue.Repository.Name),
+				RepositoryOwner: string(item.Content.Issue.Repository.Owner.Login),
 				ProjectItem: ProjectItemInfo{
 					ID:            itemID,
 					StatusValue:   statusName,
@@ -261,23 +246,22 @@ func (c *Client) getFilteredIssues(ctx context.Context, statusMap map[string]boo
 			})
 		}
 
-		if !query.Node.ProjectV2.Items.PageInfo.HasNextPage {
-			break
-		}
-
-		cursor = &query.Node.ProjectV2.Items.PageInfo.EndCursor
+		return query.Node.ProjectV2.Items.PageInfo, nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return issues, nil
 }
 
-// MoveToStuckDead moves an issue to "Stuck / Dead Issue" status
-func (c *Client) MoveToStuckDead(ctx context.Context, issue Issue) error {
-	// First, we need to get the option ID for "Stuck / Dead Issue" status
-	stuckDeadOptionID, err := c.getStatusOptionID(ctx, "Stuck / Dead Issue")
+// MoveToStatus moves an issue's project item to the named status
+func (c *Client) MoveToStatus(ctx context.Context, issue Issue, status string) error {
+	optionID, err := c.getStatusOptionID(ctx, status)
 	if err != nil {
-		return fmt.Errorf("failed to get Stuck / Dead Issue option ID: %w", err)
+		return fmt.Errorf("failed to get %s option ID: %w", status, err)
 	}
+	previous := c.previousValue(ctx, issue, "Status")
 
 	var mutation struct {
 		UpdateProjectV2ItemFieldValue struct {
@@ -292,7 +276,7 @@ func (c *Client) MoveToStuckDead(ctx context.Context, issue Issue) error {
 		ItemID:    githubv4.ID(issue.ProjectItem.ID),
 		FieldID:   githubv4.ID(c.statusFieldID),
 		Value: githubv4.ProjectV2FieldValue{
-			SingleSelectOptionID: githubv4.NewString(githubv4.String(stuckDeadOptionID)),
+			SingleSelectOptionID: githubv4.NewString(githubv4.String(optionID)),
 		},
 	}
 
@@ -300,59 +284,33 @@ func (c *Client) MoveToStuckDead(ctx context.Context, issue Issue) error {
 		return fmt.Errorf("failed to update project item: %w", err)
 	}
 
+	value := OptionValue(status)
+	value.OptionID = optionID
+	c.record(ctx, AuditEntry{Kind: AuditField, Issue: issue.Ref(), IssueID: issue.NodeID, RepositoryID: issue.RepositoryID,
+		ItemID: issue.ProjectItem.ID, Field: "Status", Previous: previous, Value: &value})
 	return nil
 }
 
 // getStatusOptionID retrieves the option ID for a given status name
 func (c *Client) getStatusOptionID(ctx context.Context, statusName string) (string, error) {
-	var query struct {
-		Node struct {
-			ProjectV2 struct {
-				Field struct {
-					TypeName          string `graphql:"__typename"`
-					SingleSelectField struct {
-						Options []struct {
-							ID   githubv4.String
-							Name githubv4.String
-						}
-					} `graphql:"... on ProjectV2SingleSelectField"`
-				} `graphql:"field(name: \"Status\")"`
-			} `graphql:"... on ProjectV2"`
-		} `graphql:"node(id: $projectID)"`
-	}
-
-	variables := map[string]interface{}{
-		"projectID": githubv4.ID(c.projectID),
-	}
-
-	if err := c.client.Query(ctx, &query, variables); err != nil {
-		return "", fmt.Errorf("failed to query status options: %w", err)
+	if c.statusFieldID == "" {
+		return "", fmt.Errorf("project %s has no single select Status field", c.projectURL)
 	}
-
-	for _, option := range query.Node.ProjectV2.Field.SingleSelectField.Options {
-		if string(option.Name) == statusName {
-			return string(option.ID), nil
-		}
+	field, _ := c.schema.Field("Status")
+	option, ok := field.Option(statusName)
+	if !ok {
+		return "", fmt.Errorf("status option %q not found", statusName)
 	}
 
-	return "", fmt.Errorf("status option %q not found", statusName)
+	return option.ID, nil
 }
 
 // AddLabel adds a label to an issue
 func (c *Client) AddLabel(ctx context.Context, issue Issue, labelName string) error {
 	// First, we need to get the label ID for the repository
-	labelID, err := c.getLabelID(ctx, issue, labelName)
+	labelID, err := c.ensureLabel(ctx, issue, labelName)
 	if err != nil {
-		// If label doesn't exist, create it first
-		if err.Error() == fmt.Sprintf("label %q not found in repository", labelName) {
-			createdLabelID, createErr := c.createLabel(ctx, issue, labelName)
-			if createErr != nil {
-				return fmt.Errorf("failed to create label: %w", createErr)
-			}
-			labelID = createdLabelID
-		} else {
-			return fmt.Errorf("failed to get label ID: %w", err)
-		}
+		return err
 	}
 
 	// Get the issue node ID
@@ -360,16 +318,11 @@ func (c *Client) AddLabel(ctx context.Context, issue Issue, labelName string) er
 	if err != nil {
 		return fmt.Errorf("failed to get issue node ID: %w", err)
 	}
+	hadLabel := c.hasLabel(ctx, issueNodeID, labelName)
 
 	var mutation struct {
 		AddLabelsToLabelable struct {
-			Labelable struct {
-				Labels struct {
-					Nodes []struct {
-						ID githubv4.ID
-					}
-				} `graphql:"labels(first: 10)"`
-			}
+			ClientMutationID *githubv4.String `graphql:"clientMutationId"`
 		} `graphql:"addLabelsToLabelable(input: $input)"`
 	}
 
@@ -382,9 +335,34 @@ func (c *Client) AddLabel(ctx context.Context, issue Issue, labelName string) er
 		return fmt.Errorf("failed to add label: %w", err)
 	}
 
+	nodeID, _ := issueNodeID.(string)
+	labelIDString, _ := labelID.(string)
+	c.record(ctx, AuditEntry{Kind: AuditLabel, Issue: issue.Ref(), IssueID: nodeID, RepositoryID: issue.RepositoryID,
+		Label: labelName, LabelID: labelIDString, HadLabel: hadLabel})
 	return nil
 }
 
+// ensureLabel returns the ID of a label in the issue's repository, creating
+// the label if it does not exist yet
+func (c *Client) ensureLabel(ctx context.Context, issue Issue, labelName string) (githubv4.ID, error) {
+	labelID, err := c.getLabelID(ctx, issue, labelName)
+	if err == nil {
+		return labelID, nil
+	}
+
+	// If label doesn't exist, create it first
+	if err.Error() != fmt.Sprintf("label %q not found in repository", labelName) {
+		return nil, fmt.Errorf("failed to get label ID: %w", err)
+	}
+
+	labelID, err = c.createLabel(ctx, issue, labelName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create label: %w", err)
+	}
+
+	return labelID, nil
+}
+
 // getLabelID retrieves the label ID for a given label name in the repository
 func (c *Client) getLabelID(ctx context.Context, issue Issue, labelName string) (githubv4.ID, error) {
 	var query struct {
@@ -464,39 +442,16 @@ func (c *Client) AddComment(ctx context.Context, issue Issue, comment string) er
 		return fmt.Errorf("failed to add comment: %w", err)
 	}
 
+	c.recordComment(ctx, issue, issueNodeID, mutation.AddComment.CommentEdge.Node.ID, comment)
 	return nil
 }
 
-// MoveToPRReview moves an issue to "PR Review" status
-func (c *Client) MoveToPRReview(ctx context.Context, issue Issue) error {
-	// Get the option ID for "PR Review" status
-	prReviewOptionID, err := c.getStatusOptionID(ctx, "PR Review")
-	if err != nil {
-		return fmt.Errorf("failed to get PR Review option ID: %w", err)
-	}
-
-	var mutation struct {
-		UpdateProjectV2ItemFieldValue struct {
-			ProjectV2Item struct {
-				ID githubv4.ID
-			} `graphql:"projectV2Item"`
-		} `graphql:"updateProjectV2ItemFieldValue(input: $input)"`
-	}
-
-	input := githubv4.UpdateProjectV2ItemFieldValueInput{
-		ProjectID: githubv4.ID(c.projectID),
-		ItemID:    githubv4.ID(issue.ProjectItem.ID),
-		FieldID:   githubv4.ID(c.statusFieldID),
-		Value: githubv4.ProjectV2FieldValue{
-			SingleSelectOptionID: githubv4.NewString(githubv4.String(prReviewOptionID)),
-		},
-	}
-
-	if err := c.client.Mutate(ctx, &mutation, input, nil); err != nil {
-		return fmt.Errorf("failed to update project item: %w", err)
-	}
-
-	return nil
+// recordComment records a posted comment in the audit log
+func (c *Client) recordComment(ctx context.Context, issue Issue, issueNodeID, commentID githubv4.ID, body string) {
+	nodeID, _ := issueNodeID.(string)
+	commentIDString, _ := commentID.(string)
+	c.record(ctx, AuditEntry{Kind: AuditComment, Issue: issue.Ref(), IssueID: nodeID, RepositoryID: issue.RepositoryID,
+		CommentID: commentIDString, Body: body})
 }
 
 // GetIssueByNumber retrieves an issue by repository and number, and checks if it's in the project
@@ -532,9 +487,10 @@ func (c *Client) GetIssueByNumber(ctx context.Context, owner, repo string, numbe
 	}
 
 	issueNodeID := query.Repository.Issue.ID
+	nodeID, _ := issueNodeID.(string)
 
 	// Now check if this issue is in our project and get its project item info
-	projectItem, err := c.getProjectItemForIssue(ctx, issueNodeID)
+	projectItem, fields, err := c.getProjectItemForIssue(ctx, issueNodeID)
 	if err != nil {
 		return nil, fmt.Errorf("issue not in project: %w", err)
 	}
@@ -544,111 +500,201 @@ func (c *Client) GetIssueByNumber(ctx context.Context, owner, repo string, numbe
 	}
 
 	return &Issue{
-		Number:       int(query.Repository.Issue.Number),
-		Title:        string(query.Repository.Issue.Title),
-		Body:         string(query.Repository.Issue.Body),
-		URL:          query.Repository.Issue.URL.String(),
-		UpdatedAt:    query.Repository.Issue.UpdatedAt.Time,
-		RepositoryID: repoID,
-		ProjectItem:  *projectItem,
+		NodeID:          nodeID,
+		Number:          int(query.Repository.Issue.Number),
+		Title:           string(query.Repository.Issue.Title),
+		Body:            string(query.Repository.Issue.Body),
+		URL:             query.Repository.Issue.URL.String(),
+		UpdatedAt:       query.Repository.Issue.UpdatedAt.Time,
+		RepositoryID:    repoID,
+		RepositoryName:  repo,
+		RepositoryOwner: owner,
+		ProjectItem:     *projectItem,
+		Fields:          fields,
 	}, nil
 }
 
-// getProjectItemForIssue finds the project item for a given issue node ID
-func (c *Client) getProjectItemForIssue(ctx context.Context, issueNodeID githubv4.ID) (*ProjectItemInfo, error) {
+// GetProjectItem retrieves the current state of a project item: its issue,
+// Status and field values
+func (c *Client) GetProjectItem(ctx context.Context, itemID string) (*Issue, error) {
 	var query struct {
 		Node struct {
-			Issue struct {
-				ProjectItems struct {
-					Nodes []struct {
-						ID      githubv4.ID
-						Project struct {
-							ID githubv4.ID
+			Item struct {
+				ID      githubv4.ID
+				Content struct {
+					TypeName string `graphql:"__typename"`
+					Issue    struct {
+						ID         githubv4.ID
+						Number     githubv4.Int
+						Title      githubv4.String
+						Body       githubv4.String
+						URL        githubv4.URI
+						UpdatedAt  githubv4.DateTime
+						Repository struct {
+							ID    githubv4.ID
+							Name  githubv4.String
+							Owner struct {
+								Login githubv4.String
+							}
 						}
-						FieldValueByName struct {
-							TypeName          string `graphql:"__typename"`
-							SingleSelectValue struct {
-								ID   githubv4.String
-								Name githubv4.String
-							} `graphql:"... on ProjectV2ItemFieldSingleSelectValue"`
-						} `graphql:"fieldValueByName(name: \"Status\")"`
-					}
-				} `graphql:"projectItems(first: 10)"`
-			} `graphql:"... on Issue"`
-		} `graphql:"node(id: $issueID)"`
+					} `graphql:"... on Issue"`
+				}
+				FieldValueByName struct {
+					SingleSelectValue struct {
+						ID   githubv4.String
+						Name githubv4.String
+					} `graphql:"... on ProjectV2ItemFieldSingleSelectValue"`
+				} `graphql:"fieldValueByName(name: \"Status\")"`
+				FieldValues fieldValueConnection `graphql:"fieldValues(first: 20)"`
+			} `graphql:"... on ProjectV2Item"`
+		} `graphql:"node(id: $itemID)"`
 	}
 
 	variables := map[string]interface{}{
-		"issueID": issueNodeID,
+		"itemID": githubv4.ID(itemID),
 	}
 
 	if err := c.client.Query(ctx, &query, variables); err != nil {
-		return nil, fmt.Errorf("failed to query project items: %w", err)
+		return nil, fmt.Errorf("failed to query project item: %w", err)
+	}
+
+	item := query.Node.Item
+	if item.Content.TypeName != "Issue" {
+		return nil, fmt.Errorf("project item %s is not an issue", itemID)
+	}
+
+	fields, err := c.itemFieldValues(ctx, item.ID, item.FieldValues)
+	if err != nil {
+		return nil, err
 	}
 
-	// Find the item that belongs to our project
-	for _, item := range query.Node.Issue.ProjectItems.Nodes {
-		projectID, ok := item.Project.ID.(string)
-		if !ok {
-			continue
+	nodeID, _ := item.Content.Issue.ID.(string)
+	repoID, _ := item.Content.Issue.Repository.ID.(string)
+
+	return &Issue{
+		NodeID:          nodeID,
+		Number:          int(item.Content.Issue.Number),
+		Title:           string(item.Content.Issue.Title),
+		Body:            string(item.Content.Issue.Body),
+		URL:             item.Content.Issue.URL.String(),
+		UpdatedAt:       item.Content.Issue.UpdatedAt.Time,
+		Fields:          fields,
+		RepositoryID:    repoID,
+		RepositoryName:  string(item.Content.Issue.Repository.Name),
+		RepositoryOwner: string(item.Content.Issue.Repository.Owner.Login),
+		ProjectItem: ProjectItemInfo{
+			ID:            itemID,
+			StatusValue:   string(item.FieldValueByName.SingleSelectValue.Name),
+			StatusValueID: string(item.FieldValueByName.SingleSelectValue.ID),
+			StatusFieldID: c.statusFieldID,
+		},
+	}, nil
+}
+
+// getProjectItemForIssue finds the project item for a given issue node ID,
+// with its field values by field name
+func (c *Client) getProjectItemForIssue(ctx context.Context, issueNodeID githubv4.ID) (*ProjectItemInfo, map[string]FieldValue, error) {
+	var found *ProjectItemInfo
+	var fields map[string]FieldValue
+
+	err := paginate(ctx, nil, fmt.Sprintf("project items of issue %v", issueNodeID), func(cursor *githubv4.String) (pageInfo, error) {
+		var query struct {
+			Node struct {
+				Issue struct {
+					ProjectItems struct {
+						PageInfo pageInfo
+						Nodes    []struct {
+							ID      githubv4.ID
+							Project struct {
+								ID githubv4.ID
+							}
+							FieldValueByName struct {
+								TypeName          string `graphql:"__typename"`
+								SingleSelectValue struct {
+									ID   githubv4.String
+									Name githubv4.String
+								} `graphql:"... on ProjectV2ItemFieldSingleSelectValue"`
+							} `graphql:"fieldValueByName(name: \"Status\")"`
+							FieldValues fieldValueConnection `graphql:"fieldValues(first: 20)"`
+						}
+					} `graphql:"projectItems(first: 20, after: $cursor)"`
+				} `graphql:"... on Issue"`
+			} `graphql:"node(id: $issueID)"`
+		}
+
+		variables := map[string]interface{}{
+			"issueID": issueNodeID,
+			"cursor":  cursor,
 		}
 
-		if projectID == c.projectID {
-			itemID, ok := item.ID.(string)
+		if err := c.client.Query(ctx, &query, variables); err != nil {
+			return pageInfo{}, fmt.Errorf("failed to query project items: %w", err)
+		}
+
+		// Find the item that belongs to our project
+		for _, item := range query.Node.Issue.ProjectItems.Nodes {
+			projectID, ok := item.Project.ID.(string)
 			if !ok {
 				continue
 			}
 
-			return &ProjectItemInfo{
-				ID:            itemID,
-				StatusValue:   string(item.FieldValueByName.SingleSelectValue.Name),
-				StatusValueID: string(item.FieldValueByName.SingleSelectValue.ID),
-				StatusFieldID: c.statusFieldID,
-			}, nil
+			if projectID == c.projectID {
+				itemID, ok := item.ID.(string)
+				if !ok {
+					continue
+				}
+
+				values, err := c.itemFieldValues(ctx, item.ID, item.FieldValues)
+				if err != nil {
+					return pageInfo{}, err
+				}
+
+				fields = values
+				found = &ProjectItemInfo{
+					ID:            itemID,
+					StatusValue:   string(item.FieldValueByName.SingleSelectValue.Name),
+					StatusValueID: string(item.FieldValueByName.SingleSelectValue.ID),
+					StatusFieldID: c.statusFieldID,
+				}
+				// No need to read further pages
+				return pageInfo{}, nil
+			}
 		}
+
+		return query.Node.Issue.ProjectItems.PageInfo, nil
+	})
+	if err != nil {
+		return nil, nil, err
 	}
 
-	return nil, nil
+	return found, fields, nil
 }
 
 // LinkPRToIssue creates a cross-reference between a PR and an issue
-// This makes the PR appear in the issue's timeline
+// This makes the PR appear in the issue's timeline. The comment is marked, so
+// linking the same PR again does not post a duplicate.
 func (c *Client) LinkPRToIssue(ctx context.Context, prOwner, prRepo string, prNumber int, issue Issue) error {
-	// Get the issue node ID
-	issueNodeID, err := c.getIssueNodeID(ctx, issue)
-	if err != nil {
-		return fmt.Errorf("failed to get issue node ID: %w", err)
-	}
-
 	// Create a comment on the issue that references the PR
 	// This creates a cross-reference link that shows in the timeline
-	prRef := fmt.Sprintf("%s/%s#%d", prOwner, prRepo, prNumber)
-	comment := fmt.Sprintf("Linked to PR %s", prRef)
-
-	var mutation struct {
-		AddComment struct {
-			CommentEdge struct {
-				Node struct {
-					ID githubv4.ID
-				}
-			}
-		} `graphql:"addComment(input: $input)"`
-	}
-
-	input := githubv4.AddCommentInput{
-		SubjectID: issueNodeID,
-		Body:      githubv4.String(comment),
-	}
+	comment := PRLinkComment(prOwner, prRepo, prNumber)
 
-	if err := c.client.Mutate(ctx, &mutation, input, nil); err != nil {
+	if _, err := c.UpsertComment(ctx, issue, PRLinkMarker(prOwner, prRepo, prNumber), comment); err != nil {
 		return fmt.Errorf("failed to create cross-reference: %w", err)
 	}
-
 	return nil
 }
 
+// PRLinkComment returns the comment LinkPRToIssue leaves on an issue
+func PRLinkComment(prOwner, prRepo string, prNumber int) string {
+	return fmt.Sprintf("Linked to PR %s/%s#%d", prOwner, prRepo, prNumber)
+}
+
 // getIssueNodeID retrieves the global node ID for an issue
 func (c *Client) getIssueNodeID(ctx context.Context, issue Issue) (githubv4.ID, error) {
+	if issue.NodeID != "" {
+		return githubv4.ID(issue.NodeID), nil
+	}
+
 	var query struct {
 		Node struct {
 			Repository struct {
@@ -674,22 +720,20 @@ func (c *Client) getIssueNodeID(ctx context.Context, issue Issue) (githubv4.ID,
 // GetInitiativeIssues retrieves all issues with GitHub Issue Type = "Initiative"
 func (c *Client) GetInitiativeIssues(ctx context.Context) ([]Issue, error) {
 	var issues []Issue
-	var cursor *githubv4.String
 
-	for {
+	err := paginate(ctx, nil, "project items", func(cursor *githubv4.String) (pageInfo, error) {
 		var query struct {
-			Node struct {
+			RateLimit rateLimitInfo
+			Node      struct {
 				ProjectV2 struct {
 					Items struct {
-						PageInfo struct {
-							HasNextPage githubv4.Boolean
-							EndCursor   githubv4.String
-						}
-						Nodes []struct {
+						PageInfo pageInfo
+						Nodes    []struct {
 							ID      githubv4.ID
 							Content struct {
 								TypeName string `graphql:"__typename"`
 								Issue    struct {
+									ID        githubv4.ID
 									Number    githubv4.Int
 									Title     githubv4.String
 									Body      githubv4.String
@@ -698,11 +742,7 @@ func (c *Client) GetInitiativeIssues(ctx context.Context) ([]Issue, error) {
 									IssueType struct {
 										Name githubv4.String
 									}
-									Assignees struct {
-										Nodes []struct {
-											Login githubv4.String
-										}
-									} `graphql:"assignees(first: 10)"`
+									Assignees  assigneeConnection `graphql:"assignees(first: 10)"`
 									Repository struct {
 										ID    githubv4.ID
 										Name  githubv4.String
@@ -719,6 +759,7 @@ func (c *Client) GetInitiativeIssues(ctx context.Context) ([]Issue, error) {
 									Name githubv4.String
 								} `graphql:"... on ProjectV2ItemFieldSingleSelectValue"`
 							} `graphql:"statusField: fieldValueByName(name: \"Status\")"`
+							FieldValues fieldValueConnection `graphql:"fieldValues(first: 20)"`
 						}
 					} `graphql:"items(first: 100, after: $cursor)"`
 				} `graphql:"... on ProjectV2"`
@@ -731,7 +772,7 @@ func (c *Client) GetInitiativeIssues(ctx context.Context) ([]Issue, error) {
 		}
 
 		if err := c.client.Query(ctx, &query, variables); err != nil {
-			return nil, fmt.Errorf("failed to query project items: %w", err)
+			return pageInfo{}, fmt.Errorf("failed to query project items: %w", err)
 		}
 
 		for _, item := range query.Node.ProjectV2.Items.Nodes {
@@ -756,21 +797,29 @@ func (c *Client) GetInitiativeIssues(ctx context.Context) ([]Issue, error) {
 				continue // Skip if we can't get item ID
 			}
 
-			// Extract assignees
-			assignees := []string{}
-			for _, assignee := range item.Content.Issue.Assignees.Nodes {
-				assignees = append(assignees, string(assignee.Login))
+			assignees, err := c.assigneeLogins(ctx, item.Content.Issue.ID, item.Content.Issue.Assignees)
+			if err != nil {
+				return pageInfo{}, err
+			}
+
+			fields, err := c.itemFieldValues(ctx, item.ID, item.FieldValues)
+			if err != nil {
+				return pageInfo{}, err
 			}
 
 			statusName := string(item.StatusField.SingleSelectValue.Name)
 
+			nodeID, _ := item.Content.Issue.ID.(string)
+
 			issues = append(issues, Issue{
+				NodeID:          nodeID,
 				Number:          int(item.Content.Issue.Number),
 				Title:           string(item.Content.Issue.Title),
 				Body:            string(item.Content.Issue.Body),
 				URL:             item.Content.Issue.URL.String(),
 				UpdatedAt:       item.Content.Issue.UpdatedAt.Time,
 				Assignees:       assignees,
+				Fields:          fields,
 				RepositoryID:    repoID,
 				RepositoryName:  string(item.Content.Issue.Repository.Name),
 				RepositoryOwner: string(item.Content.Issue.Repository.Owner.Login),
@@ -783,11 +832,10 @@ func (c *Client) GetInitiativeIssues(ctx context.Context) ([]Issue, error) {
 			})
 		}
 
-		if !query.Node.ProjectV2.Items.PageInfo.HasNextPage {
-			break
-		}
-
-		cursor = &query.Node.ProjectV2.Items.PageInfo.EndCursor
+		return query.Node.ProjectV2.Items.PageInfo, nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return issues, nil
@@ -814,42 +862,55 @@ func (c *Client) GetSubIssuesRecursive(ctx context.Context, owner, repo string,
 		}
 		visited[key] = true
 
-		var query struct {
-			Repository struct {
-				Issue struct {
-					SubIssues struct {
-						Nodes []struct {
-							Number githubv4.Int
-							Title  githubv4.String
-							Repository struct {
-								Name  githubv4.String
-								Owner struct {
-									Login githubv4.String
+		var children []SubIssue
+		err := paginate(ctx, nil, fmt.Sprintf("sub-issues of %s", key), func(cursor *githubv4.String) (pageInfo, error) {
+			var query struct {
+				Repository struct {
+					Issue struct {
+						SubIssues struct {
+							PageInfo pageInfo
+							Nodes    []struct {
+								Number     githubv4.Int
+								Title      githubv4.String
+								Repository struct {
+									Name  githubv4.String
+									Owner struct {
+										Login githubv4.String
+									}
 								}
 							}
-						}
-					} `graphql:"subIssues(first: 100)"`
-				} `graphql:"issue(number: $number)"`
-			} `graphql:"repository(owner: $owner, name: $repo)"`
-		}
+						} `graphql:"subIssues(first: 100, after: $cursor)"`
+					} `graphql:"issue(number: $number)"`
+				} `graphql:"repository(owner: $owner, name: $repo)"`
+			}
 
-		variables := map[string]interface{}{
-			"owner":  githubv4.String(owner),
-			"repo":   githubv4.String(repo),
-			"number": githubv4.Int(number),
-		}
+			variables := map[string]interface{}{
+				"owner":  githubv4.String(owner),
+				"repo":   githubv4.String(repo),
+				"number": githubv4.Int(number),
+				"cursor": cursor,
+			}
 
-		if err := c.client.Query(ctx, &query, variables); err != nil {
-			return fmt.Errorf("failed to query sub-issues for %s/%s#%d: %w", owner, repo, number, err)
-		}
+			if err := c.client.Query(ctx, &query, variables); err != nil {
+				return pageInfo{}, fmt.Errorf("failed to query sub-issues for %s/%s#%d: %w", owner, repo, number, err)
+			}
 
-		for _, node := range query.Repository.Issue.SubIssues.Nodes {
-			subIssue := SubIssue{
-				Owner:  string(node.Repository.Owner.Login),
-				Repo:   string(node.Repository.Name),
-				Number: int(node.Number),
-				Title:  string(node.Title),
+			for _, node := range query.Repository.Issue.SubIssues.Nodes {
+				children = append(children, SubIssue{
+					Owner:  string(node.Repository.Owner.Login),
+					Repo:   string(node.Repository.Name),
+					Number: int(node.Number),
+					Title:  string(node.Title),
+				})
 			}
+
+			return query.Repository.Issue.SubIssues.PageInfo, nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, subIssue := range children {
 			allSubIssues = append(allSubIssues, subIssue)
 
 			// Recursively fetch sub-issues of this sub-issue
@@ -868,8 +929,8 @@ func (c *Client) GetSubIssuesRecursive(ctx context.Context, owner, repo string,
 	return allSubIssues, nil
 }
 
-// AddIssueToProject adds an issue to the project with "Inbox" status
-func (c *Client) AddIssueToProject(ctx context.Context, owner, repo string, number int) (*Issue, error) {
+// AddIssueToProject adds an issue to the project with the given status
+func (c *Client) AddIssueToProject(ctx context.Context, owner, repo string, number int, status string) (*Issue, error) {
 	// First, get the issue and repository IDs
 	var query struct {
 		Repository struct {
@@ -901,19 +962,24 @@ func (c *Client) AddIssueToProject(ctx context.Context, owner, repo string, numb
 	}
 
 	issueNodeID := query.Repository.Issue.ID
+	nodeID, _ := issueNodeID.(string)
 
 	// Check if issue is already in the project
-	existingItem, err := c.getProjectItemForIssue(ctx, issueNodeID)
+	existingItem, fields, err := c.getProjectItemForIssue(ctx, issueNodeID)
 	if err == nil && existingItem != nil {
 		// Issue is already in project, return it
 		return &Issue{
-			Number:       int(query.Repository.Issue.Number),
-			Title:        string(query.Repository.Issue.Title),
-			Body:         string(query.Repository.Issue.Body),
-			URL:          query.Repository.Issue.URL.String(),
-			UpdatedAt:    query.Repository.Issue.UpdatedAt.Time,
-			RepositoryID: repoID,
-			ProjectItem:  *existingItem,
+			NodeID:          nodeID,
+			Number:          int(query.Repository.Issue.Number),
+			Title:           string(query.Repository.Issue.Title),
+			Body:            string(query.Repository.Issue.Body),
+			URL:             query.Repository.Issue.URL.String(),
+			UpdatedAt:       query.Repository.Issue.UpdatedAt.Time,
+			RepositoryID:    repoID,
+			RepositoryName:  repo,
+			RepositoryOwner: owner,
+			ProjectItem:     *existingItem,
+			Fields:          fields,
 		}, nil
 	}
 
@@ -939,11 +1005,14 @@ func (c *Client) AddIssueToProject(ctx context.Context, owner, repo string, numb
 	if !ok {
 		return nil, fmt.Errorf("failed to convert item ID")
 	}
+	initial := OptionValue(status)
+	c.record(ctx, AuditEntry{Kind: AuditAddToProject, Issue: fmt.Sprintf("%s/%s#%d", owner, repo, number), IssueID: nodeID,
+		RepositoryID: repoID, ItemID: itemID, Value: &initial})
 
-	// Set status to "Inbox"
-	inboxOptionID, err := c.getStatusOptionID(ctx, "Inbox")
+	// Set the initial status
+	statusOptionID, err := c.getStatusOptionID(ctx, status)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get Inbox option ID: %w", err)
+		return nil, fmt.Errorf("failed to get %s option ID: %w", status, err)
 	}
 
 	var updateMutation struct {
@@ -959,32 +1028,45 @@ func (c *Client) AddIssueToProject(ctx context.Context, owner, repo string, numb
 		ItemID:    githubv4.ID(itemID),
 		FieldID:   githubv4.ID(c.statusFieldID),
 		Value: githubv4.ProjectV2FieldValue{
-			SingleSelectOptionID: githubv4.NewString(githubv4.String(inboxOptionID)),
+			SingleSelectOptionID: githubv4.NewString(githubv4.String(statusOptionID)),
 		},
 	}
 
 	if err := c.client.Mutate(ctx, &updateMutation, updateInput, nil); err != nil {
-		return nil, fmt.Errorf("failed to set status to Inbox: %w", err)
+		return nil, fmt.Errorf("failed to set status to %s: %w", status, err)
 	}
 
+	// A new item has no field values but the Status just set
+	statusValue := OptionValue(status)
+	statusValue.OptionID = statusOptionID
+
 	return &Issue{
-		Number:       int(query.Repository.Issue.Number),
-		Title:        string(query.Repository.Issue.Title),
-		Body:         string(query.Repository.Issue.Body),
-		URL:          query.Repository.Issue.URL.String(),
-		UpdatedAt:    query.Repository.Issue.UpdatedAt.Time,
-		RepositoryID: repoID,
+		NodeID:          nodeID,
+		Number:          int(query.Repository.Issue.Number),
+		Title:           string(query.Repository.Issue.Title),
+		Body:            string(query.Repository.Issue.Body),
+		URL:             query.Repository.Issue.URL.String(),
+		UpdatedAt:       query.Repository.Issue.UpdatedAt.Time,
+		RepositoryID:    repoID,
+		RepositoryName:  repo,
+		RepositoryOwner: owner,
 		ProjectItem: ProjectItemInfo{
 			ID:            itemID,
-			StatusValue:   "Inbox",
-			StatusValueID: inboxOptionID,
+			StatusValue:   status,
+			StatusValueID: statusOptionID,
 			StatusFieldID: c.statusFieldID,
 		},
+		Fields: map[string]FieldValue{"Status": statusValue},
 	}, nil
 }
 
 // UpdateInitiativeField sets the Initiative text field for a project item
 func (c *Client) UpdateInitiativeField(ctx context.Context, issue Issue, initiativeTitle string) error {
+	if c.initiativeFieldID == "" {
+		return fmt.Errorf("project %s has no text Initiative field", c.projectURL)
+	}
+	previous := c.previousValue(ctx, issue, "Initiative")
+
 	var mutation struct {
 		UpdateProjectV2ItemFieldValue struct {
 			ProjectV2Item struct {
@@ -1006,5 +1088,8 @@ func (c *Client) UpdateInitiativeField(ctx context.Context, issue Issue, initiat
 		return fmt.Errorf("failed to update Initiative field: %w", err)
 	}
 
+	value := TextValue(initiativeTitle)
+	c.record(ctx, AuditEntry{Kind: AuditField, Issue: issue.Ref(), IssueID: issue.NodeID, RepositoryID: issue.RepositoryID,
+		ItemID: issue.ProjectItem.ID, Field: "Initiative", Previous: previous, Value: &value})
 	return nil
 }
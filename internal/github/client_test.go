@@ -0,0 +1,277 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/storacha/project-agent/internal/github/githubtest"
+)
+
+// newTestClient returns a client for a githubtest server seeded with the
+// example board
+func newTestClient(t *testing.T) (*Client, *githubtest.Server) {
+	t.Helper()
+	return newTestClientWith(t, func(*githubtest.Fixture) {})
+}
+
+// newTestClientWith is newTestClient with the example board changed by edit
+func newTestClientWith(t *testing.T, edit func(f *githubtest.Fixture)) (*Client, *githubtest.Server) {
+	t.Helper()
+	fixture, err := githubtest.LoadFixture("githubtest/testdata/board.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	edit(fixture)
+	srv, err := githubtest.NewServer(fixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(srv.Close)
+
+	client, err := NewClientWithEndpoint(srv.Endpoint(), "dummy", "storacha", 1)
+	if err != nil {
+		t.Fatalf("NewClientWithEndpoint: %v", err)
+	}
+	return client, srv
+}
+
+// issuesByRef indexes issues by repo#number
+func issuesByRef(issues []Issue) map[string]Issue {
+	out := make(map[string]Issue)
+	for _, issue := range issues {
+		out[fmt.Sprintf("%s#%d", issue.RepositoryName, issue.Number)] = issue
+	}
+	return out
+}
+
+func TestGetIssuesByStatuses(t *testing.T) {
+	client, _ := newTestClient(t)
+
+	issues, err := client.GetIssuesByStatuses(context.Background(), []string{"In Progress", "Sprint Backlog"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"guppy#2":             "In Progress",
+		"guppy#3":             "Sprint Backlog",
+		"project-tracking#10": "In Progress",
+	}
+	got := issuesByRef(issues)
+	if len(got) != len(want) {
+		t.Fatalf("got %d issues, want %v", len(issues), want)
+	}
+	for ref, status := range want {
+		issue, ok := got[ref]
+		if !ok {
+			t.Errorf("missing %s", ref)
+			continue
+		}
+		if issue.ProjectItem.StatusValue != status || issue.ProjectItem.ID == "" {
+			t.Errorf("%s project item = %+v, want status %q", ref, issue.ProjectItem, status)
+		}
+	}
+	if issue := got["guppy#2"]; issue.Title != "Implement upload resume" || len(issue.Assignees) != 1 || issue.Assignees[0] != "alice" {
+		t.Errorf("guppy#2 = %+v", issue)
+	}
+}
+
+func TestGetSubIssuesRecursive(t *testing.T) {
+	client, _ := newTestClient(t)
+
+	subIssues, err := client.GetSubIssuesRecursive(context.Background(), "storacha", "project-tracking", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, sub := range subIssues {
+		got = append(got, sub.Owner+"/"+sub.Repo+"#"+sub.Title)
+	}
+	sort.Strings(got)
+	want := []string{"storacha/guppy#Resume interrupted uploads", "storacha/guppy#Upload resume: CLI flag"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("sub-issues = %v, want %v", got, want)
+	}
+}
+
+func TestApplyBatch(t *testing.T) {
+	client, srv := newTestClient(t)
+	ctx := context.Background()
+
+	issues, err := client.GetIssuesByStatuses(ctx, []string{"In Progress", "Sprint Backlog"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	byRef := issuesByRef(issues)
+	two, three := byRef["guppy#2"], byRef["guppy#3"]
+
+	results := client.ApplyBatch(ctx, []BatchOp{
+		StatusOp(two, "PR Review"),
+		InitiativeOp(three, "Upload reliability"),
+		MarkedCommentOp(three, "<!-- test -->", "first"),
+		// The label does not exist yet, so it is created first
+		LabelOp(two, "needs-review"),
+		StatusOp(three, "No such status"),
+	})
+	if len(results) != 5 {
+		t.Fatalf("got %d results, want 5", len(results))
+	}
+	for i, r := range results[:4] {
+		if r.Err != nil {
+			t.Errorf("op %d (%s): %v", i, r.Op.Kind, r.Err)
+		}
+	}
+	if results[4].Err == nil {
+		t.Error("moving to an unknown status succeeded")
+	}
+
+	if values, _ := srv.ItemValues("storacha/guppy#2"); values["Status"] != "PR Review" {
+		t.Errorf("storacha/guppy#2 values = %v, want Status PR Review", values)
+	}
+	if values, _ := srv.ItemValues("storacha/guppy#3"); values["Initiative"] != "Upload reliability" || values["Status"] != "Sprint Backlog" {
+		t.Errorf("storacha/guppy#3 values = %v, want the initiative set and the status unchanged", values)
+	}
+
+	// A second marked comment updates the first instead of adding another
+	if r := client.ApplyBatch(ctx, []BatchOp{MarkedCommentOp(three, "<!-- test -->", "second")}); r[0].Err != nil {
+		t.Fatal(r[0].Err)
+	}
+
+	snap := srv.Snapshot()
+	for _, repo := range snap.Repositories {
+		if repo.Name != "guppy" {
+			continue
+		}
+		for _, issue := range repo.Issues {
+			switch issue.Number {
+			case 2:
+				if !contains(issue.Labels, "needs-review") {
+					t.Errorf("storacha/guppy#2 labels = %v, want needs-review", issue.Labels)
+				}
+			case 3:
+				if len(issue.Comments) != 1 || !strings.HasPrefix(issue.Comments[0].Body, "second") {
+					t.Errorf("storacha/guppy#3 comments = %+v, want the one marked comment, updated", issue.Comments)
+				}
+			}
+		}
+	}
+}
+
+func TestRequire(t *testing.T) {
+	client, _ := newTestClient(t)
+
+	if err := client.Require(Requirements{
+		Fields:        []FieldRequirement{{Name: "Initiative", Type: FieldText}, {Name: "Estimate", Type: FieldNumber}},
+		StatusOptions: []string{"Inbox", "PR Review", "Stuck / Dead Issue"},
+	}); err != nil {
+		t.Errorf("Require on a complete schema: %v", err)
+	}
+
+	err := client.Require(Requirements{
+		Fields:        []FieldRequirement{{Name: "Initiative", Type: FieldNumber}, {Name: "Effort", Type: FieldNumber}},
+		StatusOptions: []string{"Inbox", "Blocked"},
+	})
+	if err == nil {
+		t.Fatal("Require on an incomplete schema succeeded")
+	}
+	for _, want := range []string{`field "Initiative" has type TEXT, expected NUMBER`, `NUMBER field "Effort"`, `Status option "Blocked"`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %s", err, want)
+		}
+	}
+	if strings.Contains(err.Error(), `"Inbox"`) {
+		t.Errorf("error %q mentions an option the project has", err)
+	}
+}
+
+func TestHasLabelReadsEveryPage(t *testing.T) {
+	client, _ := newTestClientWith(t, func(f *githubtest.Fixture) {
+		guppy := &f.Repositories[0]
+		issue := &guppy.Issues[0]
+		for i := 0; i < 150; i++ {
+			name := fmt.Sprintf("label-%d", i)
+			guppy.Labels = append(guppy.Labels, githubtest.LabelFixture{ID: "LA_" + name, Name: name})
+			issue.Labels = append(issue.Labels, name)
+		}
+	})
+	client.SetAuditLog(NewAuditLog(filepath.Join(t.TempDir(), "audit.jsonl"), "test"))
+	ctx := context.Background()
+
+	if !client.hasLabel(ctx, "I_guppy_1", "label-120") {
+		t.Error("label-120, on the second page, was not found")
+	}
+	if client.hasLabel(ctx, "I_guppy_1", "duplicate") {
+		t.Error("found a label the issue does not have")
+	}
+}
+
+func TestGetIssueLoadsFieldValues(t *testing.T) {
+	client, _ := newTestClient(t)
+	client.SetAuditLog(NewAuditLog(filepath.Join(t.TempDir(), "audit.jsonl"), "test"))
+	ctx := context.Background()
+
+	issue, err := client.GetIssueByNumber(ctx, "storacha", "guppy", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]string{"Priority": "P1", "Estimate": "3", "Sprint": "Sprint 1", "Status": "In Progress"} {
+		if got := issue.Fields[name]; got.String() != want {
+			t.Errorf("GetIssueByNumber: %s = %q, want %q", name, got, want)
+		}
+	}
+
+	existing, err := client.AddIssueToProject(ctx, "storacha", "guppy", 2, "Backlog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := existing.Fields["Priority"]; got.String() != "P1" {
+		t.Errorf("AddIssueToProject on an item: Priority = %q, want P1", got)
+	}
+
+	added, err := client.AddIssueToProject(ctx, "storacha", "guppy", 4, "Inbox")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(added.Fields) != 1 || added.Fields["Status"].String() != "Inbox" {
+		t.Errorf("AddIssueToProject on a new item: fields = %v, want only Status Inbox", added.Fields)
+	}
+}
+
+func TestFindMarkedCommentsReadsEveryPage(t *testing.T) {
+	const marker = "<!-- project-agent:test -->"
+	client, _ := newTestClientWith(t, func(f *githubtest.Fixture) {
+		issue := &f.Repositories[0].Issues[0]
+		for i := 0; i < 150; i++ {
+			comment := githubtest.CommentFixture{ID: fmt.Sprintf("IC_%d", i), Author: "alice", Body: "a comment"}
+			switch i {
+			case 120:
+				comment.Author, comment.Body = "project-agent[bot]", marker+"\nreport"
+			case 130:
+				comment.Body = marker + "\nquoted by a person"
+			}
+			issue.Comments = append(issue.Comments, comment)
+		}
+	})
+
+	found, err := client.FindMarkedComments(context.Background(), Issue{NodeID: "I_guppy_1"}, marker)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 1 || found[0].ID != "IC_120" {
+		t.Errorf("found %+v, want only IC_120 from the second page", found)
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
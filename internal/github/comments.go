@@ -0,0 +1,237 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/shurcooL/githubv4"
+)
+
+// CommentMarker returns the hidden HTML comment that tags a bot comment as
+// the one a task keeps on an issue for key. Upserting with the same marker
+// edits that comment instead of posting another.
+func CommentMarker(task, key string) string {
+	return fmt.Sprintf("<!-- project-agent:%s:%s -->", task, key)
+}
+
+// PRLinkMarker returns the marker of the comment linking an issue to a PR
+func PRLinkMarker(prOwner, prRepo string, prNumber int) string {
+	return CommentMarker("link-pr", fmt.Sprintf("%s/%s#%d", prOwner, prRepo, prNumber))
+}
+
+// markedBody appends the marker to a comment body
+func markedBody(body, marker string) string {
+	return strings.TrimRight(body, "\n") + "\n\n" + marker
+}
+
+// CommentOptions controls how marked comments are upserted
+type CommentOptions struct {
+	// MinimizeOutdated posts a changed comment as a new one and hides the
+	// earlier ones as outdated, instead of editing the latest in place
+	MinimizeOutdated bool
+}
+
+// UpsertResult is what UpsertComment did
+type UpsertResult string
+
+// Upsert results
+const (
+	CommentCreated   UpsertResult = "created"
+	CommentUpdated   UpsertResult = "updated"
+	CommentUnchanged UpsertResult = "unchanged"
+)
+
+// MarkedComment is a comment the agent posted with a marker
+type MarkedComment struct {
+	ID        string
+	Body      string
+	Minimized bool
+}
+
+// SetCommentOptions sets how marked comments are upserted, both by
+// UpsertComment's callers in the client and by batched comment ops
+func (c *Client) SetCommentOptions(opts CommentOptions) {
+	c.commentOpts = opts
+}
+
+// FindMarkedComments returns the comments the authenticated user posted on
+// an issue with the marker, oldest first
+func (c *Client) FindMarkedComments(ctx context.Context, issue Issue, marker string) ([]MarkedComment, error) {
+	issueNodeID, err := c.getIssueNodeID(ctx, issue)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get issue node ID: %w", err)
+	}
+
+	var found []MarkedComment
+	err = paginate(ctx, nil, fmt.Sprintf("comments of issue %s", issue.Ref()), func(cursor *githubv4.String) (pageInfo, error) {
+		var query struct {
+			Node struct {
+				Issue struct {
+					Comments struct {
+						Nodes []struct {
+							ID              githubv4.ID
+							Body            githubv4.String
+							IsMinimized     githubv4.Boolean
+							ViewerDidAuthor githubv4.Boolean
+						}
+						PageInfo pageInfo
+					} `graphql:"comments(first: 100, after: $cursor)"`
+				} `graphql:"... on Issue"`
+			} `graphql:"node(id: $issueID)"`
+		}
+
+		variables := map[string]interface{}{
+			"issueID": issueNodeID,
+			"cursor":  cursor,
+		}
+
+		if err := c.client.Query(ctx, &query, variables); err != nil {
+			return pageInfo{}, fmt.Errorf("failed to query comments: %w", err)
+		}
+
+		for _, node := range query.Node.Issue.Comments.Nodes {
+			if !bool(node.ViewerDidAuthor) || !strings.Contains(string(node.Body), marker) {
+				continue
+			}
+			id, _ := node.ID.(string)
+			found = append(found, MarkedComment{ID: id, Body: string(node.Body), Minimized: bool(node.IsMinimized)})
+		}
+		return query.Node.Issue.Comments.PageInfo, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return found, nil
+}
+
+// commentPlan is what upserting a marked comment takes: the comment to edit,
+// if any, and the comments to minimize after posting a new one
+type commentPlan struct {
+	unchanged bool
+	edit      *MarkedComment
+	minimize  []MarkedComment
+}
+
+// planUpsert decides how to upsert body given the issue's marked comments.
+// The latest visible one is the current comment; if every marked comment is
+// hidden, a new one is posted.
+func (c *Client) planUpsert(existing []MarkedComment, body string) commentPlan {
+	var visible []MarkedComment
+	for _, comment := range existing {
+		if !comment.Minimized {
+			visible = append(visible, comment)
+		}
+	}
+	if len(visible) == 0 {
+		return commentPlan{}
+	}
+
+	latest := visible[len(visible)-1]
+	if strings.TrimSpace(latest.Body) == strings.TrimSpace(body) {
+		return commentPlan{unchanged: true}
+	}
+	if c.commentOpts.MinimizeOutdated {
+		return commentPlan{minimize: visible}
+	}
+	return commentPlan{edit: &latest}
+}
+
+// UpsertComment keeps one comment tagged with marker on an issue: it posts
+// body if there is none, edits the latest one if its body differs, and does
+// nothing if it is current. With MinimizeOutdated set, a changed comment is
+// posted anew and the earlier ones are minimized.
+func (c *Client) UpsertComment(ctx context.Context, issue Issue, marker, body string) (UpsertResult, error) {
+	body = markedBody(body, marker)
+	existing, err := c.FindMarkedComments(ctx, issue, marker)
+	if err != nil {
+		return "", err
+	}
+
+	plan := c.planUpsert(existing, body)
+	switch {
+	case plan.unchanged:
+		return CommentUnchanged, nil
+	case plan.edit != nil:
+		if err := c.UpdateComment(ctx, issue, *plan.edit, body); err != nil {
+			return "", err
+		}
+		return CommentUpdated, nil
+	}
+
+	if err := c.AddComment(ctx, issue, body); err != nil {
+		return "", err
+	}
+	for _, outdated := range plan.minimize {
+		if err := c.MinimizeComment(ctx, issue, outdated.ID); err != nil {
+			return "", err
+		}
+	}
+	if len(plan.minimize) > 0 {
+		return CommentUpdated, nil
+	}
+	return CommentCreated, nil
+}
+
+// UpdateComment replaces the body of a comment
+func (c *Client) UpdateComment(ctx context.Context, issue Issue, comment MarkedComment, body string) error {
+	var mutation struct {
+		UpdateIssueComment struct {
+			ClientMutationID *githubv4.String `graphql:"clientMutationId"`
+		} `graphql:"updateIssueComment(input: $input)"`
+	}
+
+	input := githubv4.UpdateIssueCommentInput{
+		ID:   githubv4.ID(comment.ID),
+		Body: githubv4.String(body),
+	}
+
+	if err := c.client.Mutate(ctx, &mutation, input, nil); err != nil {
+		return fmt.Errorf("failed to update comment: %w", err)
+	}
+
+	c.record(ctx, AuditEntry{Kind: AuditEditComment, Issue: issue.Ref(), IssueID: issue.NodeID, RepositoryID: issue.RepositoryID,
+		CommentID: comment.ID, Body: body, PreviousBody: comment.Body})
+	return nil
+}
+
+// UnminimizeComment shows a minimized comment again
+func (c *Client) UnminimizeComment(ctx context.Context, issue Issue, commentID string) error {
+	var mutation struct {
+		UnminimizeComment struct {
+			ClientMutationID *githubv4.String `graphql:"clientMutationId"`
+		} `graphql:"unminimizeComment(input: $input)"`
+	}
+
+	input := githubv4.UnminimizeCommentInput{
+		SubjectID: githubv4.ID(commentID),
+	}
+
+	if err := c.client.Mutate(ctx, &mutation, input, nil); err != nil {
+		return fmt.Errorf("failed to unminimize comment: %w", err)
+	}
+
+	c.record(ctx, AuditEntry{Kind: AuditUnminimizeComment, Issue: issue.Ref(), CommentID: commentID})
+	return nil
+}
+
+// CommentBody returns the current body of a comment
+func (c *Client) CommentBody(ctx context.Context, commentID string) (string, error) {
+	var query struct {
+		Node struct {
+			IssueComment struct {
+				Body githubv4.String
+			} `graphql:"... on IssueComment"`
+		} `graphql:"node(id: $commentID)"`
+	}
+
+	variables := map[string]interface{}{
+		"commentID": githubv4.ID(commentID),
+	}
+
+	if err := c.client.Query(ctx, &query, variables); err != nil {
+		return "", fmt.Errorf("failed to query comment: %w", err)
+	}
+	return string(query.Node.IssueComment.Body), nil
+}
@@ -0,0 +1,505 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/shurcooL/githubv4"
+)
+
+// FieldType is a ProjectV2 field data type
+type FieldType string
+
+// Field types whose values can be read and set. Built-in fields such as
+// TITLE, ASSIGNEES and LABELS are listed in the schema but are read-only here.
+const (
+	FieldText         FieldType = "TEXT"
+	FieldNumber       FieldType = "NUMBER"
+	FieldDate         FieldType = "DATE"
+	FieldSingleSelect FieldType = "SINGLE_SELECT"
+	FieldIteration    FieldType = "ITERATION"
+)
+
+// dateLayout is the format GitHub uses for DATE field values
+const dateLayout = "2006-01-02"
+
+// FieldOption is a single-select option
+type FieldOption struct {
+	ID   string
+	Name string
+}
+
+// Iteration is an iteration of an ITERATION field
+type Iteration struct {
+	ID        string
+	Title     string
+	StartDate string
+	Duration  int // Days
+	Completed bool
+}
+
+// Field describes a project field
+type Field struct {
+	ID         string
+	Name       string
+	Type       FieldType
+	Options    []FieldOption // SINGLE_SELECT only
+	Iterations []Iteration   // ITERATION only, including completed iterations
+}
+
+// Settable reports whether values of the field can be set and cleared
+func (f Field) Settable() bool {
+	switch f.Type {
+	case FieldText, FieldNumber, FieldDate, FieldSingleSelect, FieldIteration:
+		return true
+	}
+	return false
+}
+
+// Option finds a single-select option by name or ID
+func (f Field) Option(nameOrID string) (FieldOption, bool) {
+	for _, o := range f.Options {
+		if o.Name == nameOrID || o.ID == nameOrID {
+			return o, true
+		}
+	}
+	return FieldOption{}, false
+}
+
+// Iteration finds an iteration by title or ID
+func (f Field) Iteration(titleOrID string) (Iteration, bool) {
+	for _, it := range f.Iterations {
+		if it.Title == titleOrID || it.ID == titleOrID {
+			return it, true
+		}
+	}
+	return Iteration{}, false
+}
+
+// Schema is every field of the project, loaded once when the client is created
+type Schema struct {
+	Fields []Field
+}
+
+// Field finds a field by name
+func (s *Schema) Field(name string) (Field, bool) {
+	for _, f := range s.Fields {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return Field{}, false
+}
+
+// FieldValue is a typed project field value. Only the members matching Type
+// are meaningful.
+type FieldValue struct {
+	Type        FieldType
+	Text        string
+	Number      float64
+	Date        time.Time
+	Option      string // Single-select option name
+	OptionID    string
+	Iteration   string // Iteration title
+	IterationID string
+}
+
+// TextValue returns a TEXT field value
+func TextValue(text string) FieldValue {
+	return FieldValue{Type: FieldText, Text: text}
+}
+
+// NumberValue returns a NUMBER field value
+func NumberValue(number float64) FieldValue {
+	return FieldValue{Type: FieldNumber, Number: number}
+}
+
+// DateValue returns a DATE field value; only the date part of t is used
+func DateValue(t time.Time) FieldValue {
+	return FieldValue{Type: FieldDate, Date: t}
+}
+
+// OptionValue returns a SINGLE_SELECT field value for an option name
+func OptionValue(option string) FieldValue {
+	return FieldValue{Type: FieldSingleSelect, Option: option}
+}
+
+// IterationValue returns an ITERATION field value for an iteration title
+func IterationValue(title string) FieldValue {
+	return FieldValue{Type: FieldIteration, Iteration: title}
+}
+
+// fieldValueJSON is the compact form FieldValue is saved in, as in plan files
+// and the audit log
+type fieldValueJSON struct {
+	Type  FieldType `json:"type"`
+	Value string    `json:"value"`
+	ID    string    `json:"id,omitempty"` // Option or iteration ID
+}
+
+// MarshalJSON saves the value as its type, board text and option or
+// iteration ID
+func (v FieldValue) MarshalJSON() ([]byte, error) {
+	return json.Marshal(fieldValueJSON{Type: v.Type, Value: v.String(), ID: firstNonEmpty(v.OptionID, v.IterationID)})
+}
+
+// UnmarshalJSON reads a value saved by MarshalJSON
+func (v *FieldValue) UnmarshalJSON(data []byte) error {
+	var saved fieldValueJSON
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return err
+	}
+
+	*v = FieldValue{Type: saved.Type}
+	switch saved.Type {
+	case FieldText:
+		v.Text = saved.Value
+	case FieldNumber:
+		n, err := strconv.ParseFloat(saved.Value, 64)
+		if err != nil {
+			return fmt.Errorf("invalid NUMBER value %q: %w", saved.Value, err)
+		}
+		v.Number = n
+	case FieldDate:
+		date, err := time.Parse(dateLayout, saved.Value)
+		if err != nil {
+			return fmt.Errorf("invalid DATE value %q: %w", saved.Value, err)
+		}
+		v.Date = date
+	case FieldSingleSelect:
+		v.Option, v.OptionID = saved.Value, saved.ID
+	case FieldIteration:
+		v.Iteration, v.IterationID = saved.Value, saved.ID
+	}
+	return nil
+}
+
+// String renders the value the way it appears on the board
+func (v FieldValue) String() string {
+	switch v.Type {
+	case FieldText:
+		return v.Text
+	case FieldNumber:
+		return strconv.FormatFloat(v.Number, 'f', -1, 64)
+	case FieldDate:
+		return v.Date.Format(dateLayout)
+	case FieldSingleSelect:
+		return v.Option
+	case FieldIteration:
+		return v.Iteration
+	}
+	return ""
+}
+
+// Schema returns the project's fields
+func (c *Client) Schema() *Schema {
+	return c.schema
+}
+
+// fieldValueNode selects any ProjectV2 item field value along with the name
+// of its field
+type fieldValueNode struct {
+	TypeName string `graphql:"__typename"`
+	Common   struct {
+		Field struct {
+			Common struct {
+				Name githubv4.String
+			} `graphql:"... on ProjectV2FieldCommon"`
+		}
+	} `graphql:"... on ProjectV2ItemFieldValueCommon"`
+	TextValue struct {
+		Text githubv4.String
+	} `graphql:"... on ProjectV2ItemFieldTextValue"`
+	NumberValue struct {
+		Number githubv4.Float
+	} `graphql:"... on ProjectV2ItemFieldNumberValue"`
+	DateValue struct {
+		Date githubv4.String
+	} `graphql:"... on ProjectV2ItemFieldDateValue"`
+	SingleSelectValue struct {
+		OptionID githubv4.String `graphql:"optionId"`
+		Name     githubv4.String
+	} `graphql:"... on ProjectV2ItemFieldSingleSelectValue"`
+	IterationValue struct {
+		IterationID githubv4.String `graphql:"iterationId"`
+		Title       githubv4.String
+	} `graphql:"... on ProjectV2ItemFieldIterationValue"`
+}
+
+// value converts the node to a field name and typed value. ok is false for
+// value types this package does not model, such as labels or assignees.
+func (n fieldValueNode) value() (name string, value FieldValue, ok bool) {
+	name = string(n.Common.Field.Common.Name)
+
+	switch n.TypeName {
+	case "ProjectV2ItemFieldTextValue":
+		return name, TextValue(string(n.TextValue.Text)), true
+	case "ProjectV2ItemFieldNumberValue":
+		return name, NumberValue(float64(n.NumberValue.Number)), true
+	case "ProjectV2ItemFieldDateValue":
+		date, err := time.Parse(dateLayout, string(n.DateValue.Date))
+		if err != nil {
+			return name, FieldValue{}, false
+		}
+		return name, DateValue(date), true
+	case "ProjectV2ItemFieldSingleSelectValue":
+		v := OptionValue(string(n.SingleSelectValue.Name))
+		v.OptionID = string(n.SingleSelectValue.OptionID)
+		return name, v, true
+	case "ProjectV2ItemFieldIterationValue":
+		v := IterationValue(string(n.IterationValue.Title))
+		v.IterationID = string(n.IterationValue.IterationID)
+		return name, v, true
+	}
+
+	return name, FieldValue{}, false
+}
+
+// fieldValueConnection is the first page of an item's field values, selected
+// inline with a larger query
+type fieldValueConnection struct {
+	PageInfo pageInfo
+	Nodes    []fieldValueNode
+}
+
+// itemFieldValues returns every modelled field value of a project item by
+// field name, fetching any pages beyond the inline first page
+func (c *Client) itemFieldValues(ctx context.Context, itemID githubv4.ID, first fieldValueConnection) (map[string]FieldValue, error) {
+	values := make(map[string]FieldValue)
+	add := func(nodes []fieldValueNode) {
+		for _, node := range nodes {
+			if name, value, ok := node.value(); ok {
+				values[name] = value
+			}
+		}
+	}
+
+	add(first.Nodes)
+	if !first.PageInfo.HasNextPage {
+		return values, nil
+	}
+
+	start := first.PageInfo.EndCursor
+	err := paginate(ctx, &start, fmt.Sprintf("field values of item %v", itemID), func(cursor *githubv4.String) (pageInfo, error) {
+		var query struct {
+			Node struct {
+				Item struct {
+					FieldValues fieldValueConnection `graphql:"fieldValues(first: 50, after: $cursor)"`
+				} `graphql:"... on ProjectV2Item"`
+			} `graphql:"node(id: $itemID)"`
+		}
+
+		variables := map[string]interface{}{
+			"itemID": itemID,
+			"cursor": cursor,
+		}
+
+		if err := c.client.Query(ctx, &query, variables); err != nil {
+			return pageInfo{}, fmt.Errorf("failed to query field values: %w", err)
+		}
+
+		add(query.Node.Item.FieldValues.Nodes)
+		return query.Node.Item.FieldValues.PageInfo, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+// GetFieldValue reads the current value of a field on an issue's project
+// item. ok is false when the field is empty.
+func (c *Client) GetFieldValue(ctx context.Context, issue Issue, fieldName string) (value FieldValue, ok bool, err error) {
+	field, found := c.schema.Field(fieldName)
+	if !found {
+		return FieldValue{}, false, fmt.Errorf("field %q not found in project", fieldName)
+	}
+	if issue.ProjectItem.ID == "" {
+		return FieldValue{}, false, fmt.Errorf("issue #%d has no project item", issue.Number)
+	}
+
+	var query struct {
+		Node struct {
+			Item struct {
+				FieldValueByName *fieldValueNode `graphql:"fieldValueByName(name: $name)"`
+			} `graphql:"... on ProjectV2Item"`
+		} `graphql:"node(id: $itemID)"`
+	}
+
+	variables := map[string]interface{}{
+		"itemID": githubv4.ID(issue.ProjectItem.ID),
+		"name":   githubv4.String(field.Name),
+	}
+
+	if err := c.client.Query(ctx, &query, variables); err != nil {
+		return FieldValue{}, false, fmt.Errorf("failed to query field value: %w", err)
+	}
+
+	if query.Node.Item.FieldValueByName == nil {
+		return FieldValue{}, false, nil
+	}
+	_, value, ok = query.Node.Item.FieldValueByName.value()
+	return value, ok, nil
+}
+
+// SetFieldValue sets a field on an issue's project item. The value's type
+// must match the field's type; single-select options and iterations are
+// given by name or title.
+func (c *Client) SetFieldValue(ctx context.Context, issue Issue, fieldName string, value FieldValue) error {
+	return c.ApplyBatch(ctx, []BatchOp{FieldOp(issue, fieldName, value)})[0].Err
+}
+
+// ClearFieldValue empties a field on an issue's project item
+func (c *Client) ClearFieldValue(ctx context.Context, issue Issue, fieldName string) error {
+	return c.ApplyBatch(ctx, []BatchOp{ClearFieldOp(issue, fieldName)})[0].Err
+}
+
+// fieldValueInput type checks value against the schema and returns the
+// ProjectV2FieldValue input for it
+func (c *Client) fieldValueInput(fieldName string, value FieldValue) (Field, map[string]interface{}, error) {
+	field, ok := c.schema.Field(fieldName)
+	if !ok {
+		return Field{}, nil, fmt.Errorf("field %q not found in project", fieldName)
+	}
+	if !field.Settable() {
+		return Field{}, nil, fmt.Errorf("field %q has type %s, which cannot be set", field.Name, field.Type)
+	}
+	if value.Type != field.Type {
+		return Field{}, nil, fmt.Errorf("field %q has type %s, got a %s value", field.Name, field.Type, value.Type)
+	}
+
+	switch field.Type {
+	case FieldText:
+		return field, map[string]interface{}{"text": value.Text}, nil
+	case FieldNumber:
+		return field, map[string]interface{}{"number": value.Number}, nil
+	case FieldDate:
+		return field, map[string]interface{}{"date": value.Date.Format(dateLayout)}, nil
+	case FieldSingleSelect:
+		option, ok := field.Option(firstNonEmpty(value.OptionID, value.Option))
+		if !ok {
+			return Field{}, nil, fmt.Errorf("option %q not found in field %q", value.Option, field.Name)
+		}
+		return field, map[string]interface{}{"singleSelectOptionId": option.ID}, nil
+	default: // FieldIteration
+		iteration, ok := field.Iteration(firstNonEmpty(value.IterationID, value.Iteration))
+		if !ok {
+			return Field{}, nil, fmt.Errorf("iteration %q not found in field %q", value.Iteration, field.Name)
+		}
+		return field, map[string]interface{}{"iterationId": iteration.ID}, nil
+	}
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// loadSchema fetches every project field with its options and iterations
+func (c *Client) loadSchema(ctx context.Context) error {
+	type iterationNode struct {
+		ID        githubv4.String
+		Title     githubv4.String
+		StartDate githubv4.String
+		Duration  githubv4.Int
+	}
+
+	schema := &Schema{}
+	err := paginate(ctx, nil, "project fields", func(cursor *githubv4.String) (pageInfo, error) {
+		var query struct {
+			Organization struct {
+				ProjectV2 struct {
+					ID     githubv4.ID
+					URL    githubv4.URI
+					Fields struct {
+						PageInfo pageInfo
+						Nodes    []struct {
+							TypeName string `graphql:"__typename"`
+							Common   struct {
+								ID       githubv4.ID
+								Name     githubv4.String
+								DataType githubv4.String
+							} `graphql:"... on ProjectV2FieldCommon"`
+							SingleSelectField struct {
+								Options []struct {
+									ID   githubv4.String
+									Name githubv4.String
+								}
+							} `graphql:"... on ProjectV2SingleSelectField"`
+							IterationField struct {
+								Configuration struct {
+									Iterations          []iterationNode
+									CompletedIterations []iterationNode
+								}
+							} `graphql:"... on ProjectV2IterationField"`
+						}
+					} `graphql:"fields(first: 100, after: $cursor)"`
+				} `graphql:"projectV2(number: $projectNumber)"`
+			} `graphql:"organization(login: $org)"`
+		}
+
+		variables := map[string]interface{}{
+			"org":           githubv4.String(c.org),
+			"projectNumber": githubv4.Int(c.projectNumber),
+			"cursor":        cursor,
+		}
+
+		if err := c.client.Query(ctx, &query, variables); err != nil {
+			return pageInfo{}, fmt.Errorf("failed to query project: %w", err)
+		}
+
+		projectID, ok := query.Organization.ProjectV2.ID.(string)
+		if !ok {
+			return pageInfo{}, fmt.Errorf("failed to convert project ID to string")
+		}
+		c.projectID = projectID
+		c.projectURL = query.Organization.ProjectV2.URL.String()
+
+		for _, node := range query.Organization.ProjectV2.Fields.Nodes {
+			fieldID, ok := node.Common.ID.(string)
+			if !ok {
+				return pageInfo{}, fmt.Errorf("failed to convert field ID to string")
+			}
+
+			field := Field{
+				ID:   fieldID,
+				Name: string(node.Common.Name),
+				Type: FieldType(node.Common.DataType),
+			}
+			for _, o := range node.SingleSelectField.Options {
+				field.Options = append(field.Options, FieldOption{ID: string(o.ID), Name: string(o.Name)})
+			}
+			addIterations := func(nodes []iterationNode, completed bool) {
+				for _, it := range nodes {
+					field.Iterations = append(field.Iterations, Iteration{
+						ID:        string(it.ID),
+						Title:     string(it.Title),
+						StartDate: string(it.StartDate),
+						Duration:  int(it.Duration),
+						Completed: completed,
+					})
+				}
+			}
+			addIterations(node.IterationField.Configuration.Iterations, false)
+			addIterations(node.IterationField.Configuration.CompletedIterations, true)
+
+			schema.Fields = append(schema.Fields, field)
+		}
+
+		return query.Organization.ProjectV2.Fields.PageInfo, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	c.schema = schema
+	return nil
+}
@@ -0,0 +1,491 @@
+package githubtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+// Fixture describes the organization, ProjectV2 board and repositories the
+// stand-in server exposes. It is usually loaded from a JSON file; see
+// testdata/board.json for an example.
+type Fixture struct {
+	Organization string              `json:"organization"`
+	Project      ProjectFixture      `json:"project"`
+	Repositories []RepositoryFixture `json:"repositories"`
+}
+
+// ProjectFixture describes the ProjectV2 board
+type ProjectFixture struct {
+	ID     string         `json:"id"`
+	Number int            `json:"number"`
+	Title  string         `json:"title"`
+	Fields []FieldFixture `json:"fields"`
+	Items  []ItemFixture  `json:"items"`
+}
+
+// FieldFixture describes a project field. DataType is one of the ProjectV2
+// field data types (TEXT, NUMBER, DATE, SINGLE_SELECT, ITERATION, ...).
+type FieldFixture struct {
+	ID         string             `json:"id"`
+	Name       string             `json:"name"`
+	DataType   string             `json:"dataType"`
+	Options    []OptionFixture    `json:"options,omitempty"`
+	Iterations []IterationFixture `json:"iterations,omitempty"`
+}
+
+// OptionFixture is a single-select option
+type OptionFixture struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// IterationFixture is an iteration of an iteration field
+type IterationFixture struct {
+	ID        string `json:"id"`
+	Title     string `json:"title"`
+	StartDate string `json:"startDate"`
+	Duration  int    `json:"duration"`
+}
+
+// ItemFixture is a project item. Content is the issue key ("owner/repo#number").
+// Values maps field names to values: option names for single-select fields,
+// iteration titles for iteration fields, YYYY-MM-DD strings for dates, numbers
+// for number fields and strings for text fields.
+type ItemFixture struct {
+	ID        string         `json:"id"`
+	Content   string         `json:"content"`
+	UpdatedAt time.Time      `json:"updatedAt,omitempty"`
+	Values    map[string]any `json:"values,omitempty"`
+}
+
+// RepositoryFixture describes a repository and its issues
+type RepositoryFixture struct {
+	ID           string               `json:"id"`
+	Owner        string               `json:"owner"`
+	Name         string               `json:"name"`
+	Labels       []LabelFixture       `json:"labels,omitempty"`
+	Issues       []IssueFixture       `json:"issues,omitempty"`
+	PullRequests []PullRequestFixture `json:"pullRequests,omitempty"`
+}
+
+// LabelFixture is a repository label
+type LabelFixture struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Color string `json:"color,omitempty"`
+}
+
+// IssueFixture describes an issue. SubIssues lists child issue keys
+// ("owner/repo#number") and Labels lists label names.
+type IssueFixture struct {
+	ID        string           `json:"id"`
+	Number    int              `json:"number"`
+	Title     string           `json:"title"`
+	Body      string           `json:"body,omitempty"`
+	State     string           `json:"state,omitempty"`
+	UpdatedAt time.Time        `json:"updatedAt"`
+	IssueType string           `json:"issueType,omitempty"`
+	Assignees []string         `json:"assignees,omitempty"`
+	Labels    []string         `json:"labels,omitempty"`
+	SubIssues []string         `json:"subIssues,omitempty"`
+	Comments  []CommentFixture `json:"comments,omitempty"`
+}
+
+// CommentFixture is an issue comment
+type CommentFixture struct {
+	ID        string `json:"id"`
+	Author    string `json:"author"`
+	Body      string `json:"body"`
+	Minimized bool   `json:"minimized,omitempty"`
+}
+
+// PullRequestFixture describes a pull request
+type PullRequestFixture struct {
+	ID     string `json:"id"`
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+	Body   string `json:"body,omitempty"`
+	State  string `json:"state,omitempty"`
+	Author string `json:"author"`
+}
+
+// LoadFixture reads a JSON fixture from path
+func LoadFixture(path string) (*Fixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixture: %w", err)
+	}
+
+	var f Fixture
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse fixture %s: %w", path, err)
+	}
+	return &f, nil
+}
+
+// The server keeps its state in the model types below rather than the
+// fixture types, so lookups and mutations can work with pointers.
+
+type project struct {
+	id     string
+	number int
+	title  string
+	fields []*field
+	items  []*item
+}
+
+type field struct {
+	id         string
+	name       string
+	dataType   string
+	options    []OptionFixture
+	iterations []IterationFixture
+}
+
+type item struct {
+	id        string
+	content   *issue
+	updatedAt time.Time
+	values    map[string]*fieldValue // by field ID
+}
+
+// fieldValue holds exactly one of the typed values, matching the field's data type
+type fieldValue struct {
+	id          string
+	text        *string
+	number      *float64
+	date        *string
+	optionID    string
+	iterationID string
+}
+
+type repo struct {
+	id           string
+	owner        string
+	name         string
+	labels       []*label
+	issues       []*issue
+	pullRequests []*pullRequest
+}
+
+type label struct {
+	id    string
+	name  string
+	color string
+}
+
+type issue struct {
+	id        string
+	repo      *repo
+	number    int
+	title     string
+	body      string
+	state     string
+	updatedAt time.Time
+	issueType string
+	assignees []string
+	labels    []*label
+	subIssues []*issue
+	comments  []*comment
+}
+
+type comment struct {
+	id        string
+	author    string
+	body      string
+	minimized bool
+}
+
+type pullRequest struct {
+	id     string
+	repo   *repo
+	number int
+	title  string
+	body   string
+	state  string
+	author string
+}
+
+func issueKey(owner, repo string, number int) string {
+	return fmt.Sprintf("%s/%s#%d", owner, repo, number)
+}
+
+func (i *issue) key() string {
+	return issueKey(i.repo.owner, i.repo.name, i.number)
+}
+
+// build converts a fixture into server state, resolving cross references
+func (s *Server) build(f *Fixture) error {
+	s.org = f.Organization
+	s.project = &project{
+		id:     f.Project.ID,
+		number: f.Project.Number,
+		title:  f.Project.Title,
+	}
+	for _, ff := range f.Project.Fields {
+		s.project.fields = append(s.project.fields, &field{
+			id:         ff.ID,
+			name:       ff.Name,
+			dataType:   ff.DataType,
+			options:    ff.Options,
+			iterations: ff.Iterations,
+		})
+	}
+
+	issues := make(map[string]*issue)
+	subIssueKeys := make(map[*issue][]string)
+	for _, rf := range f.Repositories {
+		r := &repo{id: rf.ID, owner: rf.Owner, name: rf.Name}
+		for _, lf := range rf.Labels {
+			r.labels = append(r.labels, &label{id: lf.ID, name: lf.Name, color: lf.Color})
+		}
+		for _, inf := range rf.Issues {
+			state := inf.State
+			if state == "" {
+				state = "OPEN"
+			}
+			i := &issue{
+				id:        inf.ID,
+				repo:      r,
+				number:    inf.Number,
+				title:     inf.Title,
+				body:      inf.Body,
+				state:     state,
+				updatedAt: inf.UpdatedAt,
+				issueType: inf.IssueType,
+				assignees: inf.Assignees,
+			}
+			for _, name := range inf.Labels {
+				l := r.label(name)
+				if l == nil {
+					return fmt.Errorf("issue %s references unknown label %q", i.key(), name)
+				}
+				i.labels = append(i.labels, l)
+			}
+			for _, cf := range inf.Comments {
+				i.comments = append(i.comments, &comment{id: cf.ID, author: cf.Author, body: cf.Body, minimized: cf.Minimized})
+			}
+			r.issues = append(r.issues, i)
+			issues[i.key()] = i
+			subIssueKeys[i] = inf.SubIssues
+		}
+		for _, pf := range rf.PullRequests {
+			state := pf.State
+			if state == "" {
+				state = "OPEN"
+			}
+			r.pullRequests = append(r.pullRequests, &pullRequest{
+				id: pf.ID, repo: r, number: pf.Number, title: pf.Title, body: pf.Body, state: state, author: pf.Author,
+			})
+		}
+		s.repos = append(s.repos, r)
+	}
+
+	for parent, keys := range subIssueKeys {
+		for _, key := range keys {
+			child, ok := issues[key]
+			if !ok {
+				return fmt.Errorf("issue %s references unknown sub-issue %s", parent.key(), key)
+			}
+			parent.subIssues = append(parent.subIssues, child)
+		}
+	}
+
+	for _, itf := range f.Project.Items {
+		content, ok := issues[itf.Content]
+		if !ok {
+			return fmt.Errorf("project item %s references unknown issue %s", itf.ID, itf.Content)
+		}
+		it := &item{id: itf.ID, content: content, updatedAt: itf.UpdatedAt, values: make(map[string]*fieldValue)}
+		if it.updatedAt.IsZero() {
+			it.updatedAt = content.updatedAt
+		}
+		for name, raw := range itf.Values {
+			fld := s.project.fieldByName(name)
+			if fld == nil {
+				return fmt.Errorf("project item %s sets unknown field %q", itf.ID, name)
+			}
+			v, err := fld.valueFromFixture(raw)
+			if err != nil {
+				return fmt.Errorf("project item %s: %w", itf.ID, err)
+			}
+			v.id = s.newID("PVTFV")
+			it.values[fld.id] = v
+		}
+		s.project.items = append(s.project.items, it)
+	}
+
+	return nil
+}
+
+func (r *repo) label(name string) *label {
+	for _, l := range r.labels {
+		if l.name == name {
+			return l
+		}
+	}
+	return nil
+}
+
+func (p *project) fieldByName(name string) *field {
+	for _, f := range p.fields {
+		if f.name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+func (f *field) valueFromFixture(raw any) (*fieldValue, error) {
+	switch f.dataType {
+	case "SINGLE_SELECT":
+		name, _ := raw.(string)
+		for _, o := range f.options {
+			if o.Name == name {
+				return &fieldValue{optionID: o.ID}, nil
+			}
+		}
+		return nil, fmt.Errorf("field %q has no option %q", f.name, name)
+	case "ITERATION":
+		title, _ := raw.(string)
+		for _, it := range f.iterations {
+			if it.Title == title {
+				return &fieldValue{iterationID: it.ID}, nil
+			}
+		}
+		return nil, fmt.Errorf("field %q has no iteration %q", f.name, title)
+	case "NUMBER":
+		n, ok := raw.(float64)
+		if !ok {
+			return nil, fmt.Errorf("field %q expects a number", f.name)
+		}
+		return &fieldValue{number: &n}, nil
+	case "DATE":
+		d, ok := raw.(string)
+		if !ok {
+			return nil, fmt.Errorf("field %q expects a YYYY-MM-DD string", f.name)
+		}
+		return &fieldValue{date: &d}, nil
+	default:
+		t, ok := raw.(string)
+		if !ok {
+			return nil, fmt.Errorf("field %q expects a string", f.name)
+		}
+		return &fieldValue{text: &t}, nil
+	}
+}
+
+// fixtureValue converts a stored value back to its fixture representation
+func (f *field) fixtureValue(v *fieldValue) any {
+	switch {
+	case v.optionID != "":
+		for _, o := range f.options {
+			if o.ID == v.optionID {
+				return o.Name
+			}
+		}
+	case v.iterationID != "":
+		for _, it := range f.iterations {
+			if it.ID == v.iterationID {
+				return it.Title
+			}
+		}
+	case v.number != nil:
+		return *v.number
+	case v.date != nil:
+		return *v.date
+	case v.text != nil:
+		return *v.text
+	}
+	return nil
+}
+
+// Snapshot returns the current server state as a fixture, reflecting every
+// mutation applied so far
+func (s *Server) Snapshot() *Fixture {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f := &Fixture{
+		Organization: s.org,
+		Project: ProjectFixture{
+			ID:     s.project.id,
+			Number: s.project.number,
+			Title:  s.project.title,
+		},
+	}
+	for _, fld := range s.project.fields {
+		f.Project.Fields = append(f.Project.Fields, FieldFixture{
+			ID: fld.id, Name: fld.name, DataType: fld.dataType, Options: fld.options, Iterations: fld.iterations,
+		})
+	}
+	for _, it := range s.project.items {
+		itf := ItemFixture{ID: it.id, Content: it.content.key(), UpdatedAt: it.updatedAt, Values: make(map[string]any)}
+		for _, fld := range s.project.fields {
+			if v, ok := it.values[fld.id]; ok {
+				itf.Values[fld.name] = fld.fixtureValue(v)
+			}
+		}
+		f.Project.Items = append(f.Project.Items, itf)
+	}
+
+	for _, r := range s.repos {
+		rf := RepositoryFixture{ID: r.id, Owner: r.owner, Name: r.name}
+		for _, l := range r.labels {
+			rf.Labels = append(rf.Labels, LabelFixture{ID: l.id, Name: l.name, Color: l.color})
+		}
+		for _, i := range r.issues {
+			inf := IssueFixture{
+				ID: i.id, Number: i.number, Title: i.title, Body: i.body, State: i.state,
+				UpdatedAt: i.updatedAt, IssueType: i.issueType, Assignees: i.assignees,
+			}
+			for _, l := range i.labels {
+				inf.Labels = append(inf.Labels, l.name)
+			}
+			for _, sub := range i.subIssues {
+				inf.SubIssues = append(inf.SubIssues, sub.key())
+			}
+			for _, c := range i.comments {
+				inf.Comments = append(inf.Comments, CommentFixture{ID: c.id, Author: c.author, Body: c.body, Minimized: c.minimized})
+			}
+			rf.Issues = append(rf.Issues, inf)
+		}
+		for _, pr := range r.pullRequests {
+			rf.PullRequests = append(rf.PullRequests, PullRequestFixture{
+				ID: pr.id, Number: pr.number, Title: pr.title, Body: pr.body, State: pr.state, Author: pr.author,
+			})
+		}
+		f.Repositories = append(f.Repositories, rf)
+	}
+
+	return f
+}
+
+// ItemValues returns the project field values of the item for an issue key
+// ("owner/repo#number"), keyed by field name
+func (s *Server) ItemValues(key string) (map[string]any, bool) {
+	snap := s.Snapshot()
+	for _, it := range snap.Project.Items {
+		if it.Content == key {
+			return it.Values, true
+		}
+	}
+	return nil, false
+}
+
+// sortedFieldIDs returns value field IDs in the project's field order
+func (s *Server) sortedFieldIDs(values map[string]*fieldValue) []string {
+	order := make(map[string]int)
+	for i, f := range s.project.fields {
+		order[f.id] = i
+	}
+	ids := make([]string, 0, len(values))
+	for id := range values {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(a, b int) bool { return order[ids[a]] < order[ids[b]] })
+	return ids
+}
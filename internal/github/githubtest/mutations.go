@@ -0,0 +1,338 @@
+package githubtest
+
+import (
+	"fmt"
+)
+
+func (s *Server) mutationRoot() *object {
+	root := newObject("Mutation")
+	for name, apply := range map[string]func(in map[string]any) (*object, error){
+		"addProjectV2ItemById":          s.addProjectItem,
+		"updateProjectV2ItemFieldValue": s.updateFieldValue,
+		"clearProjectV2ItemFieldValue":  s.clearFieldValue,
+		"addComment":                    s.addComment,
+		"createLabel":                   s.createLabel,
+		"addLabelsToLabelable":          s.addLabels,
+		"removeLabelsFromLabelable":     s.removeLabels,
+		"deleteProjectV2Item":           s.deleteProjectItem,
+		"deleteIssueComment":            s.deleteComment,
+		"minimizeComment":               s.minimizeComment,
+		"unminimizeComment":             s.unminimizeComment,
+		"updateIssueComment":            s.updateComment,
+	} {
+		root.resolve(name, func(args map[string]any) (any, error) {
+			in := argInput(args)
+			obj, err := apply(in)
+			if err != nil {
+				return nil, err
+			}
+			s.mutations = append(s.mutations, Mutation{Name: name, Input: in})
+			return obj.set("clientMutationId", in["clientMutationId"]), nil
+		})
+	}
+	return root
+}
+
+func (s *Server) findItem(id string) *item {
+	for _, it := range s.project.items {
+		if it.id == id {
+			return it
+		}
+	}
+	return nil
+}
+
+func (s *Server) findIssueByID(id string) *issue {
+	for _, r := range s.repos {
+		for _, i := range r.issues {
+			if i.id == id {
+				return i
+			}
+		}
+	}
+	return nil
+}
+
+// projectItemTarget validates the project, item and field IDs shared by the
+// field value mutations
+func (s *Server) projectItemTarget(in map[string]any) (*item, *field, error) {
+	if pid := argString(in, "projectId"); pid != s.project.id {
+		return nil, nil, notFound("Could not resolve to a ProjectV2 with the global id of '%s'", pid)
+	}
+	it := s.findItem(argString(in, "itemId"))
+	if it == nil {
+		return nil, nil, notFound("Could not resolve to a ProjectV2Item with the global id of '%s'", argString(in, "itemId"))
+	}
+	f := s.fieldByID(argString(in, "fieldId"))
+	if f == nil {
+		return nil, nil, notFound("Could not resolve to a ProjectV2Field with the global id of '%s'", argString(in, "fieldId"))
+	}
+	return it, f, nil
+}
+
+func (s *Server) addProjectItem(in map[string]any) (*object, error) {
+	if pid := argString(in, "projectId"); pid != s.project.id {
+		return nil, notFound("Could not resolve to a ProjectV2 with the global id of '%s'", pid)
+	}
+	content := s.findIssueByID(argString(in, "contentId"))
+	if content == nil {
+		return nil, notFound("Could not resolve to a node with the global id of '%s'", argString(in, "contentId"))
+	}
+
+	for _, it := range s.project.items {
+		if it.content == content {
+			return newObject("AddProjectV2ItemByIdPayload").set("item", s.itemObject(it)), nil
+		}
+	}
+
+	it := &item{
+		id:        s.newID("PVTI"),
+		content:   content,
+		updatedAt: s.Now(),
+		values:    make(map[string]*fieldValue),
+	}
+	s.project.items = append(s.project.items, it)
+	return newObject("AddProjectV2ItemByIdPayload").set("item", s.itemObject(it)), nil
+}
+
+func (s *Server) updateFieldValue(in map[string]any) (*object, error) {
+	it, f, err := s.projectItemTarget(in)
+	if err != nil {
+		return nil, err
+	}
+	value, _ := in["value"].(map[string]any)
+
+	v := &fieldValue{id: s.newID("PVTFV")}
+	switch f.dataType {
+	case "SINGLE_SELECT":
+		id := argString(value, "singleSelectOptionId")
+		for _, o := range f.options {
+			if o.ID == id {
+				v.optionID = id
+			}
+		}
+		if v.optionID == "" {
+			return nil, fmt.Errorf("The single select option Id does not belong to the field")
+		}
+	case "ITERATION":
+		id := argString(value, "iterationId")
+		for _, iter := range f.iterations {
+			if iter.ID == id {
+				v.iterationID = id
+			}
+		}
+		if v.iterationID == "" {
+			return nil, fmt.Errorf("The iteration Id does not belong to the field")
+		}
+	case "NUMBER":
+		n, ok := value["number"].(float64)
+		if !ok {
+			return nil, fmt.Errorf("A number value is required for field %q", f.name)
+		}
+		v.number = &n
+	case "DATE":
+		d := argString(value, "date")
+		if d == "" {
+			return nil, fmt.Errorf("A date value is required for field %q", f.name)
+		}
+		v.date = &d
+	case "TEXT":
+		t, ok := value["text"].(string)
+		if !ok {
+			return nil, fmt.Errorf("A text value is required for field %q", f.name)
+		}
+		v.text = &t
+	default:
+		return nil, fmt.Errorf("Field %q of type %s cannot be updated with this mutation", f.name, f.dataType)
+	}
+
+	it.values[f.id] = v
+	it.updatedAt = s.Now()
+	return newObject("UpdateProjectV2ItemFieldValuePayload").set("projectV2Item", s.itemObject(it)), nil
+}
+
+func (s *Server) clearFieldValue(in map[string]any) (*object, error) {
+	it, f, err := s.projectItemTarget(in)
+	if err != nil {
+		return nil, err
+	}
+	delete(it.values, f.id)
+	it.updatedAt = s.Now()
+	return newObject("ClearProjectV2ItemFieldValuePayload").set("projectV2Item", s.itemObject(it)), nil
+}
+
+func (s *Server) addComment(in map[string]any) (*object, error) {
+	target := s.findIssueByID(argString(in, "subjectId"))
+	if target == nil {
+		return nil, notFound("Could not resolve to a node with the global id of '%s'", argString(in, "subjectId"))
+	}
+	c := &comment{id: s.newID("IC"), author: s.Viewer, body: argString(in, "body")}
+	target.comments = append(target.comments, c)
+	target.updatedAt = s.Now()
+
+	node := s.commentObject(target, c)
+	return newObject("AddCommentPayload").
+		set("commentEdge", newObject("IssueCommentEdge").set("node", node)).
+		set("subject", s.issueObject(target)), nil
+}
+
+// findComment returns a comment and the issue it is on
+func (s *Server) findComment(id string) (*issue, *comment) {
+	for _, r := range s.repos {
+		for _, i := range r.issues {
+			for _, c := range i.comments {
+				if c.id == id {
+					return i, c
+				}
+			}
+		}
+	}
+	return nil, nil
+}
+
+func (s *Server) deleteComment(in map[string]any) (*object, error) {
+	target, c := s.findComment(argString(in, "id"))
+	if c == nil {
+		return nil, notFound("Could not resolve to a node with the global id of '%s'", argString(in, "id"))
+	}
+	kept := target.comments[:0]
+	for _, existing := range target.comments {
+		if existing != c {
+			kept = append(kept, existing)
+		}
+	}
+	target.comments = kept
+	return newObject("DeleteIssueCommentPayload"), nil
+}
+
+func (s *Server) minimizeComment(in map[string]any) (*object, error) {
+	target, c := s.findComment(argString(in, "subjectId"))
+	if c == nil {
+		return nil, notFound("Could not resolve to a node with the global id of '%s'", argString(in, "subjectId"))
+	}
+	c.minimized = true
+	return newObject("MinimizeCommentPayload").set("minimizedComment", s.commentObject(target, c)), nil
+}
+
+func (s *Server) unminimizeComment(in map[string]any) (*object, error) {
+	target, c := s.findComment(argString(in, "subjectId"))
+	if c == nil {
+		return nil, notFound("Could not resolve to a node with the global id of '%s'", argString(in, "subjectId"))
+	}
+	c.minimized = false
+	return newObject("UnminimizeCommentPayload").set("unminimizedComment", s.commentObject(target, c)), nil
+}
+
+func (s *Server) updateComment(in map[string]any) (*object, error) {
+	target, c := s.findComment(argString(in, "id"))
+	if c == nil {
+		return nil, notFound("Could not resolve to a node with the global id of '%s'", argString(in, "id"))
+	}
+	if c.author != s.Viewer {
+		return nil, fmt.Errorf("Resource not accessible by integration")
+	}
+	c.body = argString(in, "body")
+	target.updatedAt = s.Now()
+	return newObject("UpdateIssueCommentPayload").set("issueComment", s.commentObject(target, c)), nil
+}
+
+func (s *Server) deleteProjectItem(in map[string]any) (*object, error) {
+	if pid := argString(in, "projectId"); pid != s.project.id {
+		return nil, notFound("Could not resolve to a ProjectV2 with the global id of '%s'", pid)
+	}
+	it := s.findItem(argString(in, "itemId"))
+	if it == nil {
+		return nil, notFound("Could not resolve to a ProjectV2Item with the global id of '%s'", argString(in, "itemId"))
+	}
+	kept := s.project.items[:0]
+	for _, existing := range s.project.items {
+		if existing != it {
+			kept = append(kept, existing)
+		}
+	}
+	s.project.items = kept
+	return newObject("DeleteProjectV2ItemPayload").set("deletedItemId", it.id), nil
+}
+
+func (s *Server) createLabel(in map[string]any) (*object, error) {
+	var r *repo
+	for _, candidate := range s.repos {
+		if candidate.id == argString(in, "repositoryId") {
+			r = candidate
+		}
+	}
+	if r == nil {
+		return nil, notFound("Could not resolve to a Repository with the global id of '%s'", argString(in, "repositoryId"))
+	}
+	name := argString(in, "name")
+	if r.label(name) != nil {
+		return nil, fmt.Errorf("Name has already been taken")
+	}
+	l := &label{id: s.newID("LA"), name: name, color: argString(in, "color")}
+	r.labels = append(r.labels, l)
+	return newObject("CreateLabelPayload").set("label", labelObject(l)), nil
+}
+
+// labelTargets resolves the labelable and label IDs shared by the label mutations
+func (s *Server) labelTargets(in map[string]any) (*issue, []*label, error) {
+	target := s.findIssueByID(argString(in, "labelableId"))
+	if target == nil {
+		return nil, nil, notFound("Could not resolve to a node with the global id of '%s'", argString(in, "labelableId"))
+	}
+	ids, _ := in["labelIds"].([]any)
+	var labels []*label
+	for _, raw := range ids {
+		id := fmt.Sprint(raw)
+		var found *label
+		for _, l := range target.repo.labels {
+			if l.id == id {
+				found = l
+			}
+		}
+		if found == nil {
+			return nil, nil, notFound("Could not resolve to a Label with the global id of '%s'", id)
+		}
+		labels = append(labels, found)
+	}
+	return target, labels, nil
+}
+
+func (s *Server) addLabels(in map[string]any) (*object, error) {
+	target, labels, err := s.labelTargets(in)
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range labels {
+		present := false
+		for _, existing := range target.labels {
+			if existing == l {
+				present = true
+			}
+		}
+		if !present {
+			target.labels = append(target.labels, l)
+		}
+	}
+	target.updatedAt = s.Now()
+	return newObject("AddLabelsToLabelablePayload").set("labelable", s.issueObject(target)), nil
+}
+
+func (s *Server) removeLabels(in map[string]any) (*object, error) {
+	target, labels, err := s.labelTargets(in)
+	if err != nil {
+		return nil, err
+	}
+	remove := make(map[*label]bool)
+	for _, l := range labels {
+		remove[l] = true
+	}
+	kept := target.labels[:0]
+	for _, l := range target.labels {
+		if !remove[l] {
+			kept = append(kept, l)
+		}
+	}
+	target.labels = kept
+	target.updatedAt = s.Now()
+	return newObject("RemoveLabelsFromLabelablePayload").set("labelable", s.issueObject(target)), nil
+}
@@ -0,0 +1,358 @@
+package githubtest
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// operation is a parsed GraphQL request document
+type operation struct {
+	Kind       string // "query" or "mutation"
+	Selections []selection
+}
+
+// selection is a field or an inline fragment within a selection set
+type selection struct {
+	Alias         string
+	Name          string
+	Args          map[string]any // values are already resolved against the request variables
+	Selections    []selection
+	TypeCondition string // set for inline fragments ("... on Type")
+}
+
+// key returns the response key for a field selection
+func (s selection) key() string {
+	if s.Alias != "" {
+		return s.Alias
+	}
+	return s.Name
+}
+
+// parser is a small recursive-descent parser for the subset of GraphQL that
+// githubv4 and the batch layer generate: a single anonymous operation with
+// variable definitions, aliases, arguments and inline fragments.
+type parser struct {
+	src       string
+	pos       int
+	variables map[string]any
+}
+
+func parseOperation(src string, variables map[string]any) (*operation, error) {
+	p := &parser{src: src, variables: variables}
+	op := &operation{Kind: "query"}
+
+	p.skipIgnored()
+	if name := p.peekName(); name == "query" || name == "mutation" {
+		p.readName()
+		op.Kind = name
+		p.skipIgnored()
+		if p.peekName() != "" {
+			p.readName() // operation name, unused
+			p.skipIgnored()
+		}
+		if p.peek() == '(' {
+			if err := p.skipVariableDefinitions(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	sels, err := p.parseSelectionSet()
+	if err != nil {
+		return nil, err
+	}
+	op.Selections = sels
+
+	p.skipIgnored()
+	if p.pos < len(p.src) {
+		return nil, p.errorf("unexpected trailing input")
+	}
+	return op, nil
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return fmt.Errorf("parse error at offset %d: %s", p.pos, fmt.Sprintf(format, args...))
+}
+
+// skipIgnored skips whitespace, commas and comments, which GraphQL treats as insignificant
+func (p *parser) skipIgnored() {
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			p.pos++
+		case c == '#':
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+				p.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (p *parser) peek() byte {
+	if p.pos >= len(p.src) {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *parser) expect(c byte) error {
+	p.skipIgnored()
+	if p.peek() != c {
+		return p.errorf("expected %q", c)
+	}
+	p.pos++
+	return nil
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || unicode.IsLetter(rune(c))
+}
+
+func isNameChar(c byte) bool {
+	return isNameStart(c) || (c >= '0' && c <= '9')
+}
+
+func (p *parser) peekName() string {
+	end := p.pos
+	if end >= len(p.src) || !isNameStart(p.src[end]) {
+		return ""
+	}
+	for end < len(p.src) && isNameChar(p.src[end]) {
+		end++
+	}
+	return p.src[p.pos:end]
+}
+
+func (p *parser) readName() string {
+	name := p.peekName()
+	p.pos += len(name)
+	return name
+}
+
+// skipVariableDefinitions skips "($a:Int!$b:[ID!])"; the values come from the variables map
+func (p *parser) skipVariableDefinitions() error {
+	depth := 0
+	for p.pos < len(p.src) {
+		switch p.src[p.pos] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				p.pos++
+				p.skipIgnored()
+				return nil
+			}
+		}
+		p.pos++
+	}
+	return p.errorf("unterminated variable definitions")
+}
+
+func (p *parser) parseSelectionSet() ([]selection, error) {
+	if err := p.expect('{'); err != nil {
+		return nil, err
+	}
+
+	var sels []selection
+	for {
+		p.skipIgnored()
+		if p.peek() == '}' {
+			p.pos++
+			return sels, nil
+		}
+		if p.pos >= len(p.src) {
+			return nil, p.errorf("unterminated selection set")
+		}
+
+		if strings.HasPrefix(p.src[p.pos:], "...") {
+			p.pos += 3
+			p.skipIgnored()
+			if p.readName() != "on" {
+				return nil, p.errorf("only inline fragments are supported")
+			}
+			p.skipIgnored()
+			typeName := p.readName()
+			if typeName == "" {
+				return nil, p.errorf("expected type condition")
+			}
+			inner, err := p.parseSelectionSet()
+			if err != nil {
+				return nil, err
+			}
+			sels = append(sels, selection{TypeCondition: typeName, Selections: inner})
+			continue
+		}
+
+		sel, err := p.parseField()
+		if err != nil {
+			return nil, err
+		}
+		sels = append(sels, sel)
+	}
+}
+
+func (p *parser) parseField() (selection, error) {
+	var sel selection
+
+	name := p.readName()
+	if name == "" {
+		return sel, p.errorf("expected field name")
+	}
+	p.skipIgnored()
+	if p.peek() == ':' {
+		p.pos++
+		p.skipIgnored()
+		sel.Alias = name
+		name = p.readName()
+		if name == "" {
+			return sel, p.errorf("expected field name after alias %q", sel.Alias)
+		}
+		p.skipIgnored()
+	}
+	sel.Name = name
+
+	if p.peek() == '(' {
+		args, err := p.parseArguments()
+		if err != nil {
+			return sel, err
+		}
+		sel.Args = args
+		p.skipIgnored()
+	}
+
+	if p.peek() == '{' {
+		inner, err := p.parseSelectionSet()
+		if err != nil {
+			return sel, err
+		}
+		sel.Selections = inner
+	}
+	return sel, nil
+}
+
+func (p *parser) parseArguments() (map[string]any, error) {
+	if err := p.expect('('); err != nil {
+		return nil, err
+	}
+	args := make(map[string]any)
+	for {
+		p.skipIgnored()
+		if p.peek() == ')' {
+			p.pos++
+			return args, nil
+		}
+		name := p.readName()
+		if name == "" {
+			return nil, p.errorf("expected argument name")
+		}
+		if err := p.expect(':'); err != nil {
+			return nil, err
+		}
+		p.skipIgnored()
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		args[name] = value
+	}
+}
+
+func (p *parser) parseValue() (any, error) {
+	p.skipIgnored()
+	c := p.peek()
+	switch {
+	case c == '$':
+		p.pos++
+		return p.variables[p.readName()], nil
+	case c == '"':
+		return p.parseString()
+	case c == '[':
+		p.pos++
+		var list []any
+		for {
+			p.skipIgnored()
+			if p.peek() == ']' {
+				p.pos++
+				return list, nil
+			}
+			v, err := p.parseValue()
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+	case c == '{':
+		p.pos++
+		obj := make(map[string]any)
+		for {
+			p.skipIgnored()
+			if p.peek() == '}' {
+				p.pos++
+				return obj, nil
+			}
+			name := p.readName()
+			if err := p.expect(':'); err != nil {
+				return nil, err
+			}
+			v, err := p.parseValue()
+			if err != nil {
+				return nil, err
+			}
+			obj[name] = v
+		}
+	case c == '-' || (c >= '0' && c <= '9'):
+		start := p.pos
+		p.pos++
+		for p.pos < len(p.src) && strings.IndexByte("0123456789.eE+-", p.src[p.pos]) >= 0 {
+			p.pos++
+		}
+		lit := p.src[start:p.pos]
+		if n, err := strconv.Atoi(lit); err == nil {
+			return float64(n), nil
+		}
+		f, err := strconv.ParseFloat(lit, 64)
+		if err != nil {
+			return nil, p.errorf("invalid number %q", lit)
+		}
+		return f, nil
+	case isNameStart(c):
+		switch name := p.readName(); name {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		default:
+			return name, nil // enum value
+		}
+	}
+	return nil, p.errorf("unexpected character %q", c)
+}
+
+func (p *parser) parseString() (string, error) {
+	start := p.pos
+	p.pos++
+	for p.pos < len(p.src) {
+		switch p.src[p.pos] {
+		case '\\':
+			p.pos += 2
+		case '"':
+			p.pos++
+			s, err := strconv.Unquote(p.src[start:p.pos])
+			if err != nil {
+				return "", p.errorf("invalid string literal: %v", err)
+			}
+			return s, nil
+		default:
+			p.pos++
+		}
+	}
+	return "", p.errorf("unterminated string")
+}
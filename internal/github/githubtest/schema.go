@@ -0,0 +1,433 @@
+package githubtest
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+func (s *Server) queryRoot() *object {
+	return newObject("Query").
+		resolve("organization", func(args map[string]any) (any, error) {
+			login := argString(args, "login")
+			if !strings.EqualFold(login, s.org) {
+				return nil, notFound("Could not resolve to an Organization with the login of '%s'.", login)
+			}
+			return s.organizationObject(), nil
+		}).
+		resolve("repository", func(args map[string]any) (any, error) {
+			owner, name := argString(args, "owner"), argString(args, "name")
+			r := s.findRepo(owner, name)
+			if r == nil {
+				return nil, notFound("Could not resolve to a Repository with the name '%s/%s'.", owner, name)
+			}
+			return s.repoObject(r), nil
+		}).
+		resolve("node", func(args map[string]any) (any, error) {
+			id := argString(args, "id")
+			obj := s.nodeObject(id)
+			if obj == nil {
+				return nil, notFound("Could not resolve to a node with the global id of '%s'", id)
+			}
+			return obj, nil
+		}).
+		resolve("viewer", func(map[string]any) (any, error) {
+			return newObject("User").set("login", s.Viewer), nil
+		}).
+		resolve("rateLimit", func(map[string]any) (any, error) {
+			return newObject("RateLimit").
+				set("cost", 1).
+				set("limit", s.RateLimit).
+				set("remaining", s.rateLimitRemaining()).
+				set("used", s.rateLimitUsed).
+				set("resetAt", s.RateLimitReset.UTC().Format(time.RFC3339)), nil
+		})
+}
+
+func (s *Server) findRepo(owner, name string) *repo {
+	for _, r := range s.repos {
+		if strings.EqualFold(r.owner, owner) && strings.EqualFold(r.name, name) {
+			return r
+		}
+	}
+	return nil
+}
+
+func (s *Server) findIssue(key string) *issue {
+	for _, r := range s.repos {
+		for _, i := range r.issues {
+			if i.key() == key {
+				return i
+			}
+		}
+	}
+	return nil
+}
+
+// nodeObject resolves a global node ID to its object, or nil
+func (s *Server) nodeObject(id string) *object {
+	if id == "" {
+		return nil
+	}
+	if s.project.id == id {
+		return s.projectObject()
+	}
+	for _, f := range s.project.fields {
+		if f.id == id {
+			return s.fieldObject(f)
+		}
+	}
+	for _, it := range s.project.items {
+		if it.id == id {
+			return s.itemObject(it)
+		}
+	}
+	for _, r := range s.repos {
+		if r.id == id {
+			return s.repoObject(r)
+		}
+		for _, l := range r.labels {
+			if l.id == id {
+				return labelObject(l)
+			}
+		}
+		for _, i := range r.issues {
+			if i.id == id {
+				return s.issueObject(i)
+			}
+			for _, c := range i.comments {
+				if c.id == id {
+					return s.commentObject(i, c)
+				}
+			}
+		}
+		for _, pr := range r.pullRequests {
+			if pr.id == id {
+				return s.pullRequestObject(pr)
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Server) organizationObject() *object {
+	return newObject("Organization", "Node").
+		set("login", s.org).
+		resolve("projectV2", func(args map[string]any) (any, error) {
+			n, _ := argInt(args, "number")
+			if n != s.project.number {
+				return nil, notFound("Could not resolve to a ProjectV2 with the number %d.", n)
+			}
+			return s.projectObject(), nil
+		}).
+		resolve("repositories", func(args map[string]any) (any, error) {
+			var nodes []*object
+			for _, r := range s.repos {
+				if strings.EqualFold(r.owner, s.org) {
+					nodes = append(nodes, s.repoObject(r))
+				}
+			}
+			return connection(nodes, args)
+		})
+}
+
+func (s *Server) projectURL() string {
+	return fmt.Sprintf("https://github.com/orgs/%s/projects/%d", s.org, s.project.number)
+}
+
+func (s *Server) projectObject() *object {
+	p := s.project
+	return newObject("ProjectV2", "Node").
+		set("id", p.id).
+		set("number", p.number).
+		set("title", p.title).
+		set("url", s.projectURL()).
+		resolve("fields", func(args map[string]any) (any, error) {
+			var nodes []*object
+			for _, f := range p.fields {
+				nodes = append(nodes, s.fieldObject(f))
+			}
+			return connection(nodes, args)
+		}).
+		resolve("field", func(args map[string]any) (any, error) {
+			if f := p.fieldByName(argString(args, "name")); f != nil {
+				return s.fieldObject(f), nil
+			}
+			return nil, nil
+		}).
+		resolve("items", func(args map[string]any) (any, error) {
+			var nodes []*object
+			for _, it := range p.items {
+				nodes = append(nodes, s.itemObject(it))
+			}
+			return connection(nodes, args)
+		})
+}
+
+// fieldTypeName maps a field data type to its GraphQL object type
+func fieldTypeName(dataType string) string {
+	switch dataType {
+	case "SINGLE_SELECT":
+		return "ProjectV2SingleSelectField"
+	case "ITERATION":
+		return "ProjectV2IterationField"
+	default:
+		return "ProjectV2Field"
+	}
+}
+
+func (s *Server) fieldObject(f *field) *object {
+	obj := newObject(fieldTypeName(f.dataType), "ProjectV2FieldCommon", "ProjectV2FieldConfiguration", "Node").
+		set("id", f.id).
+		set("name", f.name).
+		set("dataType", f.dataType)
+
+	if f.dataType == "SINGLE_SELECT" {
+		var options []*object
+		for _, o := range f.options {
+			options = append(options, newObject("ProjectV2SingleSelectFieldOption").set("id", o.ID).set("name", o.Name))
+		}
+		obj.set("options", options)
+	}
+
+	if f.dataType == "ITERATION" {
+		var iterations []*object
+		for _, it := range f.iterations {
+			iterations = append(iterations, newObject("ProjectV2IterationFieldIteration").
+				set("id", it.ID).
+				set("title", it.Title).
+				set("startDate", it.StartDate).
+				set("duration", it.Duration))
+		}
+		obj.set("configuration", newObject("ProjectV2IterationFieldConfiguration").
+			set("iterations", iterations).
+			set("completedIterations", []*object{}))
+	}
+
+	return obj
+}
+
+func (s *Server) fieldByID(id string) *field {
+	for _, f := range s.project.fields {
+		if f.id == id {
+			return f
+		}
+	}
+	return nil
+}
+
+func (s *Server) itemObject(it *item) *object {
+	return newObject("ProjectV2Item", "Node").
+		set("id", it.id).
+		set("type", "ISSUE").
+		set("isArchived", false).
+		set("updatedAt", it.updatedAt.UTC().Format(time.RFC3339)).
+		resolve("project", func(map[string]any) (any, error) {
+			return s.projectObject(), nil
+		}).
+		resolve("content", func(map[string]any) (any, error) {
+			return s.issueObject(it.content), nil
+		}).
+		resolve("fieldValueByName", func(args map[string]any) (any, error) {
+			f := s.project.fieldByName(argString(args, "name"))
+			if f == nil {
+				return nil, nil
+			}
+			v, ok := it.values[f.id]
+			if !ok {
+				return nil, nil
+			}
+			return s.fieldValueObject(it, f, v), nil
+		}).
+		resolve("fieldValues", func(args map[string]any) (any, error) {
+			var nodes []*object
+			for _, id := range s.sortedFieldIDs(it.values) {
+				nodes = append(nodes, s.fieldValueObject(it, s.fieldByID(id), it.values[id]))
+			}
+			return connection(nodes, args)
+		})
+}
+
+func (s *Server) fieldValueObject(it *item, f *field, v *fieldValue) *object {
+	var obj *object
+	switch {
+	case v.optionID != "":
+		obj = newObject("ProjectV2ItemFieldSingleSelectValue").set("optionId", v.optionID)
+		for _, o := range f.options {
+			if o.ID == v.optionID {
+				obj.set("name", o.Name)
+			}
+		}
+	case v.iterationID != "":
+		obj = newObject("ProjectV2ItemFieldIterationValue").set("iterationId", v.iterationID)
+		for _, iter := range f.iterations {
+			if iter.ID == v.iterationID {
+				obj.set("title", iter.Title).set("startDate", iter.StartDate).set("duration", iter.Duration)
+			}
+		}
+	case v.number != nil:
+		obj = newObject("ProjectV2ItemFieldNumberValue").set("number", *v.number)
+	case v.date != nil:
+		obj = newObject("ProjectV2ItemFieldDateValue").set("date", *v.date)
+	default:
+		text := ""
+		if v.text != nil {
+			text = *v.text
+		}
+		obj = newObject("ProjectV2ItemFieldTextValue").set("text", text)
+	}
+
+	obj.types = append(obj.types, "ProjectV2ItemFieldValue", "ProjectV2ItemFieldValueCommon", "Node")
+	return obj.
+		set("id", v.id).
+		set("updatedAt", it.updatedAt.UTC().Format(time.RFC3339)).
+		resolve("field", func(map[string]any) (any, error) {
+			return s.fieldObject(f), nil
+		}).
+		resolve("item", func(map[string]any) (any, error) {
+			return s.itemObject(it), nil
+		})
+}
+
+func (s *Server) repoObject(r *repo) *object {
+	return newObject("Repository", "Node").
+		set("id", r.id).
+		set("name", r.name).
+		set("nameWithOwner", r.owner+"/"+r.name).
+		set("url", fmt.Sprintf("https://github.com/%s/%s", r.owner, r.name)).
+		set("owner", newObject("Organization", "RepositoryOwner").set("login", r.owner)).
+		set("defaultBranchRef", newObject("Ref").set("name", "main")).
+		resolve("issue", func(args map[string]any) (any, error) {
+			n, _ := argInt(args, "number")
+			for _, i := range r.issues {
+				if i.number == n {
+					return s.issueObject(i), nil
+				}
+			}
+			return nil, notFound("Could not resolve to an Issue with the number of %d.", n)
+		}).
+		resolve("label", func(args map[string]any) (any, error) {
+			if l := r.label(argString(args, "name")); l != nil {
+				return labelObject(l), nil
+			}
+			return nil, nil
+		}).
+		resolve("labels", func(args map[string]any) (any, error) {
+			var nodes []*object
+			for _, l := range r.labels {
+				nodes = append(nodes, labelObject(l))
+			}
+			return connection(nodes, args)
+		}).
+		resolve("pullRequests", func(args map[string]any) (any, error) {
+			states := map[string]bool{}
+			if list, ok := args["states"].([]any); ok {
+				for _, st := range list {
+					states[fmt.Sprint(st)] = true
+				}
+			} else if st, ok := args["states"].(string); ok {
+				states[st] = true
+			}
+			var nodes []*object
+			for _, pr := range r.pullRequests {
+				if len(states) == 0 || states[pr.state] {
+					nodes = append(nodes, s.pullRequestObject(pr))
+				}
+			}
+			return connection(nodes, args)
+		})
+}
+
+func labelObject(l *label) *object {
+	return newObject("Label", "Node").set("id", l.id).set("name", l.name).set("color", l.color)
+}
+
+func userObject(login string) *object {
+	return newObject("User", "Actor", "Node").set("login", login)
+}
+
+func (s *Server) issueObject(i *issue) *object {
+	obj := newObject("Issue", "Node", "Labelable", "Comment", "Assignable", "ProjectV2ItemContent").
+		set("id", i.id).
+		set("number", i.number).
+		set("title", i.title).
+		set("body", i.body).
+		set("state", i.state).
+		set("closed", i.state == "CLOSED").
+		set("url", fmt.Sprintf("https://github.com/%s/%s/issues/%d", i.repo.owner, i.repo.name, i.number)).
+		set("updatedAt", i.updatedAt.UTC().Format(time.RFC3339)).
+		resolve("repository", func(map[string]any) (any, error) {
+			return s.repoObject(i.repo), nil
+		}).
+		resolve("issueType", func(map[string]any) (any, error) {
+			if i.issueType == "" {
+				return nil, nil
+			}
+			return newObject("IssueType").set("name", i.issueType), nil
+		}).
+		resolve("assignees", func(args map[string]any) (any, error) {
+			var nodes []*object
+			for _, login := range i.assignees {
+				nodes = append(nodes, userObject(login))
+			}
+			return connection(nodes, args)
+		}).
+		resolve("labels", func(args map[string]any) (any, error) {
+			var nodes []*object
+			for _, l := range i.labels {
+				nodes = append(nodes, labelObject(l))
+			}
+			return connection(nodes, args)
+		}).
+		resolve("subIssues", func(args map[string]any) (any, error) {
+			var nodes []*object
+			for _, sub := range i.subIssues {
+				nodes = append(nodes, s.issueObject(sub))
+			}
+			return connection(nodes, args)
+		}).
+		resolve("projectItems", func(args map[string]any) (any, error) {
+			var nodes []*object
+			for _, it := range s.project.items {
+				if it.content == i {
+					nodes = append(nodes, s.itemObject(it))
+				}
+			}
+			return connection(nodes, args)
+		}).
+		resolve("comments", func(args map[string]any) (any, error) {
+			var nodes []*object
+			for _, c := range i.comments {
+				nodes = append(nodes, s.commentObject(i, c))
+			}
+			return connection(nodes, args)
+		})
+	return obj
+}
+
+func (s *Server) commentObject(i *issue, c *comment) *object {
+	return newObject("IssueComment", "Node", "Comment", "Minimizable").
+		set("id", c.id).
+		set("body", c.body).
+		set("isMinimized", c.minimized).
+		set("viewerDidAuthor", c.author == s.Viewer).
+		set("author", userObject(c.author)).
+		set("url", fmt.Sprintf("https://github.com/%s/%s/issues/%d#issuecomment-%s", i.repo.owner, i.repo.name, i.number, c.id)).
+		resolve("issue", func(map[string]any) (any, error) {
+			return s.issueObject(i), nil
+		})
+}
+
+func (s *Server) pullRequestObject(pr *pullRequest) *object {
+	return newObject("PullRequest", "Node", "Labelable", "Comment").
+		set("id", pr.id).
+		set("number", pr.number).
+		set("title", pr.title).
+		set("body", pr.body).
+		set("state", pr.state).
+		set("url", fmt.Sprintf("https://github.com/%s/%s/pull/%d", pr.repo.owner, pr.repo.name, pr.number)).
+		set("author", userObject(pr.author)).
+		resolve("repository", func(map[string]any) (any, error) {
+			return s.repoObject(pr.repo), nil
+		})
+}
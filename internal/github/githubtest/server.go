@@ -0,0 +1,428 @@
+// Package githubtest provides a local stand-in for the GitHub GraphQL API,
+// seeded from a fixture describing a ProjectV2 board. It answers the queries
+// github.Client issues and applies its mutations to in-memory state, so the
+// client and the commands built on it can run end to end without network
+// access.
+package githubtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Mutation records a mutation field the server applied
+type Mutation struct {
+	Name  string
+	Input map[string]any
+}
+
+// Server is an in-memory GitHub GraphQL endpoint
+type Server struct {
+	mu sync.Mutex
+
+	org     string
+	project *project
+	repos   []*repo
+
+	// Viewer is the login reported as the author of comments the client creates
+	Viewer string
+	// Now returns the time used for updatedAt bumps; defaults to time.Now
+	Now func() time.Time
+
+	// RateLimit is the number of points available per window; every request
+	// costs one point. Once spent, queries fail with RATE_LIMITED until
+	// RateLimitReset.
+	RateLimit      int
+	RateLimitReset time.Time
+	rateLimitUsed  int
+
+	mutations []Mutation
+	requests  int
+	nextID    int
+	faults    []Fault
+
+	httpServer *httptest.Server
+}
+
+// NewHandler builds a server from a fixture without starting a listener.
+// Use it to mount the endpoint on an existing http.Server.
+func NewHandler(f *Fixture) (*Server, error) {
+	s := &Server{
+		Viewer:         "project-agent[bot]",
+		Now:            time.Now,
+		RateLimit:      5000,
+		RateLimitReset: time.Now().Add(time.Hour).Truncate(time.Second),
+	}
+	if err := s.build(f); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// NewServer builds a server from a fixture and starts it on a loopback port.
+// Callers must Close it when done.
+func NewServer(f *Fixture) (*Server, error) {
+	s, err := NewHandler(f)
+	if err != nil {
+		return nil, err
+	}
+	s.httpServer = httptest.NewServer(s)
+	return s, nil
+}
+
+// Endpoint returns the GraphQL URL to pass to github.NewClientWithEndpoint
+func (s *Server) Endpoint() string {
+	if s.httpServer == nil {
+		return ""
+	}
+	return s.httpServer.URL + "/graphql"
+}
+
+// Close shuts down the listener started by NewServer
+func (s *Server) Close() {
+	if s.httpServer != nil {
+		s.httpServer.Close()
+	}
+}
+
+// Mutations returns every mutation applied so far, in order
+func (s *Server) Mutations() []Mutation {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Mutation(nil), s.mutations...)
+}
+
+// Requests returns the number of GraphQL requests served
+func (s *Server) Requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.requests
+}
+
+// Fault is an HTTP error the server returns instead of serving a request
+type Fault struct {
+	Status int
+	// RetryAfter, when set, is sent as a Retry-After header, the way GitHub
+	// reports secondary rate limits
+	RetryAfter time.Duration
+}
+
+// InjectFaults queues faults to return for the next requests, in order
+func (s *Server) InjectFaults(faults ...Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.faults = append(s.faults, faults...)
+}
+
+func (s *Server) newID(prefix string) string {
+	s.nextID++
+	return fmt.Sprintf("%s_%d", prefix, s.nextID)
+}
+
+// gqlError is a GraphQL error entry with the response path it applies to
+type gqlError struct {
+	Message string `json:"message"`
+	Type    string `json:"type,omitempty"`
+	Path    []any  `json:"path,omitempty"`
+}
+
+// fieldError lets resolvers report a GitHub error type alongside the message
+type fieldError struct {
+	Type    string
+	Message string
+}
+
+func (e *fieldError) Error() string { return e.Message }
+
+func notFound(format string, args ...any) error {
+	return &fieldError{Type: "NOT_FOUND", Message: fmt.Sprintf(format, args...)}
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		Query     string         `json:"query"`
+		Variables map[string]any `json:"variables"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if fault, ok := s.nextFault(); ok {
+		if fault.RetryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(fault.RetryAfter.Seconds())))
+		}
+		http.Error(w, http.StatusText(fault.Status), fault.Status)
+		return
+	}
+
+	resp := s.execute(req.Query, req.Variables)
+
+	s.mu.Lock()
+	w.Header().Set("X-RateLimit-Limit", strconv.Itoa(s.RateLimit))
+	w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(s.rateLimitRemaining()))
+	w.Header().Set("X-RateLimit-Used", strconv.Itoa(s.rateLimitUsed))
+	w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(s.RateLimitReset.Unix(), 10))
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+type response struct {
+	Data   map[string]any `json:"data"`
+	Errors []gqlError     `json:"errors,omitempty"`
+}
+
+func (s *Server) nextFault() (Fault, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.faults) == 0 {
+		return Fault{}, false
+	}
+	fault := s.faults[0]
+	s.faults = s.faults[1:]
+	return fault, true
+}
+
+func (s *Server) rateLimitRemaining() int {
+	if s.rateLimitUsed >= s.RateLimit {
+		return 0
+	}
+	return s.RateLimit - s.rateLimitUsed
+}
+
+func (s *Server) execute(query string, variables map[string]any) response {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests++
+
+	if s.Now().After(s.RateLimitReset) {
+		s.rateLimitUsed = 0
+		s.RateLimitReset = s.Now().Add(time.Hour).Truncate(time.Second)
+	}
+	if s.rateLimitRemaining() == 0 {
+		return response{Errors: []gqlError{{
+			Type:    "RATE_LIMITED",
+			Message: "API rate limit exceeded for user.",
+		}}}
+	}
+	s.rateLimitUsed++
+
+	op, err := parseOperation(query, variables)
+	if err != nil {
+		return response{Errors: []gqlError{{Message: err.Error()}}}
+	}
+
+	ex := &executor{}
+	var root *object
+	if op.Kind == "mutation" {
+		root = s.mutationRoot()
+	} else {
+		root = s.queryRoot()
+	}
+
+	data := ex.selectionSet(root, op.Selections, nil)
+	return response{Data: data, Errors: ex.errors}
+}
+
+// resolver computes a field value. Results are scalars, *object, []*object
+// or nil.
+type resolver func(args map[string]any) (any, error)
+
+// object is a resolved GraphQL object. types lists the concrete type name
+// first, followed by the interfaces and unions it belongs to.
+type object struct {
+	types  []string
+	fields map[string]resolver
+}
+
+func newObject(types ...string) *object {
+	return &object{types: types, fields: make(map[string]resolver)}
+}
+
+func (o *object) is(typeName string) bool {
+	for _, t := range o.types {
+		if t == typeName {
+			return true
+		}
+	}
+	return false
+}
+
+// set registers a field that ignores its arguments
+func (o *object) set(name string, value any) *object {
+	o.fields[name] = func(map[string]any) (any, error) { return value, nil }
+	return o
+}
+
+// resolve registers a field with a resolver
+func (o *object) resolve(name string, r resolver) *object {
+	o.fields[name] = r
+	return o
+}
+
+type executor struct {
+	errors []gqlError
+}
+
+func (ex *executor) fail(path []any, err error) {
+	e := gqlError{Message: err.Error(), Path: append([]any(nil), path...)}
+	if fe, ok := err.(*fieldError); ok {
+		e.Type = fe.Type
+	}
+	ex.errors = append(ex.errors, e)
+}
+
+func (ex *executor) selectionSet(obj *object, sels []selection, path []any) map[string]any {
+	out := make(map[string]any)
+	ex.collect(obj, sels, path, out)
+	return out
+}
+
+func (ex *executor) collect(obj *object, sels []selection, path []any, out map[string]any) {
+	for _, sel := range sels {
+		if sel.TypeCondition != "" {
+			if obj.is(sel.TypeCondition) {
+				ex.collect(obj, sel.Selections, path, out)
+			}
+			continue
+		}
+
+		key := sel.key()
+		fieldPath := append(append([]any(nil), path...), key)
+
+		if sel.Name == "__typename" {
+			out[key] = obj.types[0]
+			continue
+		}
+
+		r, ok := obj.fields[sel.Name]
+		if !ok {
+			ex.fail(fieldPath, fmt.Errorf("Field '%s' doesn't exist on type '%s'", sel.Name, obj.types[0]))
+			out[key] = nil
+			continue
+		}
+
+		value, err := r(sel.Args)
+		if err != nil {
+			ex.fail(fieldPath, err)
+			out[key] = nil
+			continue
+		}
+
+		out[key] = ex.complete(value, sel, fieldPath)
+	}
+}
+
+func (ex *executor) complete(value any, sel selection, path []any) any {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case *object:
+		if v == nil {
+			return nil
+		}
+		if len(sel.Selections) == 0 {
+			ex.fail(path, fmt.Errorf("Field '%s' of type '%s' must have a selection of subfields", sel.Name, v.types[0]))
+			return nil
+		}
+		return ex.selectionSet(v, sel.Selections, path)
+	case []*object:
+		list := make([]any, 0, len(v))
+		for i, o := range v {
+			list = append(list, ex.complete(o, sel, append(append([]any(nil), path...), i)))
+		}
+		return list
+	default:
+		return v
+	}
+}
+
+// connection builds a cursor-paginated connection over nodes, honouring the
+// first/after arguments the way GitHub does (first is capped at 100)
+func connection(nodes []*object, args map[string]any) (*object, error) {
+	start := 0
+	if after, ok := args["after"].(string); ok && after != "" {
+		var n int
+		if _, err := fmt.Sscanf(after, "cursor:%d", &n); err != nil {
+			return nil, fmt.Errorf("`%s` does not appear to be a valid cursor.", after)
+		}
+		start = n + 1
+	}
+	if start > len(nodes) {
+		start = len(nodes)
+	}
+
+	first, hasFirst := argInt(args, "first")
+	if hasFirst && (first < 0 || first > 100) {
+		return nil, fmt.Errorf("Requesting %d records on the connection exceeds the `first` limit of 100 records.", first)
+	}
+	end := len(nodes)
+	if hasFirst && start+first < end {
+		end = start + first
+	}
+
+	page := nodes[start:end]
+	var endCursor any
+	if len(page) > 0 {
+		endCursor = fmt.Sprintf("cursor:%d", end-1)
+	}
+
+	edges := make([]*object, 0, len(page))
+	for i, n := range page {
+		edges = append(edges, newObject("Edge").set("node", n).set("cursor", fmt.Sprintf("cursor:%d", start+i)))
+	}
+
+	pageInfo := newObject("PageInfo").
+		set("hasNextPage", end < len(nodes)).
+		set("hasPreviousPage", start > 0).
+		set("endCursor", endCursor)
+
+	return newObject("Connection").
+		set("nodes", page).
+		set("edges", edges).
+		set("pageInfo", pageInfo).
+		set("totalCount", len(nodes)), nil
+}
+
+func argInt(args map[string]any, name string) (int, bool) {
+	switch v := args[name].(type) {
+	case float64:
+		return int(v), true
+	case int:
+		return v, true
+	}
+	return 0, false
+}
+
+func argString(args map[string]any, name string) string {
+	s, _ := args[name].(string)
+	return s
+}
+
+func argInput(args map[string]any) map[string]any {
+	in, _ := args["input"].(map[string]any)
+	if in == nil {
+		in = make(map[string]any)
+	}
+	return in
+}
@@ -0,0 +1,101 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/shurcooL/githubv4"
+)
+
+// maxPages caps how many pages paginate fetches from a single connection, so
+// a cursor that never terminates cannot loop forever
+const maxPages = 500
+
+// pageInfo is the cursor state selected alongside every paginated connection
+type pageInfo struct {
+	HasNextPage githubv4.Boolean
+	EndCursor   githubv4.String
+}
+
+// paginate calls fetch with successive cursors until the connection reports
+// no further pages. start is the cursor to resume after, or nil to begin at
+// the first page. fetch returns the page info of the page it just read.
+// If maxPages is reached with pages remaining, a warning naming the
+// connection is logged and the results gathered so far are kept.
+func paginate(ctx context.Context, start *githubv4.String, connection string, fetch func(cursor *githubv4.String) (pageInfo, error)) error {
+	cursor := start
+	for page := 0; page < maxPages; page++ {
+		info, err := fetch(cursor)
+		if err != nil {
+			return err
+		}
+		if !info.HasNextPage {
+			return nil
+		}
+		next := info.EndCursor
+		cursor = &next
+	}
+
+	slog.WarnContext(ctx, "Too many pages; remaining results were dropped", "connection", connection, "max_pages", maxPages)
+	return nil
+}
+
+// rateLimitInfo is selected on the larger queries so the transport can
+// track their cost in the budget
+type rateLimitInfo struct {
+	Cost      githubv4.Int
+	Remaining githubv4.Int
+	ResetAt   githubv4.DateTime
+}
+
+// assigneeConnection is the first page of an issue's assignees, selected
+// inline with a larger query
+type assigneeConnection struct {
+	PageInfo pageInfo
+	Nodes    []struct {
+		Login githubv4.String
+	}
+}
+
+// assigneeLogins returns every assignee login for an issue, fetching any
+// pages beyond the inline first page
+func (c *Client) assigneeLogins(ctx context.Context, issueID githubv4.ID, first assigneeConnection) ([]string, error) {
+	assignees := []string{}
+	for _, assignee := range first.Nodes {
+		assignees = append(assignees, string(assignee.Login))
+	}
+	if !first.PageInfo.HasNextPage {
+		return assignees, nil
+	}
+
+	start := first.PageInfo.EndCursor
+	err := paginate(ctx, &start, fmt.Sprintf("assignees of issue %v", issueID), func(cursor *githubv4.String) (pageInfo, error) {
+		var query struct {
+			Node struct {
+				Issue struct {
+					Assignees assigneeConnection `graphql:"assignees(first: 100, after: $cursor)"`
+				} `graphql:"... on Issue"`
+			} `graphql:"node(id: $issueID)"`
+		}
+
+		variables := map[string]interface{}{
+			"issueID": issueID,
+			"cursor":  cursor,
+		}
+
+		if err := c.client.Query(ctx, &query, variables); err != nil {
+			return pageInfo{}, fmt.Errorf("failed to query assignees: %w", err)
+		}
+
+		for _, assignee := range query.Node.Issue.Assignees.Nodes {
+			assignees = append(assignees, string(assignee.Login))
+		}
+		return query.Node.Issue.Assignees.PageInfo, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return assignees, nil
+}
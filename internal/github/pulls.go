@@ -0,0 +1,119 @@
+package github
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/shurcooL/githubv4"
+)
+
+// Repository is a repository in an organization
+type Repository struct {
+	Owner string
+	Name  string
+}
+
+// PullRequest is an open pull request
+type PullRequest struct {
+	Owner  string // Repository owner
+	Repo   string // Repository name
+	Number int
+	Title  string
+	Body   string
+	Author string // Author login; empty for deleted accounts
+}
+
+// Ref returns the owner/repo#number form of the pull request
+func (pr PullRequest) Ref() string {
+	return fmt.Sprintf("%s/%s#%d", pr.Owner, pr.Repo, pr.Number)
+}
+
+// GetRepositories lists every repository in an organization
+func (c *Client) GetRepositories(ctx context.Context, org string) ([]Repository, error) {
+	var repos []Repository
+
+	err := paginate(ctx, nil, fmt.Sprintf("repositories of %s", org), func(cursor *githubv4.String) (pageInfo, error) {
+		var query struct {
+			Organization struct {
+				Repositories struct {
+					PageInfo pageInfo
+					Nodes    []struct {
+						Name  githubv4.String
+						Owner struct {
+							Login githubv4.String
+						}
+					}
+				} `graphql:"repositories(first: 100, after: $cursor)"`
+			} `graphql:"organization(login: $org)"`
+		}
+
+		variables := map[string]interface{}{
+			"org":    githubv4.String(org),
+			"cursor": cursor,
+		}
+
+		if err := c.client.Query(ctx, &query, variables); err != nil {
+			return pageInfo{}, fmt.Errorf("failed to query repositories: %w", err)
+		}
+
+		for _, node := range query.Organization.Repositories.Nodes {
+			repos = append(repos, Repository{Owner: string(node.Owner.Login), Name: string(node.Name)})
+		}
+		return query.Organization.Repositories.PageInfo, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return repos, nil
+}
+
+// GetOpenPullRequests lists the open pull requests of a repository
+func (c *Client) GetOpenPullRequests(ctx context.Context, owner, repo string) ([]PullRequest, error) {
+	var prs []PullRequest
+
+	err := paginate(ctx, nil, fmt.Sprintf("pull requests of %s/%s", owner, repo), func(cursor *githubv4.String) (pageInfo, error) {
+		var query struct {
+			Repository struct {
+				PullRequests struct {
+					PageInfo pageInfo
+					Nodes    []struct {
+						Number githubv4.Int
+						Title  githubv4.String
+						Body   githubv4.String
+						Author struct {
+							Login githubv4.String
+						}
+					}
+				} `graphql:"pullRequests(first: 100, states: OPEN, after: $cursor)"`
+			} `graphql:"repository(owner: $owner, name: $name)"`
+		}
+
+		variables := map[string]interface{}{
+			"owner":  githubv4.String(owner),
+			"name":   githubv4.String(repo),
+			"cursor": cursor,
+		}
+
+		if err := c.client.Query(ctx, &query, variables); err != nil {
+			return pageInfo{}, fmt.Errorf("failed to query pull requests: %w", err)
+		}
+
+		for _, node := range query.Repository.PullRequests.Nodes {
+			prs = append(prs, PullRequest{
+				Owner:  owner,
+				Repo:   repo,
+				Number: int(node.Number),
+				Title:  string(node.Title),
+				Body:   string(node.Body),
+				Author: string(node.Author.Login),
+			})
+		}
+		return query.Repository.PullRequests.PageInfo, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return prs, nil
+}
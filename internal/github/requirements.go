@@ -0,0 +1,66 @@
+package github
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FieldRequirement is a project field a command needs, by name and type
+type FieldRequirement struct {
+	Name string
+	Type FieldType
+}
+
+// Requirements describes the parts of the project schema a command depends
+// on. Listing status options implies a single select Status field.
+type Requirements struct {
+	Fields        []FieldRequirement
+	StatusOptions []string
+}
+
+// Require checks the project schema against req and returns a single error
+// listing everything that is missing, or nil if the project has it all
+func (c *Client) Require(req Requirements) error {
+	var missing []string
+
+	fields := req.Fields
+	if len(req.StatusOptions) > 0 {
+		fields = append([]FieldRequirement{{Name: "Status", Type: FieldSingleSelect}}, fields...)
+	}
+
+	seen := make(map[string]bool)
+	for _, want := range fields {
+		if seen[want.Name] {
+			continue
+		}
+		seen[want.Name] = true
+
+		field, ok := c.schema.Field(want.Name)
+		switch {
+		case !ok:
+			missing = append(missing, fmt.Sprintf("%s field %q", want.Type, want.Name))
+		case field.Type != want.Type:
+			missing = append(missing, fmt.Sprintf("field %q has type %s, expected %s", want.Name, field.Type, want.Type))
+		}
+	}
+
+	if status, ok := c.schema.Field("Status"); ok && status.Type == FieldSingleSelect {
+		seenOptions := make(map[string]bool)
+		for _, name := range req.StatusOptions {
+			if seenOptions[name] {
+				continue
+			}
+			seenOptions[name] = true
+			if _, ok := status.Option(name); !ok {
+				missing = append(missing, fmt.Sprintf("Status option %q", name))
+			}
+		}
+	}
+
+	if len(missing) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("project %s is not set up for this command; missing: %s",
+		c.projectURL, strings.Join(missing, ", "))
+}
@@ -0,0 +1,400 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/storacha/project-agent/internal/metrics"
+	"golang.org/x/oauth2"
+)
+
+// ErrBudgetExhausted is returned when the GitHub rate limit budget is too low
+// to keep going before the limit resets
+var ErrBudgetExhausted = errors.New("GitHub API rate limit budget exhausted")
+
+// RateLimit is the most recent rate limit state reported by GitHub
+type RateLimit struct {
+	Limit     int
+	Remaining int
+	Cost      int // Cost of the last GraphQL query, when the query selected rateLimit
+	ResetAt   time.Time
+}
+
+// Budget tracks the rate limit GitHub reports on every response so callers
+// can stop cleanly before it runs out. A nil Budget never runs out.
+type Budget struct {
+	// Reserve is the number of points to keep in hand; Check fails once
+	// remaining drops to it
+	Reserve int
+
+	mu    sync.Mutex
+	state RateLimit
+	known bool
+}
+
+// DefaultReserve is the budget reserve used by NewBudget
+const DefaultReserve = 100
+
+// NewBudget creates a budget that keeps DefaultReserve points in hand
+func NewBudget() *Budget {
+	return &Budget{Reserve: DefaultReserve}
+}
+
+// Snapshot returns the last reported rate limit, and false if GitHub has not
+// reported one yet
+func (b *Budget) Snapshot() (RateLimit, bool) {
+	if b == nil {
+		return RateLimit{}, false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state, b.known
+}
+
+// Check returns an error wrapping ErrBudgetExhausted when the remaining
+// budget is at or below the reserve and the limit has not reset yet
+func (b *Budget) Check() error {
+	state, known := b.Snapshot()
+	if !known || time.Now().After(state.ResetAt) {
+		return nil
+	}
+	if state.Remaining <= b.Reserve {
+		return fmt.Errorf("%w: %d of %d points left, resets at %s",
+			ErrBudgetExhausted, state.Remaining, state.Limit, state.ResetAt.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// update merges a newly reported rate limit into the budget
+func (b *Budget) update(rl RateLimit) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if rl.Limit == 0 {
+		rl.Limit = b.state.Limit
+	}
+	if rl.ResetAt.IsZero() {
+		rl.ResetAt = b.state.ResetAt
+	}
+	b.state = rl
+	b.known = true
+}
+
+// Transport is an http.RoundTripper that paces GitHub API requests. It keeps
+// a Budget up to date from the X-RateLimit-* headers and any rateLimit the
+// GraphQL query selected, and waits out rate limits (Retry-After, an
+// exhausted budget or a RATE_LIMITED error), where GitHub did not act on the
+// request. Transient 5xx and network errors are retried with jittered
+// exponential backoff only for idempotent requests: GraphQL queries and GET
+// requests. A mutation may have been applied before the error, so resending
+// it could post a comment twice.
+type Transport struct {
+	// Base is the underlying transport; http.DefaultTransport when nil
+	Base http.RoundTripper
+	// Budget receives every rate limit update; it may be shared across clients
+	Budget *Budget
+	// MaxRetries bounds retries of a single request
+	MaxRetries int
+	// MaxWait is the longest the transport will wait for a Retry-After or a
+	// rate limit reset before giving up and returning the response
+	MaxWait time.Duration
+}
+
+// Default retry settings used by NewHTTPClient
+const (
+	DefaultMaxRetries = 4
+	DefaultMaxWait    = 2 * time.Minute
+)
+
+// NewHTTPClient returns an authenticated HTTP client that paces its requests
+// against budget
+func NewHTTPClient(token string, budget *Budget) *http.Client {
+	src := oauth2.StaticTokenSource(
+		&oauth2.Token{AccessToken: token},
+	)
+	return &http.Client{
+		Transport: &oauth2.Transport{
+			Source: src,
+			Base: &Transport{
+				Budget:     budget,
+				MaxRetries: DefaultMaxRetries,
+				MaxWait:    DefaultMaxWait,
+			},
+		},
+	}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	// Buffer the body so the request can be replayed on retry
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read request body: %w", err)
+		}
+	}
+
+	if state, known := t.Budget.Snapshot(); known && state.Remaining == 0 {
+		if err := t.waitForReset(req.Context(), state.ResetAt); err != nil {
+			return nil, err
+		}
+	}
+
+	retryable := idempotent(req, body)
+	for attempt := 0; ; attempt++ {
+		attemptReq := req.Clone(req.Context())
+		if body != nil {
+			attemptReq.Body = io.NopCloser(bytes.NewReader(body))
+			attemptReq.ContentLength = int64(len(body))
+		}
+
+		resp, err := base.RoundTrip(attemptReq)
+		if err != nil {
+			metrics.GitHubRequests.Inc("error")
+			if !retryable || attempt >= t.MaxRetries || req.Context().Err() != nil {
+				return nil, err
+			}
+			delay := backoff(attempt)
+			slog.WarnContext(req.Context(), "GitHub request failed, retrying", "error", err, "wait", delay)
+			if err := sleep(req.Context(), delay); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		metrics.GitHubRequests.Inc(strconv.Itoa(resp.StatusCode))
+		resp, limited, err := t.observe(resp)
+		if err != nil {
+			return nil, err
+		}
+
+		if attempt >= t.MaxRetries {
+			return resp, nil
+		}
+
+		var delay time.Duration
+		switch {
+		case resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests:
+			if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+				// Secondary rate limit
+				delay = retryAfter
+				slog.WarnContext(req.Context(), "GitHub secondary rate limit hit, retrying", "wait", delay)
+			} else if limited || resp.Header.Get("X-RateLimit-Remaining") == "0" {
+				delay = t.untilReset()
+				slog.WarnContext(req.Context(), "GitHub rate limit exhausted, waiting for reset", "wait", delay)
+			} else {
+				return resp, nil
+			}
+		case resp.StatusCode >= 500 && retryable:
+			delay = backoff(attempt)
+			slog.WarnContext(req.Context(), "GitHub returned a server error, retrying", "status", resp.StatusCode, "wait", delay)
+		case limited:
+			// GraphQL reports primary rate limiting as a RATE_LIMITED error on a 200
+			delay = t.untilReset()
+			slog.WarnContext(req.Context(), "GitHub rate limit exhausted, waiting for reset", "wait", delay)
+		default:
+			return resp, nil
+		}
+
+		if delay > t.MaxWait {
+			slog.WarnContext(req.Context(), "Not waiting for GitHub", "wait", delay, "max_wait", t.MaxWait)
+			return resp, nil
+		}
+
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		if err := sleep(req.Context(), delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// idempotent reports whether req can be resent after a network or server
+// error: a GET or HEAD request, or a GraphQL document that is not a mutation
+func idempotent(req *http.Request, body []byte) bool {
+	if req.Method == http.MethodGet || req.Method == http.MethodHead {
+		return true
+	}
+	var payload struct {
+		Query string `json:"query"`
+	}
+	if json.Unmarshal(body, &payload) != nil || payload.Query == "" {
+		return false
+	}
+	return graphQLOperation(payload.Query) == "query"
+}
+
+// graphQLOperation returns the operation type of a GraphQL document: query,
+// mutation or subscription. The shorthand { ... } is a query.
+func graphQLOperation(document string) string {
+	rest := document
+	for {
+		rest = strings.TrimLeft(rest, " \t\r\n,\ufeff")
+		if !strings.HasPrefix(rest, "#") {
+			break
+		}
+		// Skip a comment line
+		if i := strings.IndexByte(rest, '\n'); i >= 0 {
+			rest = rest[i+1:]
+		} else {
+			rest = ""
+		}
+	}
+	if strings.HasPrefix(rest, "{") {
+		return "query"
+	}
+	end := strings.IndexFunc(rest, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z')
+	})
+	if end < 0 {
+		end = len(rest)
+	}
+	return rest[:end]
+}
+
+// observe records the rate limit reported by resp and reports whether the
+// GraphQL response carries a RATE_LIMITED error. The returned response has
+// its body restored for the caller.
+func (t *Transport) observe(resp *http.Response) (*http.Response, bool, error) {
+	rl, ok := parseRateLimitHeaders(resp.Header)
+
+	data, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read response body: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+
+	var payload struct {
+		Data struct {
+			RateLimit *struct {
+				Limit     int
+				Cost      int
+				Remaining int
+				ResetAt   time.Time
+			} `json:"rateLimit"`
+		} `json:"data"`
+		Errors []struct {
+			Type string `json:"type"`
+		} `json:"errors"`
+	}
+	limited := false
+	if json.Unmarshal(data, &payload) == nil {
+		if gql := payload.Data.RateLimit; gql != nil {
+			rl.Cost = gql.Cost
+			rl.Remaining = gql.Remaining
+			if gql.Limit != 0 {
+				rl.Limit = gql.Limit
+			}
+			if !gql.ResetAt.IsZero() {
+				rl.ResetAt = gql.ResetAt
+			}
+			ok = true
+		}
+		for _, e := range payload.Errors {
+			if e.Type == "RATE_LIMITED" {
+				limited = true
+			}
+		}
+	}
+
+	if ok {
+		metrics.GitHubRateLimitCost.Add(float64(rl.Cost))
+		metrics.GitHubRateLimitRemaining.Set(float64(rl.Remaining))
+	}
+	if ok && t.Budget != nil {
+		t.Budget.update(rl)
+	}
+
+	return resp, limited, nil
+}
+
+// untilReset returns how long until the budget resets
+func (t *Transport) untilReset() time.Duration {
+	state, known := t.Budget.Snapshot()
+	if !known {
+		return time.Minute
+	}
+	return time.Until(state.ResetAt) + time.Second
+}
+
+// waitForReset blocks until resetAt, or fails if that is further off than MaxWait
+func (t *Transport) waitForReset(ctx context.Context, resetAt time.Time) error {
+	wait := time.Until(resetAt)
+	if wait <= 0 {
+		return nil
+	}
+	if wait > t.MaxWait {
+		return fmt.Errorf("%w: resets at %s", ErrBudgetExhausted, resetAt.Format(time.RFC3339))
+	}
+	slog.WarnContext(ctx, "GitHub rate limit exhausted, waiting for reset", "wait", wait)
+	return sleep(ctx, wait)
+}
+
+// parseRateLimitHeaders reads the X-RateLimit-* headers
+func parseRateLimitHeaders(h http.Header) (RateLimit, bool) {
+	remaining, err := strconv.Atoi(h.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return RateLimit{}, false
+	}
+	rl := RateLimit{Remaining: remaining}
+	if limit, err := strconv.Atoi(h.Get("X-RateLimit-Limit")); err == nil {
+		rl.Limit = limit
+	}
+	if reset, err := strconv.ParseInt(h.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		rl.ResetAt = time.Unix(reset, 0)
+	}
+	return rl, true
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds or as an HTTP date
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return time.Until(at), true
+	}
+	return 0, false
+}
+
+// backoff returns the jittered delay before retry attempt+1
+func backoff(attempt int) time.Duration {
+	base := time.Second << attempt
+	return base/2 + time.Duration(rand.Int63n(int64(base)))
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
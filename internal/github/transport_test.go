@@ -0,0 +1,107 @@
+package github
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/storacha/project-agent/internal/standin"
+)
+
+// graphQLStandin answers requests with replies, then with empty 200s
+func graphQLStandin(t *testing.T, replies ...standin.Reply) *standin.Server {
+	t.Helper()
+	s := standin.New(t, standin.Reply{Body: `{"data":{}}`})
+	s.Script(replies...)
+	return s
+}
+
+func post(t *testing.T, tr *Transport, url, document string) (*http.Response, error) {
+	t.Helper()
+	body, err := json.Marshal(map[string]string{"query": document})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tr.RoundTrip(req)
+}
+
+func TestTransportRetriesQueryOnServerError(t *testing.T) {
+	srv := graphQLStandin(t, standin.Reply{Status: http.StatusBadGateway})
+	tr := &Transport{MaxRetries: 2, MaxWait: time.Minute}
+
+	resp, err := post(t, tr, srv.URL, "query($n:Int!){viewer{login}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if n := len(srv.Requests()); resp.StatusCode != http.StatusOK || n != 2 {
+		t.Fatalf("got status %d after %d requests, want 200 after 2", resp.StatusCode, n)
+	}
+}
+
+func TestTransportDoesNotRetryMutationOnServerError(t *testing.T) {
+	srv := graphQLStandin(t, standin.Reply{Status: http.StatusBadGateway})
+	tr := &Transport{MaxRetries: 2, MaxWait: time.Minute}
+
+	resp, err := post(t, tr, srv.URL, "mutation {\n\tc0: addComment(input: {subjectId: \"I_1\", body: \"hi\"}) { clientMutationId }\n}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if n := len(srv.Requests()); resp.StatusCode != http.StatusBadGateway || n != 1 {
+		t.Fatalf("got status %d after %d requests, want 502 after 1", resp.StatusCode, n)
+	}
+}
+
+func TestTransportDoesNotRetryMutationOnNetworkError(t *testing.T) {
+	// The stand-in drops every connection without answering
+	srv := standin.New(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err == nil {
+			conn.Close()
+		}
+	}))
+	tr := &Transport{MaxRetries: 2, MaxWait: time.Minute}
+
+	if _, err := post(t, tr, srv.URL, "mutation { addLabelsToLabelable(input: {}) { clientMutationId } }"); err == nil {
+		t.Fatal("want an error for a dropped connection")
+	}
+	if n := len(srv.Requests()); n != 1 {
+		t.Fatalf("mutation was sent %d times, want 1", n)
+	}
+}
+
+func TestTransportRetriesMutationOnRateLimit(t *testing.T) {
+	srv := graphQLStandin(t, standin.Reply{Status: http.StatusTooManyRequests, Header: map[string]string{"Retry-After": "0"}})
+	tr := &Transport{MaxRetries: 2, MaxWait: time.Minute}
+
+	resp, err := post(t, tr, srv.URL, "mutation { addComment(input: {}) { clientMutationId } }")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if n := len(srv.Requests()); resp.StatusCode != http.StatusOK || n != 2 {
+		t.Fatalf("got status %d after %d requests, want 200 after 2", resp.StatusCode, n)
+	}
+}
+
+func TestGraphQLOperation(t *testing.T) {
+	for document, want := range map[string]string{
+		"query($id: ID!) { node(id: $id) { id } }": "query",
+		"{ viewer { login } }":                     "query",
+		"  mutation($input: AddCommentInput!) {}":  "mutation",
+		"# batched\nmutation { c0: addComment }":   "mutation",
+		"mutation{x}":                              "mutation",
+		"subscription { x }":                       "subscription",
+	} {
+		if got := graphQLOperation(document); got != want {
+			t.Errorf("graphQLOperation(%q) = %q, want %q", document, got, want)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+// Package logging sets up the agent's structured logger. Records carry the
+// attributes of the context they are logged with, such as the run ID and
+// task name, and pass through a redaction layer that keeps secrets and
+// message contents out of the logs.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// Formats are the log output formats
+var Formats = []string{"text", "json"}
+
+var levels = map[string]slog.Level{
+	"debug":   slog.LevelDebug,
+	"info":    slog.LevelInfo,
+	"warn":    slog.LevelWarn,
+	"warning": slog.LevelWarn,
+	"error":   slog.LevelError,
+}
+
+// ParseLevel parses a level name: debug, info, warn or error
+func ParseLevel(name string) (slog.Level, error) {
+	level, ok := levels[strings.ToLower(name)]
+	if !ok {
+		return 0, fmt.Errorf("unknown level %q: must be debug, info, warn or error", name)
+	}
+	return level, nil
+}
+
+// New creates a logger writing records of level and above to w, as
+// logfmt-style text or JSON lines
+func New(w io.Writer, format string, level slog.Level) (*slog.Logger, error) {
+	opts := &slog.HandlerOptions{Level: level}
+	var inner slog.Handler
+	switch format {
+	case "text":
+		inner = slog.NewTextHandler(w, opts)
+	case "json":
+		inner = slog.NewJSONHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("unknown format %q: must be text or json", format)
+	}
+	return slog.New(&handler{inner: inner}), nil
+}
+
+type contextKey struct{}
+
+// With returns a context whose log records carry args, given as for
+// slog.Logger.With, in addition to those of ctx. An argument replaces the
+// attribute of ctx with the same key, such as the run ID of a run started by
+// a long-running process.
+func With(ctx context.Context, args ...any) context.Context {
+	attrs := append([]slog.Attr(nil), contextAttrs(ctx)...)
+next:
+	for _, a := range argsToAttrs(args) {
+		for i := range attrs {
+			if attrs[i].Key == a.Key {
+				attrs[i] = a
+				continue next
+			}
+		}
+		attrs = append(attrs, a)
+	}
+	return context.WithValue(ctx, contextKey{}, attrs)
+}
+
+func contextAttrs(ctx context.Context) []slog.Attr {
+	if ctx == nil {
+		return nil
+	}
+	attrs, _ := ctx.Value(contextKey{}).([]slog.Attr)
+	return attrs[:len(attrs):len(attrs)]
+}
+
+func argsToAttrs(args []any) []slog.Attr {
+	var r slog.Record
+	r.Add(args...)
+	attrs := make([]slog.Attr, 0, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		return true
+	})
+	return attrs
+}
+
+// handler adds the context's attributes to each record and redacts it
+// before passing it on
+type handler struct {
+	inner slog.Handler
+}
+
+func (h *handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.inner.Enabled(ctx, level)
+}
+
+func (h *handler) Handle(ctx context.Context, r slog.Record) error {
+	redacted := slog.NewRecord(r.Time, r.Level, Redact(r.Message), r.PC)
+	for _, a := range contextAttrs(ctx) {
+		redacted.AddAttrs(redactAttr(a))
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		redacted.AddAttrs(redactAttr(a))
+		return true
+	})
+	return h.inner.Handle(ctx, redacted)
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		redacted[i] = redactAttr(a)
+	}
+	return &handler{inner: h.inner.WithAttrs(redacted)}
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	return &handler{inner: h.inner.WithGroup(name)}
+}
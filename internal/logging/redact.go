@@ -0,0 +1,113 @@
+package logging
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Redacted replaces whatever the redaction layer removes
+const Redacted = "[REDACTED]"
+
+// privateKeys are attribute keys whose values never reach the logs: the
+// credentials and the contents of messages sent to people. An attribute is
+// private if its key contains one of them.
+var privateKeys = []string{
+	"token", "password", "secret", "authorization", "api_key", "webhook",
+	"content", "body", "payload",
+}
+
+// secretPatterns match credentials in free text: Discord and Slack webhook
+// URLs, GitHub, Slack and Google API tokens, and bearer tokens
+var secretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?:https?://)?[\w.-]*(?:discord(?:app)?\.com/api(?:/v\d+)?/webhooks|hooks\.slack\.com)/[^\s"'<>]+`),
+	regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{20,}|github_pat_[A-Za-z0-9_]{20,})`),
+	regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]+`),
+	regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}`),
+	regexp.MustCompile(`(?i)\bBearer\s+[A-Za-z0-9._~+/=-]+`),
+}
+
+var (
+	secretsMu sync.RWMutex
+	secrets   []string // Longest first, so a secret containing another is removed whole
+)
+
+// minSecretLength keeps short values, which would match too much, from
+// being registered
+const minSecretLength = 8
+
+// AddSecrets registers values, such as the tokens in the configuration,
+// that are removed wherever they appear in a log record
+func AddSecrets(values ...string) {
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+	for _, value := range values {
+		if len(value) >= minSecretLength {
+			secrets = append(secrets, value)
+		}
+	}
+	sort.Slice(secrets, func(i, j int) bool { return len(secrets[i]) > len(secrets[j]) })
+}
+
+// Redact removes the registered secrets and anything that looks like a
+// credential from s
+func Redact(s string) string {
+	secretsMu.RLock()
+	for _, secret := range secrets {
+		s = strings.ReplaceAll(s, secret, Redacted)
+	}
+	secretsMu.RUnlock()
+	for _, pattern := range secretPatterns {
+		s = pattern.ReplaceAllString(s, Redacted)
+	}
+	return s
+}
+
+// isPrivate reports whether an attribute key names a private value
+func isPrivate(key string) bool {
+	key = strings.ToLower(key)
+	for _, private := range privateKeys {
+		if strings.Contains(key, private) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactAttr redacts an attribute: private ones entirely, and the text of
+// the rest. Errors and other values are redacted as the text they log as.
+func redactAttr(a slog.Attr) slog.Attr {
+	if isPrivate(a.Key) {
+		return slog.String(a.Key, Redacted)
+	}
+	v := a.Value.Resolve()
+	switch v.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, Redact(v.String()))
+	case slog.KindGroup:
+		group := v.Group()
+		redacted := make([]any, len(group))
+		for i, member := range group {
+			redacted[i] = redactAttr(member)
+		}
+		return slog.Group(a.Key, redacted...)
+	case slog.KindAny:
+		switch value := v.Any().(type) {
+		case error:
+			return slog.String(a.Key, Redact(value.Error()))
+		case fmt.Stringer:
+			return slog.String(a.Key, Redact(value.String()))
+		case []string:
+			redacted := make([]string, len(value))
+			for i, s := range value {
+				redacted[i] = Redact(s)
+			}
+			return slog.Any(a.Key, redacted)
+		}
+		return slog.String(a.Key, Redact(fmt.Sprint(v.Any())))
+	}
+	return slog.Attr{Key: a.Key, Value: v}
+}
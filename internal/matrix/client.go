@@ -0,0 +1,345 @@
+// Package matrix sends the agent's notifications to Matrix rooms through the
+// client-server API
+package matrix
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/storacha/project-agent/internal/directory"
+	"github.com/storacha/project-agent/internal/github"
+	"github.com/storacha/project-agent/internal/messages"
+	"github.com/storacha/project-agent/internal/notify"
+)
+
+const (
+	// maxBodyLength keeps events well under Matrix's 64 KiB limit once the
+	// HTML body is added
+	maxBodyLength = 16000
+	maxAttempts   = 5
+	retryBackoff  = time.Second
+)
+
+// Client sends messages to Matrix as the user of an access token. Reports go
+// to the report room; direct messages go to a direct chat with the user,
+// which is created on first use and recorded in the m.direct account data.
+type Client struct {
+	homeserver  string
+	accessToken string
+	room        string // Room ID reports are posted to
+	httpClient  *http.Client
+	statuses    notify.StatusLayout
+	templates   *messages.Templates
+
+	txnPrefix string // Makes transaction IDs unique across runs
+	txn       atomic.Int64
+}
+
+// NewClient creates a new Matrix client
+func NewClient(homeserver, accessToken, room string) *Client {
+	return &Client{
+		homeserver:  strings.TrimRight(homeserver, "/"),
+		accessToken: accessToken,
+		room:        room,
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+		statuses:  notify.DefaultStatusLayout(),
+		templates: messages.Default(),
+		txnPrefix: fmt.Sprintf("project-agent-%d", time.Now().UnixNano()),
+	}
+}
+
+// SetStatusLayout replaces the client's status layout
+func (c *Client) SetStatusLayout(layout notify.StatusLayout) {
+	c.statuses = layout
+}
+
+// SetTemplates sets the templates messages are rendered with
+func (c *Client) SetTemplates(templates *messages.Templates) {
+	c.templates = templates
+}
+
+// SendStaleIssuesReport posts a summary of stale issues to the report room,
+// with a section per status
+func (c *Client) SendStaleIssuesReport(ctx context.Context, staleIssues []notify.StaleIssue, people *directory.Directory, thresholdDays int) error {
+	headline, sections := c.statuses.StaleReport(staleIssues, people, transport, thresholdDays, mention)
+	text, err := c.templates.Render(messages.StaleReportTemplate, headline)
+	if err != nil {
+		return err
+	}
+	for _, section := range sections {
+		description, err := c.templates.Render(messages.StaleReportStatusTemplate, section)
+		if err != nil {
+			return err
+		}
+		text += "\n\n**" + section.Status + "**\n" + description
+	}
+	return c.sendReport(ctx, text)
+}
+
+// SendTaskFailure reports a failed task run to the report room
+func (c *Client) SendTaskFailure(ctx context.Context, task string, errs []string) error {
+	failure, err := notify.RenderTaskFailure(c.templates, task, errs)
+	if err != nil {
+		return err
+	}
+	return c.sendReport(ctx, failure.Content+"\n\n**"+failure.Title+"**\n"+failure.Description)
+}
+
+// SendWeeklyDM sends a DM to a user with their assigned issues
+func (c *Client) SendWeeklyDM(ctx context.Context, userIssues notify.UserIssues) error {
+	text, err := c.templates.Render(messages.WeeklyDMTemplate, messages.WeeklyDM{
+		GithubUsername: userIssues.GithubUsername,
+		Count:          len(userIssues.Issues),
+		Groups:         c.statuses.GroupByStatus(userIssues.Issues),
+	})
+	if err != nil {
+		return err
+	}
+	return c.sendDM(ctx, userIssues.UserID, text)
+}
+
+// SendUnassignedIssuesDM sends a DM with all unassigned issues to a user
+func (c *Client) SendUnassignedIssuesDM(ctx context.Context, userID string, issues []github.Issue) error {
+	text, err := c.templates.Render(messages.UnassignedDMTemplate, messages.UnassignedDM{
+		Count:          len(issues),
+		ActiveStatuses: c.statuses.DescribeActive("or"),
+		Groups:         c.statuses.GroupByStatus(issues),
+	})
+	if err != nil {
+		return err
+	}
+	return c.sendDM(ctx, userID, text)
+}
+
+// CreateStandupThread posts the thread name to a room and the standup prompt
+// as the first message of its thread. roleID, such as @room, is put before
+// the prompt as is.
+func (c *Client) CreateStandupThread(ctx context.Context, channelID, roleID string) error {
+	standup := messages.Standup{Date: time.Now(), RoleID: roleID, RoleMention: roleID}
+	threadName, err := c.templates.Render(messages.StandupThreadNameTemplate, standup)
+	if err != nil {
+		return err
+	}
+	text, err := c.templates.Render(messages.StandupTemplate, standup)
+	if err != nil {
+		return err
+	}
+
+	rootID, err := c.sendEvent(ctx, channelID, textMessage("**"+threadName+"**", nil))
+	if err != nil {
+		return fmt.Errorf("failed to create thread: %w", err)
+	}
+	return c.sendText(ctx, channelID, rootID, text)
+}
+
+// transport is how the directory names Matrix
+const transport = "matrix"
+
+// mention links to a Matrix user, which clients render as a mention
+func mention(userID string) string {
+	return fmt.Sprintf("[%s](https://matrix.to/#/%s)", userID, userID)
+}
+
+// sendReport posts Markdown text to the report room
+func (c *Client) sendReport(ctx context.Context, text string) error {
+	if c.room == "" {
+		return fmt.Errorf("report room not configured")
+	}
+	return c.sendText(ctx, c.room, "", text)
+}
+
+// sendDM posts Markdown text to the direct chat with a user
+func (c *Client) sendDM(ctx context.Context, userID, text string) error {
+	roomID, err := c.directRoom(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to open direct chat: %w", err)
+	}
+	return c.sendText(ctx, roomID, "", text)
+}
+
+// sendText posts Markdown text to a room, or to a thread when threadID is
+// set, split into as many messages as the event size limit requires
+func (c *Client) sendText(ctx context.Context, roomID, threadID, text string) error {
+	var relation map[string]interface{}
+	if threadID != "" {
+		relation = map[string]interface{}{
+			"rel_type":        "m.thread",
+			"event_id":        threadID,
+			"is_falling_back": true,
+			"m.in_reply_to":   map[string]string{"event_id": threadID},
+		}
+	}
+
+	parts := notify.SplitText(text, maxBodyLength)
+	for i, part := range parts {
+		if _, err := c.sendEvent(ctx, roomID, textMessage(part, relation)); err != nil {
+			if len(parts) > 1 {
+				return fmt.Errorf("failed to send message %d of %d: %w", i+1, len(parts), err)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// textMessage is an m.text event with the Markdown as its plain body and
+// its HTML rendering as the formatted body
+func textMessage(markdown string, relation map[string]interface{}) map[string]interface{} {
+	content := map[string]interface{}{
+		"msgtype":        "m.text",
+		"body":           markdown,
+		"format":         "org.matrix.custom.html",
+		"formatted_body": html(markdown),
+	}
+	if relation != nil {
+		content["m.relates_to"] = relation
+	}
+	return content
+}
+
+// sendEvent sends an m.room.message event and returns its ID. The
+// transaction ID makes resending it safe: the homeserver ignores a repeat.
+func (c *Client) sendEvent(ctx context.Context, roomID string, content map[string]interface{}) (string, error) {
+	txnID := fmt.Sprintf("%s-%d", c.txnPrefix, c.txn.Add(1))
+	path := fmt.Sprintf("/rooms/%s/send/m.room.message/%s", url.PathEscape(roomID), url.PathEscape(txnID))
+
+	var resp struct {
+		EventID string `json:"event_id"`
+	}
+	if err := c.call(ctx, "PUT", path, content, &resp); err != nil {
+		return "", fmt.Errorf("failed to send message: %w", err)
+	}
+	return resp.EventID, nil
+}
+
+// directRoom returns the direct chat with a user, creating it if there is
+// none yet
+func (c *Client) directRoom(ctx context.Context, userID string) (string, error) {
+	var whoami struct {
+		UserID string `json:"user_id"`
+	}
+	if err := c.call(ctx, "GET", "/account/whoami", nil, &whoami); err != nil {
+		return "", err
+	}
+
+	directPath := fmt.Sprintf("/user/%s/account_data/m.direct", url.PathEscape(whoami.UserID))
+	direct := make(map[string][]string)
+	if err := c.call(ctx, "GET", directPath, nil, &direct); err != nil && !isNotFound(err) {
+		return "", err
+	}
+	if rooms := direct[userID]; len(rooms) > 0 {
+		return rooms[len(rooms)-1], nil
+	}
+
+	var created struct {
+		RoomID string `json:"room_id"`
+	}
+	request := map[string]interface{}{
+		"is_direct": true,
+		"invite":    []string{userID},
+		"preset":    "trusted_private_chat",
+	}
+	if err := c.call(ctx, "POST", "/createRoom", request, &created); err != nil {
+		return "", err
+	}
+
+	direct[userID] = append(direct[userID], created.RoomID)
+	if err := c.call(ctx, "PUT", directPath, direct, nil); err != nil {
+		slog.WarnContext(ctx, "Failed to record direct chat", "user", userID, "error", err)
+	}
+	return created.RoomID, nil
+}
+
+// apiError is an error response from the homeserver
+type apiError struct {
+	Status       int
+	ErrCode      string `json:"errcode"`
+	Message      string `json:"error"`
+	RetryAfterMS int    `json:"retry_after_ms"`
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("homeserver returned %d: %s %s", e.Status, e.ErrCode, e.Message)
+}
+
+func isNotFound(err error) bool {
+	apiErr, ok := err.(*apiError)
+	return ok && apiErr.ErrCode == "M_NOT_FOUND"
+}
+
+// call makes a client-server API request. Rate limited requests are retried
+// after the wait the homeserver asks for; PUT and GET requests, which are
+// idempotent, are also retried after network and server errors.
+func (c *Client) call(ctx context.Context, method, path string, body, out interface{}) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+	}
+	idempotent := method == "PUT" || method == "GET"
+
+	for attempt := 1; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, method, c.homeserver+"/_matrix/client/v3"+path, bytes.NewReader(payload))
+		if err != nil {
+			return fmt.Errorf("failed to create request: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+c.accessToken)
+		if payload != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+
+		wait := retryBackoff * time.Duration(attempt)
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			if !idempotent || attempt == maxAttempts || ctx.Err() != nil {
+				return err
+			}
+			slog.WarnContext(ctx, "Matrix request failed, retrying", "method", method, "error", err)
+		} else {
+			data, readErr := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			switch {
+			case readErr != nil:
+				return fmt.Errorf("failed to read response: %w", readErr)
+			case resp.StatusCode >= 200 && resp.StatusCode < 300:
+				if out == nil {
+					return nil
+				}
+				if err := json.Unmarshal(data, out); err != nil {
+					return fmt.Errorf("failed to decode response: %w", err)
+				}
+				return nil
+			}
+
+			apiErr := &apiError{Status: resp.StatusCode}
+			_ = json.Unmarshal(data, apiErr)
+			retry := resp.StatusCode == http.StatusTooManyRequests || (resp.StatusCode >= 500 && idempotent)
+			if !retry || attempt == maxAttempts {
+				return apiErr
+			}
+			if apiErr.RetryAfterMS > 0 {
+				wait = time.Duration(apiErr.RetryAfterMS) * time.Millisecond
+			}
+			slog.InfoContext(ctx, "Matrix request refused, retrying", "method", method, "status", resp.StatusCode, "wait", wait)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
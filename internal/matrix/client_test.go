@@ -0,0 +1,201 @@
+package matrix
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/storacha/project-agent/internal/github"
+	"github.com/storacha/project-agent/internal/standin"
+)
+
+// event is a room message the stand-in stored
+type event struct {
+	room    string
+	id      string
+	content map[string]interface{}
+}
+
+// homeserver is a stand-in for the client-server API. Sends are
+// deduplicated by transaction ID, as on a real homeserver. The first
+// failSends sends are stored but answered with a 502, as when a proxy
+// loses the response.
+type homeserver struct {
+	*standin.Server
+	mu        sync.Mutex
+	events    []event
+	txns      map[string]string // Transaction ID to event ID
+	direct    map[string][]string
+	rooms     int
+	failSends int
+}
+
+func newHomeserver(t *testing.T) *homeserver {
+	t.Helper()
+	f := &homeserver{txns: make(map[string]string)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("PUT /_matrix/client/v3/rooms/{room}/send/m.room.message/{txn}", func(w http.ResponseWriter, r *http.Request) {
+		var content map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&content); err != nil {
+			matrixError(w, http.StatusBadRequest, "M_NOT_JSON")
+			return
+		}
+		txn := r.PathValue("txn")
+
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		id, ok := f.txns[txn]
+		if !ok {
+			id = fmt.Sprintf("$event%d", len(f.events)+1)
+			f.txns[txn] = id
+			f.events = append(f.events, event{room: r.PathValue("room"), id: id, content: content})
+		}
+		if f.failSends > 0 {
+			f.failSends--
+			matrixError(w, http.StatusBadGateway, "M_UNKNOWN")
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"event_id": id})
+	})
+	mux.HandleFunc("GET /_matrix/client/v3/account/whoami", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"user_id": "@agent:example.org"})
+	})
+	mux.HandleFunc("GET /_matrix/client/v3/user/{user}/account_data/m.direct", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if f.direct == nil {
+			matrixError(w, http.StatusNotFound, "M_NOT_FOUND")
+			return
+		}
+		json.NewEncoder(w).Encode(f.direct)
+	})
+	mux.HandleFunc("PUT /_matrix/client/v3/user/{user}/account_data/m.direct", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		json.NewDecoder(r.Body).Decode(&f.direct)
+		w.Write([]byte("{}"))
+	})
+	mux.HandleFunc("POST /_matrix/client/v3/createRoom", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.rooms++
+		json.NewEncoder(w).Encode(map[string]string{"room_id": fmt.Sprintf("!dm%d:example.org", f.rooms)})
+	})
+	f.Server = standin.New(t, authorized(mux))
+	return f
+}
+
+// authorized refuses requests without the test access token
+func authorized(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer syt_test" {
+			matrixError(w, http.StatusUnauthorized, "M_UNKNOWN_TOKEN")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func matrixError(w http.ResponseWriter, status int, errcode string) {
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"errcode": errcode, "error": http.StatusText(status)})
+}
+
+func (f *homeserver) client() *Client {
+	return NewClient(f.URL+"/", "syt_test", "!reports:example.org")
+}
+
+// sends returns the transaction ID of every send request
+func (f *homeserver) sends() []string {
+	var txns []string
+	for _, r := range f.Requests() {
+		if r.Method == http.MethodPut && strings.Contains(r.Path, "/send/m.room.message/") {
+			txns = append(txns, r.Path[strings.LastIndex(r.Path, "/")+1:])
+		}
+	}
+	return txns
+}
+
+func (f *homeserver) stored() []event {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]event(nil), f.events...)
+}
+
+func TestSendRetriesWithTheSameTransactionID(t *testing.T) {
+	f := newHomeserver(t)
+	f.failSends = 1
+
+	if err := f.client().SendTaskFailure(context.Background(), "triage-stale", []string{"boom"}); err != nil {
+		t.Fatal(err)
+	}
+
+	sends := f.sends()
+	if len(sends) != 2 || sends[0] != sends[1] {
+		t.Fatalf("sends = %v, want one retry with the same transaction ID", sends)
+	}
+	events := f.stored()
+	if len(events) != 1 || events[0].room != "!reports:example.org" {
+		t.Fatalf("events = %+v, want one message in the report room", events)
+	}
+	body, _ := events[0].content["body"].(string)
+	formatted, _ := events[0].content["formatted_body"].(string)
+	if !strings.Contains(body, "boom") || !strings.Contains(formatted, "<strong>") {
+		t.Errorf("content = %v, want the Markdown body and its HTML", events[0].content)
+	}
+}
+
+func TestCreateStandupThread(t *testing.T) {
+	f := newHomeserver(t)
+
+	if err := f.client().CreateStandupThread(context.Background(), "!standup:example.org", "@room"); err != nil {
+		t.Fatal(err)
+	}
+
+	events := f.stored()
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want the thread root and its prompt", len(events))
+	}
+	if _, ok := events[0].content["m.relates_to"]; ok {
+		t.Errorf("thread root %v is in a thread", events[0].content)
+	}
+	relation, _ := events[1].content["m.relates_to"].(map[string]interface{})
+	if relation["rel_type"] != "m.thread" || relation["event_id"] != events[0].id {
+		t.Errorf("prompt relation = %v, want a thread on %s", relation, events[0].id)
+	}
+	if body, _ := events[1].content["body"].(string); !strings.Contains(body, "@room") {
+		t.Errorf("prompt %q does not mention @room", body)
+	}
+}
+
+func TestDirectMessagesReuseTheDirectChat(t *testing.T) {
+	f := newHomeserver(t)
+	c := f.client()
+	ctx := context.Background()
+	issues := []github.Issue{{Number: 1, Title: "Old forgotten issue", RepositoryOwner: "storacha", RepositoryName: "guppy"}}
+
+	for i := 0; i < 2; i++ {
+		if err := c.SendUnassignedIssuesDM(ctx, "@bob:example.org", issues); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	f.mu.Lock()
+	created, direct := f.rooms, f.direct
+	f.mu.Unlock()
+	if created != 1 {
+		t.Errorf("created %d rooms, want one direct chat", created)
+	}
+	if rooms := direct["@bob:example.org"]; len(rooms) != 1 || rooms[0] != "!dm1:example.org" {
+		t.Errorf("m.direct = %v, want the chat recorded for bob", direct)
+	}
+	for _, e := range f.stored() {
+		if e.room != "!dm1:example.org" {
+			t.Errorf("message sent to %s, want the direct chat", e.room)
+		}
+	}
+}
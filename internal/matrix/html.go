@@ -0,0 +1,23 @@
+package matrix
+
+import (
+	htmlpkg "html"
+	"regexp"
+	"strings"
+)
+
+var (
+	markdownLink   = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+	markdownBold   = regexp.MustCompile(`\*\*(.+?)\*\*`)
+	markdownItalic = regexp.MustCompile(`\*([^*\s][^*]*?)\*`)
+)
+
+// html renders the Markdown the message templates are written in as the
+// HTML subset Matrix clients display: links, bold, italics and line breaks
+func html(markdown string) string {
+	text := htmlpkg.EscapeString(markdown)
+	text = markdownLink.ReplaceAllString(text, `<a href="$2">$1</a>`)
+	text = markdownBold.ReplaceAllString(text, "<strong>$1</strong>")
+	text = markdownItalic.ReplaceAllString(text, "<em>$1</em>")
+	return strings.ReplaceAll(text, "\n", "<br>")
+}
@@ -0,0 +1,228 @@
+// Package messages renders the text the agent posts: issue comments and
+// notifications. Every message is a text/template with a built-in
+// default; a directory of <name>.tmpl files can override any of them.
+package messages
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/storacha/project-agent/internal/github"
+)
+
+//go:embed templates/*.tmpl
+var defaults embed.FS
+
+// Template names, each rendered with the data type noted
+const (
+	StaleCommentTemplate      = "stale-comment"       // StaleComment
+	StaleReportTemplate       = "stale-report"        // StaleReport
+	StaleReportStatusTemplate = "stale-report-status" // StaleStatus, once per status
+	WeeklyDMTemplate          = "weekly-dm"           // WeeklyDM
+	UnassignedDMTemplate      = "unassigned-dm"       // UnassignedDM
+	StandupThreadNameTemplate = "standup-thread-name" // Standup
+	StandupTemplate           = "standup"             // Standup
+	TaskFailureTemplate       = "task-failure"        // TaskFailure
+	TaskFailureTitleTemplate  = "task-failure-title"  // TaskFailure
+	TaskFailureErrorsTemplate = "task-failure-errors" // TaskFailure
+)
+
+// StaleComment is the comment left on an issue moved to the dead status
+type StaleComment struct {
+	Issue           github.Issue
+	DaysSinceUpdate int
+	ThresholdDays   int
+	DeadStatus      string
+	IntakeStatus    string // Where a revived issue can go back to
+	ParkedStatus    string // Where an issue worth keeping can wait; may be empty
+}
+
+// StaleReport is the headline of the stale issues report
+type StaleReport struct {
+	Count          int    // Stale issues; 0 when everything is up to date
+	ThresholdDays  int    // Days without an update before an issue is listed
+	ActiveStatuses string // The active statuses in words, such as "In Progress and PR Review"
+}
+
+// StaleStatus is one status's section of the stale issues report
+type StaleStatus struct {
+	Status        string
+	Count         int // Stale issues in this status
+	ThresholdDays int
+	Groups        []StaleGroup
+}
+
+// StaleGroup is the stale issues sharing the same assignees
+type StaleGroup struct {
+	Assignees string // Mentions on the transport, @login for unmapped users, or "Unassigned"
+	Issues    []StaleIssue
+}
+
+// StaleIssue is an issue listed in the stale issues report
+type StaleIssue struct {
+	Issue           github.Issue
+	DaysSinceUpdate int
+}
+
+// StatusGroup is the issues in one status, in a direct message
+type StatusGroup struct {
+	Status string
+	Issues []github.Issue
+}
+
+// WeeklyDM is the weekly direct message listing a user's issues
+type WeeklyDM struct {
+	GithubUsername string
+	Count          int
+	Groups         []StatusGroup
+}
+
+// UnassignedDM is the direct message listing unassigned issues
+type UnassignedDM struct {
+	Count          int // 0 when every issue is assigned
+	ActiveStatuses string
+	Groups         []StatusGroup
+}
+
+// Standup is the async standup thread and its opening message
+type Standup struct {
+	Date        time.Time
+	RoleID      string // Role or group to mention, if any
+	RoleMention string // The role's mention on the transport, such as <@&id> on Discord
+}
+
+// TaskFailure is the message posted when a task run fails
+type TaskFailure struct {
+	Task   string
+	Errors []string // Every error
+	Shown  []string // The errors listed in the message
+	Hidden int      // Errors not listed
+}
+
+// funcs are available to every template
+var funcs = template.FuncMap{
+	"join":  strings.Join,
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+	"date": func(layout string, t time.Time) string {
+		return t.Format(layout)
+	},
+}
+
+// Templates is a set of message templates
+type Templates struct {
+	t *template.Template
+}
+
+// Default returns the built-in templates
+func Default() *Templates {
+	t, err := parseDefaults()
+	if err != nil {
+		panic(fmt.Sprintf("messages: built-in templates: %v", err))
+	}
+	return &Templates{t: t}
+}
+
+// Load returns the built-in templates with any overrides from dir. Each
+// <name>.tmpl file in dir replaces the template of that name; other files
+// are ignored. An empty dir loads only the defaults.
+func Load(dir string) (*Templates, error) {
+	t, err := parseDefaults()
+	if err != nil {
+		return nil, err
+	}
+	if dir == "" {
+		return &Templates{t: t}, nil
+	}
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*.tmpl"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list templates: %w", err)
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no .tmpl files in templates directory %s", dir)
+	}
+	for _, path := range paths {
+		name := strings.TrimSuffix(filepath.Base(path), ".tmpl")
+		if t.Lookup(name) == nil {
+			return nil, fmt.Errorf("unknown template %s (templates: %s)", path, strings.Join(Names(), ", "))
+		}
+		text, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read template: %w", err)
+		}
+		if _, err := t.New(name).Parse(string(text)); err != nil {
+			return nil, fmt.Errorf("failed to parse template %s: %w", path, err)
+		}
+	}
+	return &Templates{t: t}, nil
+}
+
+// Names lists the templates that can be overridden
+func Names() []string {
+	entries, _ := defaults.ReadDir("templates")
+	var names []string
+	for _, entry := range entries {
+		names = append(names, strings.TrimSuffix(entry.Name(), ".tmpl"))
+	}
+	sort.Strings(names)
+	return names
+}
+
+func parseDefaults() (*template.Template, error) {
+	root := template.New("messages").Funcs(funcs).Option("missingkey=error")
+	for _, name := range Names() {
+		text, err := defaults.ReadFile("templates/" + name + ".tmpl")
+		if err != nil {
+			return nil, err
+		}
+		if _, err := root.New(name).Parse(string(text)); err != nil {
+			return nil, fmt.Errorf("failed to parse template %s: %w", name, err)
+		}
+	}
+	return root, nil
+}
+
+// Render executes the named template. Leading and trailing whitespace is
+// trimmed, so template files may end with a newline.
+func (m *Templates) Render(name string, data interface{}) (string, error) {
+	var buf bytes.Buffer
+	if err := m.t.ExecuteTemplate(&buf, name, data); err != nil {
+		return "", fmt.Errorf("failed to render %s message: %w", name, err)
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// Check renders every template with sample data, to catch templates that
+// parse but refer to fields that do not exist
+func (m *Templates) Check() error {
+	issue := github.Issue{Number: 1, Title: "Example issue", URL: "https://github.com/org/repo/issues/1", RepositoryName: "repo", RepositoryOwner: "org"}
+	issues := []github.Issue{issue}
+	groups := []StatusGroup{{Status: "In Progress", Issues: issues}}
+	samples := map[string]interface{}{
+		StaleCommentTemplate: StaleComment{Issue: issue, DaysSinceUpdate: 200, ThresholdDays: 180, DeadStatus: "Stuck / Dead Issue", IntakeStatus: "Inbox", ParkedStatus: "Icebox"},
+		StaleReportTemplate:  StaleReport{Count: 1, ThresholdDays: 3, ActiveStatuses: "In Progress"},
+		StaleReportStatusTemplate: StaleStatus{Status: "In Progress", Count: 1, ThresholdDays: 3,
+			Groups: []StaleGroup{{Assignees: "Unassigned", Issues: []StaleIssue{{Issue: issue, DaysSinceUpdate: 4}}}}},
+		WeeklyDMTemplate:          WeeklyDM{GithubUsername: "octocat", Count: 1, Groups: groups},
+		UnassignedDMTemplate:      UnassignedDM{Count: 1, ActiveStatuses: "In Progress", Groups: groups},
+		StandupThreadNameTemplate: Standup{Date: time.Now()},
+		StandupTemplate:           Standup{Date: time.Now(), RoleID: "1", RoleMention: "@team"},
+		TaskFailureTemplate:       TaskFailure{Task: "triage-stale", Errors: []string{"boom"}, Shown: []string{"boom"}},
+		TaskFailureTitleTemplate:  TaskFailure{Task: "triage-stale", Errors: []string{"boom"}, Shown: []string{"boom"}},
+		TaskFailureErrorsTemplate: TaskFailure{Task: "triage-stale", Errors: []string{"boom"}, Shown: []string{"boom"}},
+	}
+	for _, name := range Names() {
+		if _, err := m.Render(name, samples[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package metrics
+
+// The agent's metrics. Command runs and task reports are recorded by the
+// command; the clients record the API calls they make.
+var (
+	// Runs counts command runs by result: success, failure or dry-run, or
+	// overlap for a scheduled run skipped because the last one was going
+	Runs = Default.NewCounter("project_agent_runs",
+		"Command runs, by result", "command", "result")
+	RunDuration = Default.NewGauge("project_agent_run_duration_seconds",
+		"How long the last run of the command took", "command")
+	LastRun = Default.NewGauge("project_agent_last_run_timestamp_seconds",
+		"When the last run of the command finished", "command")
+	LastSuccess = Default.NewGauge("project_agent_last_success_timestamp_seconds",
+		"When the last successful run of the command finished", "command")
+
+	// Items counts the items in task reports, such as DMs sent and failed
+	// (action "dm") or issues labeled as duplicates (action "label")
+	Items = Default.NewCounter("project_agent_items",
+		"Report items, by action and outcome", "task", "action", "outcome")
+	// ReportValues are the counts in each task's last report, such as
+	// duplicate_groups or issues_linked_direct and issues_linked_semantic
+	ReportValues = Default.NewGauge("project_agent_report_value",
+		"Counts in the last report of the task", "task", "key")
+	StaleIssues = Default.NewGauge("project_agent_stale_issues",
+		"Stale issues found by the last run of the task, by status", "task", "status")
+
+	GitHubRequests = Default.NewCounter("project_agent_github_requests",
+		"GitHub API requests, by response status code or error", "status")
+	GitHubRateLimitCost = Default.NewCounter("project_agent_github_rate_limit_cost",
+		"GitHub GraphQL rate limit points spent")
+	GitHubRateLimitRemaining = Default.NewGauge("project_agent_github_rate_limit_remaining",
+		"GitHub rate limit points left")
+
+	GeminiRequests = Default.NewCounter("project_agent_gemini_requests",
+		"Gemini similarity requests, by result: ok or error", "result")
+
+	// WebhookDeliveries counts webhook deliveries by event and result:
+	// queued, ignored, duplicate, unauthorized, invalid or unavailable
+	WebhookDeliveries = Default.NewCounter("project_agent_webhook_deliveries",
+		"GitHub webhook deliveries, by event and result", "event", "result")
+)
@@ -0,0 +1,97 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// WriteFile writes the registry to path in the OpenMetrics format, for the
+// node exporter's textfile collector. The file is replaced atomically so the
+// collector never reads half of it.
+func (r *Registry) WriteFile(path string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to create metrics file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := r.WriteOpenMetrics(tmp); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write metrics file: %w", err)
+	}
+	if err := tmp.Chmod(0o644); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write metrics file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write metrics file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write metrics file: %w", err)
+	}
+	return nil
+}
+
+// pushTimeout bounds a push to the Pushgateway
+const pushTimeout = 30 * time.Second
+
+// Push replaces the metrics the Pushgateway at baseURL holds for the group
+// of job and the grouping labels with the registry's, given as name, value
+// pairs
+func (r *Registry) Push(ctx context.Context, baseURL, job string, grouping ...string) error {
+	if len(grouping)%2 != 0 {
+		return fmt.Errorf("grouping labels must be name, value pairs")
+	}
+	path := "/metrics/job/" + url.PathEscape(job)
+	for i := 0; i < len(grouping); i += 2 {
+		path += "/" + url.PathEscape(grouping[i]) + "/" + url.PathEscape(grouping[i+1])
+	}
+
+	var body bytes.Buffer
+	if err := r.WriteText(&body); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, pushTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, strings.TrimSuffix(baseURL, "/")+path, &body)
+	if err != nil {
+		return fmt.Errorf("failed to create push request: %w", err)
+	}
+	req.Header.Set("Content-Type", TextContentType)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to push metrics: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("failed to push metrics: Pushgateway returned %s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+	return nil
+}
+
+// Handler serves the registry, in the OpenMetrics format to scrapers that
+// ask for it and in the Prometheus text format otherwise
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var b bytes.Buffer
+		if strings.Contains(req.Header.Get("Accept"), "application/openmetrics-text") {
+			w.Header().Set("Content-Type", OpenMetricsContentType)
+			_ = r.WriteOpenMetrics(&b)
+		} else {
+			w.Header().Set("Content-Type", TextContentType)
+			_ = r.WriteText(&b)
+		}
+		_, _ = w.Write(b.Bytes())
+	})
+}
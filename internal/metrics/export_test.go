@@ -0,0 +1,106 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/storacha/project-agent/internal/standin"
+)
+
+func TestPush(t *testing.T) {
+	gateway := standin.New(t, nil)
+	r := testRegistry()
+
+	if err := r.Push(context.Background(), gateway.URL+"/", "project-agent", "instance", "runner-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	reqs := gateway.Requests()
+	if len(reqs) != 1 {
+		t.Fatalf("got %d requests, want 1", len(reqs))
+	}
+	req := reqs[0]
+	if req.Method != http.MethodPut || req.Path != "/metrics/job/project-agent/instance/runner-1" {
+		t.Errorf("request = %s %s, want PUT to the job and instance group", req.Method, req.Path)
+	}
+	if ct := req.Header.Get("Content-Type"); ct != TextContentType {
+		t.Errorf("Content-Type = %q, want %q", ct, TextContentType)
+	}
+	var want strings.Builder
+	r.WriteText(&want)
+	if string(req.Body) != want.String() {
+		t.Errorf("pushed\n%s\nwant the text format\n%s", req.Body, want.String())
+	}
+}
+
+func TestPushReportsGatewayErrors(t *testing.T) {
+	gateway := standin.New(t, standin.Reply{Status: http.StatusBadRequest, Body: "text format parsing error"})
+
+	err := testRegistry().Push(context.Background(), gateway.URL, "project-agent")
+	if err == nil || !strings.Contains(err.Error(), "400") || !strings.Contains(err.Error(), "text format parsing error") {
+		t.Errorf("err = %v, want the status and the gateway's message", err)
+	}
+}
+
+func TestPushRejectsOddGrouping(t *testing.T) {
+	if err := testRegistry().Push(context.Background(), "http://127.0.0.1:0", "project-agent", "instance"); err == nil {
+		t.Error("Push accepted a grouping label without a value")
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "project_agent.prom")
+	if err := os.WriteFile(path, []byte("stale\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	r := testRegistry()
+
+	if err := r.WriteFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want strings.Builder
+	r.WriteOpenMetrics(&want)
+	if string(got) != want.String() {
+		t.Errorf("file holds\n%s\nwant the OpenMetrics format\n%s", got, want.String())
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o644 {
+		t.Errorf("file mode = %v (%v), want 0644 for the collector", info.Mode().Perm(), err)
+	}
+	// The temporary file was renamed into place, not left behind
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("directory holds %d files, want only the metrics file", len(entries))
+	}
+}
+
+func TestHandlerNegotiatesFormat(t *testing.T) {
+	h := testRegistry().Handler()
+
+	for accept, want := range map[string]string{
+		"":           TextContentType,
+		"text/plain": TextContentType,
+		"application/openmetrics-text; version=1.0.0": OpenMetricsContentType,
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		req.Header.Set("Accept", accept)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+
+		if ct := w.Header().Get("Content-Type"); ct != want {
+			t.Errorf("Accept %q served %q, want %q", accept, ct, want)
+		}
+		if eof := strings.HasSuffix(w.Body.String(), "# EOF\n"); eof != (want == OpenMetricsContentType) {
+			t.Errorf("Accept %q served a body ending %q", accept, w.Body.String()[w.Body.Len()-6:])
+		}
+	}
+}
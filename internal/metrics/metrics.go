@@ -0,0 +1,215 @@
+// Package metrics keeps the agent's counters and gauges and exports them in
+// the Prometheus text and OpenMetrics formats: as a textfile, pushed to a
+// Pushgateway, or served over HTTP.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Metric types
+const (
+	typeCounter = "counter"
+	typeGauge   = "gauge"
+)
+
+// Registry holds a set of metric families
+type Registry struct {
+	mu       sync.Mutex
+	families map[string]*family
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{families: make(map[string]*family)}
+}
+
+// Default is the registry the agent's own metrics are kept in
+var Default = NewRegistry()
+
+// family is a metric and its series, one per set of label values
+type family struct {
+	name   string // Without the _total suffix of counters
+	help   string
+	typ    string
+	labels []string
+	series map[string]*series // By rendered labels
+}
+
+type series struct {
+	labels string // Rendered as {name="value",...}, or empty
+	value  float64
+}
+
+// Counter is a metric that only goes up, such as requests made
+type Counter struct {
+	r *Registry
+	f *family
+}
+
+// Gauge is a metric that goes up and down, such as issues found by the last
+// run
+type Gauge struct {
+	r *Registry
+	f *family
+}
+
+// NewCounter registers a counter. The name is given without the _total
+// suffix, which the exported samples carry.
+func (r *Registry) NewCounter(name, help string, labels ...string) *Counter {
+	return &Counter{r: r, f: r.register(name, help, typeCounter, labels)}
+}
+
+// NewGauge registers a gauge
+func (r *Registry) NewGauge(name, help string, labels ...string) *Gauge {
+	return &Gauge{r: r, f: r.register(name, help, typeGauge, labels)}
+}
+
+func (r *Registry) register(name, help, typ string, labels []string) *family {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.families[name]; ok {
+		panic(fmt.Sprintf("metrics: %s registered twice", name))
+	}
+	f := &family{name: name, help: help, typ: typ, labels: labels, series: make(map[string]*series)}
+	// A metric without labels has a single series, shown from the start
+	if len(labels) == 0 {
+		f.series[""] = &series{}
+	}
+	r.families[name] = f
+	return f
+}
+
+// Inc adds one to the series with the given label values
+func (c *Counter) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+// Add adds v, which must not be negative, to the series with the given
+// label values
+func (c *Counter) Add(v float64, values ...string) {
+	if v < 0 {
+		panic(fmt.Sprintf("metrics: counter %s decreased", c.f.name))
+	}
+	c.r.mu.Lock()
+	defer c.r.mu.Unlock()
+	c.f.get(values).value += v
+}
+
+// Set sets the series with the given label values to v
+func (g *Gauge) Set(v float64, values ...string) {
+	g.r.mu.Lock()
+	defer g.r.mu.Unlock()
+	g.f.get(values).value = v
+}
+
+// get returns the series for label values, creating it at zero. The caller
+// holds the registry lock.
+func (f *family) get(values []string) *series {
+	if len(values) != len(f.labels) {
+		panic(fmt.Sprintf("metrics: %s takes %d label values, got %d", f.name, len(f.labels), len(values)))
+	}
+	key := renderLabels(f.labels, values)
+	s, ok := f.series[key]
+	if !ok {
+		s = &series{labels: key}
+		f.series[key] = s
+	}
+	return s
+}
+
+// renderLabels renders label pairs as {name="value",...}
+func renderLabels(names, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, name := range names {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(name)
+		b.WriteString(`="`)
+		b.WriteString(labelEscaper.Replace(values[i]))
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+var helpEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+// Content types of the exposition formats
+const (
+	TextContentType        = "text/plain; version=0.0.4; charset=utf-8"
+	OpenMetricsContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+)
+
+// WriteText writes the registry in the Prometheus text format, which the
+// Pushgateway accepts
+func (r *Registry) WriteText(w io.Writer) error {
+	return r.write(w, false)
+}
+
+// WriteOpenMetrics writes the registry in the OpenMetrics text format
+func (r *Registry) WriteOpenMetrics(w io.Writer) error {
+	return r.write(w, true)
+}
+
+func (r *Registry) write(w io.Writer, openMetrics bool) error {
+	r.mu.Lock()
+	var b strings.Builder
+	for _, name := range r.sortedNames() {
+		f := r.families[name]
+		if len(f.series) == 0 {
+			continue
+		}
+		sample := f.name
+		if f.typ == typeCounter {
+			sample += "_total"
+		}
+		// OpenMetrics names the family; the text format names the sample
+		described := sample
+		if openMetrics {
+			described = f.name
+		}
+		fmt.Fprintf(&b, "# HELP %s %s\n", described, helpEscaper.Replace(f.help))
+		fmt.Fprintf(&b, "# TYPE %s %s\n", described, f.typ)
+		for _, s := range f.sortedSeries() {
+			fmt.Fprintf(&b, "%s%s %s\n", sample, s.labels, strconv.FormatFloat(s.value, 'g', -1, 64))
+		}
+	}
+	r.mu.Unlock()
+
+	if openMetrics {
+		b.WriteString("# EOF\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func (r *Registry) sortedNames() []string {
+	names := make([]string, 0, len(r.families))
+	for name := range r.families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (f *family) sortedSeries() []*series {
+	all := make([]*series, 0, len(f.series))
+	for _, s := range f.series {
+		all = append(all, s)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].labels < all[j].labels })
+	return all
+}
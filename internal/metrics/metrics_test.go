@@ -0,0 +1,77 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+// testRegistry returns a registry with a counter, a labelled gauge and a
+// label value that needs escaping
+func testRegistry() *Registry {
+	r := NewRegistry()
+	runs := r.NewCounter("agent_runs", "Task runs.\nBy task and result.", "task", "result")
+	runs.Inc("triage-stale", "success")
+	runs.Add(2, "triage-stale", "failure")
+	issues := r.NewGauge("agent_stale_issues", `Stale issues found by the last run, in C:\ terms`, "status")
+	issues.Set(3, `Backlog "old"`)
+	issues.Set(1.5, "In\nProgress")
+	r.NewCounter("agent_pushes", "Pushes.")
+	r.NewGauge("agent_unused", "Never set.", "task")
+	return r
+}
+
+func TestWriteText(t *testing.T) {
+	var b strings.Builder
+	if err := testRegistry().WriteText(&b); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `# HELP agent_pushes_total Pushes.
+# TYPE agent_pushes_total counter
+agent_pushes_total 0
+# HELP agent_runs_total Task runs.\nBy task and result.
+# TYPE agent_runs_total counter
+agent_runs_total{task="triage-stale",result="failure"} 2
+agent_runs_total{task="triage-stale",result="success"} 1
+# HELP agent_stale_issues Stale issues found by the last run, in C:\\ terms
+# TYPE agent_stale_issues gauge
+agent_stale_issues{status="Backlog \"old\""} 3
+agent_stale_issues{status="In\nProgress"} 1.5
+`
+	if got := b.String(); got != want {
+		t.Errorf("WriteText wrote\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestWriteOpenMetrics(t *testing.T) {
+	var b strings.Builder
+	if err := testRegistry().WriteOpenMetrics(&b); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `# HELP agent_pushes Pushes.
+# TYPE agent_pushes counter
+agent_pushes_total 0
+# HELP agent_runs Task runs.\nBy task and result.
+# TYPE agent_runs counter
+agent_runs_total{task="triage-stale",result="failure"} 2
+agent_runs_total{task="triage-stale",result="success"} 1
+# HELP agent_stale_issues Stale issues found by the last run, in C:\\ terms
+# TYPE agent_stale_issues gauge
+agent_stale_issues{status="Backlog \"old\""} 3
+agent_stale_issues{status="In\nProgress"} 1.5
+# EOF
+`
+	if got := b.String(); got != want {
+		t.Errorf("WriteOpenMetrics wrote\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestCounterRejectsDecrease(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("a negative Add did not panic")
+		}
+	}()
+	NewRegistry().NewCounter("agent_runs", "Task runs.").Add(-1)
+}
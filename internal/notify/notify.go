@@ -0,0 +1,152 @@
+// Package notify holds what the notification transports share: the issues
+// tasks notify about, the status layout messages are grouped by, and the
+// building of templated messages from them. Each transport, such as
+// internal/discord or internal/slack, renders these into its own format.
+package notify
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/storacha/project-agent/internal/directory"
+	"github.com/storacha/project-agent/internal/github"
+	"github.com/storacha/project-agent/internal/messages"
+)
+
+// StaleIssue represents an issue that needs attention
+type StaleIssue struct {
+	Issue           github.Issue
+	DaysSinceUpdate int
+	AssignedTo      []string // GitHub usernames
+}
+
+// UserIssues groups issues by user for weekly DM
+type UserIssues struct {
+	GithubUsername string
+	UserID         string // The user's ID on the transport, such as a Discord ID or email address
+	Issues         []github.Issue
+}
+
+// Unassigned is the group of stale issues nobody is assigned to
+const Unassigned = "Unassigned"
+
+// StaleReport builds the headline and per-status sections of a stale issues
+// report. Assignees people can mention on transport are mentioned with
+// mention; the others are named as @login.
+func (l StatusLayout) StaleReport(staleIssues []StaleIssue, people *directory.Directory, transport string, thresholdDays int, mention func(userID string) string) (messages.StaleReport, []messages.StaleStatus) {
+	headline := messages.StaleReport{
+		Count:          len(staleIssues),
+		ThresholdDays:  thresholdDays,
+		ActiveStatuses: l.DescribeActive("and"),
+	}
+
+	// Group issues by status, then by assignees
+	userIDs := people.Mentions(transport)
+	byStatus := make(map[string]map[string][]StaleIssue)
+	for _, stale := range staleIssues {
+		statusMap := byStatus[stale.Issue.ProjectItem.StatusValue]
+		if statusMap == nil {
+			statusMap = make(map[string][]StaleIssue)
+			byStatus[stale.Issue.ProjectItem.StatusValue] = statusMap
+		}
+		if len(stale.AssignedTo) > 0 {
+			mentions := make([]string, 0, len(stale.AssignedTo))
+			for _, githubUser := range stale.AssignedTo {
+				if userID, ok := userIDs[strings.ToLower(githubUser)]; ok {
+					mentions = append(mentions, mention(userID))
+				} else {
+					mentions = append(mentions, fmt.Sprintf("@%s", githubUser))
+				}
+			}
+			key := strings.Join(mentions, " ")
+			statusMap[key] = append(statusMap[key], stale)
+		} else {
+			statusMap[Unassigned] = append(statusMap[Unassigned], stale)
+		}
+	}
+
+	var sections []messages.StaleStatus
+	for _, status := range StatusKeys(l, byStatus) {
+		byAssignee := byStatus[status]
+		section := messages.StaleStatus{Status: status, ThresholdDays: thresholdDays}
+		for _, assignees := range assigneeKeys(byAssignee) {
+			group := messages.StaleGroup{Assignees: assignees}
+			for _, stale := range byAssignee[assignees] {
+				group.Issues = append(group.Issues, messages.StaleIssue{Issue: stale.Issue, DaysSinceUpdate: stale.DaysSinceUpdate})
+			}
+			section.Count += len(group.Issues)
+			section.Groups = append(section.Groups, group)
+		}
+		sections = append(sections, section)
+	}
+
+	return headline, sections
+}
+
+// assigneeKeys returns the assignee groups of a status alphabetically, with
+// the unassigned issues last
+func assigneeKeys(byAssignee map[string][]StaleIssue) []string {
+	keys := make([]string, 0, len(byAssignee))
+	for key := range byAssignee {
+		if key != Unassigned {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	if _, ok := byAssignee[Unassigned]; ok {
+		keys = append(keys, Unassigned)
+	}
+	return keys
+}
+
+// GroupByStatus groups issues by status, in the layout's order
+func (l StatusLayout) GroupByStatus(issues []github.Issue) []messages.StatusGroup {
+	byStatus := make(map[string][]github.Issue)
+	for _, issue := range issues {
+		status := issue.ProjectItem.StatusValue
+		byStatus[status] = append(byStatus[status], issue)
+	}
+
+	var groups []messages.StatusGroup
+	for _, status := range StatusKeys(l, byStatus) {
+		if len(byStatus[status]) > 0 {
+			groups = append(groups, messages.StatusGroup{Status: status, Issues: byStatus[status]})
+		}
+	}
+	return groups
+}
+
+// TaskFailure is a rendered task failure message
+type TaskFailure struct {
+	Content     string // The headline
+	Title       string // Heading of the error list
+	Description string // The error list
+}
+
+// RenderTaskFailure renders the message reporting a failed task run. Only
+// the first few errors are listed.
+func RenderTaskFailure(templates *messages.Templates, task string, errs []string) (TaskFailure, error) {
+	const maxErrors = 5
+
+	data := messages.TaskFailure{Task: task, Errors: errs, Shown: errs}
+	if len(errs) > maxErrors {
+		data.Shown, data.Hidden = errs[:maxErrors], len(errs)-maxErrors
+	}
+	var failure TaskFailure
+	for _, part := range []struct {
+		template string
+		text     *string
+	}{
+		{messages.TaskFailureTemplate, &failure.Content},
+		{messages.TaskFailureTitleTemplate, &failure.Title},
+		{messages.TaskFailureErrorsTemplate, &failure.Description},
+	} {
+		text, err := templates.Render(part.template, data)
+		if err != nil {
+			return TaskFailure{}, err
+		}
+		*part.text = text
+	}
+	return failure, nil
+}
@@ -0,0 +1,30 @@
+package notify
+
+import (
+	"testing"
+
+	"github.com/storacha/project-agent/internal/directory"
+	"github.com/storacha/project-agent/internal/github"
+)
+
+func TestStaleReportMentionsAssigneesIgnoringCase(t *testing.T) {
+	people, err := directory.New([]directory.Person{
+		{GitHub: "Alice", Slack: "U0ALICE"},
+		{GitHub: "bob"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	issue := github.Issue{Number: 12, RepositoryOwner: "storacha", RepositoryName: "guppy"}
+	issue.ProjectItem.StatusValue = "In Progress"
+	stale := []StaleIssue{{Issue: issue, DaysSinceUpdate: 9, AssignedTo: []string{"alice", "Bob"}}}
+
+	_, sections := DefaultStatusLayout().StaleReport(stale, people, "slack", 7, func(userID string) string { return "<@" + userID + ">" })
+
+	if len(sections) != 1 || len(sections[0].Groups) != 1 {
+		t.Fatalf("sections = %+v, want one group", sections)
+	}
+	if got, want := sections[0].Groups[0].Assignees, "<@U0ALICE> @Bob"; got != want {
+		t.Errorf("assignees = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,68 @@
+package notify
+
+import (
+	"sort"
+	"strings"
+)
+
+// StatusLayout controls which statuses messages describe and the order they
+// are rendered in
+type StatusLayout struct {
+	Active []string // Statuses named in "all good" messages
+	Order  []string // Render order; statuses not listed follow alphabetically
+}
+
+// DefaultStatusLayout returns the layout of the Storacha project board
+func DefaultStatusLayout() StatusLayout {
+	return StatusLayout{
+		Active: []string{"Sprint Backlog", "In Progress", "PR Review"},
+		Order:  []string{"Inbox", "Backlog", "Sprint Backlog", "In Progress", "PR Review", "Stuck / Dead Issue", "Done"},
+	}
+}
+
+// sorted returns the given statuses in render order
+func (l StatusLayout) sorted(statuses []string) []string {
+	rank := make(map[string]int, len(l.Order))
+	for i, status := range l.Order {
+		rank[status] = i
+	}
+
+	sorted := append([]string{}, statuses...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		ri, iok := rank[sorted[i]]
+		rj, jok := rank[sorted[j]]
+		switch {
+		case iok && jok:
+			return ri < rj
+		case iok != jok:
+			return iok
+		default:
+			return sorted[i] < sorted[j]
+		}
+	})
+	return sorted
+}
+
+// DescribeActive names the active statuses as an English list joined with
+// conjunction, e.g. "Sprint Backlog, In Progress, and PR Review"
+func (l StatusLayout) DescribeActive(conjunction string) string {
+	names := l.sorted(l.Active)
+	switch len(names) {
+	case 0:
+		return "active statuses"
+	case 1:
+		return names[0]
+	case 2:
+		return names[0] + " " + conjunction + " " + names[1]
+	}
+	return strings.Join(names[:len(names)-1], ", ") + ", " + conjunction + " " + names[len(names)-1]
+}
+
+// StatusKeys returns the keys of a map grouped by status, in render order
+func StatusKeys[T any](l StatusLayout, byStatus map[string]T) []string {
+	statuses := make([]string, 0, len(byStatus))
+	for status := range byStatus {
+		statuses = append(statuses, status)
+	}
+	return l.sorted(statuses)
+}
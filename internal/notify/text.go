@@ -0,0 +1,113 @@
+package notify
+
+import (
+	"strings"
+)
+
+// SplitText splits text into parts of at most limit characters at line
+// boundaries. Paragraphs, separated by blank lines, are kept together when
+// they fit. A paragraph that has to be split, such as a status heading and
+// its issues, repeats its first line at the top of each later part, so every
+// part says which group its lines belong to. A line longer than a whole part
+// is split between words.
+func SplitText(text string, limit int) []string {
+	text = strings.TrimSpace(text)
+	if TextLength(text) <= limit {
+		return []string{text}
+	}
+
+	var parts []string
+	current := ""
+	flush := func() {
+		if strings.TrimSpace(current) != "" {
+			parts = append(parts, strings.TrimSpace(current))
+		}
+		current = ""
+	}
+	// appendText adds s after sep, starting a new part if it does not fit
+	appendText := func(sep, s string) bool {
+		if current == "" {
+			sep = ""
+		}
+		if TextLength(current)+TextLength(sep)+TextLength(s) > limit {
+			return false
+		}
+		current += sep + s
+		return true
+	}
+
+	for _, paragraph := range strings.Split(text, "\n\n") {
+		if appendText("\n\n", paragraph) {
+			continue
+		}
+		if TextLength(paragraph) <= limit {
+			flush()
+			current = paragraph
+			continue
+		}
+
+		lines := strings.Split(paragraph, "\n")
+		heading := lines[0]
+		for i, line := range lines {
+			sep := "\n"
+			if i == 0 {
+				sep = "\n\n"
+			}
+			if appendText(sep, line) {
+				continue
+			}
+			flush()
+			if i > 0 && TextLength(heading)+1+TextLength(line) <= limit {
+				current = heading + "\n" + line
+				continue
+			}
+			// A single line longer than a whole part is cut between words
+			for TextLength(line) > limit {
+				head, rest := cutAt(line, limit)
+				parts = append(parts, head)
+				line = rest
+			}
+			current = line
+		}
+	}
+	flush()
+
+	return parts
+}
+
+// TextLength counts characters the way Discord and Slack do, in UTF-16 code
+// units
+func TextLength(s string) int {
+	n := 0
+	for _, r := range s {
+		if r >= 0x10000 {
+			n += 2
+		} else {
+			n++
+		}
+	}
+	return n
+}
+
+// cutAt splits s after at most limit characters, at the last space that
+// fits. A word longer than limit is cut on a rune boundary.
+func cutAt(s string, limit int) (string, string) {
+	n := 0
+	for i, r := range s {
+		size := 1
+		if r >= 0x10000 {
+			size = 2
+		}
+		if n+size > limit {
+			// s[i] may itself be the space to cut at
+			if j := strings.LastIndexByte(s[:i+1], ' '); j > 0 {
+				if head := strings.TrimRight(s[:j], " "); head != "" {
+					return head, strings.TrimLeft(s[j:], " ")
+				}
+			}
+			return s[:i], s[i:]
+		}
+		n += size
+	}
+	return s, ""
+}
@@ -0,0 +1,158 @@
+package notify
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// groupedText returns a report like the stale issues report: groups
+// paragraphs, each a status heading followed by lines issue lines
+func groupedText(groups, lines int) string {
+	var paragraphs []string
+	for g := 0; g < groups; g++ {
+		paragraph := []string{fmt.Sprintf("**Status %d** (%d issues)", g, lines)}
+		for i := 0; i < lines; i++ {
+			paragraph = append(paragraph, fmt.Sprintf("• [storacha/guppy#%d](https://github.com/storacha/guppy/issues/%d) - Issue %d of status %d, with a reasonably long title", g*1000+i, g*1000+i, i, g))
+		}
+		paragraphs = append(paragraphs, strings.Join(paragraph, "\n"))
+	}
+	return strings.Join(paragraphs, "\n\n")
+}
+
+// isHeading reports whether line is a groupedText heading
+func isHeading(line string) bool {
+	return strings.HasPrefix(line, "**Status ")
+}
+
+// rejoin undoes SplitText: it drops the heading repeated at the top of a
+// part that continues the previous part's group, and returns the remaining
+// non-blank lines in order
+func rejoin(parts []string) []string {
+	var lines []string
+	heading := ""
+	for _, part := range parts {
+		for i, line := range strings.Split(part, "\n") {
+			if line == "" {
+				continue
+			}
+			if isHeading(line) {
+				if i == 0 && line == heading {
+					continue
+				}
+				heading = line
+			}
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+func nonBlankLines(text string) []string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// limit is Discord's message content limit, which the tests split to
+const limit = 2000
+
+func TestSplitTextKeepsShortTextWhole(t *testing.T) {
+	text := groupedText(2, 3)
+	if parts := SplitText(text, limit); len(parts) != 1 || parts[0] != text {
+		t.Errorf("split %d characters into %d parts", len(text), len(parts))
+	}
+}
+
+func TestSplitTextSplitsAtLineBoundaries(t *testing.T) {
+	text := groupedText(5, 40)
+	parts := SplitText(text, limit)
+
+	if len(parts) < 2 {
+		t.Fatalf("got %d parts for %d characters", len(parts), len(text))
+	}
+	inputLines := make(map[string]bool)
+	for _, line := range nonBlankLines(text) {
+		inputLines[line] = true
+	}
+	for i, part := range parts {
+		if n := TextLength(part); n > limit {
+			t.Errorf("part %d has %d characters, over %d", i, n, limit)
+		}
+		// Every line of a part is a whole line of the input
+		for _, line := range nonBlankLines(part) {
+			if !inputLines[line] {
+				t.Errorf("part %d has a partial line %q", i, line)
+			}
+		}
+		if i > 0 && !isHeading(strings.Split(part, "\n")[0]) {
+			t.Errorf("part %d starts with %q, want its group's heading", i, strings.Split(part, "\n")[0])
+		}
+	}
+
+	if got, want := rejoin(parts), nonBlankLines(text); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("parts rejoin to %d lines, want the %d input lines", len(got), len(want))
+	}
+}
+
+func TestSplitTextCutsALongLineBetweenWords(t *testing.T) {
+	var words []string
+	for i := 0; i < 600; i++ {
+		words = append(words, fmt.Sprintf("word%d", i))
+	}
+	line := strings.Join(words, " ")
+	parts := SplitText(line, limit)
+
+	if len(parts) < 2 {
+		t.Fatalf("got %d parts for %d characters", len(parts), len(line))
+	}
+	inputWords := make(map[string]bool)
+	for _, word := range words {
+		inputWords[word] = true
+	}
+	var got []string
+	for i, part := range parts {
+		if n := TextLength(part); n > limit {
+			t.Errorf("part %d has %d characters, over %d", i, n, limit)
+		}
+		if part != strings.TrimSpace(part) {
+			t.Errorf("part %d has surrounding spaces", i)
+		}
+		for _, word := range strings.Fields(part) {
+			if !inputWords[word] {
+				t.Errorf("part %d has a partial word %q", i, word)
+			}
+			got = append(got, word)
+		}
+	}
+	if strings.Join(got, " ") != line {
+		t.Error("the words of the parts are not the words of the line")
+	}
+}
+
+func TestSplitTextCutsALongWord(t *testing.T) {
+	for name, word := range map[string]string{
+		"ascii": strings.Repeat("a", 4500),
+		// Characters outside the BMP count as two, as Discord counts them
+		"emoji": strings.Repeat("🦀", 2100),
+	} {
+		t.Run(name, func(t *testing.T) {
+			parts := SplitText(word, limit)
+			if len(parts) != 3 {
+				t.Errorf("got %d parts, want 3", len(parts))
+			}
+			for i, part := range parts {
+				if n := TextLength(part); n > limit {
+					t.Errorf("part %d has %d characters, over %d", i, n, limit)
+				}
+			}
+			if strings.Join(parts, "") != word {
+				t.Error("parts do not concatenate to the word")
+			}
+		})
+	}
+}
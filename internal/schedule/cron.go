@@ -0,0 +1,217 @@
+// Package schedule parses cron expressions and works out when they next
+// fire
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed cron expression: minute, hour, day of month, month
+// and day of week
+type Schedule struct {
+	expr   string
+	minute uint64 // Bit n set when the field matches n
+	hour   uint64
+	dom    uint64
+	month  uint64
+	dow    uint64
+	// Cron fires when either day field matches if both are restricted, and
+	// when the restricted one matches otherwise
+	domAny, dowAny bool
+}
+
+// shorthands are the @ forms accepted in place of the five fields
+var shorthands = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var monthNames = []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}
+var dayNames = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// field describes the values one cron field takes
+type field struct {
+	name     string
+	min, max int
+	names    []string // Names of the values from min, if the field has any
+}
+
+var fields = []field{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12, names: monthNames},
+	{name: "day of week", min: 0, max: 7, names: dayNames}, // 0 and 7 are Sunday
+}
+
+// Parse parses a standard five-field cron expression, such as "0 9 * * 1-5",
+// or one of the shorthands @hourly, @daily, @weekly, @monthly and @yearly.
+// Fields take *, numbers, names of months and days, ranges (a-b), lists
+// (a,b) and steps (*/n, a-b/n).
+func Parse(expr string) (*Schedule, error) {
+	spec := strings.TrimSpace(expr)
+	if shorthand, ok := shorthands[strings.ToLower(spec)]; ok {
+		spec = shorthand
+	}
+	parts := strings.Fields(spec)
+	if len(parts) != len(fields) {
+		return nil, fmt.Errorf("invalid cron expression %q: want 5 fields, got %d", expr, len(parts))
+	}
+
+	bits := make([]uint64, len(fields))
+	for i, part := range parts {
+		var err error
+		bits[i], err = fields[i].parse(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron expression %q: %w", expr, err)
+		}
+	}
+
+	s := &Schedule{
+		expr:   strings.TrimSpace(expr),
+		minute: bits[0],
+		hour:   bits[1],
+		dom:    bits[2],
+		month:  bits[3],
+		dow:    bits[4],
+		domAny: strings.HasPrefix(parts[2], "*"),
+		dowAny: strings.HasPrefix(parts[4], "*"),
+	}
+	// Sunday is both 0 and 7
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	return s, nil
+}
+
+// parse parses one field into a bit set of the values it matches
+func (f field) parse(spec string) (uint64, error) {
+	var bits uint64
+	for _, item := range strings.Split(spec, ",") {
+		rangeSpec, step := item, 1
+		if slash := strings.IndexByte(item, '/'); slash >= 0 {
+			n, err := strconv.Atoi(item[slash+1:])
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step in %s field %q", f.name, item)
+			}
+			rangeSpec, step = item[:slash], n
+		}
+
+		lo, hi := f.min, f.max
+		switch {
+		case rangeSpec == "*":
+		case strings.Contains(rangeSpec, "-"):
+			dash := strings.IndexByte(rangeSpec, '-')
+			var err error
+			if lo, err = f.value(rangeSpec[:dash]); err != nil {
+				return 0, err
+			}
+			if hi, err = f.value(rangeSpec[dash+1:]); err != nil {
+				return 0, err
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("invalid range in %s field %q", f.name, item)
+			}
+		default:
+			v, err := f.value(rangeSpec)
+			if err != nil {
+				return 0, err
+			}
+			lo = v
+			// A single value with a step runs to the end of the field
+			if step == 1 {
+				hi = v
+			}
+		}
+
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// value parses a number or name in the field's range
+func (f field) value(s string) (int, error) {
+	for i, name := range f.names {
+		if strings.EqualFold(s, name) {
+			return f.min + i, nil
+		}
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("invalid %s %q: want %d-%d", f.name, s, f.min, f.max)
+	}
+	return v, nil
+}
+
+// String returns the expression the schedule was parsed from
+func (s *Schedule) String() string {
+	return s.expr
+}
+
+// maxSearch bounds the search for the next match. Every valid expression
+// matches within a few years, such as February 29th.
+const maxSearch = 5 * 366 * 24 * time.Hour
+
+// Next returns the first time after t the schedule fires, in t's location.
+// Times that daylight saving skips never fire. It returns the zero time if
+// the schedule never fires, such as on February 30th.
+func (s *Schedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	limit := t.Add(maxSearch)
+	t = t.Truncate(time.Minute).Add(time.Minute)
+
+	for t.Before(limit) {
+		var next time.Time
+		switch {
+		case s.month&(1<<uint(t.Month())) == 0:
+			next = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !s.dayMatches(t):
+			next = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		case s.hour&(1<<uint(t.Hour())) == 0:
+			// The start of the next hour, counted in elapsed time so a
+			// daylight saving change neither skips nor repeats the search
+			next = t.Add(time.Hour - time.Duration(t.Minute())*time.Minute)
+		case s.minute&(1<<uint(t.Minute())) == 0:
+			next = t.Add(time.Minute)
+		default:
+			// When daylight saving ends an hour of wall clock time repeats;
+			// the schedule fires in the first one only
+			if earlier := t.Add(-time.Hour); earlier.Hour() == t.Hour() && earlier.Day() == t.Day() {
+				next = t.Add(time.Minute)
+				break
+			}
+			return t
+		}
+		// A midnight that daylight saving skips normalizes to an earlier
+		// time; step past it instead
+		if !next.After(t) {
+			next = t.Add(time.Hour - time.Duration(t.Minute())*time.Minute)
+		}
+		t = next
+	}
+	return time.Time{}
+}
+
+func (s *Schedule) dayMatches(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+	switch {
+	case s.domAny && s.dowAny:
+		return true
+	case s.domAny:
+		return dow
+	case s.dowAny:
+		return dom
+	}
+	return dom || dow
+}
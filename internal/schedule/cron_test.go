@@ -0,0 +1,146 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+// at parses a wall clock time in loc
+func at(t *testing.T, loc *time.Location, value string) time.Time {
+	t.Helper()
+	parsed, err := time.ParseInLocation("2006-01-02 15:04", value, loc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed
+}
+
+func TestNext(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		expr string
+		from string // Times are UTC; 2024-03-04 is a Monday
+		want string
+	}{
+		{"every minute", "* * * * *", "2024-03-04 10:00", "2024-03-04 10:01"},
+		{"strictly after", "30 9 * * *", "2024-03-04 09:30", "2024-03-05 09:30"},
+		{"range", "0 9-17 * * *", "2024-03-04 17:30", "2024-03-05 09:00"},
+		{"list", "0 8,12,18 * * *", "2024-03-04 12:00", "2024-03-04 18:00"},
+		{"step", "*/15 * * * *", "2024-03-04 10:16", "2024-03-04 10:30"},
+		{"step in a range", "10-30/10 * * * *", "2024-03-04 10:31", "2024-03-04 11:10"},
+		{"step from a value", "50/5 * * * *", "2024-03-04 10:51", "2024-03-04 10:55"},
+		{"month names", "0 0 1 jan,JUL *", "2024-02-10 00:00", "2024-07-01 00:00"},
+		{"weekday names", "30 9 * * mon-fri", "2024-03-09 12:00", "2024-03-11 09:30"},
+		{"Sunday as 7", "0 0 * * 7", "2024-03-04 00:00", "2024-03-10 00:00"},
+		{"ranges, steps and lists", "*/15 9-17 * * 1-5", "2024-03-08 17:50", "2024-03-11 09:00"},
+		// When both day fields are restricted, either one matching fires;
+		// 2024-10-13 is a Sunday
+		{"day of week before day of month", "0 0 13 * fri", "2024-10-01 00:00", "2024-10-04 00:00"},
+		{"day of month before day of week", "0 0 13 * fri", "2024-10-12 00:00", "2024-10-13 00:00"},
+		{"day of month alone", "0 0 13 * *", "2024-10-01 00:00", "2024-10-13 00:00"},
+		{"day of week alone", "0 0 * * fri", "2024-10-12 00:00", "2024-10-18 00:00"},
+		{"@hourly", "@hourly", "2024-03-04 10:59", "2024-03-04 11:00"},
+		{"@daily", "@daily", "2024-03-04 10:00", "2024-03-05 00:00"},
+		{"@weekly", "@weekly", "2024-03-04 10:00", "2024-03-10 00:00"},
+		{"@monthly", "@Monthly", "2024-12-15 00:00", "2025-01-01 00:00"},
+		{"Feb 29", "0 12 29 2 *", "2025-03-01 00:00", "2028-02-29 12:00"},
+		{"31st", "0 0 31 * *", "2024-04-01 00:00", "2024-05-31 00:00"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := Parse(tc.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := s.Next(at(t, time.UTC, tc.from))
+			if want := at(t, time.UTC, tc.want); !got.Equal(want) {
+				t.Errorf("Next(%s) = %s, want %s", tc.from, got.Format(time.DateTime), want.Format(time.DateTime))
+			}
+		})
+	}
+}
+
+func TestNextNeverFires(t *testing.T) {
+	s, err := Parse("0 0 30 2 *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next := s.Next(time.Now()); !next.IsZero() {
+		t.Errorf("Next = %s, want the zero time for February 30th", next)
+	}
+}
+
+func TestNextAcrossDaylightSaving(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("no time zone data: %v", err)
+	}
+
+	for _, tc := range []struct {
+		name string
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		// 2024-03-10 02:00 to 03:00 does not exist in New York; a time
+		// inside it does not fire that day
+		{"spring-forward gap", "30 2 * * *", at(t, ny, "2024-03-09 12:00"), at(t, ny, "2024-03-11 02:30")},
+		{"after the gap", "0 3 * * *", at(t, ny, "2024-03-10 00:00"), at(t, ny, "2024-03-10 03:00")},
+		{"midnight before the gap", "@daily", at(t, ny, "2024-03-09 12:00"), at(t, ny, "2024-03-10 00:00")},
+		// 2024-11-03 01:00 to 02:00 happens twice; the schedule fires in
+		// the first one only
+		{"fall-back first hour", "30 1 * * *", at(t, ny, "2024-11-03 00:00"), time.Date(2024, 11, 3, 5, 30, 0, 0, time.UTC)},
+		{"fall-back repeated hour", "30 1 * * *", time.Date(2024, 11, 3, 5, 30, 0, 0, time.UTC), at(t, ny, "2024-11-04 01:30")},
+		{"after the repeated hour", "0 2 * * *", time.Date(2024, 11, 3, 5, 30, 0, 0, time.UTC), time.Date(2024, 11, 3, 7, 0, 0, 0, time.UTC)},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := Parse(tc.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := s.Next(tc.from.In(ny))
+			if !got.Equal(tc.want) {
+				t.Errorf("Next(%s) = %s, want %s", tc.from.In(ny), got, tc.want.In(ny))
+			}
+			if got.Location() != ny {
+				t.Errorf("Next returned a time in %s, want %s", got.Location(), ny)
+			}
+		})
+	}
+}
+
+func TestParseRejectsInvalidExpressions(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * 32 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"-1 * * * *",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"*/x * * * *",
+		"1,,2 * * * *",
+		"a * * * *",
+		"* * * foo *",
+		"* * * * monday",
+		"@fortnightly",
+	} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("Parse(%q) succeeded", expr)
+		}
+	}
+}
+
+func TestString(t *testing.T) {
+	s, err := Parse("  0 9 * * mon-fri ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := s.String(); got != "0 9 * * mon-fri" {
+		t.Errorf("String = %q", got)
+	}
+}
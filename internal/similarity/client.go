@@ -7,6 +7,7 @@ import (
 
 	"github.com/google/generative-ai-go/genai"
 	"github.com/storacha/project-agent/internal/github"
+	"github.com/storacha/project-agent/internal/metrics"
 	"google.golang.org/api/option"
 )
 
@@ -68,8 +69,10 @@ Are these issues duplicates or highly similar? Respond in JSON format.`,
 
 	resp, err := c.model.GenerateContent(ctx, genai.Text(prompt))
 	if err != nil {
+		metrics.GeminiRequests.Inc("error")
 		return 0, fmt.Errorf("failed to generate content: %w", err)
 	}
+	metrics.GeminiRequests.Inc("ok")
 
 	if len(resp.Candidates) == 0 || len(resp.Candidates[0].Content.Parts) == 0 {
 		return 0, fmt.Errorf("no response from Gemini")
@@ -0,0 +1,289 @@
+// Package slack sends the agent's notifications to Slack, through an
+// incoming webhook or the Web API with a bot token
+package slack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/storacha/project-agent/internal/directory"
+	"github.com/storacha/project-agent/internal/github"
+	"github.com/storacha/project-agent/internal/messages"
+	"github.com/storacha/project-agent/internal/notify"
+)
+
+// DefaultAPIURL is the Slack Web API the bot client talks to
+const DefaultAPIURL = "https://slack.com/api"
+
+const (
+	// maxTextLength is the longest message text Slack displays in full
+	maxTextLength = 4000
+	maxAttempts   = 5
+)
+
+// Client sends messages to Slack. Reports go to the incoming webhook if one
+// is set, and otherwise to the report channel with the bot token; direct
+// messages and threads need the bot token.
+type Client struct {
+	webhookURL string
+	botToken   string
+	channel    string // Channel ID reports are posted to with the bot token
+	apiURL     string
+	httpClient *http.Client
+	statuses   notify.StatusLayout
+	templates  *messages.Templates
+}
+
+// NewClient creates a new Slack client
+func NewClient(webhookURL, botToken, channel string) *Client {
+	return &Client{
+		webhookURL: webhookURL,
+		botToken:   botToken,
+		channel:    channel,
+		apiURL:     DefaultAPIURL,
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+		statuses:  notify.DefaultStatusLayout(),
+		templates: messages.Default(),
+	}
+}
+
+// SetAPIURL points the client at another Slack Web API, such as a local
+// stand-in
+func (c *Client) SetAPIURL(apiURL string) {
+	c.apiURL = strings.TrimRight(apiURL, "/")
+}
+
+// SetStatusLayout replaces the client's status layout
+func (c *Client) SetStatusLayout(layout notify.StatusLayout) {
+	c.statuses = layout
+}
+
+// SetTemplates sets the templates messages are rendered with
+func (c *Client) SetTemplates(templates *messages.Templates) {
+	c.templates = templates
+}
+
+// SendStaleIssuesReport posts a summary of stale issues to the report
+// channel, with a section per status
+func (c *Client) SendStaleIssuesReport(ctx context.Context, staleIssues []notify.StaleIssue, people *directory.Directory, thresholdDays int) error {
+	headline, sections := c.statuses.StaleReport(staleIssues, people, transport, thresholdDays, mention)
+	text, err := c.templates.Render(messages.StaleReportTemplate, headline)
+	if err != nil {
+		return err
+	}
+	for _, section := range sections {
+		description, err := c.templates.Render(messages.StaleReportStatusTemplate, section)
+		if err != nil {
+			return err
+		}
+		text += "\n\n**" + section.Status + "**\n" + description
+	}
+	return c.sendReport(ctx, text)
+}
+
+// SendTaskFailure reports a failed task run to the report channel
+func (c *Client) SendTaskFailure(ctx context.Context, task string, errs []string) error {
+	failure, err := notify.RenderTaskFailure(c.templates, task, errs)
+	if err != nil {
+		return err
+	}
+	return c.sendReport(ctx, failure.Content+"\n\n**"+failure.Title+"**\n"+failure.Description)
+}
+
+// SendWeeklyDM sends a DM to a user with their assigned issues
+func (c *Client) SendWeeklyDM(ctx context.Context, userIssues notify.UserIssues) error {
+	text, err := c.templates.Render(messages.WeeklyDMTemplate, messages.WeeklyDM{
+		GithubUsername: userIssues.GithubUsername,
+		Count:          len(userIssues.Issues),
+		Groups:         c.statuses.GroupByStatus(userIssues.Issues),
+	})
+	if err != nil {
+		return err
+	}
+	return c.sendDM(ctx, userIssues.UserID, text)
+}
+
+// SendUnassignedIssuesDM sends a DM with all unassigned issues to a user
+func (c *Client) SendUnassignedIssuesDM(ctx context.Context, userID string, issues []github.Issue) error {
+	text, err := c.templates.Render(messages.UnassignedDMTemplate, messages.UnassignedDM{
+		Count:          len(issues),
+		ActiveStatuses: c.statuses.DescribeActive("or"),
+		Groups:         c.statuses.GroupByStatus(issues),
+	})
+	if err != nil {
+		return err
+	}
+	return c.sendDM(ctx, userID, text)
+}
+
+// CreateStandupThread posts the thread name to a channel and the standup
+// prompt as a reply in its thread. roleID is a user group to mention.
+func (c *Client) CreateStandupThread(ctx context.Context, channelID, roleID string) error {
+	if c.botToken == "" {
+		return fmt.Errorf("bot token not configured")
+	}
+
+	standup := messages.Standup{Date: time.Now(), RoleID: roleID}
+	if roleID != "" {
+		standup.RoleMention = fmt.Sprintf("<!subteam^%s>", roleID)
+	}
+	threadName, err := c.templates.Render(messages.StandupThreadNameTemplate, standup)
+	if err != nil {
+		return err
+	}
+	text, err := c.templates.Render(messages.StandupTemplate, standup)
+	if err != nil {
+		return err
+	}
+
+	ts, err := c.postMessage(ctx, channelID, "", "*"+threadName+"*")
+	if err != nil {
+		return fmt.Errorf("failed to create thread: %w", err)
+	}
+	return c.postText(ctx, channelID, ts, text)
+}
+
+// transport is how the directory names Slack
+const transport = "slack"
+
+// mention mentions a Slack user
+func mention(userID string) string {
+	return fmt.Sprintf("<@%s>", userID)
+}
+
+// sendReport posts Markdown text to the report channel
+func (c *Client) sendReport(ctx context.Context, text string) error {
+	if c.webhookURL == "" {
+		if c.botToken == "" || c.channel == "" {
+			return fmt.Errorf("neither a webhook URL nor a bot token and channel are configured")
+		}
+		return c.postText(ctx, c.channel, "", text)
+	}
+
+	parts := notify.SplitText(mrkdwn(text), maxTextLength)
+	for i, part := range parts {
+		payload, err := json.Marshal(map[string]interface{}{"text": part})
+		if err != nil {
+			return fmt.Errorf("failed to marshal webhook message: %w", err)
+		}
+		if _, err := c.post(ctx, c.webhookURL, payload, false); err != nil {
+			if len(parts) > 1 {
+				return fmt.Errorf("failed to send message %d of %d: %w", i+1, len(parts), err)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// sendDM posts Markdown text to a user's direct messages with the app
+func (c *Client) sendDM(ctx context.Context, userID, text string) error {
+	if c.botToken == "" {
+		return fmt.Errorf("bot token not configured")
+	}
+	// Posting to a user ID posts to the user's DM with the app
+	return c.postText(ctx, userID, "", text)
+}
+
+// postText posts Markdown text to a channel, or to a thread when threadTS
+// is set, split into as many messages as Slack's limit requires
+func (c *Client) postText(ctx context.Context, channel, threadTS, text string) error {
+	parts := notify.SplitText(mrkdwn(text), maxTextLength)
+	for i, part := range parts {
+		if _, err := c.postMessage(ctx, channel, threadTS, part); err != nil {
+			if len(parts) > 1 {
+				return fmt.Errorf("failed to send message %d of %d: %w", i+1, len(parts), err)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// postMessage posts mrkdwn text with chat.postMessage and returns the
+// message's timestamp, which identifies it as a thread
+func (c *Client) postMessage(ctx context.Context, channel, threadTS, text string) (string, error) {
+	msg := map[string]interface{}{
+		"channel":      channel,
+		"text":         text,
+		"unfurl_links": false,
+	}
+	if threadTS != "" {
+		msg["thread_ts"] = threadTS
+	}
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal message: %w", err)
+	}
+
+	body, err := c.post(ctx, c.apiURL+"/chat.postMessage", payload, true)
+	if err != nil {
+		return "", err
+	}
+
+	var resp struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+		TS    string `json:"ts"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+	if !resp.OK {
+		return "", fmt.Errorf("chat.postMessage failed: %s", resp.Error)
+	}
+	return resp.TS, nil
+}
+
+// post sends a JSON payload and returns the response body. A 429 response is
+// retried after the Retry-After Slack asks for; Slack did not act on it.
+func (c *Client) post(ctx context.Context, url string, payload []byte, auth bool) ([]byte, error) {
+	for attempt := 1; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(payload))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json; charset=utf-8")
+		if auth {
+			req.Header.Set("Authorization", "Bearer "+c.botToken)
+		}
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to send message: %w", err)
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read response: %w", err)
+		}
+
+		if resp.StatusCode == http.StatusTooManyRequests && attempt < maxAttempts {
+			wait, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+			if err != nil || wait < 1 {
+				wait = 1
+			}
+			slog.InfoContext(ctx, "Rate limited by Slack, retrying", "wait", time.Duration(wait)*time.Second)
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(time.Duration(wait) * time.Second):
+			}
+			continue
+		}
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return nil, fmt.Errorf("Slack returned non-success status: %d, body: %s", resp.StatusCode, string(body))
+		}
+		return body, nil
+	}
+}
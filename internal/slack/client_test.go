@@ -0,0 +1,150 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/storacha/project-agent/internal/standin"
+)
+
+// posted is a message the stand-in received
+type posted struct {
+	path     string
+	auth     string
+	channel  string
+	threadTS string
+	text     string
+}
+
+// slackStandin is a stand-in for Slack's incoming webhooks and
+// chat.postMessage. Each message is given a new ts; posting to the
+// channel named missing fails the way Slack does.
+type slackStandin struct {
+	*standin.Server
+}
+
+func newSlackStandin(t *testing.T) *slackStandin {
+	t.Helper()
+	f := &slackStandin{}
+	f.Server = standin.New(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg struct {
+			Channel string `json:"channel"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			http.Error(w, "invalid_payload", http.StatusBadRequest)
+			return
+		}
+		ts := fmt.Sprintf("1700000000.%06d", len(f.Requests()))
+
+		switch {
+		case r.URL.Path == "/webhook":
+			fmt.Fprint(w, "ok")
+		case msg.Channel == "missing":
+			fmt.Fprint(w, `{"ok":false,"error":"channel_not_found"}`)
+		default:
+			fmt.Fprintf(w, `{"ok":true,"channel":%q,"ts":%q}`, msg.Channel, ts)
+		}
+	}))
+	return f
+}
+
+// posted returns the messages received so far
+func (f *slackStandin) posted() []posted {
+	var messages []posted
+	for _, r := range f.Requests() {
+		var msg struct {
+			Channel  string `json:"channel"`
+			ThreadTS string `json:"thread_ts"`
+			Text     string `json:"text"`
+		}
+		_ = r.Decode(&msg)
+		messages = append(messages, posted{
+			path: r.Path, auth: r.Header.Get("Authorization"),
+			channel: msg.Channel, threadTS: msg.ThreadTS, text: msg.Text,
+		})
+	}
+	return messages
+}
+
+// client returns a client for the stand-in, with a webhook if webhook is
+// set
+func (f *slackStandin) client(webhook bool) *Client {
+	webhookURL := ""
+	if webhook {
+		webhookURL = f.URL + "/webhook"
+	}
+	c := NewClient(webhookURL, "xoxb-test", "C0REPORTS")
+	c.SetAPIURL(f.URL + "/")
+	return c
+}
+
+func TestCreateStandupThread(t *testing.T) {
+	f := newSlackStandin(t)
+
+	if err := f.client(false).CreateStandupThread(context.Background(), "C0STANDUP", "S0TEAM"); err != nil {
+		t.Fatal(err)
+	}
+
+	msgs := f.posted()
+	if len(msgs) != 2 {
+		t.Fatalf("got %d messages, want the thread and its prompt", len(msgs))
+	}
+	root, reply := msgs[0], msgs[1]
+	if root.path != "/chat.postMessage" || root.auth != "Bearer xoxb-test" || root.channel != "C0STANDUP" || root.threadTS != "" {
+		t.Errorf("thread message = %+v", root)
+	}
+	if !strings.HasPrefix(root.text, "*") || strings.HasPrefix(root.text, "**") {
+		t.Errorf("thread name %q is not bold mrkdwn", root.text)
+	}
+	if reply.channel != "C0STANDUP" || reply.threadTS != "1700000000.000001" {
+		t.Errorf("prompt = %+v, want it in the thread of the first message", reply)
+	}
+	if !strings.Contains(reply.text, "<!subteam^S0TEAM>") {
+		t.Errorf("prompt %q does not mention the user group", reply.text)
+	}
+}
+
+func TestSendTaskFailureToWebhook(t *testing.T) {
+	f := newSlackStandin(t)
+
+	if err := f.client(true).SendTaskFailure(context.Background(), "triage-stale", []string{"boom"}); err != nil {
+		t.Fatal(err)
+	}
+
+	msgs := f.posted()
+	if len(msgs) != 1 || msgs[0].path != "/webhook" || msgs[0].auth != "" {
+		t.Fatalf("messages = %+v, want one unauthenticated webhook post", msgs)
+	}
+	if !strings.Contains(msgs[0].text, "triage-stale") || !strings.Contains(msgs[0].text, "boom") || strings.Contains(msgs[0].text, "**") {
+		t.Errorf("text = %q, want the task and error in mrkdwn", msgs[0].text)
+	}
+}
+
+func TestSendTaskFailureToChannel(t *testing.T) {
+	f := newSlackStandin(t)
+
+	if err := f.client(false).SendTaskFailure(context.Background(), "triage-stale", []string{"boom"}); err != nil {
+		t.Fatal(err)
+	}
+
+	msgs := f.posted()
+	if len(msgs) != 1 || msgs[0].path != "/chat.postMessage" || msgs[0].channel != "C0REPORTS" {
+		t.Fatalf("messages = %+v, want one post to the report channel", msgs)
+	}
+}
+
+func TestPostMessageReportsSlackErrors(t *testing.T) {
+	f := newSlackStandin(t)
+
+	err := f.client(false).CreateStandupThread(context.Background(), "missing", "")
+	if err == nil || !strings.Contains(err.Error(), "channel_not_found") {
+		t.Fatalf("err = %v, want channel_not_found", err)
+	}
+	if n := len(f.posted()); n != 1 {
+		t.Errorf("got %d messages, want the prompt not sent without a thread", n)
+	}
+}
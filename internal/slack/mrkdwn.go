@@ -0,0 +1,40 @@
+package slack
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	markdownLink   = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+	markdownBold   = regexp.MustCompile(`\*\*(.+?)\*\*`)
+	markdownItalic = regexp.MustCompile(`\*([^*\s][^*]*?)\*`)
+	slackEntity    = regexp.MustCompile(`<[@#!][^<>]*>`)
+)
+
+// mrkdwn converts the Markdown the message templates are written in to
+// Slack's mrkdwn: links become <url|text>, **bold** becomes *bold* and
+// *italic* becomes _italic_. &, < and > are escaped, except in mentions.
+func mrkdwn(text string) string {
+	// Mentions are set aside so escaping leaves them alone
+	var entities []string
+	text = slackEntity.ReplaceAllStringFunc(text, func(entity string) string {
+		entities = append(entities, entity)
+		return "\x00"
+	})
+	text = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(text)
+
+	text = markdownLink.ReplaceAllStringFunc(text, func(link string) string {
+		m := markdownLink.FindStringSubmatch(link)
+		return "<" + m[2] + "|" + strings.ReplaceAll(m[1], "|", "¦") + ">"
+	})
+	// Bold is marked with \x01 until italics are converted
+	text = markdownBold.ReplaceAllString(text, "\x01$1\x01")
+	text = markdownItalic.ReplaceAllString(text, "_${1}_")
+	text = strings.ReplaceAll(text, "\x01", "*")
+
+	for _, entity := range entities {
+		text = strings.Replace(text, "\x00", entity, 1)
+	}
+	return text
+}
@@ -0,0 +1,116 @@
+// Package standin provides a local HTTP server that stands in for a remote
+// API in tests. It records every request it serves and answers with scripted
+// replies in order, then with its handler, so API clients can be exercised
+// without network access.
+package standin
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Request is a request the stand-in served
+type Request struct {
+	Method string
+	Path   string
+	Header http.Header
+	Body   []byte
+	At     time.Time
+}
+
+// Decode unmarshals the JSON request body into v
+func (r Request) Decode(v any) error {
+	return json.Unmarshal(r.Body, v)
+}
+
+// Reply is a scripted response. A zero Status is a 200.
+type Reply struct {
+	Status int
+	Header map[string]string
+	Body   string
+}
+
+// ServeHTTP answers every request with the reply, so a Reply can be a
+// stand-in's handler
+func (r Reply) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	for k, v := range r.Header {
+		w.Header().Set(k, v)
+	}
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	status := r.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	w.WriteHeader(status)
+	io.WriteString(w, r.Body)
+}
+
+// Server is a stand-in for a remote API
+type Server struct {
+	URL string
+
+	handler  http.Handler
+	mu       sync.Mutex
+	replies  []Reply
+	requests []Request
+}
+
+// New starts a stand-in that is closed when the test finishes. Requests
+// without a scripted reply are passed to handler, with their body intact; a
+// nil handler answers them with an empty 200.
+func New(t testing.TB, handler http.Handler) *Server {
+	t.Helper()
+	if handler == nil {
+		handler = Reply{}
+	}
+	s := &Server{handler: handler}
+	server := httptest.NewServer(http.HandlerFunc(s.serve))
+	t.Cleanup(server.Close)
+	s.URL = server.URL
+	return s
+}
+
+// Script queues replies to answer the next requests with, in order
+func (s *Server) Script(replies ...Reply) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.replies = append(s.replies, replies...)
+}
+
+// Requests returns the requests served so far
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	s.mu.Lock()
+	s.requests = append(s.requests, Request{
+		Method: r.Method,
+		Path:   r.URL.Path,
+		Header: r.Header.Clone(),
+		Body:   body,
+		At:     time.Now(),
+	})
+	if len(s.replies) == 0 {
+		s.mu.Unlock()
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		s.handler.ServeHTTP(w, r)
+		return
+	}
+	next := s.replies[0]
+	s.replies = s.replies[1:]
+	s.mu.Unlock()
+
+	next.ServeHTTP(w, r)
+}
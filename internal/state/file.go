@@ -0,0 +1,203 @@
+package state
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// valuesFile holds the key/value state, and logsDir one JSONL file per log
+const (
+	valuesFile = "state.json"
+	logsDir    = "logs"
+)
+
+// FileStore keeps state in a local directory: values in state.json and each
+// log in logs/<name>.jsonl. It is not safe for concurrent processes.
+type FileStore struct {
+	dir     string
+	values  map[string]json.RawMessage
+	pending map[string][]json.RawMessage // Log entries not saved yet
+	dirty   bool
+}
+
+var _ Store = (*FileStore)(nil)
+
+// OpenFile opens the state in dir. A missing directory is empty state; it is
+// created on Save.
+func OpenFile(dir string) (*FileStore, error) {
+	s := &FileStore{
+		dir:     dir,
+		values:  make(map[string]json.RawMessage),
+		pending: make(map[string][]json.RawMessage),
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, valuesFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state: %w", err)
+	}
+	if err := json.Unmarshal(data, &s.values); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filepath.Join(dir, valuesFile), err)
+	}
+	if s.values == nil {
+		s.values = make(map[string]json.RawMessage)
+	}
+	return s, nil
+}
+
+// Get implements Store
+func (s *FileStore) Get(key string, v interface{}) (bool, error) {
+	raw, ok := s.values[key]
+	if !ok {
+		return false, nil
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return true, fmt.Errorf("failed to decode state %q: %w", key, err)
+	}
+	return true, nil
+}
+
+// Put implements Store
+func (s *FileStore) Put(key string, v interface{}) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode state %q: %w", key, err)
+	}
+	s.values[key] = raw
+	s.dirty = true
+	return nil
+}
+
+// Delete implements Store
+func (s *FileStore) Delete(key string) error {
+	if _, ok := s.values[key]; ok {
+		delete(s.values, key)
+		s.dirty = true
+	}
+	return nil
+}
+
+// Keys implements Store
+func (s *FileStore) Keys(prefix string) []string {
+	var keys []string
+	for key := range s.values {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Append implements Store
+func (s *FileStore) Append(log string, v interface{}) error {
+	if err := checkLogName(log); err != nil {
+		return err
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s log entry: %w", log, err)
+	}
+	s.pending[log] = append(s.pending[log], raw)
+	return nil
+}
+
+// Log implements Store
+func (s *FileStore) Log(log string) ([]json.RawMessage, error) {
+	if err := checkLogName(log); err != nil {
+		return nil, err
+	}
+
+	var entries []json.RawMessage
+	f, err := os.Open(s.logPath(log))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to open %s log: %w", log, err)
+	}
+	if err == nil {
+		defer f.Close()
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+		for scanner.Scan() {
+			if line := bytes.TrimSpace(scanner.Bytes()); len(line) > 0 {
+				entries = append(entries, append(json.RawMessage{}, line...))
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read %s log: %w", log, err)
+		}
+	}
+
+	return append(entries, s.pending[log]...), nil
+}
+
+// Save implements Store. Values are replaced atomically; log entries are
+// appended.
+func (s *FileStore) Save(ctx context.Context) error {
+	if !s.dirty && len(s.pending) == 0 {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Join(s.dir, logsDir), 0o755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	if s.dirty {
+		// Keys are sorted and indented so the file diffs well in git
+		data, err := json.MarshalIndent(s.values, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode state: %w", err)
+		}
+		tmp := filepath.Join(s.dir, valuesFile+".tmp")
+		if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+			return fmt.Errorf("failed to write state: %w", err)
+		}
+		if err := os.Rename(tmp, filepath.Join(s.dir, valuesFile)); err != nil {
+			return fmt.Errorf("failed to write state: %w", err)
+		}
+		s.dirty = false
+	}
+
+	logs := make([]string, 0, len(s.pending))
+	for log := range s.pending {
+		logs = append(logs, log)
+	}
+	sort.Strings(logs)
+	for _, log := range logs {
+		var buf bytes.Buffer
+		for _, entry := range s.pending[log] {
+			buf.Write(entry)
+			buf.WriteByte('\n')
+		}
+		f, err := os.OpenFile(s.logPath(log), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+		if err != nil {
+			return fmt.Errorf("failed to open %s log: %w", log, err)
+		}
+		_, err = f.Write(buf.Bytes())
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return fmt.Errorf("failed to write %s log: %w", log, err)
+		}
+		delete(s.pending, log)
+	}
+	return nil
+}
+
+// Close implements Store
+func (s *FileStore) Close() error {
+	return nil
+}
+
+func (s *FileStore) logPath(log string) string {
+	return filepath.Join(s.dir, logsDir, log+".jsonl")
+}
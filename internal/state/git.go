@@ -0,0 +1,224 @@
+package state
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// pushAttempts is how many times Save retries when another run pushed to the
+// branch first
+const pushAttempts = 3
+
+// GitStore keeps state in the file layout of FileStore on a dedicated branch
+// of a git repository, so runs on different machines share it. Save commits
+// and pushes; if the branch moved since it was fetched, the store's changes
+// are replayed on the new head and pushed again.
+type GitStore struct {
+	repo   string
+	branch string
+	token  string
+	dir    string // Scratch clone holding the branch
+	files  *FileStore
+	// ops replays this store's unsaved changes onto freshly fetched state
+	ops []func(*FileStore) error
+}
+
+var _ Store = (*GitStore)(nil)
+
+// OpenGit fetches branch from repo, a URL or local path, into a scratch
+// clone. A missing branch is empty state; it is created on Save. token, if
+// set, authenticates HTTPS remotes as a GitHub token.
+func OpenGit(ctx context.Context, repo, branch, token string) (*GitStore, error) {
+	dir, err := os.MkdirTemp("", "project-agent-state-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create state clone: %w", err)
+	}
+
+	s := &GitStore{repo: repo, branch: branch, token: token, dir: dir}
+	if _, err := s.git(ctx, "init", "-q"); err != nil {
+		s.Close()
+		return nil, err
+	}
+	if err := s.checkout(ctx); err != nil {
+		s.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// checkout fetches the branch and loads its state, discarding anything
+// committed locally
+func (s *GitStore) checkout(ctx context.Context) error {
+	found, err := s.fetch(ctx)
+	if err != nil {
+		return err
+	}
+	if found {
+		if _, err := s.git(ctx, "checkout", "-q", "-B", s.branch, "FETCH_HEAD"); err != nil {
+			return err
+		}
+		if _, err := s.git(ctx, "reset", "-q", "--hard", "FETCH_HEAD"); err != nil {
+			return err
+		}
+	} else if _, err := s.git(ctx, "checkout", "-q", "--orphan", s.branch); err != nil {
+		return err
+	}
+
+	files, err := OpenFile(s.dir)
+	if err != nil {
+		return err
+	}
+	s.files = files
+	return nil
+}
+
+// fetch fetches the branch into FETCH_HEAD, reporting whether it exists
+func (s *GitStore) fetch(ctx context.Context) (bool, error) {
+	refs, err := s.git(ctx, "ls-remote", "--heads", s.repo, s.branch)
+	if err != nil {
+		return false, err
+	}
+	if strings.TrimSpace(refs) == "" {
+		return false, nil
+	}
+	if _, err := s.git(ctx, "fetch", "-q", s.repo, "refs/heads/"+s.branch); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Get implements Store
+func (s *GitStore) Get(key string, v interface{}) (bool, error) {
+	return s.files.Get(key, v)
+}
+
+// Put implements Store
+func (s *GitStore) Put(key string, v interface{}) error {
+	return s.record(func(f *FileStore) error { return f.Put(key, v) })
+}
+
+// Delete implements Store
+func (s *GitStore) Delete(key string) error {
+	return s.record(func(f *FileStore) error { return f.Delete(key) })
+}
+
+// Keys implements Store
+func (s *GitStore) Keys(prefix string) []string {
+	return s.files.Keys(prefix)
+}
+
+// Append implements Store
+func (s *GitStore) Append(log string, v interface{}) error {
+	return s.record(func(f *FileStore) error { return f.Append(log, v) })
+}
+
+// Log implements Store
+func (s *GitStore) Log(log string) ([]json.RawMessage, error) {
+	return s.files.Log(log)
+}
+
+// record applies a change and keeps it for replaying after a conflict
+func (s *GitStore) record(op func(*FileStore) error) error {
+	if err := op(s.files); err != nil {
+		return err
+	}
+	s.ops = append(s.ops, op)
+	return nil
+}
+
+// Save implements Store
+func (s *GitStore) Save(ctx context.Context) error {
+	if len(s.ops) == 0 {
+		return nil
+	}
+
+	for attempt := 1; ; attempt++ {
+		if err := s.files.Save(ctx); err != nil {
+			return err
+		}
+		if _, err := s.git(ctx, "add", "-A"); err != nil {
+			return err
+		}
+		if status, err := s.git(ctx, "status", "--porcelain"); err != nil {
+			return err
+		} else if strings.TrimSpace(status) == "" {
+			s.ops = nil
+			return nil
+		}
+		if _, err := s.git(ctx, "commit", "-q", "-m", "Update project-agent state"); err != nil {
+			return err
+		}
+
+		_, err := s.git(ctx, "push", "-q", s.repo, "HEAD:refs/heads/"+s.branch)
+		if err == nil {
+			s.ops = nil
+			return nil
+		}
+		if attempt == pushAttempts {
+			return fmt.Errorf("failed to push state after %d attempts: %w", attempt, err)
+		}
+
+		// Another run pushed first: start again from its state
+		slog.WarnContext(ctx, "State branch moved since it was fetched, replaying changes", "branch", s.branch, "changes", len(s.ops))
+		if err := s.checkout(ctx); err != nil {
+			return err
+		}
+		for _, op := range s.ops {
+			if err := op(s.files); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Close implements Store. It removes the scratch clone.
+func (s *GitStore) Close() error {
+	return os.RemoveAll(s.dir)
+}
+
+// git runs a git command in the scratch clone and returns its output
+func (s *GitStore) git(ctx context.Context, args ...string) (string, error) {
+	config := []string{
+		"-c", "user.name=project-agent",
+		"-c", "user.email=project-agent@users.noreply.github.com",
+		"-c", "commit.gpgsign=false",
+	}
+
+	cmd := exec.CommandContext(ctx, "git", append(config, args...)...)
+	cmd.Dir = s.dir
+	cmd.Env = s.env()
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s failed: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+// env returns the environment for git commands. The token is passed as
+// configuration in the environment rather than on the command line, where
+// other users of the machine could read it.
+func (s *GitStore) env() []string {
+	env := append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if s.token == "" || !strings.HasPrefix(s.repo, "https://") {
+		return env
+	}
+
+	// Add to any configuration already in the environment
+	n, _ := strconv.Atoi(os.Getenv("GIT_CONFIG_COUNT"))
+	auth := base64.StdEncoding.EncodeToString([]byte("x-access-token:" + s.token))
+	return append(env,
+		fmt.Sprintf("GIT_CONFIG_COUNT=%d", n+1),
+		fmt.Sprintf("GIT_CONFIG_KEY_%d=http.extraHeader", n),
+		fmt.Sprintf("GIT_CONFIG_VALUE_%d=Authorization: basic %s", n, auth),
+	)
+}
@@ -0,0 +1,106 @@
+package state
+
+import (
+	"context"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// bareRepo returns the path of a new bare repository
+func bareRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := filepath.Join(t.TempDir(), "state.git")
+	if out, err := exec.Command("git", "init", "-q", "--bare", dir).CombinedOutput(); err != nil {
+		t.Fatalf("git init --bare: %v: %s", err, out)
+	}
+	return dir
+}
+
+func openGit(t *testing.T, repo string) *GitStore {
+	t.Helper()
+	s, err := OpenGit(context.Background(), repo, "state", "")
+	if err != nil {
+		t.Fatalf("OpenGit: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestGitStoreSaveReplaysAfterConflict(t *testing.T) {
+	repo := bareRepo(t)
+	ctx := context.Background()
+
+	// Both writers fetch the branch before either pushes
+	first, second := openGit(t, repo), openGit(t, repo)
+	if err := first.Put("first", 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := first.Append("runs", "first"); err != nil {
+		t.Fatal(err)
+	}
+	if err := second.Put("second", 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := second.Append("runs", "second"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := first.Save(ctx); err != nil {
+		t.Fatalf("first Save: %v", err)
+	}
+	if err := second.Save(ctx); err != nil {
+		t.Fatalf("second Save: %v", err)
+	}
+
+	merged := openGit(t, repo)
+	for key, want := range map[string]int{"first": 1, "second": 2} {
+		var got int
+		if ok, err := merged.Get(key, &got); err != nil || !ok || got != want {
+			t.Errorf("Get(%q) = %d, %v, %v; want %d", key, got, ok, err, want)
+		}
+	}
+	entries, err := merged.Log("runs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var runs []string
+	for _, e := range entries {
+		runs = append(runs, string(e))
+	}
+	if got := strings.Join(runs, ","); got != `"first","second"` {
+		t.Errorf("runs log = %s, want both writers' entries in push order", got)
+	}
+}
+
+func TestGitStoreTokenStaysOffCommandLine(t *testing.T) {
+	t.Setenv("GIT_CONFIG_COUNT", "1")
+	s := &GitStore{repo: "https://github.com/storacha/state.git", token: "ghs_secret"}
+
+	var count, key, value string
+	for _, kv := range s.env() {
+		name, v, _ := strings.Cut(kv, "=")
+		switch name {
+		case "GIT_CONFIG_COUNT":
+			count = v
+		case "GIT_CONFIG_KEY_1":
+			key = v
+		case "GIT_CONFIG_VALUE_1":
+			value = v
+		}
+	}
+	if count != "2" || key != "http.extraHeader" || !strings.HasPrefix(value, "Authorization: basic ") {
+		t.Errorf("GIT_CONFIG_COUNT=%s KEY_1=%s VALUE_1=%s, want the header added after the existing entry", count, key, value)
+	}
+
+	s.repo = "/srv/state.git"
+	for _, kv := range s.env() {
+		if strings.HasPrefix(kv, "GIT_CONFIG_VALUE_1=") {
+			t.Errorf("token sent to a local repository: %s", kv)
+		}
+	}
+}
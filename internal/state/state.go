@@ -0,0 +1,61 @@
+// Package state stores what the agent remembers between runs: JSON values by
+// key and append-only logs. Changes are kept in memory until Save.
+package state
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+)
+
+// Store is persistent key/value state with append-only logs
+type Store interface {
+	// Get decodes the value stored under key into v, reporting whether the
+	// key was set
+	Get(key string, v interface{}) (bool, error)
+	// Put stores v as JSON under key
+	Put(key string, v interface{}) error
+	// Delete removes key
+	Delete(key string) error
+	// Keys returns the keys starting with prefix, sorted
+	Keys(prefix string) []string
+	// Append adds v as JSON to the end of the named log
+	Append(log string, v interface{}) error
+	// Log returns every entry of the named log, oldest first
+	Log(log string) ([]json.RawMessage, error)
+	// Save persists the changes made since the store was opened or last saved
+	Save(ctx context.Context) error
+	// Close releases the store without saving
+	Close() error
+}
+
+// Options selects and configures a backend
+type Options struct {
+	Backend string // "file" or "git"
+	Path    string // Directory for the file backend
+	Repo    string // Repository URL or path for the git backend
+	Branch  string // Branch for the git backend
+	Token   string // GitHub token the git backend authenticates HTTPS remotes with
+}
+
+// Open opens the configured backend
+func Open(ctx context.Context, opts Options) (Store, error) {
+	switch opts.Backend {
+	case "file":
+		return OpenFile(opts.Path)
+	case "git":
+		return OpenGit(ctx, opts.Repo, opts.Branch, opts.Token)
+	}
+	return nil, fmt.Errorf("unknown state backend %q", opts.Backend)
+}
+
+// logNamePattern limits log names to what is safe as a file name
+var logNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+func checkLogName(name string) error {
+	if !logNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid log name %q: use letters, digits, '.', '_' and '-'", name)
+	}
+	return nil
+}
@@ -3,51 +3,77 @@ package tasks
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 
 	"github.com/storacha/project-agent/internal/config"
-	"github.com/storacha/project-agent/internal/discord"
 )
 
-// AsyncStandupReport contains the results of async standup thread creation
-type AsyncStandupReport struct {
-	ThreadCreated bool
-	Error         string
+func init() {
+	Register(AsyncStandup{})
 }
 
-// CreateAsyncStandup creates a new standup thread in Discord
-func CreateAsyncStandup(ctx context.Context, discordClient *discord.Client, cfg *config.Config) (*AsyncStandupReport, error) {
-	report := &AsyncStandupReport{}
+// AsyncStandup opens the async standup thread in the configured channel
+type AsyncStandup struct{}
 
-	if cfg.DiscordStandupChannelID == "" {
-		err := fmt.Errorf("DISCORD_STANDUP_CHANNEL_ID not configured")
-		log.Printf("ERROR: %v\n", err)
-		report.Error = err.Error()
-		return report, err
-	}
+// Name implements Task
+func (AsyncStandup) Name() string { return "async-standup" }
 
-	log.Println("Creating async standup thread in Discord...")
+// Summary implements Task
+func (AsyncStandup) Summary() string {
+	return "Open the daily async standup thread"
+}
 
-	if cfg.DryRun {
-		log.Println("[DRY RUN] Would create standup thread in channel:", cfg.DiscordStandupChannelID)
-		if cfg.DiscordStandupRoleID != "" {
-			log.Println("[DRY RUN] Would mention role:", cfg.DiscordStandupRoleID)
-		}
-		report.ThreadCreated = true
-		return report, nil
+// Needs implements Task
+func (AsyncStandup) Needs(cfg *config.Config) Needs {
+	return Needs{Notifier: DirectNotifier}
+}
+
+// Schema implements Task
+func (AsyncStandup) Schema(cfg *config.Config) ReportSchema {
+	return ReportSchema{
+		Title: "Async Standup Report",
+		Fields: []FieldSpec{
+			{Key: "thread_created", Label: "Standup thread created"},
+		},
 	}
+}
 
-	// Create the standup thread
-	err := discordClient.CreateStandupThread(ctx, cfg.DiscordStandupChannelID, cfg.DiscordStandupRoleID)
-	if err != nil {
-		errMsg := fmt.Sprintf("Failed to create standup thread: %v", err)
-		log.Printf("ERROR: %s\n", errMsg)
-		report.Error = errMsg
-		return report, err
+// Plan implements Task
+func (t AsyncStandup) Plan(ctx context.Context, env *Env) (*Plan, error) {
+	cfg := env.Config
+	if cfg.AsyncStandup.ChannelID == "" {
+		return nil, fmt.Errorf("DISCORD_STANDUP_CHANNEL_ID not configured")
 	}
 
-	report.ThreadCreated = true
-	log.Println("Successfully created standup thread")
+	plan := &Plan{Task: t.Name(), Report: NewReport(t.Name())}
+	plan.Report.Set("thread_created", "no")
+	plan.Actions = append(plan.Actions, Action{
+		Kind:   ActionStandup,
+		Value:  cfg.AsyncStandup.ChannelID,
+		Target: cfg.AsyncStandup.RoleID,
+	})
+
+	return plan, nil
+}
+
+// Apply implements Task
+func (AsyncStandup) Apply(ctx context.Context, env *Env, plan *Plan) (*Report, error) {
+	report := plan.Report
+
+	for _, action := range plan.actionsOfKind(ActionStandup) {
+		slog.InfoContext(ctx, "Creating async standup thread", "channel", action.Value)
+
+		err := env.Notifier.CreateStandupThread(ctx, action.Value, action.Target)
+		report.Record(action, err)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to create standup thread", "error", err)
+			report.Errors = append(report.Errors, fmt.Sprintf("Failed to create standup thread: %v", err))
+			continue
+		}
+
+		report.Set("thread_created", "yes")
+		slog.InfoContext(ctx, "Successfully created standup thread")
+	}
 
 	return report, nil
 }
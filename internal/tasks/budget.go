@@ -0,0 +1,20 @@
+package tasks
+
+import (
+	"context"
+	"log/slog"
+)
+
+// budgetExhausted reports whether the board's API budget is too low to keep
+// making changes. When it is, the reason is logged and added to errs so the
+// run stops cleanly instead of failing halfway through a write.
+func budgetExhausted(ctx context.Context, board ProjectBoard, errs *[]string) bool {
+	err := board.Budget().Check()
+	if err == nil {
+		return false
+	}
+
+	slog.WarnContext(ctx, "Stopping early", "error", err)
+	*errs = append(*errs, "Stopping early: "+err.Error())
+	return true
+}
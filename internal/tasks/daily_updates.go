@@ -2,90 +2,149 @@ package tasks
 
 import (
 	"context"
-	"log"
+	"fmt"
+	"log/slog"
+	"sort"
 	"time"
 
 	"github.com/storacha/project-agent/internal/config"
-	"github.com/storacha/project-agent/internal/discord"
 	"github.com/storacha/project-agent/internal/github"
+	"github.com/storacha/project-agent/internal/notify"
 )
 
-// DailyUpdateReport contains the results of the daily update check
-type DailyUpdateReport struct {
-	TotalIssuesChecked int
-	StaleIssues        []discord.StaleIssue
-	Errors             []string
+func init() {
+	Register(DailyUpdates{})
 }
 
-// CheckDailyUpdates checks active issues for staleness and reports to Discord
-func CheckDailyUpdates(ctx context.Context, githubClient *github.Client, discordClient *discord.Client, cfg *config.Config) (*DailyUpdateReport, error) {
-	report := &DailyUpdateReport{}
+// DailyUpdates posts the active issues without a recent update to the
+// report channel
+type DailyUpdates struct{}
 
-	// Fetch issues with active statuses (Sprint Backlog, In Progress, PR Review)
-	activeStatuses := []string{"Sprint Backlog", "In Progress", "PR Review"}
-	log.Printf("Fetching issues with statuses: %v\n", activeStatuses)
+// Name implements Task
+func (DailyUpdates) Name() string { return "check-daily-updates" }
 
-	issues, err := githubClient.GetIssuesByStatuses(ctx, activeStatuses)
+// Summary implements Task
+func (DailyUpdates) Summary() string {
+	return "Post active issues without a recent update to the report channel"
+}
+
+// Needs implements Task
+func (DailyUpdates) Needs(cfg *config.Config) Needs {
+	return Needs{Board: true, Schema: DailyUpdateRequirements(cfg), Notifier: ChannelNotifier}
+}
+
+// Schema implements Task
+func (DailyUpdates) Schema(cfg *config.Config) ReportSchema {
+	return ReportSchema{
+		Title: "Daily Update Check Report",
+		Fields: []FieldSpec{
+			{Key: "issues_checked", Label: "Total issues checked"},
+			{Key: "stale_issues", Label: "Stale issues found"},
+		},
+	}
+}
+
+// Plan implements Task. The report is planned even when no issue is stale,
+// so the channel hears that everything is up to date.
+func (t DailyUpdates) Plan(ctx context.Context, env *Env) (*Plan, error) {
+	cfg := env.Config
+	plan := &Plan{Task: t.Name(), Report: NewReport(t.Name())}
+
+	slog.InfoContext(ctx, "Fetching issues", "statuses", cfg.Statuses.Active, "threshold_days", cfg.DailyUpdates.ThresholdDays)
+
+	issues, err := env.Board.GetIssuesByStatuses(ctx, cfg.Statuses.Active)
 	if err != nil {
-		return report, err
+		return nil, err
 	}
 
-	report.TotalIssuesChecked = len(issues)
-	log.Printf("Found %d active issues to check\n", len(issues))
+	plan.Report.Set("issues_checked", len(issues))
+	slog.InfoContext(ctx, "Found active issues to check", "count", len(issues))
 
 	// Check each issue for staleness
 	now := time.Now()
-	threshold := time.Duration(cfg.DailyUpdateThreshold) * 24 * time.Hour
+	threshold := time.Duration(cfg.DailyUpdates.ThresholdDays) * 24 * time.Hour
 
+	var stale []github.Issue
+	byStatus := make(map[string]int)
 	for _, issue := range issues {
-		daysSinceUpdate := int(now.Sub(issue.UpdatedAt).Hours() / 24)
-
 		if now.Sub(issue.UpdatedAt) > threshold {
-			log.Printf("Issue #%d is stale (%d days since update)\n", issue.Number, daysSinceUpdate)
+			slog.InfoContext(ctx, "Issue is stale", "issue", issue.Ref(), "days_since_update", daysSince(issue.UpdatedAt))
+			stale = append(stale, issue)
+			byStatus[issue.ProjectItem.StatusValue]++
+		}
+	}
+
+	slog.InfoContext(ctx, "Found stale issues", "count", len(stale))
+	plan.Report.Set("stale_issues", len(stale))
+	recordStaleIssues(t.Name(), cfg.Statuses.Active, stale)
+
+	var lines []string
+	for _, status := range orderedStatuses(cfg.Statuses.Order, byStatus) {
+		lines = append(lines, fmt.Sprintf("%s: %d", status, byStatus[status]))
+	}
+	plan.Report.Section("Stale issues by status", lines)
 
-			staleIssue := discord.StaleIssue{
+	if env.Notifier == nil {
+		slog.WarnContext(ctx, "No report channel configured, skipping notification")
+		return plan, nil
+	}
+	plan.Actions = append(plan.Actions, Action{Kind: ActionStaleReport, Issues: stale})
+
+	return plan, nil
+}
+
+// Apply implements Task
+func (DailyUpdates) Apply(ctx context.Context, env *Env, plan *Plan) (*Report, error) {
+	report := plan.Report
+
+	for _, action := range plan.actionsOfKind(ActionStaleReport) {
+		var staleIssues []notify.StaleIssue
+		for _, issue := range action.Issues {
+			staleIssues = append(staleIssues, notify.StaleIssue{
 				Issue:           issue,
-				DaysSinceUpdate: daysSinceUpdate,
+				DaysSinceUpdate: daysSince(issue.UpdatedAt),
 				AssignedTo:      issue.Assignees,
-			}
+			})
+		}
 
-			report.StaleIssues = append(report.StaleIssues, staleIssue)
+		slog.InfoContext(ctx, "Sending notification", "issues", len(staleIssues))
+		err := env.Notifier.SendStaleIssuesReport(ctx, staleIssues, env.Directory, env.Config.DailyUpdates.ThresholdDays)
+		report.Record(action, err)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to send notification", "error", err)
+			report.Errors = append(report.Errors, "Failed to send notification: "+err.Error())
+		} else {
+			slog.InfoContext(ctx, "Notification sent successfully")
 		}
 	}
 
-	log.Printf("Found %d stale issues\n", len(report.StaleIssues))
+	return report, nil
+}
 
-	// Send Discord notification
-	if !cfg.DryRun {
-		if cfg.DiscordWebhookURL == "" {
-			log.Println("WARNING: DISCORD_WEBHOOK_URL not set, skipping Discord notification")
-		} else {
-			log.Println("Sending Discord notification...")
-			if err := discordClient.SendStaleIssuesReport(ctx, report.StaleIssues, cfg.UserMappings); err != nil {
-				errMsg := "Failed to send Discord notification: " + err.Error()
-				log.Printf("ERROR: %s\n", errMsg)
-				report.Errors = append(report.Errors, errMsg)
-			} else {
-				log.Println("Discord notification sent successfully")
-			}
+// daysSince returns the whole days elapsed since t
+func daysSince(t time.Time) int {
+	return int(time.Since(t).Hours() / 24)
+}
+
+// orderedStatuses returns the statuses in counts, those in order first and
+// the rest alphabetically
+func orderedStatuses(order []string, counts map[string]int) []string {
+	var statuses []string
+	listed := make(map[string]bool)
+	for _, status := range order {
+		if _, ok := counts[status]; ok {
+			statuses = append(statuses, status)
 		}
-	} else {
-		log.Println("[DRY RUN] Would send Discord notification for the following stale issues:")
-		for _, stale := range report.StaleIssues {
-			assignees := "unassigned"
-			if len(stale.AssignedTo) > 0 {
-				assignees = ""
-				for i, a := range stale.AssignedTo {
-					if i > 0 {
-						assignees += ", "
-					}
-					assignees += a
-				}
-			}
-			log.Printf("  -  [%s #%d]: %s (%d days, assigned: %s)\n",
-				stale.Issue.RepositoryName, stale.Issue.Number, stale.Issue.Title, stale.DaysSinceUpdate, assignees)
+		listed[status] = true
+	}
+
+	var rest []string
+	for status := range counts {
+		if !listed[status] {
+			rest = append(rest, status)
 		}
 	}
+	sort.Strings(rest)
 
-	return report, nil
+	return append(statuses, rest...)
 }
@@ -3,12 +3,10 @@ package tasks
 import (
 	"context"
 	"fmt"
-	"log"
-	"time"
+	"log/slog"
 
 	"github.com/storacha/project-agent/internal/config"
 	"github.com/storacha/project-agent/internal/github"
-	"github.com/storacha/project-agent/internal/similarity"
 )
 
 // DuplicateGroup represents a group of potentially duplicate issues
@@ -17,26 +15,102 @@ type DuplicateGroup struct {
 	Similarity float64
 }
 
-// DuplicateDetectionReport contains the results of duplicate detection
-type DuplicateDetectionReport struct {
-	IssuesAnalyzed  int
-	DuplicateGroups []DuplicateGroup
-	IssuesLabeled   int
-	Errors          []string
+// duplicateLabel is added to every issue in a duplicate group
+const duplicateLabel = "possible duplicate"
+
+func init() {
+	Register(DuplicateDetection{})
+}
+
+// DuplicateDetection uses semantic similarity to find potential duplicate
+// issues and labels them
+type DuplicateDetection struct{}
+
+// Name implements Task
+func (DuplicateDetection) Name() string { return "detect-duplicates" }
+
+// Summary implements Task
+func (DuplicateDetection) Summary() string {
+	return "Label issues that look like duplicates of each other"
 }
 
-// DetectDuplicates uses semantic similarity to find potential duplicate issues
-func DetectDuplicates(ctx context.Context, githubClient *github.Client, similarityClient *similarity.Client, issues []github.Issue, cfg *config.Config) (*DuplicateDetectionReport, error) {
-	report := &DuplicateDetectionReport{
-		IssuesAnalyzed: len(issues),
+// Needs implements Task
+func (DuplicateDetection) Needs(cfg *config.Config) Needs {
+	return Needs{Board: true, Schema: DuplicateDetectionRequirements(cfg), Similarity: true}
+}
+
+// Schema implements Task
+func (DuplicateDetection) Schema(cfg *config.Config) ReportSchema {
+	return ReportSchema{
+		Title: "Duplicate Detection Report",
+		Fields: []FieldSpec{
+			{Key: "issues_analyzed", Label: "Issues Analyzed"},
+			{Key: "duplicate_groups", Label: "Potential Duplicate Groups"},
+			{Key: "issues_labeled", Label: "Issues Labeled"},
+		},
 	}
+}
 
-	log.Println("Detecting potential duplicate issues...")
+// Plan implements Task. Every issue in a duplicate group gets the
+// duplicate label.
+func (t DuplicateDetection) Plan(ctx context.Context, env *Env) (*Plan, error) {
+	cfg := env.Config
+	plan := &Plan{Task: t.Name(), Report: NewReport(t.Name())}
 
-	if len(issues) < 2 {
+	slog.InfoContext(ctx, "Fetching issues", "statuses", cfg.DuplicateDetection.TargetStatuses, "similarity", cfg.DuplicateDetection.Similarity)
+	issues, err := env.Board.GetIssuesByStatuses(ctx, cfg.DuplicateDetection.TargetStatuses)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch issues: %w", err)
+	}
+	slog.InfoContext(ctx, "Found issues with target statuses", "count", len(issues))
+	plan.Report.Set("issues_analyzed", len(issues))
+
+	groups := findDuplicateGroups(ctx, env.Scorer, issues, cfg.DuplicateDetection.Similarity)
+	plan.Report.Set("duplicate_groups", len(groups))
+
+	for i, group := range groups {
+		var lines []string
+		for _, issue := range group.Issues {
+			lines = append(lines, fmt.Sprintf("#%d: %s", issue.Number, issue.Title))
+			plan.Actions = append(plan.Actions, Action{Kind: ActionLabel, Issue: issue, Value: duplicateLabel})
+		}
+		plan.Report.Section(fmt.Sprintf("Group %d (similarity: %.2f)", i+1, group.Similarity), lines)
+	}
+
+	return plan, nil
+}
+
+// Apply implements Task
+func (DuplicateDetection) Apply(ctx context.Context, env *Env, plan *Plan) (*Report, error) {
+	report := plan.Report
+	labels := plan.actionsOfKind(ActionLabel)
+	if len(labels) == 0 || budgetExhausted(ctx, env.Board, &report.Errors) {
 		return report, nil
 	}
 
+	for i, err := range applyBoardActions(ctx, env.Board, labels) {
+		issue := labels[i].Issue
+		report.Record(labels[i], err)
+		if err != nil {
+			slog.WarnContext(ctx, "Failed to label duplicate", "issue", issue.Ref(), "error", err)
+			report.Errors = append(report.Errors, fmt.Sprintf("Failed to label duplicates: failed to label issue #%d: %v", issue.Number, err))
+			continue
+		}
+		report.Add("issues_labeled", 1)
+		slog.InfoContext(ctx, "Added label", "issue", issue.Ref(), "label", duplicateLabel)
+	}
+
+	return report, nil
+}
+
+// findDuplicateGroups groups issues whose similarity meets the threshold
+func findDuplicateGroups(ctx context.Context, scorer SimilarityScorer, issues []github.Issue, threshold float64) []DuplicateGroup {
+	slog.InfoContext(ctx, "Detecting potential duplicate issues")
+
+	if len(issues) < 2 {
+		return nil
+	}
+
 	var groups []DuplicateGroup
 	processed := make(map[int]bool)
 
@@ -51,14 +125,13 @@ func DetectDuplicates(ctx context.Context, githubClient *github.Client, similari
 				continue
 			}
 
-			similarityScore, err := similarityClient.CompareSimilarity(ctx, issue1, issue2)
+			similarityScore, err := scorer.CompareSimilarity(ctx, issue1, issue2)
 			if err != nil {
-				log.Printf("WARNING: Failed to compare issues #%d and #%d: %v\n",
-					issue1.Number, issue2.Number, err)
+				slog.WarnContext(ctx, "Failed to compare issues", "issue", issue1.Ref(), "other_issue", issue2.Ref(), "error", err)
 				continue
 			}
 
-			if similarityScore >= cfg.DuplicateSimilarity {
+			if similarityScore >= threshold {
 				if len(group) == 0 {
 					group = append(group, issue1)
 					processed[issue1.Number] = true
@@ -71,39 +144,11 @@ func DetectDuplicates(ctx context.Context, githubClient *github.Client, similari
 		if len(group) > 1 {
 			groups = append(groups, DuplicateGroup{
 				Issues:     group,
-				Similarity: cfg.DuplicateSimilarity,
+				Similarity: threshold,
 			})
 		}
 	}
 
-	report.DuplicateGroups = groups
-	log.Printf("Found %d potential duplicate groups\n", len(groups))
-
-	// Label duplicate issues
-	if len(groups) > 0 && !cfg.DryRun {
-		for _, group := range groups {
-			if err := labelDuplicates(ctx, githubClient, group); err != nil {
-				errMsg := fmt.Sprintf("Failed to label duplicates: %v", err)
-				log.Printf("WARNING: %s\n", errMsg)
-				report.Errors = append(report.Errors, errMsg)
-			} else {
-				report.IssuesLabeled += len(group.Issues)
-			}
-			time.Sleep(2 * time.Second)
-		}
-	}
-
-	return report, nil
-}
-
-// labelDuplicates adds a "possible duplicate" label to all issues in a duplicate group
-func labelDuplicates(ctx context.Context, client *github.Client, group DuplicateGroup) error {
-	for _, issue := range group.Issues {
-		if err := client.AddLabel(ctx, issue, "possible duplicate"); err != nil {
-			return fmt.Errorf("failed to label issue #%d: %w", issue.Number, err)
-		}
-		log.Printf("Added 'possible duplicate' label to issue #%d\n", issue.Number)
-	}
-
-	return nil
+	slog.InfoContext(ctx, "Found potential duplicate groups", "count", len(groups))
+	return groups
 }
@@ -0,0 +1,67 @@
+package tasks
+
+import (
+	"context"
+
+	"github.com/storacha/project-agent/internal/directory"
+	"github.com/storacha/project-agent/internal/discord"
+	"github.com/storacha/project-agent/internal/email"
+	"github.com/storacha/project-agent/internal/github"
+	"github.com/storacha/project-agent/internal/matrix"
+	"github.com/storacha/project-agent/internal/notify"
+	"github.com/storacha/project-agent/internal/similarity"
+	"github.com/storacha/project-agent/internal/slack"
+)
+
+var (
+	_ ProjectBoard      = (*github.Client)(nil)
+	_ PullRequestSource = (*github.Client)(nil)
+	_ Notifier          = (*discord.Client)(nil)
+	_ Notifier          = (*slack.Client)(nil)
+	_ Notifier          = (*matrix.Client)(nil)
+	_ Notifier          = (*email.Client)(nil)
+	_ SimilarityScorer  = (*similarity.Client)(nil)
+)
+
+// ProjectBoard is the subset of the GitHub client the tasks use to read and
+// update the project board. *github.Client satisfies it.
+type ProjectBoard interface {
+	GetIssuesByStatuses(ctx context.Context, statuses []string) ([]github.Issue, error)
+	GetIssueByNumber(ctx context.Context, owner, repo string, number int) (*github.Issue, error)
+	GetProjectItem(ctx context.Context, itemID string) (*github.Issue, error)
+	GetInitiativeIssues(ctx context.Context) ([]github.Issue, error)
+	GetSubIssuesRecursive(ctx context.Context, owner, repo string, number int) ([]github.SubIssue, error)
+	AddIssueToProject(ctx context.Context, owner, repo string, number int, status string) (*github.Issue, error)
+	UpdateInitiativeField(ctx context.Context, issue github.Issue, initiativeTitle string) error
+	MoveToStatus(ctx context.Context, issue github.Issue, status string) error
+	AddComment(ctx context.Context, issue github.Issue, comment string) error
+	AddLabel(ctx context.Context, issue github.Issue, labelName string) error
+	LinkPRToIssue(ctx context.Context, prOwner, prRepo string, prNumber int, issue github.Issue) error
+	ApplyBatch(ctx context.Context, ops []github.BatchOp) []github.BatchResult
+	// Budget reports the remaining API rate limit; a nil budget never runs out
+	Budget() *github.Budget
+}
+
+// PullRequestSource lists an organization's repositories and their open pull
+// requests. *github.Client satisfies it.
+type PullRequestSource interface {
+	GetRepositories(ctx context.Context, org string) ([]github.Repository, error)
+	GetOpenPullRequests(ctx context.Context, owner, repo string) ([]github.PullRequest, error)
+}
+
+// Notifier is what the tasks use to send channel reports, direct messages
+// and threads, whatever the transport. User, channel and role IDs are the
+// transport's own. *discord.Client, *slack.Client, *matrix.Client and
+// *email.Client satisfy it.
+type Notifier interface {
+	SendStaleIssuesReport(ctx context.Context, staleIssues []notify.StaleIssue, people *directory.Directory, thresholdDays int) error
+	SendWeeklyDM(ctx context.Context, userIssues notify.UserIssues) error
+	SendUnassignedIssuesDM(ctx context.Context, userID string, issues []github.Issue) error
+	CreateStandupThread(ctx context.Context, channelID, roleID string) error
+}
+
+// SimilarityScorer scores how similar two issues are, from 0.0 to 1.0.
+// *similarity.Client satisfies it.
+type SimilarityScorer interface {
+	CompareSimilarity(ctx context.Context, issue1, issue2 github.Issue) (float64, error)
+}
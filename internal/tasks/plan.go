@@ -0,0 +1,145 @@
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/storacha/project-agent/internal/github"
+)
+
+// ActionKind identifies the change an Action makes
+type ActionKind string
+
+// Action kinds
+const (
+	ActionMove         ActionKind = "move"          // Move Issue to the status in Value
+	ActionComment      ActionKind = "comment"       // Comment Value on Issue, upserting the comment marked Target if set
+	ActionLabel        ActionKind = "label"         // Add the label named Value to Issue
+	ActionAdd          ActionKind = "add"           // Add Issue to the project with the status in Value
+	ActionInitiative   ActionKind = "initiative"    // Set Issue's Initiative field to Value
+	ActionStaleReport  ActionKind = "stale-report"  // Post Issues as the stale issues report
+	ActionDM           ActionKind = "dm"            // DM Issues to user Value, the GitHub user Target
+	ActionUnassignedDM ActionKind = "unassigned-dm" // DM the unassigned Issues to user Value, named Target
+	ActionStandup      ActionKind = "standup"       // Open a standup thread in channel Value, mentioning role Target
+)
+
+// Action is one change a task plans to make
+type Action struct {
+	Kind   ActionKind     `json:"kind"`
+	Issue  github.Issue   `json:"issue"`            // The issue changed, for board actions
+	Issues []github.Issue `json:"issues,omitempty"` // The issues listed, for notifications
+	Value  string         `json:"value,omitempty"`
+	Target string         `json:"target,omitempty"`
+}
+
+// Plan is the set of changes a task would make, and what it found while
+// working them out
+type Plan struct {
+	Task    string   `json:"task"`
+	Actions []Action `json:"actions"`
+	Report  *Report  `json:"report"`
+}
+
+// String describes the action for dry-run output
+func (a Action) String() string {
+	issue := a.Issue.Ref()
+	switch a.Kind {
+	case ActionMove:
+		return fmt.Sprintf("Move %s to %s: %s", issue, a.Value, a.Issue.Title)
+	case ActionComment:
+		return fmt.Sprintf("Comment on %s: %s", issue, firstLine(a.Value))
+	case ActionLabel:
+		return fmt.Sprintf("Label %s %q: %s", issue, a.Value, a.Issue.Title)
+	case ActionAdd:
+		return fmt.Sprintf("Add %s to the project with status %s: %s", issue, a.Value, a.Issue.Title)
+	case ActionInitiative:
+		return fmt.Sprintf("Set Initiative of %s to %q", issue, a.Value)
+	case ActionStaleReport:
+		return fmt.Sprintf("Post the stale issues report (%d issues)", len(a.Issues))
+	case ActionDM:
+		return fmt.Sprintf("DM %s their %d issues", a.Target, len(a.Issues))
+	case ActionUnassignedDM:
+		return fmt.Sprintf("DM the %d unassigned issues to %s", len(a.Issues), a.Value)
+	case ActionStandup:
+		return fmt.Sprintf("Open a standup thread in channel %s", a.Value)
+	}
+	return fmt.Sprintf("%s %s %s", a.Kind, issue, a.Value)
+}
+
+// item is the report item for the action
+func (a Action) item(outcome Outcome) Item {
+	item := Item{Outcome: outcome, Action: a.Kind, Summary: a.String()}
+	switch {
+	case a.Issue.Number != 0:
+		item.Issue = a.Issue.Ref()
+	case a.Kind == ActionDM || a.Kind == ActionUnassignedDM:
+		item.User = a.Target
+	}
+	return item
+}
+
+// LogAttrs are the attributes the action is logged with: its kind, and the
+// issue it changes or the user it notifies
+func (a Action) LogAttrs() []any {
+	attrs := []any{"action", a.Kind}
+	switch {
+	case a.Issue.Number != 0:
+		attrs = append(attrs, "issue", a.Issue.Ref())
+	case a.Kind == ActionDM || a.Kind == ActionUnassignedDM:
+		attrs = append(attrs, "user", a.Target)
+	}
+	return attrs
+}
+
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i] + " ..."
+	}
+	return s
+}
+
+// batchOp returns the board write for a move, comment, label or initiative
+// action
+func (a Action) batchOp() github.BatchOp {
+	switch a.Kind {
+	case ActionMove:
+		return github.StatusOp(a.Issue, a.Value)
+	case ActionComment:
+		if a.Target != "" {
+			return github.MarkedCommentOp(a.Issue, a.Target, a.Value)
+		}
+		return github.CommentOp(a.Issue, a.Value)
+	case ActionLabel:
+		return github.LabelOp(a.Issue, a.Value)
+	case ActionInitiative:
+		return github.InitiativeOp(a.Issue, a.Value)
+	}
+	panic(fmt.Sprintf("tasks: %s action is not a board write", a.Kind))
+}
+
+// applyBoardActions applies move, comment, label and initiative actions in
+// one batch, returning each action's error (nil on success) in order
+func applyBoardActions(ctx context.Context, board ProjectBoard, actions []Action) []error {
+	ops := make([]github.BatchOp, len(actions))
+	for i, action := range actions {
+		ops[i] = action.batchOp()
+	}
+
+	errs := make([]error, len(actions))
+	for i, result := range board.ApplyBatch(ctx, ops) {
+		errs[i] = result.Err
+	}
+	return errs
+}
+
+// actionsOfKind returns the plan's actions of the given kind, in order
+func (p *Plan) actionsOfKind(kind ActionKind) []Action {
+	var actions []Action
+	for _, action := range p.Actions {
+		if action.Kind == kind {
+			actions = append(actions, action)
+		}
+	}
+	return actions
+}
@@ -0,0 +1,204 @@
+package tasks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/storacha/project-agent/internal/config"
+	"github.com/storacha/project-agent/internal/github"
+)
+
+// PlanFileVersion is the plan file format written by WritePlanFile
+const PlanFileVersion = 1
+
+// PlanFile is a plan saved for review and a later apply. Changes is the
+// human-readable view of Actions; apply executes Actions.
+type PlanFile struct {
+	Version       int       `json:"version"`
+	Task          string    `json:"task"`
+	Org           string    `json:"org"`
+	ProjectNumber int       `json:"project_number"`
+	CreatedAt     time.Time `json:"created_at"`
+	Changes       []Change  `json:"changes"`
+	Actions       []Action  `json:"actions"`
+	Report        *Report   `json:"report"`
+}
+
+// Change describes one planned action for review: the project item it
+// touches, the field and its old and new values, or the comment body
+type Change struct {
+	Summary   string     `json:"summary"`
+	Item      string     `json:"item,omitempty"`  // Project item ID
+	Issue     string     `json:"issue,omitempty"` // owner/repo#number
+	URL       string     `json:"url,omitempty"`
+	Field     string     `json:"field,omitempty"`
+	Old       string     `json:"old,omitempty"`
+	New       string     `json:"new,omitempty"`
+	Body      string     `json:"body,omitempty"`       // Comment body
+	UpdatedAt *time.Time `json:"updated_at,omitempty"` // Issue's last update when planned
+}
+
+// Change returns the reviewable form of the action
+func (a Action) Change() Change {
+	c := Change{Summary: a.String()}
+	if a.Issue.Number == 0 {
+		return c
+	}
+
+	c.Item = a.Issue.ProjectItem.ID
+	c.Issue = fmt.Sprintf("%s/%s#%d", a.Issue.RepositoryOwner, a.Issue.RepositoryName, a.Issue.Number)
+	c.URL = a.Issue.URL
+	if !a.Issue.UpdatedAt.IsZero() {
+		updatedAt := a.Issue.UpdatedAt
+		c.UpdatedAt = &updatedAt
+	}
+
+	switch a.Kind {
+	case ActionMove, ActionAdd:
+		c.Field, c.Old, c.New = "Status", a.Issue.ProjectItem.StatusValue, a.Value
+	case ActionInitiative:
+		c.Field, c.Old, c.New = "Initiative", a.Issue.Fields["Initiative"].String(), a.Value
+	case ActionLabel:
+		c.Field, c.New = "Labels", a.Value
+	case ActionComment:
+		c.Body = a.Value
+	}
+	return c
+}
+
+// NewPlanFile wraps a plan for saving
+func NewPlanFile(plan *Plan, cfg *config.Config) *PlanFile {
+	f := &PlanFile{
+		Version:       PlanFileVersion,
+		Task:          plan.Task,
+		Org:           cfg.GithubOrg,
+		ProjectNumber: cfg.ProjectNumber,
+		CreatedAt:     time.Now().UTC(),
+		Changes:       []Change{},
+		Actions:       plan.Actions,
+		Report:        plan.Report,
+	}
+	if f.Actions == nil {
+		f.Actions = []Action{}
+	}
+	for _, action := range plan.Actions {
+		f.Changes = append(f.Changes, action.Change())
+	}
+	return f
+}
+
+// Plan returns the saved plan
+func (f *PlanFile) Plan() *Plan {
+	report := f.Report
+	if report == nil {
+		report = NewReport(f.Task)
+	}
+	if report.Values == nil {
+		report.Values = make(map[string]interface{})
+	}
+	return &Plan{Task: f.Task, Actions: f.Actions, Report: report}
+}
+
+// WritePlanFile saves a plan file as indented JSON
+func WritePlanFile(path string, f *PlanFile) error {
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode plan: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write plan file: %w", err)
+	}
+	return nil
+}
+
+// ReadPlanFile loads a plan file written by WritePlanFile
+func ReadPlanFile(path string) (*PlanFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plan file: %w", err)
+	}
+
+	var f PlanFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse plan file %s: %w", path, err)
+	}
+	if f.Version != PlanFileVersion {
+		return nil, fmt.Errorf("plan file %s has version %d, expected %d", path, f.Version, PlanFileVersion)
+	}
+	if f.Task == "" {
+		return nil, fmt.Errorf("plan file %s does not name a task", path)
+	}
+	return &f, nil
+}
+
+// CheckCurrent compares every project item the actions change with its
+// state when the plan was made, returning an error listing each item that
+// was updated, moved or had a field changed since. Issues not yet on the
+// project are not checked.
+func CheckCurrent(ctx context.Context, board ProjectBoard, actions []Action) error {
+	var changed []string
+	checked := make(map[string]bool)
+
+	for _, action := range actions {
+		planned := action.Issue
+		itemID := planned.ProjectItem.ID
+		if itemID == "" || checked[itemID] {
+			continue
+		}
+		checked[itemID] = true
+
+		name := fmt.Sprintf("%s/%s#%d", planned.RepositoryOwner, planned.RepositoryName, planned.Number)
+		current, err := board.GetProjectItem(ctx, itemID)
+		if err != nil {
+			changed = append(changed, fmt.Sprintf("%s: %v", name, err))
+			continue
+		}
+		if diff := itemDiff(planned, *current); len(diff) > 0 {
+			changed = append(changed, fmt.Sprintf("%s: %s", name, strings.Join(diff, ", ")))
+		}
+	}
+
+	if len(changed) > 0 {
+		return fmt.Errorf("%d item(s) changed since the plan was made:\n  %s", len(changed), strings.Join(changed, "\n  "))
+	}
+	return nil
+}
+
+// itemDiff describes how an issue differs from its planned state
+func itemDiff(planned, current github.Issue) []string {
+	var diff []string
+	if !planned.UpdatedAt.IsZero() && !current.UpdatedAt.Equal(planned.UpdatedAt) {
+		diff = append(diff, fmt.Sprintf("updated at %s (planned against %s)",
+			current.UpdatedAt.UTC().Format(time.RFC3339), planned.UpdatedAt.UTC().Format(time.RFC3339)))
+	}
+	if current.ProjectItem.StatusValue != planned.ProjectItem.StatusValue {
+		diff = append(diff, fmt.Sprintf("Status is %q (planned against %q)", current.ProjectItem.StatusValue, planned.ProjectItem.StatusValue))
+	}
+
+	// Only fields loaded when planning can be compared
+	names := make([]string, 0, len(planned.Fields))
+	for name := range planned.Fields {
+		names = append(names, name)
+	}
+	for name := range current.Fields {
+		if _, ok := planned.Fields[name]; !ok && planned.Fields != nil {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if name == "Status" {
+			continue
+		}
+		was, now := planned.Fields[name].String(), current.Fields[name].String()
+		if was != now {
+			diff = append(diff, fmt.Sprintf("%s is %q (planned against %q)", name, now, was))
+		}
+	}
+	return diff
+}
@@ -0,0 +1,112 @@
+package tasks_test
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/storacha/project-agent/internal/fakes"
+	"github.com/storacha/project-agent/internal/github"
+	"github.com/storacha/project-agent/internal/tasks"
+)
+
+// lastYear is when the stale issue in planBoard was last updated
+var lastYear = time.Now().AddDate(-1, 0, 0).UTC().Truncate(time.Second)
+
+// planBoard returns a board with one stale issue whose fields were loaded,
+// so a triage-stale plan moves and comments on it
+func planBoard() *fakes.Board {
+	issue := projectIssue(1, "Old forgotten issue", "Backlog", lastYear)
+	issue.Fields = map[string]github.FieldValue{
+		"Status":     github.OptionValue("Backlog"),
+		"Initiative": github.TextValue("Upload reliability"),
+	}
+	return fakes.NewBoard(issue)
+}
+
+// savedPlan plans triage-stale against board and round-trips the plan
+// through a plan file, as plan --plan-out and apply do
+func savedPlan(t *testing.T, board *fakes.Board) []tasks.Action {
+	t.Helper()
+	env := newEnv(board)
+	plan, err := tasks.StaleTriage{}.Plan(context.Background(), env)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "plan.json")
+	if err := tasks.WritePlanFile(path, tasks.NewPlanFile(plan, env.Config)); err != nil {
+		t.Fatal(err)
+	}
+	file, err := tasks.ReadPlanFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(file.Actions) == 0 {
+		t.Fatal("plan has no actions")
+	}
+	return file.Actions
+}
+
+func TestCheckCurrentAcceptsAnUnchangedBoard(t *testing.T) {
+	board := planBoard()
+	actions := savedPlan(t, board)
+
+	if err := tasks.CheckCurrent(context.Background(), board, actions); err != nil {
+		t.Fatalf("CheckCurrent: %v", err)
+	}
+}
+
+func TestCheckCurrentRefusesStalePlans(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		edit func(issue *github.Issue)
+		want string
+	}{
+		{"updated", func(issue *github.Issue) { issue.UpdatedAt = time.Now() }, "updated at"},
+		{"moved", func(issue *github.Issue) { issue.ProjectItem.StatusValue = "In Progress" }, `Status is "In Progress" (planned against "Backlog")`},
+		{"field changed", func(issue *github.Issue) {
+			issue.Fields["Initiative"] = github.TextValue("Faster retrievals")
+		}, `Initiative is "Faster retrievals" (planned against "Upload reliability")`},
+		{"field set", func(issue *github.Issue) {
+			issue.Fields["Estimate"] = github.NumberValue(3)
+		}, `Estimate is "3" (planned against "")`},
+		{"removed", func(issue *github.Issue) { issue.ProjectItem.ID = "" }, "not found"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			board := planBoard()
+			actions := savedPlan(t, board)
+			tc.edit(&board.Issues[0])
+
+			err := tasks.CheckCurrent(context.Background(), board, actions)
+			if err == nil {
+				t.Fatal("CheckCurrent accepted a stale plan")
+			}
+			if !strings.Contains(err.Error(), "storacha/guppy#1") || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("err = %v, want storacha/guppy#1 and %q", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestCheckCurrentWithoutPlannedFields(t *testing.T) {
+	// Issues read without their field values are only checked for updates
+	// and moves
+	board := fakes.NewBoard(projectIssue(1, "Old forgotten issue", "Backlog", lastYear))
+	actions := savedPlan(t, board)
+	if actions[0].Issue.Fields != nil {
+		t.Fatalf("planned fields = %v, want none", actions[0].Issue.Fields)
+	}
+
+	board.Issues[0].Fields = map[string]github.FieldValue{"Initiative": github.TextValue("Faster retrievals")}
+	if err := tasks.CheckCurrent(context.Background(), board, actions); err != nil {
+		t.Errorf("CheckCurrent compared fields that were not planned against: %v", err)
+	}
+
+	board.Issues[0].ProjectItem.StatusValue = "In Progress"
+	if err := tasks.CheckCurrent(context.Background(), board, actions); err == nil || !strings.Contains(err.Error(), "Status") {
+		t.Errorf("err = %v, want the move reported", err)
+	}
+}
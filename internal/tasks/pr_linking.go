@@ -2,150 +2,232 @@ package tasks
 
 import (
 	"context"
+	"flag"
 	"fmt"
-	"log"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/storacha/project-agent/internal/config"
 	"github.com/storacha/project-agent/internal/github"
+	"github.com/storacha/project-agent/internal/logging"
 	"github.com/storacha/project-agent/internal/parser"
-	"github.com/storacha/project-agent/internal/similarity"
 )
 
-// PRLinkingReport contains the results of PR-to-issue linking
-type PRLinkingReport struct {
-	DirectReferencesFound int
-	IssuesLinkedDirect    int
-	SemanticMatchFound    bool
-	IssueLinkedSemantic   int
-	IssuesMovedToPRReview int
-	Errors                []string
+func init() {
+	Register(&PRLinking{})
 }
 
-// LinkPRToIssues links a PR to related issues and moves them to PR Review status
-func LinkPRToIssues(ctx context.Context, githubClient *github.Client, similarityClient *similarity.Client,
-	prOwner, prRepo string, prNumber int, prTitle, prBody string, cfg *config.Config) (*PRLinkingReport, error) {
+// PRLinking links one pull request to the issues it references, or to the
+// most similar in-progress issue, and moves them to the review status. The
+// pull request is set with Flags, or from a webhook delivery.
+type PRLinking struct {
+	Repo   string // owner/repo
+	Number int
+	Author string
+	Title  string
+	Body   string
+}
+
+// Name implements Task
+func (*PRLinking) Name() string { return "link-pr" }
+
+// Summary implements Task
+func (*PRLinking) Summary() string {
+	return "Link a pull request to the issues it references and move them to review"
+}
+
+// Flags implements FlagTask. The defaults come from the PR_* variables the
+// repository_dispatch workflow sets.
+func (t *PRLinking) Flags(fs *flag.FlagSet) {
+	number, _ := strconv.Atoi(os.Getenv("PR_NUMBER"))
+	fs.StringVar(&t.Repo, "repo", os.Getenv("PR_REPO"), "pull request repository as owner/repo (default from PR_REPO)")
+	fs.IntVar(&t.Number, "number", number, "pull request number (default from PR_NUMBER)")
+	fs.StringVar(&t.Author, "author", os.Getenv("PR_AUTHOR"), "pull request author login (default from PR_AUTHOR)")
+	fs.StringVar(&t.Title, "title", os.Getenv("PR_TITLE"), "pull request title (default from PR_TITLE)")
+	fs.StringVar(&t.Body, "body", os.Getenv("PR_BODY"), "pull request body (default from PR_BODY)")
+}
+
+// Needs implements Task
+func (*PRLinking) Needs(cfg *config.Config) Needs {
+	return Needs{Board: true, Schema: PRLinkingRequirements(cfg), Similarity: cfg.PRLinking.SemanticMatching}
+}
+
+// Schema implements Task
+func (*PRLinking) Schema(cfg *config.Config) ReportSchema {
+	return ReportSchema{
+		Title: "PR Linking Report",
+		Fields: []FieldSpec{
+			{Key: "pr", Label: "PR"},
+			{Key: "direct_references", Label: "Direct References Found"},
+			{Key: "issues_linked_direct", Label: "Issues Linked (Direct)"},
+			{Key: "issues_linked_semantic", Label: "Issues Linked (Semantic)"},
+			{Key: "issues_moved", Label: "Total Issues Moved to " + cfg.Statuses.Review},
+		},
+	}
+}
 
-	report := &PRLinkingReport{}
+// Plan implements Task
+func (t *PRLinking) Plan(ctx context.Context, env *Env) (*Plan, error) {
+	plan := &Plan{Task: t.Name(), Report: NewReport(t.Name())}
 
-	log.Printf("Processing PR %s/%s#%d\n", prOwner, prRepo, prNumber)
+	if t.Repo == "" || t.Number == 0 {
+		return nil, fmt.Errorf("--repo and --number (or PR_REPO and PR_NUMBER) are required")
+	}
+
+	// Parse owner/repo
+	parts := strings.Split(t.Repo, "/")
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("invalid PR repository format, expected owner/repo: %s", t.Repo)
+	}
+	pr := github.PullRequest{Owner: parts[0], Repo: parts[1], Number: t.Number, Title: t.Title, Body: t.Body, Author: t.Author}
+	plan.Report.Set("pr", pr.Ref())
+	ctx = logging.With(ctx, "pr", pr.Ref())
+
+	// Only process PRs from team members
+	if pr.Author != "" && env.Directory.Len() > 0 {
+		if !env.Directory.IsMember(pr.Author) {
+			slog.InfoContext(ctx, "Skipping PR from external contributor", "author", pr.Author)
+			plan.Report.Notes = append(plan.Report.Notes,
+				fmt.Sprintf("Skipped: author %s is not an active member in the team directory (external contributor)", pr.Author))
+			plan.Report.Skip(externalPRItem(pr), "external contributor")
+			return plan, nil
+		}
+		slog.InfoContext(ctx, "PR author is a team member, proceeding with linking", "author", pr.Author)
+	}
+
+	if err := planPRLink(ctx, env, pr, plan); err != nil {
+		return nil, err
+	}
+
+	return plan, nil
+}
+
+// Apply implements Task
+func (*PRLinking) Apply(ctx context.Context, env *Env, plan *Plan) (*Report, error) {
+	applyPRLinks(ctx, env, plan)
+	return plan.Report, nil
+}
+
+// planPRLink finds the issues a PR should be linked to and adds the moves
+// and link comments to the plan. Direct references need no link, since
+// GitHub links the PR when it references the issue; a semantic match gets a
+// cross-reference comment. ctx carries the PR's log attributes.
+func planPRLink(ctx context.Context, env *Env, pr github.PullRequest, plan *Plan) error {
+	cfg := env.Config
+	report := plan.Report
+
+	slog.InfoContext(ctx, "Processing PR", "title", pr.Title, "author", pr.Author)
 
 	// Step 1: Parse direct issue references from PR
-	refs := parser.ParseIssueReferences(prTitle, prBody, prOwner, prRepo)
-	report.DirectReferencesFound = len(refs)
+	refs := parser.ParseIssueReferences(pr.Title, pr.Body, pr.Owner, pr.Repo)
+	report.Add("direct_references", len(refs))
 
 	if len(refs) > 0 {
-		log.Printf("Found %d direct issue reference(s)\n", len(refs))
+		slog.InfoContext(ctx, "Found direct issue references", "count", len(refs))
 		for _, ref := range refs {
-			log.Printf("  - %s/%s#%d (explicit: %v)\n", ref.Owner, ref.Repo, ref.Number, ref.IsExplicit)
+			slog.DebugContext(ctx, "Issue reference", "issue", fmt.Sprintf("%s/%s#%d", ref.Owner, ref.Repo, ref.Number), "explicit", ref.IsExplicit)
 		}
 	}
 
 	// Step 2: For each referenced issue, check if it's in the project
-	var matchedIssues []github.Issue
+	matched := 0
 	for _, ref := range refs {
-		issue, err := githubClient.GetIssueByNumber(ctx, ref.Owner, ref.Repo, ref.Number)
+		issue, err := env.Board.GetIssueByNumber(ctx, ref.Owner, ref.Repo, ref.Number)
 		if err != nil {
-			log.Printf("WARNING: Issue %s/%s#%d not in project or not accessible: %v\n",
-				ref.Owner, ref.Repo, ref.Number, err)
+			name := fmt.Sprintf("%s/%s#%d", ref.Owner, ref.Repo, ref.Number)
+			slog.WarnContext(ctx, "Issue not in project or not accessible", "issue", name, "error", err)
+			report.Skip(Item{Issue: name, PR: pr.Ref(), Summary: fmt.Sprintf("Link %s to %s", pr.Ref(), name)},
+				"not in the project or not accessible")
 			continue
 		}
 
-		matchedIssues = append(matchedIssues, *issue)
-		report.IssuesLinkedDirect++
+		plan.Actions = append(plan.Actions, Action{Kind: ActionMove, Issue: *issue, Value: cfg.Statuses.Review})
+		report.Add("issues_linked_direct", 1)
+		matched++
 	}
 
-	log.Printf("Found %d referenced issue(s) in the project\n", len(matchedIssues))
+	slog.InfoContext(ctx, "Found referenced issues in the project", "count", matched)
 
-	// Step 3: If no direct references, try semantic matching (if enabled)
-	var semanticMatch *github.Issue
-	if len(matchedIssues) == 0 && cfg.SemanticMatching {
-		log.Println("No direct references found, attempting semantic matching...")
+	if matched > 0 {
+		return nil
+	}
+	if !cfg.PRLinking.SemanticMatching {
+		slog.InfoContext(ctx, "No direct references found, and semantic matching is disabled")
+		return nil
+	}
 
-		// Fetch issues with target statuses (In Progress, Sprint Backlog)
-		targetStatuses := []string{"In Progress", "Sprint Backlog"}
-		issues, err := githubClient.GetIssuesByStatuses(ctx, targetStatuses)
-		if err != nil {
-			return report, fmt.Errorf("failed to fetch issues for semantic matching: %w", err)
-		}
+	// Step 3: If no direct references, try semantic matching
+	slog.InfoContext(ctx, "No direct references found, attempting semantic matching")
 
-		log.Printf("Checking semantic similarity against %d issues\n", len(issues))
-
-		if len(issues) > 0 {
-			bestMatch, bestSimilarity, err := findBestSemanticMatch(ctx, similarityClient,
-				prTitle, prBody, issues, cfg.DuplicateSimilarity)
-			if err != nil {
-				errMsg := fmt.Sprintf("Semantic matching failed: %v", err)
-				log.Printf("WARNING: %s\n", errMsg)
-				report.Errors = append(report.Errors, errMsg)
-			} else if bestMatch != nil {
-				log.Printf("Found semantic match: issue #%d (similarity: %.2f)\n",
-					bestMatch.Number, bestSimilarity)
-				semanticMatch = bestMatch
-				report.SemanticMatchFound = true
-				report.IssueLinkedSemantic++
-			} else {
-				log.Println("No semantic matches found above threshold")
-			}
-		}
-	} else if len(matchedIssues) == 0 && !cfg.SemanticMatching {
-		log.Println("No direct references found, and semantic matching is disabled")
-	}
-
-	// Step 4: Move matched issues to PR Review and create links
-	if !cfg.DryRun {
-		// Handle direct references
-		for _, issue := range matchedIssues {
-			// Move to PR Review
-			if err := githubClient.MoveToPRReview(ctx, issue); err != nil {
-				errMsg := fmt.Sprintf("Failed to move issue #%d to PR Review: %v", issue.Number, err)
-				log.Printf("ERROR: %s\n", errMsg)
-				report.Errors = append(report.Errors, errMsg)
-			} else {
-				log.Printf("Moved issue #%d to PR Review status\n", issue.Number)
-				report.IssuesMovedToPRReview++
-			}
-
-			// No need to create link - GitHub automatically links when PR references issue
-			time.Sleep(1 * time.Second)
-		}
+	// Fetch issues that are in progress
+	issues, err := env.Board.GetIssuesByStatuses(ctx, cfg.Statuses.InProgress)
+	if err != nil {
+		return fmt.Errorf("failed to fetch issues for semantic matching: %w", err)
+	}
 
-		// Handle semantic match
-		if semanticMatch != nil {
-			// Move to PR Review
-			if err := githubClient.MoveToPRReview(ctx, *semanticMatch); err != nil {
-				errMsg := fmt.Sprintf("Failed to move issue #%d to PR Review: %v", semanticMatch.Number, err)
-				log.Printf("ERROR: %s\n", errMsg)
-				report.Errors = append(report.Errors, errMsg)
-			} else {
-				log.Printf("Moved issue #%d to PR Review status\n", semanticMatch.Number)
-				report.IssuesMovedToPRReview++
-			}
-
-			// Create cross-reference link (adds minimal comment)
-			if err := githubClient.LinkPRToIssue(ctx, prOwner, prRepo, prNumber, *semanticMatch); err != nil {
-				errMsg := fmt.Sprintf("Failed to link PR to issue #%d: %v", semanticMatch.Number, err)
-				log.Printf("ERROR: %s\n", errMsg)
-				report.Errors = append(report.Errors, errMsg)
-			} else {
-				log.Printf("Created cross-reference link to issue #%d\n", semanticMatch.Number)
-			}
-		}
-	} else {
-		log.Println("[DRY RUN] Would move the following issues to PR Review:")
-		for _, issue := range matchedIssues {
-			log.Printf("  - Issue #%d (direct reference)\n", issue.Number)
-		}
-		if semanticMatch != nil {
-			log.Printf("  - Issue #%d (semantic match)\n", semanticMatch.Number)
+	slog.InfoContext(ctx, "Checking semantic similarity", "issues", len(issues))
+	if len(issues) == 0 {
+		return nil
+	}
+
+	bestMatch, bestSimilarity, err := findBestSemanticMatch(ctx, env.Scorer,
+		pr.Title, pr.Body, issues, cfg.PRLinking.Similarity)
+	switch {
+	case err != nil:
+		slog.WarnContext(ctx, "Semantic matching failed", "error", err)
+		report.Errors = append(report.Errors, fmt.Sprintf("Semantic matching failed: %v", err))
+	case bestMatch != nil:
+		slog.InfoContext(ctx, "Found semantic match", "issue", bestMatch.Ref(), "similarity", bestSimilarity)
+		plan.Actions = append(plan.Actions,
+			Action{Kind: ActionMove, Issue: *bestMatch, Value: cfg.Statuses.Review},
+			Action{Kind: ActionComment, Issue: *bestMatch, Value: github.PRLinkComment(pr.Owner, pr.Repo, pr.Number),
+				Target: github.PRLinkMarker(pr.Owner, pr.Repo, pr.Number)})
+		report.Add("issues_linked_semantic", 1)
+	default:
+		slog.InfoContext(ctx, "No semantic matches found above threshold")
+	}
+
+	return nil
+}
+
+// applyPRLinks applies the moves and link comments planned by planPRLink
+func applyPRLinks(ctx context.Context, env *Env, plan *Plan) {
+	report := plan.Report
+	if len(plan.Actions) == 0 || budgetExhausted(ctx, env.Board, &report.Errors) {
+		return
+	}
+
+	for i, err := range applyBoardActions(ctx, env.Board, plan.Actions) {
+		action := plan.Actions[i]
+		issue := action.Issue
+		report.Record(action, err)
+		switch {
+		case action.Kind == ActionMove && err != nil:
+			slog.ErrorContext(ctx, "Failed to move issue", "issue", issue.Ref(), "status", action.Value, "error", err)
+			report.Errors = append(report.Errors, fmt.Sprintf("Failed to move issue #%d to %s: %v", issue.Number, action.Value, err))
+		case action.Kind == ActionMove:
+			slog.InfoContext(ctx, "Moved issue", "issue", issue.Ref(), "status", action.Value)
+			report.Add("issues_moved", 1)
+		case err != nil:
+			slog.ErrorContext(ctx, "Failed to link PR to issue", "issue", issue.Ref(), "error", err)
+			report.Errors = append(report.Errors, fmt.Sprintf("Failed to link PR to issue #%d: %v", issue.Number, err))
+		default:
+			slog.InfoContext(ctx, "Created cross-reference link", "issue", issue.Ref())
 		}
 	}
+}
 
-	return report, nil
+// externalPRItem is the report item for a PR skipped because its author is
+// not on the team
+func externalPRItem(pr github.PullRequest) Item {
+	return Item{PR: pr.Ref(), User: pr.Author, Summary: fmt.Sprintf("Link %s: %s", pr.Ref(), pr.Title)}
 }
 
 // findBestSemanticMatch finds the most similar issue to the PR
-func findBestSemanticMatch(ctx context.Context, client *similarity.Client,
+func findBestSemanticMatch(ctx context.Context, scorer SimilarityScorer,
 	prTitle, prBody string, issues []github.Issue, threshold float64) (*github.Issue, float64, error) {
 
 	var bestMatch *github.Issue
@@ -158,9 +240,9 @@ func findBestSemanticMatch(ctx context.Context, client *similarity.Client,
 	}
 
 	for _, issue := range issues {
-		similarityScore, err := client.CompareSimilarity(ctx, prIssue, issue)
+		similarityScore, err := scorer.CompareSimilarity(ctx, prIssue, issue)
 		if err != nil {
-			log.Printf("WARNING: Failed to compare PR with issue #%d: %v\n", issue.Number, err)
+			slog.WarnContext(ctx, "Failed to compare PR with issue", "issue", issue.Ref(), "error", err)
 			continue
 		}
 
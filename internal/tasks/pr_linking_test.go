@@ -0,0 +1,79 @@
+package tasks_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/storacha/project-agent/internal/directory"
+	"github.com/storacha/project-agent/internal/fakes"
+	"github.com/storacha/project-agent/internal/tasks"
+)
+
+func TestPRLinkingMovesReferencedIssues(t *testing.T) {
+	board := fakes.NewBoard(projectIssue(2, "Implement upload resume", "In Progress", time.Now()))
+	env := newEnv(board)
+	task := &tasks.PRLinking{Repo: "storacha/guppy", Number: 7, Author: "alice", Title: "Add resume support", Body: "Fixes #2, see also #5"}
+
+	plan, report := run(t, task, env)
+
+	if got, want := kinds(plan.Actions), []tasks.ActionKind{tasks.ActionMove}; !equal(got, want) {
+		t.Fatalf("planned %v, want %v", got, want)
+	}
+	if issue, _ := board.Issue("storacha/guppy#2"); issue.ProjectItem.StatusValue != env.Config.Statuses.Review {
+		t.Errorf("#2 status = %q, want %q", issue.ProjectItem.StatusValue, env.Config.Statuses.Review)
+	}
+	if comments := board.MutationsOfKind(fakes.MutationComment); len(comments) != 0 {
+		t.Errorf("commented %+v on a directly referenced issue", comments)
+	}
+	// #5 is not on the project
+	if report.Count(tasks.OutcomeSkipped) != 1 {
+		t.Errorf("report has %d skipped items, want 1 for #5", report.Count(tasks.OutcomeSkipped))
+	}
+	if report.Int("direct_references") != 2 || report.Int("issues_linked_direct") != 1 || report.Int("issues_moved") != 1 {
+		t.Errorf("report values = %v", report.Values)
+	}
+}
+
+func TestPRLinkingSkipsExternalContributors(t *testing.T) {
+	board := fakes.NewBoard(projectIssue(2, "Implement upload resume", "In Progress", time.Now()))
+	env := newEnv(board)
+	dir, err := directory.New([]directory.Person{{GitHub: "alice"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	env.Directory = dir
+	task := &tasks.PRLinking{Repo: "storacha/guppy", Number: 8, Author: "mallory", Title: "Drive-by fix", Body: "Fixes #2"}
+
+	plan, report := run(t, task, env)
+
+	if len(plan.Actions) != 0 || len(board.Mutations) != 0 {
+		t.Fatalf("planned %v and applied %+v for an external contributor", kinds(plan.Actions), board.Mutations)
+	}
+	if report.Count(tasks.OutcomeSkipped) != 1 || len(report.Notes) != 1 || !strings.Contains(report.Notes[0], "mallory") {
+		t.Errorf("report has %d skipped items and notes %v, want mallory skipped", report.Count(tasks.OutcomeSkipped), report.Notes)
+	}
+}
+
+func TestPRLinkingFallsBackToSemanticMatch(t *testing.T) {
+	board := fakes.NewBoard(projectIssue(3, "Resume interrupted uploads", "In Progress", time.Now()))
+	env := newEnv(board)
+	env.Scorer = fakes.ScoreByTitle([2]string{"Add resume support", "Resume interrupted uploads"})
+	task := &tasks.PRLinking{Repo: "storacha/guppy", Number: 7, Author: "alice", Title: "Add resume support"}
+
+	plan, report := run(t, task, env)
+
+	if got, want := kinds(plan.Actions), []tasks.ActionKind{tasks.ActionMove, tasks.ActionComment}; !equal(got, want) {
+		t.Fatalf("planned %v, want %v", got, want)
+	}
+	comments := board.MutationsOfKind(fakes.MutationComment)
+	if len(comments) != 1 || comments[0].Issue != "storacha/guppy#3" || comments[0].Marker == "" || !strings.Contains(comments[0].Value, "storacha/guppy#7") {
+		t.Fatalf("comments = %+v, want a marked link to storacha/guppy#7 on storacha/guppy#3", comments)
+	}
+	if issue, _ := board.Issue("storacha/guppy#3"); issue.ProjectItem.StatusValue != env.Config.Statuses.Review {
+		t.Errorf("#3 status = %q, want %q", issue.ProjectItem.StatusValue, env.Config.Statuses.Review)
+	}
+	if report.Int("issues_linked_semantic") != 1 || report.Int("issues_moved") != 1 {
+		t.Errorf("report values = %v", report.Values)
+	}
+}
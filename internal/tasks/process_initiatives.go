@@ -3,89 +3,152 @@ package tasks
 import (
 	"context"
 	"fmt"
-	"log"
-	"time"
+	"log/slog"
 
 	"github.com/storacha/project-agent/internal/config"
 	"github.com/storacha/project-agent/internal/github"
+	"github.com/storacha/project-agent/internal/logging"
 )
 
-// ProcessInitiativesReport contains the results of initiative processing
-type ProcessInitiativesReport struct {
-	InitiativesProcessed int
-	SubIssuesFound       int
-	SubIssuesAdded       int
-	SubIssuesUpdated     int
-	Errors               []string
+func init() {
+	Register(ProcessInitiatives{})
 }
 
-// ProcessInitiatives finds all Initiative-type issues and processes their sub-issues
-func ProcessInitiatives(ctx context.Context, client *github.Client, initiatives []github.Issue, cfg *config.Config) (*ProcessInitiativesReport, error) {
-	report := &ProcessInitiativesReport{
-		InitiativesProcessed: len(initiatives),
+// ProcessInitiatives adds the sub-issues of every Initiative-type issue to
+// the project and sets their Initiative field to the initiative's title
+type ProcessInitiatives struct{}
+
+// Name implements Task
+func (ProcessInitiatives) Name() string { return "process-initiatives" }
+
+// Summary implements Task
+func (ProcessInitiatives) Summary() string {
+	return "Add initiative sub-issues to the project and set their Initiative field"
+}
+
+// Needs implements Task
+func (ProcessInitiatives) Needs(cfg *config.Config) Needs {
+	return Needs{Board: true, Schema: ProcessInitiativesRequirements(cfg)}
+}
+
+// Schema implements Task
+func (ProcessInitiatives) Schema(cfg *config.Config) ReportSchema {
+	return ReportSchema{
+		Title: "Initiative Processing Report",
+		Fields: []FieldSpec{
+			{Key: "initiatives_processed", Label: "Initiatives Processed"},
+			{Key: "sub_issues_found", Label: "Sub-issues Found (total)"},
+			{Key: "sub_issues_added", Label: "Sub-issues Added to Project"},
+			{Key: "sub_issues_updated", Label: "Sub-issues Updated (Initiative field)"},
+		},
 	}
+}
 
-	log.Printf("Processing %d initiatives...\n", len(initiatives))
+// Plan implements Task. Each sub-issue gets an add, which leaves issues
+// already on the project unchanged, followed by an initiative update.
+func (t ProcessInitiatives) Plan(ctx context.Context, env *Env) (*Plan, error) {
+	cfg := env.Config
+	plan := &Plan{Task: t.Name(), Report: NewReport(t.Name())}
+
+	// Fetch all Initiative-type issues
+	slog.InfoContext(ctx, "Fetching all Initiative-type issues from project")
+	initiatives, err := env.Board.GetInitiativeIssues(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch Initiative issues: %w", err)
+	}
+
+	slog.InfoContext(ctx, "Processing initiatives", "count", len(initiatives))
+	plan.Report.Set("initiatives_processed", len(initiatives))
 
 	for _, initiative := range initiatives {
-		log.Printf("Processing initiative #%d: %s\n", initiative.Number, initiative.Title)
+		ctx := logging.With(ctx, "initiative", initiative.Ref())
+		slog.InfoContext(ctx, "Processing initiative", "title", initiative.Title)
 
 		// Get all sub-issues recursively
-		subIssues, err := client.GetSubIssuesRecursive(ctx, initiative.RepositoryOwner, initiative.RepositoryName, initiative.Number)
+		subIssues, err := env.Board.GetSubIssuesRecursive(ctx, initiative.RepositoryOwner, initiative.RepositoryName, initiative.Number)
 		if err != nil {
-			errMsg := fmt.Sprintf("Failed to fetch sub-issues for initiative #%d: %v", initiative.Number, err)
-			log.Printf("ERROR: %s\n", errMsg)
-			report.Errors = append(report.Errors, errMsg)
+			slog.ErrorContext(ctx, "Failed to fetch sub-issues", "error", err)
+			plan.Report.Errors = append(plan.Report.Errors, fmt.Sprintf("Failed to fetch sub-issues for initiative #%d: %v", initiative.Number, err))
 			continue
 		}
 
-		log.Printf("Found %d sub-issues (including descendants) for initiative #%d\n", len(subIssues), initiative.Number)
-		report.SubIssuesFound += len(subIssues)
+		slog.InfoContext(ctx, "Found sub-issues, including descendants", "count", len(subIssues))
+		plan.Report.Add("sub_issues_found", len(subIssues))
 
-		// Process each sub-issue
 		for _, subIssue := range subIssues {
-			if cfg.DryRun {
-				log.Printf("[DRY RUN] Would process sub-issue %s/%s#%d: %s\n",
-					subIssue.Owner, subIssue.Repo, subIssue.Number, subIssue.Title)
-				log.Printf("[DRY RUN]   - Add to project (if not present) with status 'Inbox'\n")
-				log.Printf("[DRY RUN]   - Set Initiative field to '%s'\n", initiative.Title)
-				continue
+			issue := github.Issue{
+				Number:          subIssue.Number,
+				Title:           subIssue.Title,
+				RepositoryOwner: subIssue.Owner,
+				RepositoryName:  subIssue.Repo,
+			}
+			plan.Actions = append(plan.Actions,
+				Action{Kind: ActionAdd, Issue: issue, Value: cfg.Statuses.Intake},
+				Action{Kind: ActionInitiative, Issue: issue, Value: initiative.Title})
+		}
+	}
+
+	return plan, nil
+}
+
+// Apply implements Task. Sub-issues are added one at a time, since each
+// initiative update needs the project item its add returns; the initiative
+// updates are then batched.
+func (ProcessInitiatives) Apply(ctx context.Context, env *Env, plan *Plan) (*Report, error) {
+	cfg := env.Config
+	report := plan.Report
+
+	added := make(map[string]github.Issue)
+	var updates []Action
+actions:
+	for _, action := range plan.Actions {
+		name := fmt.Sprintf("%s/%s#%d", action.Issue.RepositoryOwner, action.Issue.RepositoryName, action.Issue.Number)
+		switch action.Kind {
+		case ActionAdd:
+			if budgetExhausted(ctx, env.Board, &report.Errors) {
+				break actions
 			}
 
 			// Add sub-issue to project (or get existing)
-			issue, err := client.AddIssueToProject(ctx, subIssue.Owner, subIssue.Repo, subIssue.Number)
+			issue, err := env.Board.AddIssueToProject(ctx, action.Issue.RepositoryOwner, action.Issue.RepositoryName, action.Issue.Number, action.Value)
+			report.Record(action, err)
 			if err != nil {
-				errMsg := fmt.Sprintf("Failed to add sub-issue %s/%s#%d to project: %v",
-					subIssue.Owner, subIssue.Repo, subIssue.Number, err)
-				log.Printf("ERROR: %s\n", errMsg)
-				report.Errors = append(report.Errors, errMsg)
+				slog.ErrorContext(ctx, "Failed to add sub-issue to project", "issue", name, "error", err)
+				report.Errors = append(report.Errors, fmt.Sprintf("Failed to add sub-issue %s to project: %v", name, err))
 				continue
 			}
 
-			// Check if issue was just added (status is "Inbox") or already existed
-			if issue.ProjectItem.StatusValue == "Inbox" {
-				report.SubIssuesAdded++
-				log.Printf("Added sub-issue %s/%s#%d to project with status 'Inbox'\n",
-					subIssue.Owner, subIssue.Repo, subIssue.Number)
+			// Check if issue was just added (intake status) or already existed
+			if issue.ProjectItem.StatusValue == cfg.Statuses.Intake {
+				report.Add("sub_issues_added", 1)
+				slog.InfoContext(ctx, "Added sub-issue to project", "issue", name, "status", cfg.Statuses.Intake)
 			}
+			added[name] = *issue
 
-			// Update Initiative field
-			err = client.UpdateInitiativeField(ctx, *issue, initiative.Title)
-			if err != nil {
-				errMsg := fmt.Sprintf("Failed to update Initiative field for %s/%s#%d: %v",
-					subIssue.Owner, subIssue.Repo, subIssue.Number, err)
-				log.Printf("ERROR: %s\n", errMsg)
-				report.Errors = append(report.Errors, errMsg)
-				continue
+		case ActionInitiative:
+			// Skip sub-issues whose add failed
+			if issue, ok := added[name]; ok {
+				action.Issue = issue
+				updates = append(updates, action)
+			} else {
+				report.Skip(action.item(""), "adding it to the project failed")
 			}
+		}
+	}
 
-			report.SubIssuesUpdated++
-			log.Printf("Set Initiative field to '%s' for %s/%s#%d\n",
-				initiative.Title, subIssue.Owner, subIssue.Repo, subIssue.Number)
-
-			// Rate limit to avoid overwhelming GitHub API
-			time.Sleep(2 * time.Second)
+	// Update Initiative fields
+	for i, err := range applyBoardActions(ctx, env.Board, updates) {
+		update := updates[i]
+		name := fmt.Sprintf("%s/%s#%d", update.Issue.RepositoryOwner, update.Issue.RepositoryName, update.Issue.Number)
+		report.Record(update, err)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to update Initiative field", "issue", name, "error", err)
+			report.Errors = append(report.Errors, fmt.Sprintf("Failed to update Initiative field for %s: %v", name, err))
+			continue
 		}
+
+		report.Add("sub_issues_updated", 1)
+		slog.InfoContext(ctx, "Set Initiative field", "issue", name, "initiative", update.Value)
 	}
 
 	return report, nil
@@ -0,0 +1,104 @@
+package tasks_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/storacha/project-agent/internal/fakes"
+	"github.com/storacha/project-agent/internal/github"
+	"github.com/storacha/project-agent/internal/tasks"
+)
+
+// initiativeBoard returns a board with the "Upload reliability" initiative,
+// whose sub-issue #3 is already on the project and whose sub-issue #4 and
+// grandchild #5 are not
+func initiativeBoard() *fakes.Board {
+	initiative := projectIssue(10, "Upload reliability", "In Progress", time.Now())
+	initiative.RepositoryName = "project-tracking"
+	initiative.ProjectItem.ID = fakes.IssueKey("storacha", "project-tracking", 10)
+	offProject := func(number int, title string) github.Issue {
+		return github.Issue{Number: number, Title: title, RepositoryOwner: "storacha", RepositoryName: "guppy"}
+	}
+
+	board := fakes.NewBoard(
+		initiative,
+		projectIssue(3, "Resume interrupted uploads", "Backlog", time.Now()),
+		offProject(4, "Upload resume: CLI flag"),
+		offProject(5, "Document the resume flag"),
+	)
+	board.Initiatives = []string{"storacha/project-tracking#10"}
+	board.SubIssues["storacha/project-tracking#10"] = []github.SubIssue{
+		{Owner: "storacha", Repo: "guppy", Number: 3, Title: "Resume interrupted uploads"},
+		{Owner: "storacha", Repo: "guppy", Number: 4, Title: "Upload resume: CLI flag"},
+	}
+	board.SubIssues["storacha/guppy#4"] = []github.SubIssue{
+		{Owner: "storacha", Repo: "guppy", Number: 5, Title: "Document the resume flag"},
+	}
+	return board
+}
+
+func TestProcessInitiativesAddsAndTagsSubIssues(t *testing.T) {
+	board := initiativeBoard()
+	env := newEnv(board)
+
+	plan, report := run(t, tasks.ProcessInitiatives{}, env)
+
+	if len(plan.Actions) != 6 {
+		t.Fatalf("planned %v, want an add and an initiative update for each of 3 sub-issues", kinds(plan.Actions))
+	}
+
+	var added []string
+	for _, m := range board.MutationsOfKind(fakes.MutationAddToProject) {
+		if m.Value != env.Config.Statuses.Intake {
+			t.Errorf("%s added with status %q, want %q", m.Issue, m.Value, env.Config.Statuses.Intake)
+		}
+		added = append(added, m.Issue)
+	}
+	if want := []string{"storacha/guppy#4", "storacha/guppy#5"}; !equal(added, want) {
+		t.Errorf("added %v, want %v", added, want)
+	}
+	if issue, _ := board.Issue("storacha/guppy#3"); issue.ProjectItem.StatusValue != "Backlog" {
+		t.Errorf("#3 status = %q, want it left in Backlog", issue.ProjectItem.StatusValue)
+	}
+
+	var tagged []string
+	for _, m := range board.MutationsOfKind(fakes.MutationInitiative) {
+		if m.Value != "Upload reliability" || m.ItemID == "" {
+			t.Errorf("%s initiative update = %+v, want Upload reliability on its project item", m.Issue, m)
+		}
+		tagged = append(tagged, m.Issue)
+	}
+	if want := []string{"storacha/guppy#3", "storacha/guppy#4", "storacha/guppy#5"}; !equal(tagged, want) {
+		t.Errorf("tagged %v, want %v", tagged, want)
+	}
+
+	if report.Int("initiatives_processed") != 1 || report.Int("sub_issues_found") != 3 ||
+		report.Int("sub_issues_added") != 2 || report.Int("sub_issues_updated") != 3 {
+		t.Errorf("report values = %v", report.Values)
+	}
+}
+
+func TestProcessInitiativesSkipsUpdateWhenAddFails(t *testing.T) {
+	board := initiativeBoard()
+	board.FailOn = func(m fakes.Mutation) error {
+		if m.Kind == fakes.MutationAddToProject && m.Issue == "storacha/guppy#4" {
+			return errors.New("add refused")
+		}
+		return nil
+	}
+
+	_, report := run(t, tasks.ProcessInitiatives{}, newEnv(board))
+
+	for _, m := range board.MutationsOfKind(fakes.MutationInitiative) {
+		if m.Issue == "storacha/guppy#4" {
+			t.Fatalf("updated the initiative of #4 after adding it failed")
+		}
+	}
+	if report.Count(tasks.OutcomeSkipped) != 1 || len(report.Errors) != 1 {
+		t.Errorf("report has %d skipped items and errors %v, want 1 of each", report.Count(tasks.OutcomeSkipped), report.Errors)
+	}
+	if report.Int("sub_issues_updated") != 2 {
+		t.Errorf("sub_issues_updated = %d, want 2", report.Int("sub_issues_updated"))
+	}
+}
@@ -0,0 +1,148 @@
+package tasks
+
+// ReportSchema describes a task's report: its title and the headline values
+// it sets, in display order
+type ReportSchema struct {
+	Title  string
+	Fields []FieldSpec
+}
+
+// FieldSpec declares one headline value of a report
+type FieldSpec struct {
+	Key   string // Stable key used in Report.Values and JSON output
+	Label string // Human-readable label
+	// OmitZero hides the field from text and markdown output when unset or zero
+	OmitZero bool
+}
+
+// Report is the result of a task run. Every task reports in this shape so
+// the runner can render it the same way.
+type Report struct {
+	Task     string                 `json:"task"`
+	Values   map[string]interface{} `json:"values"`
+	Items    []Item                 `json:"items"`
+	Sections []Section              `json:"sections,omitempty"`
+	Notes    []string               `json:"notes,omitempty"`
+	Errors   []string               `json:"errors"`
+}
+
+// Outcome is what became of one item of a report
+type Outcome string
+
+// Outcomes
+const (
+	OutcomePlanned Outcome = "planned" // A dry run or saved plan: not changed yet
+	OutcomeDone    Outcome = "done"
+	OutcomeFailed  Outcome = "failed"
+	OutcomeSkipped Outcome = "skipped" // Left alone, for the Reason given
+)
+
+// Item is one change a task made or planned, or one thing it decided to
+// leave alone
+type Item struct {
+	Outcome Outcome    `json:"outcome"`
+	Action  ActionKind `json:"action,omitempty"`
+	Issue   string     `json:"issue,omitempty"` // owner/repo#number
+	PR      string     `json:"pr,omitempty"`    // owner/repo#number
+	User    string     `json:"user,omitempty"`  // GitHub login
+	Summary string     `json:"summary"`
+	Reason  string     `json:"reason,omitempty"` // Why it failed or was skipped
+}
+
+// Section is a detail list in a report
+type Section struct {
+	Heading string   `json:"heading"`
+	Lines   []string `json:"lines"`
+}
+
+// NewReport creates an empty report for a task
+func NewReport(task string) *Report {
+	return &Report{
+		Task:   task,
+		Values: make(map[string]interface{}),
+		Items:  []Item{},
+		Errors: []string{},
+	}
+}
+
+// Set sets a headline value
+func (r *Report) Set(key string, value interface{}) {
+	r.Values[key] = value
+}
+
+// Add adds n to a headline count
+func (r *Report) Add(key string, n int) {
+	r.Values[key] = r.Int(key) + n
+}
+
+// Int returns a headline count, or 0 if it is unset
+func (r *Report) Int(key string) int {
+	switch v := r.Values[key].(type) {
+	case int:
+		return v
+	case float64: // Decoded from JSON
+		return int(v)
+	}
+	return 0
+}
+
+// Section appends a detail list; empty lists are skipped
+func (r *Report) Section(heading string, lines []string) {
+	if len(lines) > 0 {
+		r.Sections = append(r.Sections, Section{Heading: heading, Lines: lines})
+	}
+}
+
+// Record adds the outcome of applying an action: done, or failed with err
+func (r *Report) Record(a Action, err error) {
+	item := a.item(OutcomeDone)
+	if err != nil {
+		item.Outcome, item.Reason = OutcomeFailed, err.Error()
+	}
+	r.Items = append(r.Items, item)
+}
+
+// Skip adds an item the task left alone, for reason
+func (r *Report) Skip(item Item, reason string) {
+	item.Outcome, item.Reason = OutcomeSkipped, reason
+	r.Items = append(r.Items, item)
+}
+
+// Planned adds the actions of a plan that was not applied
+func (r *Report) Planned(actions []Action) {
+	for _, a := range actions {
+		r.Items = append(r.Items, a.item(OutcomePlanned))
+	}
+}
+
+// Unapplied adds the actions that Apply never got to, such as those after
+// the API budget ran out, as skipped
+func (r *Report) Unapplied(actions []Action) {
+	recorded := make(map[string]int)
+	for _, item := range r.Items {
+		if item.Action != "" {
+			recorded[string(item.Action)+" "+item.Summary]++
+		}
+	}
+	for _, a := range actions {
+		item := a.item(OutcomeSkipped)
+		key := string(item.Action) + " " + item.Summary
+		if recorded[key] > 0 {
+			recorded[key]--
+			continue
+		}
+		item.Reason = "not attempted"
+		r.Items = append(r.Items, item)
+	}
+}
+
+// Count returns how many items have an outcome
+func (r *Report) Count(outcome Outcome) int {
+	n := 0
+	for _, item := range r.Items {
+		if item.Outcome == outcome {
+			n++
+		}
+	}
+	return n
+}
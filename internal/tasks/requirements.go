@@ -0,0 +1,55 @@
+package tasks
+
+import (
+	"github.com/storacha/project-agent/internal/config"
+	"github.com/storacha/project-agent/internal/github"
+)
+
+// StaleTriageRequirements returns the project schema TriageStaleIssues needs
+func StaleTriageRequirements(cfg *config.Config) github.Requirements {
+	return github.Requirements{
+		StatusOptions: append(append([]string{}, cfg.StaleTriage.TargetStatuses...), cfg.Statuses.Dead),
+	}
+}
+
+// DuplicateDetectionRequirements returns the project schema DetectDuplicates needs
+func DuplicateDetectionRequirements(cfg *config.Config) github.Requirements {
+	return github.Requirements{
+		StatusOptions: cfg.DuplicateDetection.TargetStatuses,
+	}
+}
+
+// PRLinkingRequirements returns the project schema LinkPRToIssues needs
+func PRLinkingRequirements(cfg *config.Config) github.Requirements {
+	req := github.Requirements{
+		StatusOptions: []string{cfg.Statuses.Review},
+	}
+	if cfg.PRLinking.SemanticMatching {
+		req.StatusOptions = append(req.StatusOptions, cfg.Statuses.InProgress...)
+	}
+	return req
+}
+
+// ProcessInitiativesRequirements returns the project schema ProcessInitiatives needs
+func ProcessInitiativesRequirements(cfg *config.Config) github.Requirements {
+	return github.Requirements{
+		Fields: []github.FieldRequirement{
+			{Name: "Initiative", Type: github.FieldText},
+		},
+		StatusOptions: []string{cfg.Statuses.Intake},
+	}
+}
+
+// DailyUpdateRequirements returns the project schema CheckDailyUpdates needs
+func DailyUpdateRequirements(cfg *config.Config) github.Requirements {
+	return github.Requirements{
+		StatusOptions: cfg.Statuses.Active,
+	}
+}
+
+// WeeklyDMRequirements returns the project schema SendWeeklyDMs needs
+func WeeklyDMRequirements(cfg *config.Config) github.Requirements {
+	return github.Requirements{
+		StatusOptions: cfg.Statuses.Active,
+	}
+}
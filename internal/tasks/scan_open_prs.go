@@ -0,0 +1,141 @@
+package tasks
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/storacha/project-agent/internal/config"
+	"github.com/storacha/project-agent/internal/logging"
+)
+
+func init() {
+	Register(&ScanOpenPRs{})
+}
+
+// ScanOpenPRs runs PR linking over every open pull request in an
+// organization, for catching up on PRs opened before the notification
+// workflow was deployed
+type ScanOpenPRs struct {
+	Org string // Organization to scan; empty means the configured one
+}
+
+// Name implements Task
+func (*ScanOpenPRs) Name() string { return "scan-open-prs" }
+
+// Summary implements Task
+func (*ScanOpenPRs) Summary() string {
+	return "Link every open pull request in the organization to its issues"
+}
+
+// Flags implements FlagTask
+func (t *ScanOpenPRs) Flags(fs *flag.FlagSet) {
+	fs.StringVar(&t.Org, "org", os.Getenv("SCAN_ORG"), "organization to scan (default from SCAN_ORG, then the configured org)")
+}
+
+// Needs implements Task
+func (*ScanOpenPRs) Needs(cfg *config.Config) Needs {
+	return Needs{Board: true, Schema: PRLinkingRequirements(cfg), Similarity: cfg.PRLinking.SemanticMatching}
+}
+
+// Schema implements Task
+func (*ScanOpenPRs) Schema(cfg *config.Config) ReportSchema {
+	return ReportSchema{
+		Title: "Scan Summary Report",
+		Fields: []FieldSpec{
+			{Key: "repos_scanned", Label: "Repositories scanned"},
+			{Key: "prs_found", Label: "Total PRs found"},
+			{Key: "prs_skipped", Label: "PRs skipped (external contributors)", OmitZero: true},
+			{Key: "issues_linked", Label: "Total issues linked"},
+			{Key: "issues_moved", Label: "Total issues moved to " + cfg.Statuses.Review},
+			{Key: "repos_with_errors", Label: "Repositories with errors", OmitZero: true},
+		},
+	}
+}
+
+// Plan implements Task
+func (t *ScanOpenPRs) Plan(ctx context.Context, env *Env) (*Plan, error) {
+	cfg := env.Config
+	plan := &Plan{Task: t.Name(), Report: NewReport(t.Name())}
+	report := plan.Report
+
+	org := t.Org
+	if org == "" {
+		org = cfg.GithubOrg
+	}
+
+	repos, err := env.PullRequests.GetRepositories(ctx, org)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch repositories: %w", err)
+	}
+
+	slog.InfoContext(ctx, "Found repositories", "org", org, "count", len(repos))
+	report.Set("repos_scanned", len(repos))
+
+repos:
+	for _, repo := range repos {
+		ctx := logging.With(ctx, "repo", repo.Owner+"/"+repo.Name)
+		slog.InfoContext(ctx, "Scanning repository")
+
+		// Fetch all open PRs for this repo
+		prs, err := env.PullRequests.GetOpenPullRequests(ctx, repo.Owner, repo.Name)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to fetch PRs", "error", err)
+			report.Errors = append(report.Errors, fmt.Sprintf("Failed to fetch PRs for %s/%s: %v", repo.Owner, repo.Name, err))
+			report.Add("repos_with_errors", 1)
+			continue
+		}
+
+		if len(prs) == 0 {
+			slog.InfoContext(ctx, "No open PRs found")
+			continue
+		}
+
+		slog.InfoContext(ctx, "Found open PRs", "count", len(prs))
+		report.Add("prs_found", len(prs))
+
+		for _, pr := range prs {
+			if budgetExhausted(ctx, env.Board, &report.Errors) {
+				break repos
+			}
+
+			ctx := logging.With(ctx, "pr", pr.Ref())
+
+			// Only process PRs from team members
+			if env.Directory.Len() > 0 && pr.Author != "" {
+				if !env.Directory.IsMember(pr.Author) {
+					slog.InfoContext(ctx, "Skipping PR from external contributor", "author", pr.Author)
+					report.Add("prs_skipped", 1)
+					report.Skip(externalPRItem(pr), "external contributor")
+					continue
+				}
+			}
+
+			before := len(plan.Actions)
+			if err := planPRLink(ctx, env, pr, plan); err != nil {
+				slog.ErrorContext(ctx, "Failed to process PR", "error", err)
+				report.Errors = append(report.Errors, fmt.Sprintf("Failed to process PR %s: %v", pr.Ref(), err))
+				continue
+			}
+
+			linked := 0
+			for _, action := range plan.Actions[before:] {
+				if action.Kind == ActionMove {
+					linked++
+				}
+			}
+			report.Add("issues_linked", linked)
+			slog.InfoContext(ctx, "Planned PR links", "issues", linked)
+		}
+	}
+
+	return plan, nil
+}
+
+// Apply implements Task
+func (*ScanOpenPRs) Apply(ctx context.Context, env *Env, plan *Plan) (*Report, error) {
+	applyPRLinks(ctx, env, plan)
+	return plan.Report, nil
+}
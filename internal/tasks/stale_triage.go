@@ -3,67 +3,154 @@ package tasks
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"time"
 
 	"github.com/storacha/project-agent/internal/config"
 	"github.com/storacha/project-agent/internal/github"
+	"github.com/storacha/project-agent/internal/messages"
+	"github.com/storacha/project-agent/internal/metrics"
 )
 
-// StaleTriageReport contains the results of stale issue triage
-type StaleTriageReport struct {
-	IssuesAnalyzed   int
-	StaleIssuesFound int
-	IssuesMoved      int
-	Errors           []string
+func init() {
+	Register(StaleTriage{})
 }
 
-// TriageStaleIssues identifies and moves stale issues to Stuck/Dead status
-func TriageStaleIssues(ctx context.Context, client *github.Client, issues []github.Issue, cfg *config.Config) (*StaleTriageReport, error) {
-	report := &StaleTriageReport{
-		IssuesAnalyzed: len(issues),
+// StaleTriage moves issues with no recent activity to the dead status,
+// commenting on each to explain why
+type StaleTriage struct{}
+
+// Name implements Task
+func (StaleTriage) Name() string { return "triage-stale" }
+
+// Summary implements Task
+func (StaleTriage) Summary() string {
+	return "Move issues with no recent activity to the dead status"
+}
+
+// Needs implements Task
+func (StaleTriage) Needs(cfg *config.Config) Needs {
+	return Needs{Board: true, Schema: StaleTriageRequirements(cfg)}
+}
+
+// Schema implements Task
+func (StaleTriage) Schema(cfg *config.Config) ReportSchema {
+	return ReportSchema{
+		Title: "Stale Issue Triage Report",
+		Fields: []FieldSpec{
+			{Key: "issues_analyzed", Label: "Issues Analyzed"},
+			{Key: "stale_issues_found", Label: "Stale Issues Found"},
+			{Key: "issues_moved", Label: "Issues Moved to " + cfg.Statuses.Dead},
+		},
+	}
+}
+
+// Plan implements Task. Each stale issue gets a comment followed by a move.
+func (t StaleTriage) Plan(ctx context.Context, env *Env) (*Plan, error) {
+	cfg := env.Config
+	plan := &Plan{Task: t.Name(), Report: NewReport(t.Name())}
+
+	slog.InfoContext(ctx, "Fetching issues", "statuses", cfg.StaleTriage.TargetStatuses, "threshold_days", cfg.StaleTriage.ThresholdDays)
+	issues, err := env.Board.GetIssuesByStatuses(ctx, cfg.StaleTriage.TargetStatuses)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch issues: %w", err)
 	}
+	slog.InfoContext(ctx, "Found issues with target statuses", "count", len(issues))
+	plan.Report.Set("issues_analyzed", len(issues))
 
 	// Identify stale issues
-	log.Println("Analyzing issue staleness...")
-	staleIssues := identifyStaleIssues(issues, cfg.StalenessThresholdDays)
-	report.StaleIssuesFound = len(staleIssues)
-	log.Printf("Found %d stale issues (>%d days)\n", len(staleIssues), cfg.StalenessThresholdDays)
-
-	// Move stale issues to Stuck / Dead Issue status
-	if len(staleIssues) > 0 {
-		log.Println("Moving stale issues to Stuck / Dead Issue status...")
-		for _, issue := range staleIssues {
-			if cfg.DryRun {
-				log.Printf("[DRY RUN] Would move issue #%d: %s\n", issue.Number, issue.Title)
-				continue
-			}
-
-			err := moveStaleIssue(ctx, client, issue, cfg.StalenessThresholdDays)
-			if err != nil {
-				errMsg := fmt.Sprintf("Failed to move issue #%d: %v", issue.Number, err)
-				log.Printf("ERROR: %s\n", errMsg)
-				report.Errors = append(report.Errors, errMsg)
-				continue
-			}
-
-			report.IssuesMoved++
-			log.Printf("Moved issue #%d to Stuck / Dead Issue\n", issue.Number)
-
-			// Rate limit to avoid overwhelming GitHub API
-			time.Sleep(2 * time.Second)
+	staleIssues := identifyStaleIssues(issues, cfg.StaleTriage.ThresholdDays, cfg.Statuses.Dead, cfg.Statuses.Done)
+	plan.Report.Set("stale_issues_found", len(staleIssues))
+	recordStaleIssues(t.Name(), cfg.StaleTriage.TargetStatuses, staleIssues)
+	slog.InfoContext(ctx, "Found stale issues", "count", len(staleIssues))
+
+	for _, issue := range staleIssues {
+		comment, err := env.Messages.Render(messages.StaleCommentTemplate, messages.StaleComment{
+			Issue:           issue,
+			DaysSinceUpdate: daysSince(issue.UpdatedAt),
+			ThresholdDays:   cfg.StaleTriage.ThresholdDays,
+			DeadStatus:      cfg.Statuses.Dead,
+			IntakeStatus:    cfg.Statuses.Intake,
+			ParkedStatus:    cfg.Statuses.Parked,
+		})
+		if err != nil {
+			return nil, err
 		}
+		plan.Actions = append(plan.Actions,
+			Action{Kind: ActionComment, Issue: issue, Value: comment, Target: staleCommentMarker},
+			Action{Kind: ActionMove, Issue: issue, Value: cfg.Statuses.Dead})
+	}
+
+	return plan, nil
+}
+
+// Apply implements Task. The comments are posted first, then the issues
+// whose comment was posted are moved; both steps are batched.
+func (StaleTriage) Apply(ctx context.Context, env *Env, plan *Plan) (*Report, error) {
+	report := plan.Report
+	comments := plan.actionsOfKind(ActionComment)
+	if len(comments) == 0 || budgetExhausted(ctx, env.Board, &report.Errors) {
+		return report, nil
+	}
+
+	slog.InfoContext(ctx, "Moving stale issues", "status", env.Config.Statuses.Dead)
+	fail := func(issue github.Issue, err error) {
+		slog.ErrorContext(ctx, "Failed to move issue", "issue", issue.Ref(), "error", err)
+		report.Errors = append(report.Errors, fmt.Sprintf("Failed to move issue #%d: %v", issue.Number, err))
+	}
+
+	// Add comments explaining why the issues are being moved
+	commented := make(map[string]bool)
+	for i, err := range applyBoardActions(ctx, env.Board, comments) {
+		report.Record(comments[i], err)
+		if err != nil {
+			fail(comments[i].Issue, fmt.Errorf("failed to add comment: %w", err))
+			continue
+		}
+		commented[comments[i].Issue.ProjectItem.ID] = true
+	}
+
+	var moves []Action
+	for _, move := range plan.actionsOfKind(ActionMove) {
+		if commented[move.Issue.ProjectItem.ID] {
+			moves = append(moves, move)
+		} else {
+			report.Skip(move.item(""), "the comment failed")
+		}
+	}
+	if len(moves) == 0 || budgetExhausted(ctx, env.Board, &report.Errors) {
+		return report, nil
+	}
+
+	// Move to the dead status
+	for i, err := range applyBoardActions(ctx, env.Board, moves) {
+		report.Record(moves[i], err)
+		if err != nil {
+			fail(moves[i].Issue, fmt.Errorf("failed to move issue: %w", err))
+			continue
+		}
+		report.Add("issues_moved", 1)
+		slog.InfoContext(ctx, "Moved issue", "issue", moves[i].Issue.Ref(), "status", moves[i].Value)
 	}
 
 	return report, nil
 }
 
-// identifyStaleIssues finds issues that haven't been updated within the threshold
-func identifyStaleIssues(issues []github.Issue, thresholdDays int) []github.Issue {
+// identifyStaleIssues finds issues that haven't been updated within the
+// threshold, skipping issues already in one of the excluded statuses
+func identifyStaleIssues(issues []github.Issue, thresholdDays int, excluded ...string) []github.Issue {
 	threshold := time.Now().AddDate(0, 0, -thresholdDays)
 	var staleIssues []github.Issue
 
+	skip := make(map[string]bool)
+	for _, status := range excluded {
+		skip[status] = true
+	}
+
 	for _, issue := range issues {
+		if skip[issue.ProjectItem.StatusValue] {
+			continue
+		}
 		if issue.UpdatedAt.Before(threshold) {
 			staleIssues = append(staleIssues, issue)
 		}
@@ -72,30 +159,21 @@ func identifyStaleIssues(issues []github.Issue, thresholdDays int) []github.Issu
 	return staleIssues
 }
 
-// moveStaleIssue moves an issue to Stuck / Dead Issue status and adds a comment
-func moveStaleIssue(ctx context.Context, client *github.Client, issue github.Issue, thresholdDays int) error {
-	// Add comment explaining why the issue is being moved
-	daysSinceUpdate := int(time.Since(issue.UpdatedAt).Hours() / 24)
-	comment := fmt.Sprintf(`This issue has been automatically moved to **Stuck / Dead Issue** status.
-
-**Reason:** No activity for %d days (threshold: %d days)
-
-If this issue is still relevant and you'd like to work on it, please:
-1. Comment on this issue with an update
-2. Move it back to Backlog or another appropriate status
-3. Consider if this should be moved to Icebox instead
+// staleCommentMarker tags the stale comment, so an issue that goes stale
+// again keeps a single one
+var staleCommentMarker = github.CommentMarker("triage-stale", "stale")
 
----
-*Automated by project-agent*`, daysSinceUpdate, thresholdDays)
-
-	if err := client.AddComment(ctx, issue, comment); err != nil {
-		return fmt.Errorf("failed to add comment: %w", err)
+// recordStaleIssues sets the stale issues metric of a task to the number of
+// stale issues in each of the statuses it checked
+func recordStaleIssues(task string, statuses []string, stale []github.Issue) {
+	byStatus := make(map[string]int)
+	for _, status := range statuses {
+		byStatus[status] = 0
 	}
-
-	// Move to Stuck / Dead Issue status
-	if err := client.MoveToStuckDead(ctx, issue); err != nil {
-		return fmt.Errorf("failed to move issue: %w", err)
+	for _, issue := range stale {
+		byStatus[issue.ProjectItem.StatusValue]++
+	}
+	for status, n := range byStatus {
+		metrics.StaleIssues.Set(float64(n), task, status)
 	}
-
-	return nil
 }
@@ -0,0 +1,96 @@
+package tasks_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/storacha/project-agent/internal/fakes"
+	"github.com/storacha/project-agent/internal/tasks"
+)
+
+func TestStaleTriageMovesStaleIssues(t *testing.T) {
+	old := time.Now().AddDate(-1, 0, 0)
+	board := fakes.NewBoard(
+		projectIssue(1, "Old forgotten issue", "Backlog", old),
+		projectIssue(2, "Recent issue", "Backlog", time.Now()),
+		projectIssue(3, "Old but dead already", "Stuck / Dead Issue", old),
+	)
+	env := newEnv(board)
+
+	plan, report := run(t, tasks.StaleTriage{}, env)
+
+	if got, want := kinds(plan.Actions), []tasks.ActionKind{tasks.ActionComment, tasks.ActionMove}; !equal(got, want) {
+		t.Fatalf("planned %v, want %v", got, want)
+	}
+	if plan.Actions[0].Issue.Number != 1 {
+		t.Errorf("planned changes to #%d, want #1", plan.Actions[0].Issue.Number)
+	}
+
+	comments := board.MutationsOfKind(fakes.MutationComment)
+	if len(comments) != 1 || comments[0].Issue != "storacha/guppy#1" || comments[0].Marker == "" {
+		t.Fatalf("comments = %+v, want one marked comment on storacha/guppy#1", comments)
+	}
+	if !strings.Contains(comments[0].Value, "Stuck / Dead Issue") {
+		t.Errorf("comment does not name the dead status: %q", comments[0].Value)
+	}
+	if !strings.Contains(comments[0].Value, "back to Inbox") || strings.Contains(comments[0].Value, "Icebox") {
+		t.Errorf("comment does not name only the configured statuses: %q", comments[0].Value)
+	}
+	if issue, _ := board.Issue("storacha/guppy#1"); issue.ProjectItem.StatusValue != env.Config.Statuses.Dead {
+		t.Errorf("#1 status = %q, want %q", issue.ProjectItem.StatusValue, env.Config.Statuses.Dead)
+	}
+	if issue, _ := board.Issue("storacha/guppy#2"); issue.ProjectItem.StatusValue != "Backlog" {
+		t.Errorf("#2 status = %q, want it left in Backlog", issue.ProjectItem.StatusValue)
+	}
+
+	if report.Int("issues_analyzed") != 2 || report.Int("stale_issues_found") != 1 || report.Int("issues_moved") != 1 {
+		t.Errorf("report values = %v", report.Values)
+	}
+	if report.Count(tasks.OutcomeDone) != 2 || len(report.Errors) != 0 {
+		t.Errorf("report has %d done items and errors %v, want 2 and none", report.Count(tasks.OutcomeDone), report.Errors)
+	}
+}
+
+func TestStaleTriageSuggestsTheParkedStatus(t *testing.T) {
+	board := fakes.NewBoard(projectIssue(1, "Old forgotten issue", "Backlog", time.Now().AddDate(-1, 0, 0)))
+	env := newEnv(board)
+	env.Config.Statuses.Intake = "Todo"
+	env.Config.Statuses.Parked = "Someday"
+
+	run(t, tasks.StaleTriage{}, env)
+
+	comments := board.MutationsOfKind(fakes.MutationComment)
+	if len(comments) != 1 {
+		t.Fatalf("comments = %+v, want one", comments)
+	}
+	for _, status := range []string{"back to Todo", "moved to Someday"} {
+		if !strings.Contains(comments[0].Value, status) {
+			t.Errorf("comment does not say %q: %q", status, comments[0].Value)
+		}
+	}
+}
+
+func TestStaleTriageDoesNotMoveWhenCommentFails(t *testing.T) {
+	board := fakes.NewBoard(projectIssue(1, "Old forgotten issue", "Backlog", time.Now().AddDate(-1, 0, 0)))
+	board.FailOn = func(m fakes.Mutation) error {
+		if m.Kind == fakes.MutationComment {
+			return errors.New("comment refused")
+		}
+		return nil
+	}
+
+	_, report := run(t, tasks.StaleTriage{}, newEnv(board))
+
+	if moves := board.MutationsOfKind(fakes.MutationStatus); len(moves) != 0 {
+		t.Fatalf("moved %+v after the comment failed", moves)
+	}
+	if report.Count(tasks.OutcomeFailed) != 1 || report.Count(tasks.OutcomeSkipped) != 1 {
+		t.Errorf("report has %d failed and %d skipped items, want 1 and 1",
+			report.Count(tasks.OutcomeFailed), report.Count(tasks.OutcomeSkipped))
+	}
+	if len(report.Errors) != 1 || report.Int("issues_moved") != 0 {
+		t.Errorf("errors = %v, issues_moved = %d", report.Errors, report.Int("issues_moved"))
+	}
+}
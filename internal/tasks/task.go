@@ -0,0 +1,102 @@
+package tasks
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"sort"
+
+	"github.com/storacha/project-agent/internal/config"
+	"github.com/storacha/project-agent/internal/directory"
+	"github.com/storacha/project-agent/internal/github"
+	"github.com/storacha/project-agent/internal/messages"
+	"github.com/storacha/project-agent/internal/state"
+)
+
+// Task is a maintenance task run by the generic runner. Plan works out what
+// the task would change without changing anything; Apply makes the planned
+// changes. A dry run stops after Plan.
+type Task interface {
+	// Name is the task's subcommand name, such as "triage-stale"
+	Name() string
+	// Summary is the one-line description shown in usage
+	Summary() string
+	// Needs lists the clients and configuration the task requires
+	Needs(cfg *config.Config) Needs
+	// Schema describes the task's report
+	Schema(cfg *config.Config) ReportSchema
+	Plan(ctx context.Context, env *Env) (*Plan, error)
+	Apply(ctx context.Context, env *Env, plan *Plan) (*Report, error)
+}
+
+// FlagTask is implemented by tasks that take their own command line flags
+type FlagTask interface {
+	Task
+	Flags(fs *flag.FlagSet)
+}
+
+// Needs lists what the runner must set up before a task runs
+type Needs struct {
+	// Board is set when the task reads the project board; Schema lists the
+	// fields and statuses it uses
+	Board  bool
+	Schema github.Requirements
+	// Similarity is set when the task scores issue similarity, which needs
+	// GEMINI_API_KEY
+	Similarity bool
+	// Notifier is what the task sends through, if anything
+	Notifier NotifierKind
+	// State is set when the task remembers things between runs. The runner
+	// saves the store after Apply.
+	State bool
+}
+
+// NotifierKind is what a task sends through the configured transport
+type NotifierKind int
+
+// Notifier kinds
+const (
+	NoNotifier      NotifierKind = iota
+	ChannelNotifier              // Reports to a channel. Optional: Env.Notifier is nil when the transport has no report channel configured
+	DirectNotifier               // Direct messages and threads. Required: the transport's credentials must be set
+)
+
+// Env is what a task runs against. Clients the task does not need are nil.
+type Env struct {
+	Config       *config.Config
+	Board        ProjectBoard
+	PullRequests PullRequestSource
+	Scorer       SimilarityScorer
+	Notifier     Notifier
+	State        state.Store
+	Messages     *messages.Templates
+	Directory    *directory.Directory
+}
+
+// registry holds every registered task by name
+var registry = make(map[string]Task)
+
+// Register adds a task to the registry. It panics if a task with the same
+// name is already registered.
+func Register(t Task) {
+	if _, dup := registry[t.Name()]; dup {
+		panic(fmt.Sprintf("tasks: task %q registered twice", t.Name()))
+	}
+	registry[t.Name()] = t
+}
+
+// Lookup returns the registered task with the given name
+func Lookup(name string) (Task, bool) {
+	t, ok := registry[name]
+	return t, ok
+}
+
+// All returns every registered task, sorted by name
+func All() []Task {
+	all := make([]Task, 0, len(registry))
+	for _, t := range registry {
+		all = append(all, t)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Name() < all[j].Name() })
+	return all
+}
@@ -0,0 +1,72 @@
+package tasks_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/storacha/project-agent/internal/config"
+	"github.com/storacha/project-agent/internal/fakes"
+	"github.com/storacha/project-agent/internal/github"
+	"github.com/storacha/project-agent/internal/messages"
+	"github.com/storacha/project-agent/internal/tasks"
+)
+
+// newEnv returns an environment backed by board, with the default
+// configuration and templates
+func newEnv(board *fakes.Board) *tasks.Env {
+	return &tasks.Env{
+		Config:       config.Default(),
+		Board:        board,
+		PullRequests: board,
+		Messages:     messages.Default(),
+	}
+}
+
+// projectIssue returns an issue of storacha/guppy on the project
+func projectIssue(number int, title, status string, updated time.Time) github.Issue {
+	return github.Issue{
+		Number:          number,
+		Title:           title,
+		RepositoryOwner: "storacha",
+		RepositoryName:  "guppy",
+		UpdatedAt:       updated,
+		ProjectItem:     github.ProjectItemInfo{ID: fakes.IssueKey("storacha", "guppy", number), StatusValue: status},
+	}
+}
+
+// run plans and applies a task
+func run(t *testing.T, task tasks.Task, env *tasks.Env) (*tasks.Plan, *tasks.Report) {
+	t.Helper()
+	ctx := context.Background()
+	plan, err := task.Plan(ctx, env)
+	if err != nil {
+		t.Fatalf("Plan: %v", err)
+	}
+	report, err := task.Apply(ctx, env, plan)
+	if err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	return plan, report
+}
+
+// kinds lists the kinds of actions, in order
+func kinds(actions []tasks.Action) []tasks.ActionKind {
+	var out []tasks.ActionKind
+	for _, a := range actions {
+		out = append(out, a.Kind)
+	}
+	return out
+}
+
+func equal[T comparable](a, b []T) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
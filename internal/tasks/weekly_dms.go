@@ -3,41 +3,73 @@ package tasks
 import (
 	"context"
 	"fmt"
-	"log"
-	"time"
+	"log/slog"
+	"strings"
 
 	"github.com/storacha/project-agent/internal/config"
-	"github.com/storacha/project-agent/internal/discord"
+	"github.com/storacha/project-agent/internal/directory"
 	"github.com/storacha/project-agent/internal/github"
+	"github.com/storacha/project-agent/internal/notify"
 )
 
-// WeeklyDMReport contains the results of sending weekly DMs
-type WeeklyDMReport struct {
-	TotalUsers              int
-	TotalIssues             int
-	UnassignedIssuesCount   int
-	DMsSent                 int
-	UsersWithNoIssues       int
-	UsersNotInMappings      int
-	UnassignedIssuesDMSent  bool
-	Errors                  []string
+func init() {
+	Register(WeeklyDMs{})
 }
 
-// SendWeeklyDMs sends DMs to each team member with their assigned issues
-func SendWeeklyDMs(ctx context.Context, githubClient *github.Client, discordClient *discord.Client, cfg *config.Config) (*WeeklyDMReport, error) {
-	report := &WeeklyDMReport{}
+// WeeklyDMs DMs each active team member in the directory the active issues
+// assigned to them, and sends the unassigned issues to a designated user or,
+// failing that, the team leads
+type WeeklyDMs struct{}
 
-	log.Println("Fetching issues from active statuses...")
+// Name implements Task
+func (WeeklyDMs) Name() string { return "send-weekly-dms" }
 
-	// Fetch issues with active statuses (Sprint Backlog, In Progress, PR Review)
-	activeStatuses := []string{"Sprint Backlog", "In Progress", "PR Review"}
-	issues, err := githubClient.GetIssuesByStatuses(ctx, activeStatuses)
+// Summary implements Task
+func (WeeklyDMs) Summary() string {
+	return "DM each team member the active issues assigned to them"
+}
+
+// Needs implements Task
+func (WeeklyDMs) Needs(cfg *config.Config) Needs {
+	return Needs{Board: true, Schema: WeeklyDMRequirements(cfg), Notifier: DirectNotifier}
+}
+
+// Schema implements Task
+func (WeeklyDMs) Schema(cfg *config.Config) ReportSchema {
+	return ReportSchema{
+		Title: "Weekly DM Report",
+		Fields: []FieldSpec{
+			{Key: "users", Label: "Team members to DM"},
+			{Key: "active_issues", Label: "Total active issues"},
+			{Key: "unassigned_issues", Label: "Unassigned issues"},
+			{Key: "dms_sent", Label: "User DMs sent"},
+			{Key: "users_without_issues", Label: "Users with no assigned issues"},
+			{Key: "unassigned_dm_sent", Label: "Unassigned issues report sent", OmitZero: true},
+		},
+	}
+}
+
+// Plan implements Task
+func (t WeeklyDMs) Plan(ctx context.Context, env *Env) (*Plan, error) {
+	cfg := env.Config
+	plan := &Plan{Task: t.Name(), Report: NewReport(t.Name())}
+	report := plan.Report
+
+	transport := cfg.Notifier.Transport
+	if len(env.Directory.Active()) == 0 {
+		return nil, fmt.Errorf("the team directory has no active members - no users to notify")
+	}
+
+	slog.InfoContext(ctx, "Fetching issues", "statuses", cfg.Statuses.Active)
+
+	// Fetch issues with active statuses
+	issues, err := env.Board.GetIssuesByStatuses(ctx, cfg.Statuses.Active)
 	if err != nil {
-		return report, fmt.Errorf("failed to fetch issues: %w", err)
+		return nil, fmt.Errorf("failed to fetch issues: %w", err)
 	}
 
-	log.Printf("Found %d active issues\n", len(issues))
-	report.TotalIssues = len(issues)
+	slog.InfoContext(ctx, "Found active issues", "count", len(issues))
+	report.Set("active_issues", len(issues))
 
 	// Group issues by assignee and collect unassigned
 	issuesByUser := make(map[string][]github.Issue)
@@ -50,93 +82,124 @@ func SendWeeklyDMs(ctx context.Context, githubClient *github.Client, discordClie
 		}
 
 		for _, assignee := range issue.Assignees {
-			issuesByUser[assignee] = append(issuesByUser[assignee], issue)
+			login := strings.ToLower(assignee)
+			issuesByUser[login] = append(issuesByUser[login], issue)
 		}
 	}
 
-	report.UnassignedIssuesCount = len(unassignedIssues)
-	log.Printf("Found %d unassigned issues\n", len(unassignedIssues))
-
-	log.Printf("Issues are assigned to %d unique users\n", len(issuesByUser))
-
-	// Get all users from mappings (these are the users we want to DM)
-	usersToNotify := make(map[string]bool)
-	for githubUser := range cfg.UserMappings {
-		usersToNotify[githubUser] = true
+	report.Set("unassigned_issues", len(unassignedIssues))
+	slog.InfoContext(ctx, "Grouped issues by assignee", "unassigned", len(unassignedIssues), "assignees", len(issuesByUser))
+
+	// DM every active member who wants the weekly DM, in login order
+	var people []directory.Person
+	for _, person := range env.Directory.Active() {
+		switch {
+		case !person.WantsWeeklyDM():
+			slog.InfoContext(ctx, "User has opted out of the weekly DM", "user", person.GitHub)
+			report.Skip(dmItem(person.GitHub), "opted out")
+		case person.Handle(transport) == "":
+			slog.WarnContext(ctx, "User has no handle in the directory for the transport, skipping DM", "user", person.GitHub, "transport", transport)
+			report.Notes = append(report.Notes, fmt.Sprintf("- %s has no %s handle in the directory", person.GitHub, transport))
+			report.Skip(dmItem(person.GitHub), "no "+transport+" handle in the directory")
+		default:
+			people = append(people, person)
+		}
 	}
 
-	report.TotalUsers = len(usersToNotify)
-	log.Printf("Will send DMs to %d users from mappings\n", report.TotalUsers)
+	report.Set("users", len(people))
+	slog.InfoContext(ctx, "Will send DMs to users from the directory", "count", len(people))
 
-	// Send DM to each user
-	for githubUser := range usersToNotify {
-		discordUserID, ok := cfg.UserMappings[githubUser]
-		if !ok {
-			// This shouldn't happen since we're iterating over the mappings, but just in case
-			report.UsersNotInMappings++
+	for _, person := range people {
+		userIssues := issuesByUser[strings.ToLower(person.GitHub)]
+		if len(userIssues) == 0 {
+			slog.InfoContext(ctx, "User has no assigned issues, skipping DM", "user", person.GitHub)
+			report.Add("users_without_issues", 1)
+			report.Skip(dmItem(person.GitHub), "no assigned issues")
 			continue
 		}
 
-		userIssues := issuesByUser[githubUser]
+		plan.Actions = append(plan.Actions, Action{
+			Kind:   ActionDM,
+			Issues: userIssues,
+			Value:  person.Handle(transport),
+			Target: person.GitHub,
+		})
+	}
 
-		if len(userIssues) == 0 {
-			log.Printf("User %s has no assigned issues, skipping DM\n", githubUser)
-			report.UsersWithNoIssues++
-			continue
+	// Send unassigned issues DM to the designated user, or else the leads
+	if cfg.WeeklyDMs.UnassignedUserID != "" {
+		plan.Actions = append(plan.Actions, Action{
+			Kind:   ActionUnassignedDM,
+			Issues: unassignedIssues,
+			Value:  cfg.WeeklyDMs.UnassignedUserID,
+			Target: cfg.WeeklyDMs.UnassignedUserID,
+		})
+		return plan, nil
+	}
+
+	var leads int
+	for _, lead := range env.Directory.WithRole(directory.RoleLead) {
+		if handle := lead.Handle(transport); handle != "" {
+			plan.Actions = append(plan.Actions, Action{
+				Kind:   ActionUnassignedDM,
+				Issues: unassignedIssues,
+				Value:  handle,
+				Target: lead.GitHub,
+			})
+			leads++
 		}
+	}
+	if leads == 0 {
+		slog.InfoContext(ctx, "UNASSIGNED_ISSUES_USER_ID not set and no leads in the directory, skipping unassigned issues report")
+		report.Notes = append(report.Notes, "- Unassigned issues report skipped (UNASSIGNED_ISSUES_USER_ID not set and no leads in the directory)")
+	}
 
-		log.Printf("Sending DM to %s (%d issues)...\n", githubUser, len(userIssues))
+	return plan, nil
+}
 
-		if !cfg.DryRun {
-			userIssuesData := discord.UserIssues{
-				GithubUsername: githubUser,
-				DiscordUserID:  discordUserID,
-				Issues:         userIssues,
-			}
+// Apply implements Task
+func (WeeklyDMs) Apply(ctx context.Context, env *Env, plan *Plan) (*Report, error) {
+	report := plan.Report
 
-			if err := discordClient.SendWeeklyDM(ctx, userIssuesData); err != nil {
-				errMsg := fmt.Sprintf("Failed to send DM to %s: %v", githubUser, err)
-				log.Printf("ERROR: %s\n", errMsg)
-				report.Errors = append(report.Errors, errMsg)
-			} else {
-				log.Printf("Successfully sent DM to %s\n", githubUser)
-				report.DMsSent++
-			}
+	for _, action := range plan.Actions {
+		switch action.Kind {
+		case ActionDM:
+			slog.InfoContext(ctx, "Sending DM", "user", action.Target, "issues", len(action.Issues))
 
-			// Rate limiting - be nice to Discord API
-			time.Sleep(1 * time.Second)
-		} else {
-			log.Printf("[DRY RUN] Would send DM to %s with %d issues\n", githubUser, len(userIssues))
-			for _, issue := range userIssues {
-				log.Printf("  - #%d [%s]: %s\n", issue.Number, issue.ProjectItem.StatusValue, issue.Title)
+			userIssues := notify.UserIssues{
+				GithubUsername: action.Target,
+				UserID:         action.Value,
+				Issues:         action.Issues,
+			}
+			err := env.Notifier.SendWeeklyDM(ctx, userIssues)
+			report.Record(action, err)
+			if err != nil {
+				slog.ErrorContext(ctx, "Failed to send DM", "user", action.Target, "error", err)
+				report.Errors = append(report.Errors, fmt.Sprintf("Failed to send DM to %s: %v", action.Target, err))
+			} else {
+				slog.InfoContext(ctx, "Successfully sent DM", "user", action.Target)
+				report.Add("dms_sent", 1)
 			}
-			report.DMsSent++
-		}
-	}
 
-	// Send unassigned issues DM if configured
-	if cfg.UnassignedIssuesUserID != "" {
-		log.Printf("\nSending unassigned issues report to designated user...\n")
+		case ActionUnassignedDM:
+			slog.InfoContext(ctx, "Sending unassigned issues report", "user", action.Target, "issues", len(action.Issues))
 
-		if !cfg.DryRun {
-			if err := discordClient.SendUnassignedIssuesDM(ctx, cfg.UnassignedIssuesUserID, unassignedIssues); err != nil {
-				errMsg := fmt.Sprintf("Failed to send unassigned issues DM: %v", err)
-				log.Printf("ERROR: %s\n", errMsg)
-				report.Errors = append(report.Errors, errMsg)
+			err := env.Notifier.SendUnassignedIssuesDM(ctx, action.Value, action.Issues)
+			report.Record(action, err)
+			if err != nil {
+				slog.ErrorContext(ctx, "Failed to send unassigned issues DM", "user", action.Target, "error", err)
+				report.Errors = append(report.Errors, fmt.Sprintf("Failed to send unassigned issues DM to %s: %v", action.Target, err))
 			} else {
-				log.Printf("Successfully sent unassigned issues DM (%d issues)\n", len(unassignedIssues))
-				report.UnassignedIssuesDMSent = true
-			}
-		} else {
-			log.Printf("[DRY RUN] Would send unassigned issues DM with %d issues:\n", len(unassignedIssues))
-			for _, issue := range unassignedIssues {
-				log.Printf("  - #%d [%s]: %s\n", issue.Number, issue.ProjectItem.StatusValue, issue.Title)
+				slog.InfoContext(ctx, "Successfully sent unassigned issues DM", "user", action.Target)
+				report.Set("unassigned_dm_sent", "yes")
 			}
-			report.UnassignedIssuesDMSent = true
 		}
-	} else {
-		log.Println("\nUNASSIGNED_ISSUES_USER_ID not set, skipping unassigned issues report")
 	}
 
 	return report, nil
 }
+
+// dmItem is the report item for a weekly DM that was not planned
+func dmItem(login string) Item {
+	return Item{Action: ActionDM, User: login, Summary: "DM " + login}
+}
@@ -0,0 +1,52 @@
+package webhook
+
+import "sync"
+
+// Deliveries remembers the IDs of recent deliveries, so an event GitHub
+// delivers again is handled once. The oldest IDs are forgotten first.
+type Deliveries struct {
+	mu    sync.Mutex
+	max   int
+	seen  map[string]uint64 // ID to when it was added
+	order []entry           // Oldest first
+	next  uint64
+}
+
+type entry struct {
+	id  string
+	seq uint64
+}
+
+// NewDeliveries remembers up to max delivery IDs
+func NewDeliveries(max int) *Deliveries {
+	return &Deliveries{max: max, seen: make(map[string]uint64)}
+}
+
+// Add records a delivery ID. It reports false if the ID was already seen.
+func (d *Deliveries) Add(id string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.seen[id]; ok {
+		return false
+	}
+	d.next++
+	d.seen[id] = d.next
+	d.order = append(d.order, entry{id: id, seq: d.next})
+	for len(d.order) > d.max {
+		oldest := d.order[0]
+		d.order = d.order[1:]
+		// A forgotten and re-added ID has a newer entry
+		if d.seen[oldest.id] == oldest.seq {
+			delete(d.seen, oldest.id)
+		}
+	}
+	return true
+}
+
+// Forget removes a delivery ID, so the delivery is handled if it comes again
+func (d *Deliveries) Forget(id string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.seen, id)
+}
@@ -0,0 +1,224 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/storacha/project-agent/internal/tasks"
+)
+
+// initiativeType is the GitHub issue type of initiatives
+const initiativeType = "Initiative"
+
+// Job is the task run a delivery calls for
+type Job struct {
+	// Key names the work, such as "link-pr storacha/guppy#12". Runs with the
+	// same key never overlap.
+	Key string
+	// Task is the task to run, or nil when the delivery calls for none
+	Task tasks.Task
+	// Reason says why the delivery does or does not call for a run
+	Reason string
+}
+
+// Options tune how deliveries are routed
+type Options struct {
+	// ProjectID is the project's node ID. projects_v2_item events for other
+	// projects are ignored; when empty, none are.
+	ProjectID string
+}
+
+type repository struct {
+	FullName string `json:"full_name"`
+}
+
+type user struct {
+	Login string `json:"login"`
+	Type  string `json:"type"` // "User" or "Bot"
+}
+
+type issue struct {
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+	Body   string `json:"body"`
+	User   user   `json:"user"`
+	Type   *struct {
+		Name string `json:"name"`
+	} `json:"type"`
+	// PullRequest is set when the issue is a pull request
+	PullRequest *struct {
+		URL string `json:"url"`
+	} `json:"pull_request"`
+}
+
+func (i *issue) isInitiative() bool {
+	return i.Type != nil && i.Type.Name == initiativeType
+}
+
+type pullRequest struct {
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+	Body   string `json:"body"`
+	User   user   `json:"user"`
+}
+
+type comment struct {
+	Body string `json:"body"`
+	User user   `json:"user"`
+}
+
+type projectItem struct {
+	ProjectNodeID string `json:"project_node_id"`
+	ContentType   string `json:"content_type"` // "Issue", "PullRequest" or "DraftIssue"
+}
+
+// payload holds the fields of every handled event the agent reads
+type payload struct {
+	Action      string                     `json:"action"`
+	Repository  repository                 `json:"repository"`
+	Changes     map[string]json.RawMessage `json:"changes"`
+	PullRequest *pullRequest               `json:"pull_request"`
+	Issue       *issue                     `json:"issue"`
+	Comment     *comment                   `json:"comment"`
+	ProjectItem *projectItem               `json:"projects_v2_item"`
+}
+
+// Route works out the job a delivery of event calls for:
+//   - pull_request: opened, reopened, ready for review, or a changed title
+//     or body links the pull request (link-pr)
+//   - issue_comment: a new or edited comment on a pull request links it
+//     with the comment's references too (link-pr)
+//   - issues: an initiative that is opened, reopened, given the Initiative
+//     type or retitled updates its sub-issues (process-initiatives)
+//   - sub_issues: an added sub-issue is added to its initiative
+//     (process-initiatives)
+//   - projects_v2_item: an issue added to or restored on the project has its
+//     sub-issues added (process-initiatives)
+//
+// Other events and actions need no run.
+func Route(event string, body []byte, opts Options) (Job, error) {
+	if event == "ping" {
+		return Job{Reason: "ping"}, nil
+	}
+	var p payload
+	if err := json.Unmarshal(body, &p); err != nil {
+		return Job{}, fmt.Errorf("failed to parse %s payload: %w", event, err)
+	}
+
+	switch event {
+	case "pull_request":
+		return routePullRequest(p)
+	case "issue_comment":
+		return routeIssueComment(p)
+	case "issues":
+		return routeIssue(p)
+	case "sub_issues":
+		switch p.Action {
+		case "sub_issue_added", "parent_issue_added":
+			return initiativesJob("sub-issue added"), nil
+		}
+	case "projects_v2_item":
+		return routeProjectItem(p, opts)
+	default:
+		return Job{Reason: fmt.Sprintf("%s events are not handled", event)}, nil
+	}
+	return ignored(event, p.Action), nil
+}
+
+func routePullRequest(p payload) (Job, error) {
+	pr := p.PullRequest
+	if pr == nil {
+		return Job{}, fmt.Errorf("pull_request payload has no pull_request")
+	}
+	switch p.Action {
+	case "opened", "reopened", "ready_for_review":
+	case "edited":
+		// Edits also fire for a new base branch, which changes no reference
+		if p.Changes["title"] == nil && p.Changes["body"] == nil {
+			return Job{Reason: "pull request edited without a title or body change"}, nil
+		}
+	default:
+		return ignored("pull_request", p.Action), nil
+	}
+	return linkJob(p.Repository.FullName, pr.Number, pr.User.Login, pr.Title, pr.Body, "pull request "+p.Action), nil
+}
+
+func routeIssueComment(p payload) (Job, error) {
+	if p.Issue == nil || p.Comment == nil {
+		return Job{}, fmt.Errorf("issue_comment payload has no issue or comment")
+	}
+	if p.Action != "created" && p.Action != "edited" {
+		return ignored("issue_comment", p.Action), nil
+	}
+	if p.Issue.PullRequest == nil {
+		return Job{Reason: "comment is on an issue, not a pull request"}, nil
+	}
+	// The agent's own comments, and other bots', reference nothing new
+	if p.Comment.User.Type == "Bot" {
+		return Job{Reason: "comment is by a bot"}, nil
+	}
+	body := p.Issue.Body
+	if body != "" {
+		body += "\n\n"
+	}
+	body += p.Comment.Body
+	return linkJob(p.Repository.FullName, p.Issue.Number, p.Issue.User.Login, p.Issue.Title, body, "comment "+p.Action), nil
+}
+
+func routeIssue(p payload) (Job, error) {
+	if p.Issue == nil {
+		return Job{}, fmt.Errorf("issues payload has no issue")
+	}
+	switch p.Action {
+	case "opened", "reopened", "typed":
+	case "edited":
+		// Sub-issues carry the initiative's title in the Initiative field
+		if p.Changes["title"] == nil {
+			return Job{Reason: "issue edited without a title change"}, nil
+		}
+	default:
+		return ignored("issues", p.Action), nil
+	}
+	if !p.Issue.isInitiative() {
+		return Job{Reason: "issue is not an initiative"}, nil
+	}
+	return initiativesJob(fmt.Sprintf("initiative %s#%d %s", p.Repository.FullName, p.Issue.Number, p.Action)), nil
+}
+
+func routeProjectItem(p payload, opts Options) (Job, error) {
+	item := p.ProjectItem
+	if item == nil {
+		return Job{}, fmt.Errorf("projects_v2_item payload has no projects_v2_item")
+	}
+	if opts.ProjectID != "" && item.ProjectNodeID != opts.ProjectID {
+		return Job{Reason: "item is on another project"}, nil
+	}
+	if p.Action != "created" && p.Action != "restored" {
+		return ignored("projects_v2_item", p.Action), nil
+	}
+	if item.ContentType != "Issue" {
+		return Job{Reason: fmt.Sprintf("item is a %s, not an issue", item.ContentType)}, nil
+	}
+	return initiativesJob("issue " + p.Action + " on the project"), nil
+}
+
+// linkJob links a pull request to the issues it references
+func linkJob(repo string, number int, author, title, body, reason string) Job {
+	t := &tasks.PRLinking{Repo: repo, Number: number, Author: author, Title: title, Body: body}
+	return Job{
+		Key:    fmt.Sprintf("%s %s#%d", t.Name(), repo, number),
+		Task:   t,
+		Reason: reason,
+	}
+}
+
+// initiativesJob processes every initiative. The run is cheap next to
+// working out which initiative a change belongs to.
+func initiativesJob(reason string) Job {
+	t := tasks.ProcessInitiatives{}
+	return Job{Key: t.Name(), Task: t, Reason: reason}
+}
+
+func ignored(event, action string) Job {
+	return Job{Reason: fmt.Sprintf("%s action %q is not handled", event, action)}
+}
@@ -0,0 +1,133 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/storacha/project-agent/internal/metrics"
+)
+
+// maxPayload is the largest payload GitHub delivers
+const maxPayload = 25 << 20
+
+// rememberedDeliveries is how many delivery IDs a handler remembers
+const rememberedDeliveries = 10000
+
+// unknownEvent labels the metrics of deliveries whose event is not known
+const unknownEvent = "unknown"
+
+// Delivery identifies a webhook delivery
+type Delivery struct {
+	ID    string // X-GitHub-Delivery
+	Event string // X-GitHub-Event
+}
+
+// DispatchFunc starts a delivery's job in the background. It reports false
+// if the job cannot start, such as while shutting down.
+type DispatchFunc func(d Delivery, job Job) bool
+
+// Handler receives GitHub webhook deliveries. Deliveries must be signed
+// with the secret; each is handled once, by dispatching the job Route finds
+// for it. The response is sent before the job runs, as GitHub waits only
+// ten seconds.
+type Handler struct {
+	secret     []byte
+	opts       Options
+	deliveries *Deliveries
+	dispatch   DispatchFunc
+}
+
+// NewHandler creates a handler for deliveries signed with secret
+func NewHandler(secret string, opts Options, dispatch DispatchFunc) *Handler {
+	return &Handler{
+		secret:     []byte(secret),
+		opts:       opts,
+		deliveries: NewDeliveries(rememberedDeliveries),
+		dispatch:   dispatch,
+	}
+}
+
+// ServeHTTP implements http.Handler
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	d := Delivery{ID: r.Header.Get("X-GitHub-Delivery"), Event: r.Header.Get("X-GitHub-Event")}
+	// Anyone can send the event header, so it only labels the metrics once
+	// the signature shows the delivery is GitHub's
+	event := unknownEvent
+	respond := func(status int, result, message string) {
+		metrics.WebhookDeliveries.Inc(event, result)
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(status)
+		fmt.Fprintln(w, message)
+	}
+
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxPayload))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			respond(http.StatusRequestEntityTooLarge, "invalid", "payload too large")
+			return
+		}
+		respond(http.StatusBadRequest, "invalid", "failed to read payload")
+		return
+	}
+
+	if err := Verify(h.secret, body, r.Header.Get(SignatureHeader)); err != nil {
+		slog.WarnContext(ctx, "Rejected webhook delivery", "delivery", d.ID, "event", d.Event, "remote", r.RemoteAddr, "error", err)
+		respond(http.StatusUnauthorized, "unauthorized", "invalid signature")
+		return
+	}
+	if d.ID == "" || d.Event == "" {
+		respond(http.StatusBadRequest, "invalid", "missing X-GitHub-Delivery or X-GitHub-Event header")
+		return
+	}
+	event = d.Event
+	// Form-encoded deliveries carry the JSON in the payload field
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			respond(http.StatusBadRequest, "invalid", "malformed form payload")
+			return
+		}
+		body = []byte(form.Get("payload"))
+	}
+
+	if !h.deliveries.Add(d.ID) {
+		slog.InfoContext(ctx, "Skipping a delivery already handled", "delivery", d.ID, "event", d.Event)
+		respond(http.StatusOK, "duplicate", "duplicate delivery")
+		return
+	}
+
+	job, err := Route(d.Event, body, h.opts)
+	if err != nil {
+		slog.WarnContext(ctx, "Invalid webhook payload", "delivery", d.ID, "event", d.Event, "error", err)
+		// Let a corrected redelivery through
+		h.deliveries.Forget(d.ID)
+		respond(http.StatusBadRequest, "invalid", err.Error())
+		return
+	}
+	if job.Task == nil {
+		slog.DebugContext(ctx, "Ignoring webhook delivery", "delivery", d.ID, "event", d.Event, "reason", job.Reason)
+		respond(http.StatusOK, "ignored", "ignored: "+job.Reason)
+		return
+	}
+
+	if !h.dispatch(d, job) {
+		// Let a redelivery through once the agent is back
+		h.deliveries.Forget(d.ID)
+		respond(http.StatusServiceUnavailable, "unavailable", "shutting down")
+		return
+	}
+	slog.InfoContext(ctx, "Queued a run for webhook delivery", "delivery", d.ID, "event", d.Event, "job", job.Key, "reason", job.Reason)
+	respond(http.StatusAccepted, "queued", "queued "+job.Key)
+}
@@ -0,0 +1,319 @@
+package webhook
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/storacha/project-agent/internal/metrics"
+	"github.com/storacha/project-agent/internal/tasks"
+)
+
+const testSecret = "It's a Secret to Everybody"
+
+// dispatcher records the jobs a handler dispatches, and refuses them while
+// closed is set
+type dispatcher struct {
+	mu     sync.Mutex
+	jobs   []Job
+	closed bool
+}
+
+func (d *dispatcher) dispatch(_ Delivery, job Job) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.closed {
+		return false
+	}
+	d.jobs = append(d.jobs, job)
+	return true
+}
+
+func newTestHandler() (*Handler, *dispatcher) {
+	d := &dispatcher{}
+	return NewHandler(testSecret, Options{ProjectID: "PVT_kwDOBoard"}, d.dispatch), d
+}
+
+// deliver sends body as GitHub would, signed with secret
+func deliver(h *Handler, event, id string, body []byte, secret string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/webhook", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-GitHub-Event", event)
+	req.Header.Set("X-GitHub-Delivery", id)
+	req.Header.Set(SignatureHeader, Sign([]byte(secret), body))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func readPayload(t *testing.T, name string) []byte {
+	t.Helper()
+	body, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return body
+}
+
+func TestHandlerRoutesTestdataPayloads(t *testing.T) {
+	want := map[string]struct {
+		status int
+		reply  string
+	}{
+		"ping.json":                       {http.StatusOK, "ignored: ping"},
+		"pull_request.opened.json":        {http.StatusAccepted, "queued link-pr storacha/guppy#7"},
+		"pull_request.edited.json":        {http.StatusAccepted, "queued link-pr storacha/guppy#7"},
+		"issue_comment.created.json":      {http.StatusAccepted, "queued link-pr storacha/guppy#7"},
+		"issues.typed.json":               {http.StatusAccepted, "queued process-initiatives"},
+		"sub_issues.sub_issue_added.json": {http.StatusAccepted, "queued process-initiatives"},
+		"projects_v2_item.created.json":   {http.StatusAccepted, "queued process-initiatives"},
+	}
+
+	files, err := os.ReadDir("testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, _ := newTestHandler()
+	for _, f := range files {
+		expected, ok := want[f.Name()]
+		if !ok {
+			t.Errorf("no expectation for testdata/%s", f.Name())
+			continue
+		}
+		event, _, _ := strings.Cut(f.Name(), ".")
+		rec := deliver(h, event, f.Name(), readPayload(t, f.Name()), testSecret)
+		if rec.Code != expected.status || strings.TrimSpace(rec.Body.String()) != expected.reply {
+			t.Errorf("%s: got %d %q, want %d %q", f.Name(), rec.Code, strings.TrimSpace(rec.Body.String()), expected.status, expected.reply)
+		}
+	}
+}
+
+func TestHandlerLinksCommentReferences(t *testing.T) {
+	h, d := newTestHandler()
+
+	if rec := deliver(h, "issue_comment", "delivery-1", readPayload(t, "issue_comment.created.json"), testSecret); rec.Code != http.StatusAccepted {
+		t.Fatalf("got %d %s", rec.Code, rec.Body)
+	}
+
+	if len(d.jobs) != 1 {
+		t.Fatalf("dispatched %d jobs, want 1", len(d.jobs))
+	}
+	link, ok := d.jobs[0].Task.(*tasks.PRLinking)
+	if !ok {
+		t.Fatalf("task = %T, want link-pr", d.jobs[0].Task)
+	}
+	// The PR body and the comment are both searched for references
+	if link.Repo != "storacha/guppy" || link.Number != 7 || link.Author != "alice" ||
+		!strings.HasPrefix(link.Body, "Fixes #2\n\n") || !strings.Contains(link.Body, "#3") {
+		t.Errorf("link-pr = %+v", link)
+	}
+}
+
+func TestHandlerRejectsBadSignatures(t *testing.T) {
+	h, d := newTestHandler()
+	body := readPayload(t, "pull_request.opened.json")
+
+	if rec := deliver(h, "pull_request", "delivery-1", body, "wrong secret"); rec.Code != http.StatusUnauthorized {
+		t.Errorf("wrong secret: got %d %s, want 401", rec.Code, rec.Body)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/webhook", bytes.NewReader(body))
+	req.Header.Set("X-GitHub-Event", "pull_request")
+	req.Header.Set("X-GitHub-Delivery", "delivery-2")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("unsigned: got %d %s, want 401", rec.Code, rec.Body)
+	}
+
+	if len(d.jobs) != 0 {
+		t.Errorf("dispatched %v for unverified deliveries", d.jobs)
+	}
+	// The event of an unverified delivery is not trusted as a metric label
+	deliver(h, "made-up-event", "delivery-3", body, "wrong secret")
+	var exported strings.Builder
+	metrics.Default.WriteText(&exported)
+	if strings.Contains(exported.String(), "made-up-event") ||
+		!strings.Contains(exported.String(), `event="unknown",result="unauthorized"`) {
+		t.Errorf("unverified deliveries were not counted under the unknown event:\n%s", exported.String())
+	}
+	// A rejected delivery is not remembered, so a signed redelivery runs
+	if rec := deliver(h, "pull_request", "delivery-1", body, testSecret); rec.Code != http.StatusAccepted {
+		t.Errorf("signed redelivery: got %d %s, want 202", rec.Code, rec.Body)
+	}
+}
+
+func TestHandlerSkipsDuplicateDeliveries(t *testing.T) {
+	h, d := newTestHandler()
+	body := readPayload(t, "pull_request.opened.json")
+
+	first := deliver(h, "pull_request", "delivery-1", body, testSecret)
+	second := deliver(h, "pull_request", "delivery-1", body, testSecret)
+
+	if first.Code != http.StatusAccepted {
+		t.Errorf("first delivery: got %d %s", first.Code, first.Body)
+	}
+	if second.Code != http.StatusOK || strings.TrimSpace(second.Body.String()) != "duplicate delivery" {
+		t.Errorf("duplicate: got %d %q, want 200 duplicate delivery", second.Code, second.Body)
+	}
+	if len(d.jobs) != 1 {
+		t.Errorf("dispatched %d jobs, want 1", len(d.jobs))
+	}
+}
+
+func TestHandlerAcceptsRedeliveryAfterShutdown(t *testing.T) {
+	h, d := newTestHandler()
+	body := readPayload(t, "issues.typed.json")
+
+	d.closed = true
+	if rec := deliver(h, "issues", "delivery-1", body, testSecret); rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("while shutting down: got %d %s, want 503", rec.Code, rec.Body)
+	}
+	d.closed = false
+	if rec := deliver(h, "issues", "delivery-1", body, testSecret); rec.Code != http.StatusAccepted {
+		t.Errorf("redelivery: got %d %s, want 202", rec.Code, rec.Body)
+	}
+}
+
+func TestHandlerAcceptsRedeliveryAfterInvalidPayload(t *testing.T) {
+	h, _ := newTestHandler()
+
+	if rec := deliver(h, "pull_request", "delivery-1", []byte(`{"action": "opened"}`), testSecret); rec.Code != http.StatusBadRequest {
+		t.Fatalf("incomplete payload: got %d %s, want 400", rec.Code, rec.Body)
+	}
+	body := readPayload(t, "pull_request.opened.json")
+	if rec := deliver(h, "pull_request", "delivery-1", body, testSecret); rec.Code != http.StatusAccepted {
+		t.Errorf("redelivery: got %d %s, want 202", rec.Code, rec.Body)
+	}
+}
+
+func TestHandlerIgnoresUnhandledActions(t *testing.T) {
+	h, d := newTestHandler()
+	body := bytes.Replace(readPayload(t, "pull_request.opened.json"), []byte(`"action": "opened"`), []byte(`"action": "closed"`), 1)
+
+	rec := deliver(h, "pull_request", "delivery-1", body, testSecret)
+	if rec.Code != http.StatusOK || strings.TrimSpace(rec.Body.String()) != `ignored: pull_request action "closed" is not handled` {
+		t.Errorf("got %d %q", rec.Code, rec.Body)
+	}
+	if len(d.jobs) != 0 {
+		t.Errorf("dispatched %v for an ignored action", d.jobs)
+	}
+}
+
+func TestHandlerReadsFormPayloads(t *testing.T) {
+	h, d := newTestHandler()
+	body := []byte(url.Values{"payload": {string(readPayload(t, "sub_issues.sub_issue_added.json"))}}.Encode())
+
+	req := httptest.NewRequest(http.MethodPost, "/webhook", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-GitHub-Event", "sub_issues")
+	req.Header.Set("X-GitHub-Delivery", "delivery-1")
+	req.Header.Set(SignatureHeader, Sign([]byte(testSecret), body))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusAccepted || len(d.jobs) != 1 {
+		t.Errorf("got %d %s and %d jobs, want 202 and 1", rec.Code, rec.Body, len(d.jobs))
+	}
+}
+
+func TestHandlerRejectsBadRequests(t *testing.T) {
+	h, _ := newTestHandler()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/webhook", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET: got %d, want 405", rec.Code)
+	}
+
+	if rec := deliver(h, "", "delivery-1", []byte(`{}`), testSecret); rec.Code != http.StatusBadRequest {
+		t.Errorf("no event: got %d, want 400", rec.Code)
+	}
+	if rec := deliver(h, "pull_request", "delivery-2", []byte(`{not json`), testSecret); rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid JSON: got %d, want 400", rec.Code)
+	}
+}
+
+func TestRoute(t *testing.T) {
+	opts := Options{ProjectID: "PVT_kwDOBoard"}
+	for _, tc := range []struct {
+		name   string
+		event  string
+		body   string
+		key    string // Empty when no run is wanted
+		reason string
+	}{
+		{"pr reopened", "pull_request",
+			`{"action":"reopened","repository":{"full_name":"storacha/guppy"},"pull_request":{"number":9,"title":"t","user":{"login":"bob"}}}`,
+			"link-pr storacha/guppy#9", "pull request reopened"},
+		{"pr base changed", "pull_request",
+			`{"action":"edited","changes":{"base":{}},"repository":{"full_name":"storacha/guppy"},"pull_request":{"number":9}}`,
+			"", "pull request edited without a title or body change"},
+		{"pr title changed", "pull_request",
+			`{"action":"edited","changes":{"title":{"from":"x"}},"repository":{"full_name":"storacha/guppy"},"pull_request":{"number":9}}`,
+			"link-pr storacha/guppy#9", "pull request edited"},
+		{"comment on issue", "issue_comment",
+			`{"action":"created","repository":{"full_name":"storacha/guppy"},"issue":{"number":2},"comment":{"body":"#3","user":{"login":"bob","type":"User"}}}`,
+			"", "comment is on an issue, not a pull request"},
+		{"comment by bot", "issue_comment",
+			`{"action":"created","repository":{"full_name":"storacha/guppy"},"issue":{"number":7,"pull_request":{"url":"u"}},"comment":{"body":"#3","user":{"login":"project-agent[bot]","type":"Bot"}}}`,
+			"", "comment is by a bot"},
+		{"comment deleted", "issue_comment",
+			`{"action":"deleted","issue":{"number":7},"comment":{}}`,
+			"", `issue_comment action "deleted" is not handled`},
+		{"issue not an initiative", "issues",
+			`{"action":"opened","repository":{"full_name":"storacha/guppy"},"issue":{"number":2}}`,
+			"", "issue is not an initiative"},
+		{"initiative retitled", "issues",
+			`{"action":"edited","changes":{"title":{"from":"x"}},"repository":{"full_name":"storacha/project-tracking"},"issue":{"number":10,"type":{"name":"Initiative"}}}`,
+			"process-initiatives", "initiative storacha/project-tracking#10 edited"},
+		{"initiative body edited", "issues",
+			`{"action":"edited","changes":{"body":{"from":"x"}},"issue":{"number":10,"type":{"name":"Initiative"}}}`,
+			"", "issue edited without a title change"},
+		{"sub-issue removed", "sub_issues",
+			`{"action":"sub_issue_removed"}`,
+			"", `sub_issues action "sub_issue_removed" is not handled`},
+		{"item on another project", "projects_v2_item",
+			`{"action":"created","projects_v2_item":{"project_node_id":"PVT_other","content_type":"Issue"}}`,
+			"", "item is on another project"},
+		{"draft item", "projects_v2_item",
+			`{"action":"created","projects_v2_item":{"project_node_id":"PVT_kwDOBoard","content_type":"DraftIssue"}}`,
+			"", "item is a DraftIssue, not an issue"},
+		{"item restored", "projects_v2_item",
+			`{"action":"restored","projects_v2_item":{"project_node_id":"PVT_kwDOBoard","content_type":"Issue"}}`,
+			"process-initiatives", "issue restored on the project"},
+		{"unknown event", "star",
+			`{"action":"created"}`,
+			"", "star events are not handled"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			job, err := Route(tc.event, []byte(tc.body), opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if job.Key != tc.key || job.Reason != tc.reason || (job.Task == nil) != (tc.key == "") {
+				t.Errorf("Route = %q %q (task %v), want %q %q", job.Key, job.Reason, job.Task, tc.key, tc.reason)
+			}
+		})
+	}
+}
+
+func TestRouteRejectsIncompletePayloads(t *testing.T) {
+	for event, body := range map[string]string{
+		"pull_request":     `{"action":"opened"}`,
+		"issue_comment":    `{"action":"created","issue":{"number":7}}`,
+		"issues":           `{"action":"opened"}`,
+		"projects_v2_item": `{"action":"created"}`,
+		"sub_issues":       `[`,
+	} {
+		if _, err := Route(event, []byte(body), Options{}); err == nil {
+			t.Errorf("Route(%s, %s) succeeded", event, body)
+		}
+	}
+}
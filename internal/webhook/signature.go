@@ -0,0 +1,45 @@
+// Package webhook receives GitHub webhook deliveries: it checks their
+// signatures, drops redeliveries and works out which task each event calls
+// for.
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// SignatureHeader carries the HMAC-SHA256 of the payload, keyed with the
+// webhook's secret
+const SignatureHeader = "X-Hub-Signature-256"
+
+// Sign returns the X-Hub-Signature-256 value GitHub sends with body
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks that signature, the X-Hub-Signature-256 header, was made
+// from body with secret
+func Verify(secret, body []byte, signature string) error {
+	if len(secret) == 0 {
+		return fmt.Errorf("no webhook secret is configured")
+	}
+	hexSum, ok := strings.CutPrefix(signature, "sha256=")
+	if !ok {
+		return fmt.Errorf("missing or malformed %s header", SignatureHeader)
+	}
+	sum, err := hex.DecodeString(hexSum)
+	if err != nil {
+		return fmt.Errorf("malformed %s header: %w", SignatureHeader, err)
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	if !hmac.Equal(sum, mac.Sum(nil)) {
+		return fmt.Errorf("%s does not match the payload", SignatureHeader)
+	}
+	return nil
+}